@@ -5,6 +5,7 @@ package integration
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -85,3 +86,120 @@ func TestLockHandle_Release_nilHandle_noError(t *testing.T) {
 	err := handle.Release(context.Background())
 	require.NoError(t, err)
 }
+
+func TestAcquireLock_differentKeys_doNotConflict(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	handle1, err := database.AcquireLock(ctx, pool, database.LockOptions{Key: 1})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = handle1.Release(context.Background()) })
+
+	handle2, err := database.AcquireLock(ctx, pool, database.LockOptions{Key: -1})
+	require.NoError(t, err)
+	require.NoError(t, handle2.Release(ctx))
+}
+
+func TestAcquireLock_wait_reportsHolderAndTimesOut(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	key := database.DeriveLockKey("migrate_test", "", "schema_migrations")
+
+	handle1, err := database.AcquireLock(ctx, pool, database.LockOptions{Key: key})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = handle1.Release(context.Background()) })
+
+	var holders []database.LockHolder
+
+	start := time.Now()
+	handle2, err := database.AcquireLock(ctx, pool, database.LockOptions{
+		Key:          key,
+		Wait:         300 * time.Millisecond,
+		PollInterval: 50 * time.Millisecond,
+		OnWait:       func(h database.LockHolder) { holders = append(holders, h) },
+	})
+
+	assert.Nil(t, handle2)
+	require.ErrorIs(t, err, database.ErrLockNotAcquired)
+	assert.Contains(t, err.Error(), "held by pid")
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+	require.Len(t, holders, 1, "holder reported once while it does not change")
+	assert.Positive(t, holders[0].PID)
+}
+
+func TestAcquireLock_wait_succeedsAfterRelease(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	handle1, err := database.AcquireLock(ctx, pool, database.LockOptions{Key: 99})
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = handle1.Release(context.Background())
+	}()
+
+	handle2, err := database.AcquireLock(ctx, pool, database.LockOptions{
+		Key:          99,
+		Wait:         5 * time.Second,
+		PollInterval: 50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.NoError(t, handle2.Release(ctx))
+}
+
+func TestLockHandle_Verify_heldLock(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	handle, err := database.AcquireLock(ctx, pool, database.LockOptions{Key: 7})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = handle.Release(context.Background())
+	})
+
+	require.NoError(t, handle.Verify(ctx))
+}
+
+func TestLockHandle_Keep_terminatedBackend_cancelsWithErrLockLost(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	handle, err := database.AcquireLock(ctx, pool, database.LockOptions{Key: 8})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = handle.Release(context.Background())
+	})
+
+	keepCtx, stop := handle.Keep(ctx, 50*time.Millisecond)
+	defer stop()
+
+	holder, err := database.FindLockHolder(ctx, pool, 8)
+	require.NoError(t, err)
+	require.NotNil(t, holder)
+
+	_, err = pool.Exec(ctx, "SELECT pg_terminate_backend($1)", holder.PID)
+	require.NoError(t, err)
+
+	select {
+	case <-keepCtx.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("lock loss was not detected")
+	}
+
+	require.ErrorIs(t, context.Cause(keepCtx), database.ErrLockLost)
+}
@@ -0,0 +1,94 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/database"
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+func TestDiff_appliesAndRollsBack(t *testing.T) {
+	t.Parallel()
+
+	live := SetupPostgres(t)
+	server := SetupPostgresDSN(t)
+	ctx := context.Background()
+
+	_, err := live.Exec(ctx, `
+		CREATE TYPE mood AS ENUM ('ok');
+		CREATE TABLE users (id bigint PRIMARY KEY, email text, nickname text);
+		CREATE INDEX users_nickname_idx ON users (nickname);
+		CREATE VIEW user_emails AS SELECT id, email FROM users;
+		INSERT INTO users VALUES (1, 'a@example.com', 'a');`)
+	require.NoError(t, err)
+
+	scratch, err := database.NewScratch(ctx, server)
+	require.NoError(t, err)
+
+	_, err = scratch.Pool.Exec(ctx, `
+		CREATE TYPE mood AS ENUM ('sad', 'ok', 'happy');
+		CREATE TABLE users (id bigint PRIMARY KEY, email text NOT NULL UNIQUE, status text DEFAULT 'active' CHECK (status <> ''));
+		CREATE INDEX users_status_idx ON users (status);
+		CREATE TABLE orders (id bigint PRIMARY KEY, user_id bigint REFERENCES users (id));
+		CREATE VIEW user_emails AS SELECT id, email, status FROM users;`)
+	require.NoError(t, err)
+
+	desired, err := schema.Inspect(ctx, scratch.Pool)
+	require.NoError(t, err)
+	require.NoError(t, scratch.Drop(ctx))
+
+	original, err := schema.Inspect(ctx, live)
+	require.NoError(t, err)
+
+	plan := schema.Diff(original, desired)
+	assert.Empty(t, plan.Warnings)
+
+	for _, step := range plan.Steps {
+		execAll(ctx, t, live, step.Up)
+	}
+
+	migrated, err := schema.Inspect(ctx, live)
+	require.NoError(t, err)
+	assert.Empty(t, schema.Compare(desired, migrated))
+
+	for i := len(plan.Steps) - 1; i >= 0; i-- {
+		execAll(ctx, t, live, plan.Steps[i].Down)
+	}
+
+	rolledBack, err := schema.Inspect(ctx, live)
+	require.NoError(t, err)
+
+	// Enum labels cannot be removed, so only the enum differs.
+	assert.Equal(t, []schema.Difference{{Kind: schema.Changed, Object: "enum", Name: "public.mood", Property: "labels",
+		Expected: "'ok'", Actual: "'sad', 'ok', 'happy'"}}, schema.Compare(original, rolledBack))
+}
+
+// execAll runs the statements of a step, outside a transaction block when
+// it holds a single statement, as the executor does for CONCURRENTLY.
+func execAll(ctx context.Context, t *testing.T, pool *pgxpool.Pool, stmts []string) {
+	t.Helper()
+
+	if len(stmts) == 1 {
+		_, err := pool.Exec(ctx, stmts[0])
+		require.NoError(t, err, stmts[0])
+
+		return
+	}
+
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+
+	for _, s := range stmts {
+		_, err := tx.Exec(ctx, s)
+		require.NoError(t, err, s)
+	}
+
+	require.NoError(t, tx.Commit(ctx))
+}
@@ -0,0 +1,62 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/database"
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+func TestDrift_detectsHotfix(t *testing.T) {
+	t.Parallel()
+
+	live := SetupPostgres(t)
+	server := SetupPostgresDSN(t)
+	ctx := context.Background()
+
+	const migrations = `
+		CREATE TABLE users (id bigint PRIMARY KEY, email varchar(255) NOT NULL);
+		CREATE INDEX users_email_idx ON users (email);`
+
+	_, err := live.Exec(ctx, migrations+`
+		ALTER TABLE users ALTER COLUMN email TYPE text;
+		ALTER TABLE users ADD COLUMN nickname text;
+		DROP INDEX users_email_idx;`)
+	require.NoError(t, err)
+
+	scratch, err := database.NewScratch(ctx, server)
+	require.NoError(t, err)
+
+	_, err = scratch.Pool.Exec(ctx, migrations)
+	require.NoError(t, err)
+
+	expected, err := schema.Inspect(ctx, scratch.Pool)
+	require.NoError(t, err)
+
+	require.NoError(t, scratch.Drop(ctx))
+
+	actual, err := schema.Inspect(ctx, live)
+	require.NoError(t, err)
+
+	assert.Equal(t, []schema.Difference{
+		{Kind: schema.Changed, Object: "column", Name: "public.users.email", Property: "type",
+			Expected: "character varying(255)", Actual: "text"},
+		{Kind: schema.Extra, Object: "column", Name: "public.users.nickname"},
+		{Kind: schema.Missing, Object: "index", Name: "public.users_email_idx"},
+	}, schema.Compare(expected, actual))
+
+	pool, err := database.NewPool(ctx, server)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	var exists bool
+	require.NoError(t, pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)`,
+		scratch.Name).Scan(&exists))
+	assert.False(t, exists, "the scratch database is dropped")
+}
@@ -0,0 +1,93 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/importer"
+)
+
+func TestImport_readsSourceHistories(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	_, err := pool.Exec(ctx, `
+		CREATE SCHEMA gm;
+		CREATE TABLE gm.schema_migrations (version BIGINT PRIMARY KEY, dirty BOOLEAN NOT NULL);
+		INSERT INTO gm.schema_migrations VALUES (3, false);
+
+		CREATE TABLE goose_db_version (
+			id SERIAL PRIMARY KEY, version_id BIGINT NOT NULL, is_applied BOOLEAN NOT NULL,
+			tstamp TIMESTAMP DEFAULT now());
+		INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, true), (1, true), (2, true), (2, false), (3, true);
+
+		CREATE TABLE flyway_schema_history (
+			installed_rank INT PRIMARY KEY, version VARCHAR(50), type VARCHAR(20) NOT NULL, success BOOLEAN NOT NULL);
+		INSERT INTO flyway_schema_history VALUES
+			(1, '1', 'BASELINE', true), (2, '2', 'SQL', true), (3, '3', 'SQL', false),
+			(4, NULL, 'SQL', true), (5, '4', 'SQL', true), (6, '4', 'UNDO_SQL', true);
+
+		CREATE SCHEMA rails;
+		CREATE TABLE rails.schema_migrations (version VARCHAR PRIMARY KEY);
+		INSERT INTO rails.schema_migrations VALUES ('20240101120000'), ('20240102120000');`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		tool     importer.Tool
+		table    importer.Table
+		expected importer.Applied
+	}{
+		{importer.GolangMigrate, importer.Table{Schema: "gm", Name: "schema_migrations"},
+			importer.Applied{Versions: []string{"3"}, Through: "3"}},
+		{importer.Goose, importer.Table{Name: "goose_db_version"},
+			importer.Applied{Versions: []string{"1", "3"}}},
+		{importer.Flyway, importer.Table{Name: "flyway_schema_history"},
+			importer.Applied{Versions: []string{"2"}, Through: "1"}},
+		{importer.Rails, importer.Table{Schema: "rails", Name: "schema_migrations"},
+			importer.Applied{Versions: []string{"20240101120000", "20240102120000"}}},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, importer.CheckTables(ctx, pool, tt.table, importer.Table{Name: "schema_migrations"}))
+
+		applied, err := importer.ReadApplied(ctx, pool, tt.tool, tt.table)
+		require.NoError(t, err, tt.tool)
+		assert.Equal(t, tt.expected, applied, tt.tool)
+	}
+
+	_, err = pool.Exec(ctx, `UPDATE gm.schema_migrations SET dirty = true`)
+	require.NoError(t, err)
+
+	_, err = importer.ReadApplied(ctx, pool, importer.GolangMigrate, importer.Table{Schema: "gm", Name: "schema_migrations"})
+	require.ErrorIs(t, err, importer.ErrDirtyHistory)
+}
+
+func TestImport_checkTables(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	_, err := pool.Exec(ctx, `CREATE TABLE schema_migrations (version BIGINT PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	require.NoError(t, err)
+
+	source := importer.Table{Name: "schema_migrations"}
+
+	err = importer.CheckTables(ctx, pool, source, importer.Table{Name: "schema_migrations"})
+	require.ErrorIs(t, err, importer.ErrTableConflict)
+
+	err = importer.CheckTables(ctx, pool, source, importer.Table{Schema: "public", Name: "schema_migrations"})
+	require.ErrorIs(t, err, importer.ErrTableConflict)
+
+	require.NoError(t, importer.CheckTables(ctx, pool, source, importer.Table{Name: "migrate_versions"}))
+
+	err = importer.CheckTables(ctx, pool, importer.Table{Name: "goose_db_version"}, importer.Table{Name: "migrate_versions"})
+	require.ErrorIs(t, err, importer.ErrSourceTableNotFound)
+}
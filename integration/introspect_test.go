@@ -0,0 +1,114 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/parser"
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+const introspectFixture = `
+	CREATE ROLE introspect_reader;
+	CREATE SCHEMA app;
+	CREATE TYPE public.mood AS ENUM ('sad', 'happy');
+	CREATE FUNCTION public.new_code() RETURNS text LANGUAGE sql AS $$ SELECT md5(random()::text) $$;
+	REVOKE EXECUTE ON FUNCTION public.new_code() FROM PUBLIC;
+
+	CREATE TABLE users (
+		id bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+		email text NOT NULL UNIQUE,
+		name text COLLATE "C",
+		mood mood DEFAULT 'happy',
+		lower_email text GENERATED ALWAYS AS (lower(email)) STORED,
+		CONSTRAINT email_has_at CHECK (email LIKE '%@%')
+	);
+	CREATE TABLE orders (
+		id serial PRIMARY KEY,
+		user_id bigint NOT NULL REFERENCES users (id),
+		code text DEFAULT public.new_code()
+	);
+	CREATE INDEX orders_user_id_idx ON orders (user_id);
+	CREATE TABLE events (created_at date NOT NULL, payload jsonb) PARTITION BY RANGE (created_at);
+	CREATE TABLE events_2024 PARTITION OF events FOR VALUES FROM ('2024-01-01') TO ('2025-01-01');
+	CREATE INDEX events_created_at_idx ON events (created_at);
+
+	CREATE MATERIALIZED VIEW app.user_emails AS SELECT id, email FROM users;
+	CREATE VIEW app.active_users AS SELECT id FROM app.user_emails;
+	CREATE SEQUENCE app.tickets START 100 INCREMENT 5;
+
+	GRANT USAGE ON SCHEMA app TO introspect_reader;
+	GRANT SELECT, INSERT ON users TO introspect_reader WITH GRANT OPTION;
+	GRANT EXECUTE ON FUNCTION public.new_code() TO introspect_reader;`
+
+func TestInspect_roundTrips(t *testing.T) {
+	t.Parallel()
+
+	source := SetupPostgres(t)
+	target := SetupPostgres(t)
+	ctx := context.Background()
+
+	_, err := source.Exec(ctx, introspectFixture)
+	require.NoError(t, err)
+
+	tr := tracker.New(source)
+	require.NoError(t, tr.EnsureTable(ctx))
+
+	s, err := schema.Inspect(ctx, source, schema.WithExcludedTables(tr.Tables()...))
+	require.NoError(t, err)
+
+	sql := s.SQL()
+
+	_, err = parser.Parse(sql)
+	require.NoError(t, err)
+
+	assert.NotContains(t, sql, "schema_migrations")
+	assert.Contains(t, sql, "CREATE TABLE public.events_2024 PARTITION OF public.events")
+	assert.Contains(t, sql, "ALTER SEQUENCE public.orders_id_seq OWNED BY public.orders.id")
+	assert.Contains(t, sql, "ALTER TABLE public.orders ALTER COLUMN code SET DEFAULT public.new_code()")
+	assert.Contains(t, sql, "REVOKE EXECUTE ON FUNCTION public.new_code() FROM PUBLIC")
+	assert.Contains(t, sql, "GRANT INSERT, SELECT ON TABLE public.users TO introspect_reader WITH GRANT OPTION")
+
+	again, err := schema.Inspect(ctx, source, schema.WithExcludedTables(tr.Tables()...))
+	require.NoError(t, err)
+	assert.Equal(t, sql, again.SQL(), "introspection is deterministic")
+
+	_, err = target.Exec(ctx, `CREATE ROLE introspect_reader`)
+	require.NoError(t, err)
+
+	require.NoError(t, pgx.BeginFunc(ctx, target, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, sql)
+		return err
+	}))
+
+	replayed, err := schema.Inspect(ctx, target)
+	require.NoError(t, err)
+	assert.Equal(t, sql, replayed.SQL(), "the generated SQL recreates the schema")
+}
+
+func TestInspect_withSchemas(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	_, err := pool.Exec(ctx, `
+		CREATE SCHEMA app;
+		CREATE TABLE app.a (id int);
+		CREATE TABLE public.b (id int);`)
+	require.NoError(t, err)
+
+	s, err := schema.Inspect(ctx, pool, schema.WithSchemas("app"))
+	require.NoError(t, err)
+
+	require.Len(t, s.Tables, 1)
+	assert.Equal(t, "app.a", s.Tables[0].Name)
+	assert.Equal(t, []string{"app"}, s.Schemas)
+}
@@ -6,7 +6,10 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -220,11 +223,11 @@ func TestApply_dryRun_noChanges(t *testing.T) {
 	err := exec.Apply(ctx, migrations)
 	require.NoError(t, err)
 
-	// All should be skipped in dry-run.
+	// None should run in dry-run.
 	require.Len(t, events, 3)
 
 	for _, e := range events {
-		assert.Equal(t, executor.StatusSkipped, e.Status)
+		assert.Equal(t, executor.StatusDryRun, e.Status)
 	}
 
 	// No migrations should be recorded.
@@ -589,3 +592,354 @@ func TestRollback_reapplyAfterRollback(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, applied, 3)
 }
+
+func TestApplyAndRollback_recordHistory(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool)
+	migrations := makeMigrationsWithDown()
+
+	exec := executor.New(pool, tr, executor.WithTicket("CHG-9"))
+
+	require.NoError(t, exec.Apply(ctx, migrations))
+	require.NoError(t, exec.Rollback(ctx, migrations, 1))
+	require.NoError(t, exec.Apply(ctx, migrations))
+
+	history, err := tr.GetHistory(ctx, tracker.HistoryQuery{Version: migrations[len(migrations)-1].Version})
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, tracker.DirectionUp, history[0].Direction)
+	assert.Equal(t, tracker.DirectionDown, history[1].Direction)
+	assert.Equal(t, tracker.DirectionUp, history[2].Direction)
+
+	for _, h := range history {
+		assert.Equal(t, tracker.OutcomeSucceeded, h.Outcome)
+		assert.Equal(t, "CHG-9", h.Ticket)
+	}
+}
+
+func TestApply_interrupted_cancelsInFlightQueryAndReleasesLock(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	tr := tracker.New(pool)
+
+	slowSQL := "CREATE TABLE slow (id INT); SELECT pg_sleep(60);"
+	migrations := []migration.Migration{
+		{
+			Version:  "001",
+			Name:     "slow",
+			UpSQL:    slowSQL,
+			Checksum: migration.ComputeChecksum(slowSQL),
+			FilePath: "V001_slow.up.sql",
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	exec := executor.New(pool, tr,
+		executor.WithStatementTimeout(0),
+		executor.WithGracePeriod(100*time.Millisecond),
+	)
+
+	time.AfterFunc(500*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := exec.Apply(ctx, migrations)
+
+	require.ErrorIs(t, err, executor.ErrInterrupted)
+	assert.Less(t, time.Since(start), 30*time.Second)
+
+	bg := context.Background()
+
+	applied, err := tr.IsApplied(bg, "001")
+	require.NoError(t, err)
+	assert.False(t, applied)
+
+	var exists bool
+	require.NoError(t, pool.QueryRow(bg, "SELECT to_regclass('slow') IS NOT NULL").Scan(&exists))
+	assert.False(t, exists, "interrupted transaction should be rolled back")
+
+	history, err := tr.GetHistory(bg, tracker.HistoryQuery{Version: "001"})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, tracker.OutcomeInterrupted, history[0].Outcome)
+
+	handle, err := database.TryAcquireLock(bg, pool)
+	require.NoError(t, err, "advisory lock should be released after interruption")
+	require.NoError(t, handle.Release(bg))
+}
+
+func TestApplyStepsAndToVersion_stagedRollout(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool)
+	migrations := makeMigrations()
+	exec := executor.New(pool, tr)
+
+	require.NoError(t, exec.ApplySteps(ctx, migrations, 1))
+
+	applied, err := tr.GetApplied(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, migrations[0].Version, applied[0].Version)
+
+	require.NoError(t, exec.ApplyToVersion(ctx, migrations, migrations[1].Version))
+
+	applied, err = tr.GetApplied(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+
+	err = exec.ApplyToVersion(ctx, migrations, "999")
+	require.ErrorIs(t, err, migration.ErrVersionNotFound)
+}
+
+func TestApply_outOfOrder_rejectedUnlessAllowed(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool)
+	migrations := makeMigrations()
+	late := []migration.Migration{migrations[0], migrations[2]}
+
+	require.NoError(t, executor.New(pool, tr).Apply(ctx, late))
+
+	err := executor.New(pool, tr).Apply(ctx, migrations)
+	require.ErrorIs(t, err, executor.ErrOutOfOrder)
+
+	applied, err := tr.IsApplied(ctx, migrations[1].Version)
+	require.NoError(t, err)
+	assert.False(t, applied)
+
+	require.NoError(t, executor.New(pool, tr, executor.WithAllowOutOfOrder(true)).Apply(ctx, migrations))
+
+	applied, err = tr.IsApplied(ctx, migrations[1].Version)
+	require.NoError(t, err)
+	assert.True(t, applied)
+}
+
+func TestApply_repeatables_reappliedWhenChanged(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool)
+
+	view := func(sql string) migration.Migration {
+		return migration.Migration{
+			Name:       "user_names",
+			UpSQL:      sql,
+			Checksum:   migration.ComputeChecksum(sql),
+			FilePath:   "migrations/R__user_names.sql",
+			Repeatable: true,
+		}
+	}
+
+	v1 := view("CREATE OR REPLACE VIEW user_names AS SELECT name FROM users;")
+	exec := executor.New(pool, tr, executor.WithRepeatables([]migration.Migration{v1}))
+	require.NoError(t, exec.Apply(ctx, makeMigrations()))
+
+	// Unchanged: applying again does not re-run the view.
+	require.NoError(t, exec.Apply(ctx, makeMigrations()))
+
+	history, err := tr.GetHistory(ctx, tracker.HistoryQuery{})
+	require.NoError(t, err)
+	assert.Len(t, history, 4)
+
+	// Edited in place: the view is replaced after the versioned migrations.
+	v2 := view("CREATE OR REPLACE VIEW user_names AS SELECT name, email FROM users;")
+	exec = executor.New(pool, tr, executor.WithRepeatables([]migration.Migration{v2}))
+	require.NoError(t, exec.Apply(ctx, makeMigrations()))
+
+	var cols int
+	require.NoError(t, pool.QueryRow(ctx,
+		`SELECT count(*) FROM information_schema.columns WHERE table_name = 'user_names'`).Scan(&cols))
+	assert.Equal(t, 2, cols)
+
+	applied, err := tr.GetRepeatables(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, v2.Checksum, applied[0].Checksum)
+	assert.Equal(t, "R__user_names.sql", applied[0].Filename)
+}
+
+func TestApply_hooks_runInMigrationTransaction(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool)
+
+	logVersion := func(ctx context.Context, db migration.DB, ev migration.HookEvent) error {
+		_, err := db.Exec(ctx, "INSERT INTO hook_log VALUES ($1)", string(ev.Point)+" "+ev.Migration.Version)
+		return err
+	}
+
+	exec := executor.New(pool, tr, executor.WithHooks([]migration.Hook{
+		{Point: migration.HookBeforeAll, SQL: "CREATE TABLE IF NOT EXISTS hook_log (entry TEXT);", FilePath: "beforeAll.sql"},
+		{Point: migration.HookAfterEach, Func: logVersion},
+		{Point: migration.HookAfterAll, SQL: "INSERT INTO hook_log VALUES ('afterAll');", FilePath: "afterAll.sql"},
+	}))
+	require.NoError(t, exec.Apply(ctx, makeMigrations()))
+
+	var entries []string
+
+	rows, err := pool.Query(ctx, "SELECT entry FROM hook_log")
+	require.NoError(t, err)
+
+	for rows.Next() {
+		var entry string
+		require.NoError(t, rows.Scan(&entry))
+		entries = append(entries, entry)
+	}
+
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"afterEach 001", "afterEach 002", "afterEach 003", "afterAll"}, entries)
+
+	// A failing afterEach hook rolls back the migration it ran with.
+	failing := executor.New(pool, tr, executor.WithHooks([]migration.Hook{
+		{Point: migration.HookAfterEach, SQL: "SELECT 1/0;", FilePath: "afterEach.sql"},
+	}))
+
+	tagsSQL := "CREATE TABLE tags (id SERIAL PRIMARY KEY);"
+	err = failing.Apply(ctx, append(makeMigrations(), migration.Migration{
+		Version:  "004",
+		Name:     "create_tags",
+		UpSQL:    tagsSQL,
+		Checksum: migration.ComputeChecksum(tagsSQL),
+		FilePath: "migrations/V004_create_tags.up.sql",
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "running hook afterEach.sql")
+
+	var exists bool
+	require.NoError(t, pool.QueryRow(ctx, "SELECT to_regclass('tags') IS NOT NULL").Scan(&exists))
+	assert.False(t, exists)
+}
+
+func TestApply_directives_roleAndNoTransaction(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	_, err := pool.Exec(ctx, "CREATE ROLE app_owner; GRANT CREATE ON SCHEMA public TO app_owner")
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{
+		"V001_users.up.sql": {Data: []byte("-- migrate:role app_owner\nCREATE TABLE users (id INT, email TEXT);")},
+		"V002_index.up.sql": {Data: []byte("-- migrate:no-transaction\n-- migrate:lock-timeout 1s\n" +
+			"VACUUM users;")},
+	}
+
+	ms, err := migration.LoadFromFS(fsys, ".")
+	require.NoError(t, err)
+
+	exec := executor.New(pool, tracker.New(pool))
+	require.NoError(t, exec.Apply(ctx, migration.Sort(ms)))
+
+	var owner string
+	require.NoError(t, pool.QueryRow(ctx,
+		"SELECT tableowner FROM pg_tables WHERE tablename = 'users'").Scan(&owner))
+	assert.Equal(t, "app_owner", owner)
+
+	var lockTimeout string
+	require.NoError(t, pool.QueryRow(ctx, "SHOW lock_timeout").Scan(&lockTimeout))
+	assert.Equal(t, "0", lockTimeout, "session settings must not leak into pooled connections")
+}
+
+func TestApply_noTransaction_usesConfiguredTimeouts(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	_, err := pool.Exec(ctx, "CREATE TABLE users (id INT)")
+	require.NoError(t, err)
+
+	var lockTimeout, stmtTimeout string
+
+	exec := executor.New(pool, tracker.New(pool),
+		executor.WithLockTimeout(3*time.Second),
+		executor.WithStatementTimeout(time.Minute),
+		executor.WithHooks([]migration.Hook{{
+			Point: migration.HookBeforeEach,
+			Func: func(ctx context.Context, db migration.DB, _ migration.HookEvent) error {
+				if err := db.QueryRow(ctx, "SHOW lock_timeout").Scan(&lockTimeout); err != nil {
+					return err
+				}
+
+				return db.QueryRow(ctx, "SHOW statement_timeout").Scan(&stmtTimeout)
+			},
+		}}),
+	)
+
+	fsys := fstest.MapFS{
+		"V001_index.up.sql": {Data: []byte("-- migrate:statement-timeout 2m\n" +
+			"CREATE INDEX CONCURRENTLY idx_users_id ON users (id);")},
+	}
+
+	ms, err := migration.LoadFromFS(fsys, ".")
+	require.NoError(t, err)
+	require.NoError(t, exec.Apply(ctx, ms))
+
+	assert.Equal(t, "3s", lockTimeout)
+	assert.Equal(t, "2min", stmtTimeout, "directives take precedence over the configured timeouts")
+}
+
+func TestApply_hooksOutsideTransaction_resetSession(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// Two connections: one holds the advisory lock, the other runs everything
+	// else, so both can be inspected afterwards.
+	cfg, err := pgxpool.ParseConfig(SetupPostgresDSN(t))
+	require.NoError(t, err)
+	cfg.MaxConns = 2
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	_, err = pool.Exec(ctx, "CREATE TABLE users (id INT)")
+	require.NoError(t, err)
+
+	exec := executor.New(pool, tracker.New(pool), executor.WithHooks([]migration.Hook{
+		{Point: migration.HookBeforeEach, SQL: "SET application_name = 'hooked'", FilePath: "beforeEach.sql"},
+	}))
+
+	require.NoError(t, exec.Apply(ctx, []migration.Migration{
+		{
+			Version:    "001",
+			Name:       "index_users",
+			UpSQL:      "CREATE INDEX CONCURRENTLY idx_users_id ON users (id);",
+			Checksum:   "a",
+			FilePath:   "migrations/V001_index_users.up.sql",
+			Directives: migration.Directives{NoTransaction: true},
+		},
+		{
+			Version:  "002",
+			Name:     "analyze_users",
+			Checksum: "b",
+			UpFunc: &migration.GoFunc{Pool: func(ctx context.Context, pool *pgxpool.Pool) error {
+				_, err := pool.Exec(ctx, "ANALYZE users")
+				return err
+			}},
+		},
+	}))
+
+	for range cfg.MaxConns {
+		conn, err := pool.Acquire(ctx)
+		require.NoError(t, err)
+		defer conn.Release()
+
+		var name string
+		require.NoError(t, conn.QueryRow(ctx, "SHOW application_name").Scan(&name))
+		assert.NotEqual(t, "hooked", name, "hook settings must not leak into pooled connections")
+	}
+}
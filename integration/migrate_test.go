@@ -0,0 +1,163 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/pkg/migrate"
+)
+
+func TestPublicAPI_loadPlanApplyRollback(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	ms, err := migrate.LoadFS(fstest.MapFS{
+		"migrations/V001_users.up.sql":   {Data: []byte("CREATE TABLE users (id int);")},
+		"migrations/V001_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations/V002_posts.up.sql":   {Data: []byte("CREATE TABLE posts (id int);")},
+		"migrations/V002_posts.down.sql": {Data: []byte("DROP TABLE posts;")},
+	}, "migrations")
+	require.NoError(t, err)
+
+	var events []migrate.Event
+
+	m := migrate.New(pool, migrate.WithProgress(func(ev migrate.Event) { events = append(events, ev) }))
+
+	plan, err := m.Plan(ctx, ms)
+	require.NoError(t, err)
+	assert.Len(t, plan.Pending(), 2)
+
+	result, err := m.ApplySteps(ctx, ms, 1)
+	require.NoError(t, err)
+	require.Len(t, result.Completed(), 1)
+	assert.Equal(t, "001", result.Completed()[0].Version)
+
+	// A dry run reports only 002: 001 is already applied.
+	result, err = migrate.New(pool, migrate.WithDryRun(true)).Apply(ctx, ms)
+	require.NoError(t, err)
+	require.Len(t, result.Migrations, 1)
+	assert.Equal(t, "002", result.Migrations[0].Migration.Version)
+	assert.Equal(t, migrate.StatusDryRun, result.Migrations[0].Status)
+
+	result, err = m.Apply(ctx, ms)
+	require.NoError(t, err)
+	require.Len(t, result.Migrations, 1)
+	require.Len(t, result.Completed(), 1)
+	assert.Equal(t, "002", result.Completed()[0].Version)
+
+	// Starting and completed for 001 and 002; the already-applied 001 is
+	// not reported on the second Apply.
+	assert.Len(t, events, 4)
+
+	var toolVersion string
+	require.NoError(t, pool.QueryRow(ctx,
+		"SELECT tool_version FROM schema_migrations_history WHERE version = '002'").Scan(&toolVersion))
+	assert.NotEqual(t, "unknown", toolVersion)
+
+	plan, err = m.Plan(ctx, ms)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Pending())
+
+	result, err = m.Rollback(ctx, ms, 2)
+	require.NoError(t, err)
+	require.Len(t, result.Completed(), 2)
+	assert.Equal(t, "002", result.Completed()[0].Version)
+
+	_, err = m.Rollback(ctx, ms, 1)
+	require.ErrorIs(t, err, migrate.ErrNothingToRollback)
+}
+
+func TestPublicAPI_failedMigration_reportedInResult(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	ms := []migrate.Migration{
+		{Version: "001", Name: "ok", UpSQL: "CREATE TABLE a (id int);"},
+		{Version: "002", Name: "bad", UpSQL: "ALTER TABLE missing ADD COLUMN x int;"},
+	}
+
+	result, err := migrate.New(pool).Apply(ctx, ms)
+	require.Error(t, err)
+	require.Len(t, result.Migrations, 2)
+	assert.Equal(t, migrate.StatusCompleted, result.Migrations[0].Status)
+	assert.Equal(t, migrate.StatusFailed, result.Migrations[1].Status)
+	assert.Error(t, result.Migrations[1].Err)
+}
+
+func TestPublicAPI_goMigrations_interleaveWithSQL(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	r := migrate.NewRegistry()
+	r.MustRegister(migrate.GoMigration{
+		Version: "002",
+		Name:    "seed_users",
+		Hash:    "v1",
+		Up: migrate.GoFunc{Tx: func(ctx context.Context, tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, "INSERT INTO users (id) SELECT generate_series(1, 10)")
+			return err
+		}},
+		Down: &migrate.GoFunc{Tx: func(ctx context.Context, tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, "DELETE FROM users")
+			return err
+		}},
+	})
+	r.MustRegister(migrate.GoMigration{
+		Version: "004",
+		Name:    "backfill_flag",
+		Hash:    "v1",
+		Up: migrate.GoFunc{Pool: func(ctx context.Context, pool *pgxpool.Pool) error {
+			for {
+				tag, err := pool.Exec(ctx, `UPDATE users SET flag = true
+					WHERE id IN (SELECT id FROM users WHERE flag IS NULL LIMIT 3)`)
+				if err != nil || tag.RowsAffected() == 0 {
+					return err
+				}
+			}
+		}},
+	})
+
+	ms, err := migrate.Merge(r.Migrations(), []migrate.Migration{
+		{Version: "001", Name: "users", UpSQL: "CREATE TABLE users (id int PRIMARY KEY);"},
+		{Version: "003", Name: "flag", UpSQL: "ALTER TABLE users ADD COLUMN flag boolean;"},
+	})
+	require.NoError(t, err)
+
+	m := migrate.New(pool)
+
+	result, err := m.Apply(ctx, ms)
+	require.NoError(t, err)
+	require.Len(t, result.Completed(), 4)
+
+	var flagged int
+	require.NoError(t, pool.QueryRow(ctx, "SELECT count(*) FROM users WHERE flag").Scan(&flagged))
+	assert.Equal(t, 10, flagged)
+
+	// Changing a Go migration's hash is caught like an edited SQL file.
+	changed := migrate.NewRegistry()
+	changed.MustRegister(migrate.GoMigration{
+		Version: "002", Name: "seed_users", Hash: "v2",
+		Up: migrate.GoFunc{Tx: func(context.Context, pgx.Tx) error { return nil }},
+	})
+
+	_, err = m.Apply(ctx, append([]migrate.Migration{ms[0]}, changed.Migrations()...))
+	require.ErrorIs(t, err, migrate.ErrChecksumMismatch)
+
+	// Rolling back 004 fails: it has no down function.
+	_, err = m.Rollback(ctx, ms, 1)
+	require.ErrorIs(t, err, migrate.ErrNoDownSQL)
+}
@@ -4,6 +4,7 @@ package integration
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -129,3 +130,276 @@ func TestTracker_RecordApplied_upsertAfterRollback(t *testing.T) {
 	require.Len(t, applied, 1)
 	assert.Equal(t, 35, applied[0].DurationMs)
 }
+
+func TestTracker_RecordBaseline_countsAsApplied(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool)
+
+	require.NoError(t, tr.EnsureTable(ctx))
+
+	require.NoError(t, tr.RecordBaseline(ctx, tracker.RecordParams{
+		Version:  "001",
+		Filename: "V001_create_users.up.sql",
+		Checksum: "abc123",
+	}))
+
+	ok, err := tr.IsApplied(ctx, "001")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	applied, err := tr.GetApplied(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, tracker.StatusBaseline, applied[0].Status)
+}
+
+func TestTracker_History_appendOnly(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool, tracker.WithToolVersion("test"))
+
+	require.NoError(t, tr.EnsureTable(ctx))
+
+	for _, p := range []tracker.HistoryParams{
+		{Version: "001", Filename: "V001_a.up.sql", Direction: tracker.DirectionUp, Checksum: "abc", Outcome: tracker.OutcomeSucceeded, Ticket: "CHG-1"},
+		{Version: "001", Filename: "V001_a.up.sql", Direction: tracker.DirectionDown, Checksum: "abc", Outcome: tracker.OutcomeSucceeded},
+		{Version: "002", Filename: "V002_b.up.sql", Direction: tracker.DirectionUp, Checksum: "def", Outcome: tracker.OutcomeFailed, Error: "boom"},
+	} {
+		require.NoError(t, tr.RecordHistory(ctx, p))
+	}
+
+	all, err := tr.GetHistory(ctx, tracker.HistoryQuery{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	// Newest first.
+	assert.Equal(t, "002", all[0].Version)
+	assert.Equal(t, tracker.OutcomeFailed, all[0].Outcome)
+	assert.Equal(t, "boom", all[0].Error)
+	assert.Equal(t, testUser, all[0].DBUser)
+	assert.Equal(t, "test", all[0].ToolVersion)
+	assert.NotEmpty(t, all[0].Hostname)
+	assert.Equal(t, "CHG-1", all[2].Ticket)
+
+	byVersion, err := tr.GetHistory(ctx, tracker.HistoryQuery{Version: "001"})
+	require.NoError(t, err)
+	require.Len(t, byVersion, 2)
+	assert.Equal(t, tracker.DirectionDown, byVersion[0].Direction)
+
+	limited, err := tr.GetHistory(ctx, tracker.HistoryQuery{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+}
+
+func TestTracker_customSchemaAndTable(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool, tracker.WithTable("migrate", "Billing Migrations"))
+
+	require.NoError(t, tr.EnsureTable(ctx))
+	require.NoError(t, tr.EnsureTable(ctx))
+
+	require.NoError(t, tr.RecordApplied(ctx, tracker.RecordParams{
+		Version: "001", Filename: "V001_a.up.sql", Checksum: "abc", DurationMs: 1,
+	}))
+
+	var count int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT count(*) FROM "migrate"."Billing Migrations"`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	var exists bool
+	require.NoError(t, pool.QueryRow(ctx,
+		`SELECT to_regclass('"migrate"."Billing Migrations_history"') IS NOT NULL`).Scan(&exists))
+	assert.True(t, exists)
+
+	// The default table is untouched, so an independent tracker sees nothing.
+	other := tracker.New(pool)
+	require.NoError(t, other.EnsureTable(ctx))
+
+	applied, err := other.GetApplied(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestTracker_EnsureTable_upgradesLegacySchema(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+
+	// A tracking table as created before the schema was versioned.
+	_, err := pool.Exec(ctx, `CREATE TABLE schema_migrations (
+		version      TEXT PRIMARY KEY,
+		filename     TEXT NOT NULL,
+		checksum     TEXT NOT NULL,
+		applied_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		duration_ms  INTEGER NOT NULL,
+		status       TEXT NOT NULL DEFAULT 'applied'
+	)`)
+	require.NoError(t, err)
+
+	_, err = pool.Exec(ctx,
+		`INSERT INTO schema_migrations (version, filename, checksum, duration_ms) VALUES ('001', 'V001_a.up.sql', 'abc', 5)`)
+	require.NoError(t, err)
+
+	tr := tracker.New(pool)
+	require.NoError(t, tr.EnsureTable(ctx))
+
+	var version int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT schema_version FROM schema_migrations_meta`).Scan(&version))
+	assert.Equal(t, tracker.SchemaVersion, version)
+
+	// Existing rows survive and new columns are readable.
+	applied, err := tr.GetApplied(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, "abc", applied[0].Checksum)
+	assert.Empty(t, applied[0].DownChecksum)
+
+	require.NoError(t, tr.RecordApplied(ctx, tracker.RecordParams{
+		Version: "002", Filename: "V002_b.up.sql", Checksum: "def", DownChecksum: "ghi", DurationMs: 1,
+	}))
+
+	applied, err = tr.GetApplied(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+	assert.Equal(t, "ghi", applied[1].DownChecksum)
+	assert.NotEmpty(t, applied[1].Hostname)
+}
+
+func TestTracker_EnsureTable_refusesNewerSchema(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool)
+
+	require.NoError(t, tr.EnsureTable(ctx))
+
+	_, err := pool.Exec(ctx, `UPDATE schema_migrations_meta SET schema_version = $1`, tracker.SchemaVersion+1)
+	require.NoError(t, err)
+
+	err = tr.EnsureTable(ctx)
+	require.ErrorIs(t, err, tracker.ErrSchemaTooNew)
+}
+
+func TestTracker_GetApplied_ordersNumerically(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool)
+	require.NoError(t, tr.EnsureTable(ctx))
+
+	for _, v := range []string{"10", "9", "100"} {
+		require.NoError(t, tr.RecordApplied(ctx, tracker.RecordParams{
+			Version:  v,
+			Filename: "V" + v + "_m.up.sql",
+			Checksum: "c" + v,
+		}))
+	}
+
+	applied, err := tr.GetApplied(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 3)
+	assert.Equal(t, "9", applied[0].Version)
+	assert.Equal(t, "10", applied[1].Version)
+	assert.Equal(t, "100", applied[2].Version)
+}
+
+func TestTracker_RecordRepeatable_replacesPreviousRun(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool)
+	require.NoError(t, tr.EnsureTable(ctx))
+
+	applied, err := tr.GetRepeatables(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	require.NoError(t, tr.RecordRepeatable(ctx, tracker.RepeatableParams{
+		Name: "views", Filename: "R__views.sql", Checksum: "v1", DurationMs: 3,
+	}))
+	require.NoError(t, tr.RecordRepeatable(ctx, tracker.RepeatableParams{
+		Name: "functions", Filename: "R__functions.sql", Checksum: "f1", DurationMs: 1,
+	}))
+	require.NoError(t, tr.RecordRepeatable(ctx, tracker.RepeatableParams{
+		Name: "views", Filename: "R__views.sql", Checksum: "v2", DurationMs: 4,
+	}))
+
+	applied, err = tr.GetRepeatables(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+	assert.Equal(t, "functions", applied[0].Name)
+	assert.Equal(t, "views", applied[1].Name)
+	assert.Equal(t, "v2", applied[1].Checksum)
+	assert.Equal(t, 4, applied[1].DurationMs)
+	assert.NotEmpty(t, applied[1].Hostname)
+
+	// Repeatables are tracked apart from versioned migrations.
+	versioned, err := tr.GetApplied(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, versioned)
+}
+
+func TestTracker_Exists_doesNotCreate(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool, tracker.WithTable("ops", "versions"))
+
+	exists, err := tr.Exists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	var schemaExists bool
+	require.NoError(t, pool.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = 'ops')").Scan(&schemaExists))
+	assert.False(t, schemaExists)
+
+	require.NoError(t, tr.EnsureTable(ctx))
+
+	exists, err = tr.Exists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestTracker_InTx_rollsBackOnError(t *testing.T) {
+	t.Parallel()
+
+	pool := SetupPostgres(t)
+	ctx := context.Background()
+	tr := tracker.New(pool)
+	require.NoError(t, tr.EnsureTable(ctx))
+
+	errStop := errors.New("stop")
+
+	err := tr.InTx(ctx, func(tx *tracker.Tracker) error {
+		require.NoError(t, tx.RecordApplied(ctx, tracker.RecordParams{Version: "001", Filename: "V001_a.up.sql", Checksum: "a"}))
+
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+
+	applied, err := tr.GetApplied(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	require.NoError(t, tr.InTx(ctx, func(tx *tracker.Tracker) error {
+		return tx.RecordApplied(ctx, tracker.RecordParams{Version: "001", Filename: "V001_a.up.sql", Checksum: "a"})
+	}))
+
+	applied, err = tr.GetApplied(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+}
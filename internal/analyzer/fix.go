@@ -0,0 +1,427 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/parser"
+)
+
+// Fixer is implemented by rules that can rewrite a statement they flag into
+// a safe equivalent.
+type Fixer interface {
+	// Fix returns the statements that replace stmt, or nil if there is
+	// nothing to fix. stmt may be modified and returned among them.
+	Fix(stmt *pg_query.RawStmt, ctx *RuleContext) []FixedStmt
+}
+
+// FixedStmt is a statement written by a Fixer.
+type FixedStmt struct {
+	Stmt *pg_query.Node
+	// Alone marks a statement that cannot run in a transaction block, such
+	// as CREATE INDEX CONCURRENTLY, so it needs a migration of its own.
+	Alone bool
+	// AfterCommit marks a statement that must not share a transaction with
+	// the statements before it, such as VALIDATE CONSTRAINT after ADD
+	// CONSTRAINT ... NOT VALID, whose lock would otherwise be held while
+	// the table is scanned.
+	AfterCommit bool
+}
+
+// FixResult is a migration rewritten by Fix.
+type FixResult struct {
+	Migration *migration.Migration
+	// Parts is the fixed up SQL. The first part replaces the migration's;
+	// any others hold statements that need a transaction of their own and
+	// must follow it as migrations of their own, in order.
+	Parts []string
+	// Undo holds how to undo each part after the first, in order. The first
+	// part is undone by the migration's own down SQL.
+	Undo  []PartUndo
+	Rules []string // IDs of the rules that fixed a statement, in order of first fix
+}
+
+// PartUndo is the down SQL of one part of a FixResult.
+type PartUndo struct {
+	SQL string // statements undoing the part; empty if there is nothing to undo
+	// Reversible is false if the part holds a statement Fix cannot undo,
+	// such as one it did not rewrite, so the part gets no down SQL.
+	Reversible bool
+}
+
+// Changed reports whether any statement was fixed.
+func (r *FixResult) Changed() bool {
+	return len(r.Rules) > 0
+}
+
+// Fix rewrites the statements of m's up SQL that the registered rules
+// implementing Fixer flag, and splits the SQL where a rewritten statement
+// cannot share a transaction with the ones before it. Statements no rule
+// fixes keep their text; fixed ones are deparsed from the rewritten syntax
+// tree. Comments before a statement stay with it, and so do comments
+// after it on the same line.
+func (a *Analyzer) Fix(m *migration.Migration) (*FixResult, error) {
+	sql := m.UpSQL
+
+	result, err := a.parseFn(sql)
+	if err != nil {
+		return nil, fmt.Errorf("parsing migration %s: %w", m.Version, err)
+	}
+
+	res := &FixResult{Migration: m, Parts: []string{sql}}
+	names := constraintNames(result.Stmts)
+
+	// The parser reads the SQL without its surrounding whitespace.
+	offset := len(sql) - len(strings.TrimLeftFunc(sql, unicode.IsSpace))
+	trimmedEnd := len(strings.TrimRightFunc(sql, unicode.IsSpace))
+	b := &partBuilder{}
+	b.cur.WriteString(sql[:offset])
+	written := offset
+
+	for i, raw := range result.Stmts {
+		start := offset + int(raw.StmtLocation)
+		end := trimmedEnd
+
+		if raw.StmtLen > 0 {
+			end = start + int(raw.StmtLen)
+		}
+
+		// The statement starts after the comment that ended the one before.
+		text := sql[max(start, written):end]
+
+		written = end
+		if written < len(sql) && sql[written] == ';' {
+			written++
+		}
+
+		comment := sql[written : written+trailingComment(sql[written:])]
+		written += len(comment)
+
+		ctx := &RuleContext{Migration: m, TargetPGVersion: a.pgVersion, StmtIndex: i, SQL: sql, names: names}
+
+		fixed, rules := a.fixStmt(raw, ctx)
+		if len(rules) == 0 {
+			b.add(text+";"+comment, FixedStmt{})
+			continue
+		}
+
+		for _, id := range rules {
+			if !slices.Contains(res.Rules, id) {
+				res.Rules = append(res.Rules, id)
+			}
+		}
+
+		if err := b.addFixed(text[:leadingTrivia(text)], fixed, comment); err != nil {
+			return nil, fmt.Errorf("migration %s: %w", m.Version, err)
+		}
+	}
+
+	if !res.Changed() {
+		return res, nil
+	}
+
+	b.cur.WriteString(sql[written:])
+	res.Parts, res.Undo, err = b.finish()
+	if err != nil {
+		return nil, fmt.Errorf("migration %s: %w", m.Version, err)
+	}
+
+	return res, nil
+}
+
+// constraintNames returns the names of the constraints stmts declare, so
+// fixes do not give the same name to another.
+func constraintNames(stmts []*pg_query.RawStmt) map[string]bool {
+	names := make(map[string]bool)
+
+	for _, raw := range stmts {
+		parser.Walk(raw, func(msg proto.Message) {
+			if c, ok := msg.(*pg_query.Constraint); ok && c.Conname != "" {
+				names[c.Conname] = true
+			}
+		})
+	}
+
+	return names
+}
+
+// fixStmt runs the fixers over a statement, each over the statements the
+// ones before it returned, and returns the result with the IDs of the rules
+// that changed it.
+func (a *Analyzer) fixStmt(raw *pg_query.RawStmt, ctx *RuleContext) ([]FixedStmt, []string) {
+	stmts := []FixedStmt{{Stmt: raw.Stmt}}
+
+	var rules []string
+
+	for _, rule := range a.registry.Rules() {
+		fixer, ok := rule.(Fixer)
+		if !ok {
+			continue
+		}
+
+		var next []FixedStmt
+
+		for _, s := range stmts {
+			out := fixer.Fix(&pg_query.RawStmt{Stmt: s.Stmt}, ctx)
+			if len(out) == 0 {
+				next = append(next, s)
+				continue
+			}
+
+			out[0].Alone = out[0].Alone || s.Alone
+			out[0].AfterCommit = out[0].AfterCommit || s.AfterCommit
+			next = append(next, out...)
+
+			if !slices.Contains(rules, rule.ID()) {
+				rules = append(rules, rule.ID())
+			}
+		}
+
+		stmts = next
+	}
+
+	return stmts, rules
+}
+
+// partBuilder accumulates the parts of fixed SQL, starting a new part
+// before a statement that cannot share a transaction with those before it,
+// and after one that must be alone, and the statements undoing each part.
+type partBuilder struct {
+	parts []string
+	undo  [][]*pg_query.Node // undo each part in order; nil where irreversible
+	cur   strings.Builder
+	stmts int  // statements in cur
+	alone bool // cur holds a statement that must be alone
+
+	undoStmts    []*pg_query.Node // undo the statements in cur, last first
+	irreversible bool             // cur holds a statement with no undo
+}
+
+// add appends a statement, with the text before it, to the current part or
+// a new one. f.Stmt is nil for a statement that was not rewritten.
+func (b *partBuilder) add(text string, f FixedStmt) {
+	if b.stmts > 0 && (b.alone || f.Alone || f.AfterCommit) {
+		b.flush()
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+	}
+
+	b.cur.WriteString(text)
+	b.stmts++
+	b.alone = f.Alone
+
+	if undo, ok := undoStmt(f.Stmt); !ok {
+		b.irreversible = true
+	} else if undo != nil {
+		b.undoStmts = slices.Insert(b.undoStmts, 0, undo)
+	}
+}
+
+// addFixed deparses fixed statements and appends them, the first after
+// prefix, the comments and whitespace that came before the original, and
+// the last followed by comment, the one after the original on its line.
+func (b *partBuilder) addFixed(prefix string, fixed []FixedStmt, comment string) error {
+	for i, f := range fixed {
+		sql, err := pg_query.Deparse(&pg_query.ParseResult{Stmts: []*pg_query.RawStmt{{Stmt: f.Stmt}}})
+		if err != nil {
+			return fmt.Errorf("deparsing fixed statement: %w", err)
+		}
+
+		if i > 0 {
+			prefix = "\n"
+		}
+
+		suffix := ""
+		if i == len(fixed)-1 {
+			suffix = comment
+		}
+
+		b.add(prefix+sql+";"+suffix, f)
+	}
+
+	return nil
+}
+
+// flush ends the current part.
+func (b *partBuilder) flush() {
+	b.parts = append(b.parts, strings.TrimRightFunc(b.cur.String(), unicode.IsSpace)+"\n")
+
+	if b.irreversible {
+		b.undo = append(b.undo, nil)
+	} else {
+		b.undo = append(b.undo, append([]*pg_query.Node{}, b.undoStmts...))
+	}
+
+	b.cur.Reset()
+	b.stmts, b.alone = 0, false
+	b.undoStmts, b.irreversible = nil, false
+}
+
+// finish ends the last part and returns all of them, with the undo of
+// each one after the first.
+func (b *partBuilder) finish() ([]string, []PartUndo, error) {
+	b.flush()
+
+	undo := make([]PartUndo, len(b.undo)-1)
+
+	for i, stmts := range b.undo[1:] {
+		if stmts == nil {
+			continue
+		}
+
+		undo[i].Reversible = true
+
+		for _, stmt := range stmts {
+			sql, err := pg_query.Deparse(&pg_query.ParseResult{Stmts: []*pg_query.RawStmt{{Stmt: stmt}}})
+			if err != nil {
+				return nil, nil, fmt.Errorf("deparsing undo statement: %w", err)
+			}
+
+			undo[i].SQL += sql + ";\n"
+		}
+	}
+
+	return b.parts, undo, nil
+}
+
+// undoStmt returns the statement undoing stmt, a statement rewritten by a
+// fixer, or nil if there is nothing to undo. Reports false if stmt cannot
+// be undone, or was not rewritten (nil).
+func undoStmt(stmt *pg_query.Node) (*pg_query.Node, bool) {
+	switch {
+	case stmt == nil:
+		return nil, false
+	case stmt.GetIndexStmt() != nil:
+		x := stmt.GetIndexStmt()
+		if !x.Concurrent || x.Idxname == "" {
+			return nil, false
+		}
+
+		// An index is created in the schema of its table.
+		name := []*pg_query.Node{pg_query.MakeStrNode(x.Idxname)}
+		if schema := x.GetRelation().GetSchemaname(); schema != "" {
+			name = slices.Insert(name, 0, pg_query.MakeStrNode(schema))
+		}
+
+		return &pg_query.Node{Node: &pg_query.Node_DropStmt{DropStmt: &pg_query.DropStmt{
+			Objects:    []*pg_query.Node{pg_query.MakeListNode(name)},
+			RemoveType: pg_query.ObjectType_OBJECT_INDEX,
+			Behavior:   pg_query.DropBehavior_DROP_RESTRICT,
+			MissingOk:  true,
+			Concurrent: true,
+		}}}, true
+	case stmt.GetAlterTableStmt() != nil:
+		return undoAlterTable(stmt.GetAlterTableStmt())
+	default:
+		return nil, false
+	}
+}
+
+// undoAlterTable returns the ALTER TABLE dropping the constraints alter
+// adds, or nil if it only validates constraints. Reports false if alter
+// does anything else.
+func undoAlterTable(alter *pg_query.AlterTableStmt) (*pg_query.Node, bool) {
+	var drops []*pg_query.Node
+
+	for _, node := range alter.Cmds {
+		cmd := node.GetAlterTableCmd()
+
+		switch cmd.GetSubtype() {
+		case pg_query.AlterTableType_AT_ValidateConstraint:
+			// Validating a constraint changes nothing to undo.
+		case pg_query.AlterTableType_AT_AddConstraint:
+			name := cmd.GetDef().GetConstraint().GetConname()
+			if name == "" {
+				return nil, false
+			}
+
+			drops = slices.Insert(drops, 0, &pg_query.Node{Node: &pg_query.Node_AlterTableCmd{AlterTableCmd: &pg_query.AlterTableCmd{
+				Subtype:   pg_query.AlterTableType_AT_DropConstraint,
+				Name:      name,
+				Behavior:  pg_query.DropBehavior_DROP_RESTRICT,
+				MissingOk: true,
+			}}})
+		default:
+			return nil, false
+		}
+	}
+
+	if len(drops) == 0 {
+		return nil, true
+	}
+
+	return &pg_query.Node{Node: &pg_query.Node_AlterTableStmt{AlterTableStmt: &pg_query.AlterTableStmt{
+		Relation: alter.Relation,
+		Cmds:     drops,
+		Objtype:  pg_query.ObjectType_OBJECT_TABLE,
+	}}}, true
+}
+
+// leadingTrivia returns the length of the whitespace and comments at the
+// start of text.
+func leadingTrivia(text string) int {
+	i := 0
+
+	for i < len(text) {
+		switch rest := text[i:]; {
+		case unicode.IsSpace(rune(text[i])):
+			i++
+		case strings.HasPrefix(rest, "--"):
+			n := strings.IndexByte(rest, '\n')
+			if n < 0 {
+				return len(text)
+			}
+
+			i += n + 1
+		case strings.HasPrefix(rest, "/*"):
+			n := strings.Index(rest, "*/")
+			if n < 0 {
+				return len(text)
+			}
+
+			i += n + len("*/")
+		default:
+			return i
+		}
+	}
+
+	return i
+}
+
+// trailingComment returns the length of the comments, and the spaces before
+// them, that follow a statement on the rest of its line, or 0 if there are
+// none.
+func trailingComment(text string) int {
+	i, end := 0, 0
+
+	for i < len(text) {
+		switch rest := text[i:]; {
+		case text[i] == ' ' || text[i] == '\t':
+			i++
+		case strings.HasPrefix(rest, "--"):
+			n := strings.IndexByte(rest, '\n')
+			if n < 0 {
+				return len(text)
+			}
+
+			return i + n
+		case strings.HasPrefix(rest, "/*"):
+			n := strings.Index(rest, "*/")
+			if n < 0 {
+				return len(text)
+			}
+
+			i += n + len("*/")
+			end = i
+		default:
+			return end
+		}
+	}
+
+	return end
+}
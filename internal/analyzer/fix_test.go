@@ -0,0 +1,113 @@
+package analyzer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/analyzer"
+	"github.com/aqasim81/database-migration-engine/internal/analyzer/rules"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+func TestFix_splitsStatementsThatNeedTheirOwnTransaction(t *testing.T) {
+	t.Parallel()
+
+	m := &migration.Migration{
+		Version: "002",
+		Name:    "orders",
+		UpSQL: `
+CREATE TABLE orders (id bigint PRIMARY KEY, user_id bigint);
+
+-- Look up orders by user.
+CREATE INDEX orders_user_id_idx ON orders (user_id);
+ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id);
+UPDATE orders SET user_id = 1;
+`,
+	}
+
+	a := analyzer.New(analyzer.WithRegistry(rules.NewDefaultRegistry()), analyzer.WithPGVersion(14))
+
+	res, err := a.Fix(m)
+	require.NoError(t, err)
+	assert.True(t, res.Changed())
+	assert.Equal(t, []string{"create-index-not-concurrent", "add-constraint-without-not-valid"}, res.Rules)
+	assert.Equal(t, []string{
+		"\nCREATE TABLE orders (id bigint PRIMARY KEY, user_id bigint);\n",
+		"-- Look up orders by user.\nCREATE INDEX CONCURRENTLY orders_user_id_idx ON orders USING btree (user_id);\n",
+		"ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;\n",
+		"ALTER TABLE orders VALIDATE CONSTRAINT orders_user_fk;\nUPDATE orders SET user_id = 1;\n",
+	}, res.Parts)
+	assert.Equal(t, []analyzer.PartUndo{
+		{SQL: "DROP INDEX CONCURRENTLY IF EXISTS orders_user_id_idx;\n", Reversible: true},
+		{SQL: "ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_user_fk;\n", Reversible: true},
+		{}, // UPDATE was not rewritten, so Fix cannot undo it
+	}, res.Undo)
+}
+
+func TestFix_namesConstraintsUniquelyAcrossStatements(t *testing.T) {
+	t.Parallel()
+
+	m := &migration.Migration{
+		Version: "003",
+		Name:    "checks",
+		UpSQL: `ALTER TABLE t ADD CHECK (a > 0), ADD CHECK (b > 0);
+ALTER TABLE t ADD CONSTRAINT t_a_check1 CHECK (a < 100) NOT VALID;
+ALTER TABLE t ADD CHECK (a < 10);
+`,
+	}
+
+	a := analyzer.New(analyzer.WithRegistry(rules.NewDefaultRegistry()), analyzer.WithPGVersion(14))
+
+	res, err := a.Fix(m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"ALTER TABLE t ADD CONSTRAINT t_a_check CHECK (a > 0) NOT VALID, ADD CONSTRAINT t_b_check CHECK (b > 0) NOT VALID;\n",
+		"ALTER TABLE t VALIDATE CONSTRAINT t_a_check, VALIDATE CONSTRAINT t_b_check;\n" +
+			"ALTER TABLE t ADD CONSTRAINT t_a_check1 CHECK (a < 100) NOT VALID;\n" +
+			"ALTER TABLE t ADD CONSTRAINT t_a_check2 CHECK (a < 10) NOT VALID;\n",
+		"ALTER TABLE t VALIDATE CONSTRAINT t_a_check2;\n",
+	}, res.Parts)
+	assert.Equal(t, []analyzer.PartUndo{
+		{}, // the ADD CONSTRAINT ... NOT VALID t_a_check1 was not rewritten
+		{Reversible: true},
+	}, res.Undo)
+}
+
+func TestFix_keepsTrailingCommentsWithTheirStatement(t *testing.T) {
+	t.Parallel()
+
+	m := &migration.Migration{
+		Version: "004",
+		Name:    "comments",
+		UpSQL: `CREATE TABLE t (a int); -- the table
+CREATE INDEX t_a_idx ON t (a); /* by a */ -- for lookups
+UPDATE t SET a = 1;`,
+	}
+
+	a := analyzer.New(analyzer.WithRegistry(rules.NewDefaultRegistry()), analyzer.WithPGVersion(14))
+
+	res, err := a.Fix(m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE t (a int); -- the table\n",
+		"CREATE INDEX CONCURRENTLY t_a_idx ON t USING btree (a); /* by a */ -- for lookups\n",
+		"UPDATE t SET a = 1;\n",
+	}, res.Parts)
+}
+
+func TestFix_unchanged(t *testing.T) {
+	t.Parallel()
+
+	sql := "CREATE INDEX CONCURRENTLY idx ON users (email);\n"
+	m := &migration.Migration{Version: "001", Name: "idx", UpSQL: sql}
+
+	res, err := analyzer.New(analyzer.WithRegistry(rules.NewDefaultRegistry())).Fix(m)
+	require.NoError(t, err)
+	assert.False(t, res.Changed())
+	assert.Equal(t, []string{sql}, res.Parts)
+
+	_, err = analyzer.New().Fix(&migration.Migration{Version: "003", UpSQL: "ALTER TABL users"})
+	require.ErrorContains(t, err, "parsing migration 003")
+}
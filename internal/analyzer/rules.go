@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"strconv"
 	"strings"
 
 	pg_query "github.com/pganalyze/pg_query_go/v6"
@@ -22,6 +23,33 @@ type RuleContext struct {
 	TargetPGVersion int
 	StmtIndex       int
 	SQL             string // The full migration SQL (for extracting statement text)
+
+	names map[string]bool // constraint names in use, shared by the statements of a Fix
+}
+
+// maxNameLength is PostgreSQL's NAMEDATALEN - 1; longer names are truncated
+// by the server.
+const maxNameLength = 63
+
+// UniqueName returns name, or name with the lowest numeric suffix that is
+// not in use yet, e.g. orders_check1, the way PostgreSQL names constraints,
+// and marks the result in use. During Fix the constraint names declared in
+// the migration and those given by earlier fixes are in use.
+func (c *RuleContext) UniqueName(name string) string {
+	if c.names == nil {
+		c.names = make(map[string]bool)
+	}
+
+	unique := name
+
+	for n := 1; c.names[unique]; n++ {
+		suffix := strconv.Itoa(n)
+		unique = name[:min(len(name), maxNameLength-len(suffix))] + suffix
+	}
+
+	c.names[unique] = true
+
+	return unique
 }
 
 // Registry holds a collection of rules.
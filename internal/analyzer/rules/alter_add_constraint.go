@@ -1,9 +1,13 @@
 package rules
 
 import (
+	"slices"
+
 	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/aqasim81/database-migration-engine/internal/analyzer"
+	"github.com/aqasim81/database-migration-engine/internal/parser"
 )
 
 // AddConstraintRule detects ADD CONSTRAINT without NOT VALID for CHECK and FK constraints (R-3).
@@ -30,36 +34,10 @@ func (r *AddConstraintRule) Check(stmt *pg_query.RawStmt, ctx *analyzer.RuleCont
 	var findings []analyzer.Finding
 
 	for _, cmdNode := range alt.Cmds {
-		cmd, ok := cmdNode.Node.(*pg_query.Node_AlterTableCmd)
-		if !ok {
+		if unvalidatedConstraint(cmdNode) == nil {
 			continue
 		}
 
-		if cmd.AlterTableCmd.Subtype != pg_query.AlterTableType_AT_AddConstraint {
-			continue
-		}
-
-		if cmd.AlterTableCmd.Def == nil {
-			continue
-		}
-
-		constraintNode, ok := cmd.AlterTableCmd.Def.Node.(*pg_query.Node_Constraint)
-		if !ok {
-			continue
-		}
-
-		constraint := constraintNode.Constraint
-
-		// Only flag CHECK and FOREIGN KEY constraints
-		if constraint.Contype != pg_query.ConstrType_CONSTR_CHECK &&
-			constraint.Contype != pg_query.ConstrType_CONSTR_FOREIGN {
-			continue
-		}
-
-		if constraint.SkipValidation {
-			continue // Has NOT VALID — safe
-		}
-
 		findings = append(findings, analyzer.Finding{
 			Rule:       r.ID(),
 			Severity:   analyzer.High,
@@ -73,3 +51,105 @@ func (r *AddConstraintRule) Check(stmt *pg_query.RawStmt, ctx *analyzer.RuleCont
 
 	return findings
 }
+
+// Fix adds the CHECK and foreign key constraints NOT VALID and validates
+// them in a statement that runs after they are committed, so the table is
+// scanned without blocking writes. Unnamed constraints are given a name
+// unique in the migration, which VALIDATE CONSTRAINT needs.
+func (r *AddConstraintRule) Fix(stmt *pg_query.RawStmt, ctx *analyzer.RuleContext) []analyzer.FixedStmt {
+	alt := alterTableStmt(stmt)
+	if alt == nil {
+		return nil
+	}
+
+	var validate []*pg_query.Node
+
+	for _, cmdNode := range alt.Cmds {
+		constraint := unvalidatedConstraint(cmdNode)
+		if constraint == nil {
+			continue
+		}
+
+		constraint.SkipValidation = true
+		constraint.InitiallyValid = false
+
+		if constraint.Conname == "" {
+			constraint.Conname = ctx.UniqueName(constraintName(alt.Relation, constraint))
+		}
+
+		validate = append(validate, alterTableCmd(pg_query.AlterTableType_AT_ValidateConstraint, constraint.Conname, nil))
+	}
+
+	if len(validate) == 0 {
+		return nil
+	}
+
+	return []analyzer.FixedStmt{
+		{Stmt: stmt.Stmt},
+		{Stmt: alterTableNode(alt.Relation, validate...), AfterCommit: true},
+	}
+}
+
+// unvalidatedConstraint returns the CHECK or foreign key constraint an ADD
+// CONSTRAINT subcommand adds without NOT VALID, or nil.
+func unvalidatedConstraint(cmdNode *pg_query.Node) *pg_query.Constraint {
+	cmd, ok := cmdNode.Node.(*pg_query.Node_AlterTableCmd)
+	if !ok || cmd.AlterTableCmd.Subtype != pg_query.AlterTableType_AT_AddConstraint || cmd.AlterTableCmd.Def == nil {
+		return nil
+	}
+
+	constraintNode, ok := cmd.AlterTableCmd.Def.Node.(*pg_query.Node_Constraint)
+	if !ok {
+		return nil
+	}
+
+	constraint := constraintNode.Constraint
+	if constraint.Contype != pg_query.ConstrType_CONSTR_CHECK && constraint.Contype != pg_query.ConstrType_CONSTR_FOREIGN {
+		return nil
+	}
+
+	if constraint.SkipValidation {
+		return nil // Has NOT VALID — safe
+	}
+
+	return constraint
+}
+
+// constraintName names an unnamed constraint of relation the way
+// PostgreSQL does: orders_user_id_fkey for foreign keys, orders_total_check
+// for CHECK constraints on a single column and orders_check for others.
+func constraintName(relation *pg_query.RangeVar, constraint *pg_query.Constraint) string {
+	if constraint.Contype != pg_query.ConstrType_CONSTR_FOREIGN {
+		if columns := referencedColumns(constraint.RawExpr); len(columns) == 1 {
+			return identifier(relation.Relname, columns[0], "check")
+		}
+
+		return identifier(relation.Relname, "check")
+	}
+
+	parts := []string{relation.Relname}
+	for _, attr := range constraint.FkAttrs {
+		parts = append(parts, attr.GetString_().GetSval())
+	}
+
+	return identifier(append(parts, "fkey")...)
+}
+
+// referencedColumns returns the distinct columns expr refers to, in order.
+func referencedColumns(expr *pg_query.Node) []string {
+	var columns []string
+
+	parser.Walk(expr, func(msg proto.Message) {
+		ref, ok := msg.(*pg_query.ColumnRef)
+		if !ok || len(ref.Fields) == 0 {
+			return
+		}
+
+		name := ref.Fields[len(ref.Fields)-1].GetString_().GetSval()
+		if name != "" && !slices.Contains(columns, name) {
+			columns = append(columns, name)
+		}
+	})
+
+	return columns
+}
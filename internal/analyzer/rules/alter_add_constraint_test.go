@@ -91,3 +91,34 @@ func TestAddConstraintRule_Check(t *testing.T) {
 		})
 	}
 }
+
+func TestAddConstraintRule_Fix(t *testing.T) {
+	t.Parallel()
+
+	rule := rules.NewAddConstraintRule()
+
+	stmts, fixed := fixSQL(t, rule,
+		"ALTER TABLE orders ADD CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id), ADD CHECK (total > 0);", 14)
+	assert.Equal(t, []string{
+		"ALTER TABLE orders ADD CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID, " +
+			"ADD CONSTRAINT orders_total_check CHECK (total > 0) NOT VALID",
+		"ALTER TABLE orders VALIDATE CONSTRAINT fk_user, VALIDATE CONSTRAINT orders_total_check",
+	}, stmts)
+	assert.False(t, fixed[0].AfterCommit)
+	assert.True(t, fixed[1].AfterCommit)
+
+	stmts, _ = fixSQL(t, rule, "ALTER TABLE orders ADD FOREIGN KEY (user_id, region) REFERENCES users (id, region);", 14)
+	assert.Equal(t, "ALTER TABLE orders VALIDATE CONSTRAINT orders_user_id_region_fkey", stmts[1])
+
+	stmts, _ = fixSQL(t, rule, "ALTER TABLE t ADD CHECK (a > 0), ADD CHECK (b > 0), ADD CHECK (a < b), ADD CHECK (a < 10);", 14)
+	assert.Equal(t,
+		"ALTER TABLE t VALIDATE CONSTRAINT t_a_check, VALIDATE CONSTRAINT t_b_check, "+
+			"VALIDATE CONSTRAINT t_check, VALIDATE CONSTRAINT t_a_check1",
+		stmts[1])
+
+	stmts, _ = fixSQL(t, rule, "ALTER TABLE orders ADD CONSTRAINT c CHECK (total > 0) NOT VALID;", 14)
+	assert.Empty(t, stmts)
+
+	stmts, _ = fixSQL(t, rule, "ALTER TABLE orders ADD CONSTRAINT pk PRIMARY KEY (id);", 14)
+	assert.Empty(t, stmts)
+}
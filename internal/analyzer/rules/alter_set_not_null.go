@@ -62,3 +62,44 @@ func (r *SetNotNullRule) Check(stmt *pg_query.RawStmt, ctx *analyzer.RuleContext
 
 	return findings
 }
+
+// Fix replaces SET NOT NULL on PostgreSQL 12 and later with a CHECK
+// (column IS NOT NULL) constraint added NOT VALID, validated after it is
+// committed, then SET NOT NULL, which uses the validated constraint instead
+// of scanning the table, and the constraint is dropped. The constraint is
+// dropped in a statement of its own, as ALTER TABLE drops constraints
+// before it sets columns NOT NULL.
+func (r *SetNotNullRule) Fix(stmt *pg_query.RawStmt, ctx *analyzer.RuleContext) []analyzer.FixedStmt {
+	alt := alterTableStmt(stmt)
+	if alt == nil || ctx.TargetPGVersion < pgVersionSafeSetNotNull {
+		return nil
+	}
+
+	var setNotNull, validate, drop []*pg_query.Node
+
+	for i, cmdNode := range alt.Cmds {
+		cmd, ok := cmdNode.Node.(*pg_query.Node_AlterTableCmd)
+		if !ok || cmd.AlterTableCmd.Subtype != pg_query.AlterTableType_AT_SetNotNull {
+			continue
+		}
+
+		column := cmd.AlterTableCmd.Name
+		name := ctx.UniqueName(identifier(alt.Relation.Relname, column, "not_null"))
+
+		alt.Cmds[i] = alterTableCmd(pg_query.AlterTableType_AT_AddConstraint, "", notNullCheck(name, column))
+		setNotNull = append(setNotNull, cmdNode)
+		validate = append(validate, alterTableCmd(pg_query.AlterTableType_AT_ValidateConstraint, name, nil))
+		drop = append(drop, alterTableCmd(pg_query.AlterTableType_AT_DropConstraint, name, nil))
+	}
+
+	if len(setNotNull) == 0 {
+		return nil
+	}
+
+	return []analyzer.FixedStmt{
+		{Stmt: stmt.Stmt},
+		{Stmt: alterTableNode(alt.Relation, validate...), AfterCommit: true},
+		{Stmt: alterTableNode(alt.Relation, setNotNull...)},
+		{Stmt: alterTableNode(alt.Relation, drop...)},
+	}
+}
@@ -95,3 +95,25 @@ func TestSetNotNullRule_Check(t *testing.T) {
 		})
 	}
 }
+
+func TestSetNotNullRule_Fix(t *testing.T) {
+	t.Parallel()
+
+	rule := rules.NewSetNotNullRule()
+
+	stmts, fixed := fixSQL(t, rule, "ALTER TABLE users ALTER COLUMN email SET NOT NULL, ADD COLUMN age int;", 12)
+	assert.Equal(t, []string{
+		"ALTER TABLE users ADD CONSTRAINT users_email_not_null CHECK (email IS NOT NULL) NOT VALID, ADD COLUMN age int",
+		"ALTER TABLE users VALIDATE CONSTRAINT users_email_not_null",
+		"ALTER TABLE users ALTER COLUMN email SET NOT NULL",
+		"ALTER TABLE users DROP CONSTRAINT users_email_not_null",
+	}, stmts)
+	assert.True(t, fixed[1].AfterCommit)
+	assert.False(t, fixed[2].AfterCommit)
+
+	stmts, _ = fixSQL(t, rule, "ALTER TABLE users ALTER COLUMN email SET NOT NULL;", 11)
+	assert.Empty(t, stmts, "PostgreSQL 11 does not use the constraint")
+
+	stmts, _ = fixSQL(t, rule, "ALTER TABLE users ALTER COLUMN email DROP NOT NULL;", 14)
+	assert.Empty(t, stmts)
+}
@@ -37,3 +37,16 @@ func (r *CreateIndexRule) Check(stmt *pg_query.RawStmt, ctx *analyzer.RuleContex
 		StmtIndex:  ctx.StmtIndex,
 	}}
 }
+
+// Fix adds CONCURRENTLY, which needs a migration of its own as it cannot
+// run in a transaction block.
+func (r *CreateIndexRule) Fix(stmt *pg_query.RawStmt, _ *analyzer.RuleContext) []analyzer.FixedStmt {
+	node, ok := stmt.Stmt.Node.(*pg_query.Node_IndexStmt)
+	if !ok || node.IndexStmt == nil || node.IndexStmt.Concurrent {
+		return nil
+	}
+
+	node.IndexStmt.Concurrent = true
+
+	return []analyzer.FixedStmt{{Stmt: stmt.Stmt, Alone: true}}
+}
@@ -95,3 +95,19 @@ func TestCreateIndexRule_Check(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateIndexRule_Fix(t *testing.T) {
+	t.Parallel()
+
+	rule := rules.NewCreateIndexRule()
+
+	stmts, fixed := fixSQL(t, rule, "CREATE UNIQUE INDEX idx ON users (email) WHERE active;", 14)
+	assert.Equal(t, []string{"CREATE UNIQUE INDEX CONCURRENTLY idx ON users USING btree (email) WHERE active"}, stmts)
+	assert.True(t, fixed[0].Alone)
+
+	stmts, _ = fixSQL(t, rule, "CREATE INDEX CONCURRENTLY idx ON users (email);", 14)
+	assert.Empty(t, stmts)
+
+	stmts, _ = fixSQL(t, rule, "CREATE TABLE users (id INT);", 14)
+	assert.Empty(t, stmts)
+}
@@ -0,0 +1,69 @@
+package rules
+
+import (
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+)
+
+// maxIdentifierLength is PostgreSQL's NAMEDATALEN - 1; longer names are
+// truncated by the server.
+const maxIdentifierLength = 63
+
+// alterTableStmt returns the ALTER TABLE statement in stmt, or nil.
+func alterTableStmt(stmt *pg_query.RawStmt) *pg_query.AlterTableStmt {
+	node, ok := stmt.Stmt.Node.(*pg_query.Node_AlterTableStmt)
+	if !ok {
+		return nil
+	}
+
+	return node.AlterTableStmt
+}
+
+// alterTableNode builds ALTER TABLE relation with cmds.
+func alterTableNode(relation *pg_query.RangeVar, cmds ...*pg_query.Node) *pg_query.Node {
+	return &pg_query.Node{Node: &pg_query.Node_AlterTableStmt{AlterTableStmt: &pg_query.AlterTableStmt{
+		Relation: relation,
+		Cmds:     cmds,
+		Objtype:  pg_query.ObjectType_OBJECT_TABLE,
+	}}}
+}
+
+// alterTableCmd builds an ALTER TABLE subcommand acting on the column or
+// constraint name, or adding def.
+func alterTableCmd(subtype pg_query.AlterTableType, name string, def *pg_query.Node) *pg_query.Node {
+	return &pg_query.Node{Node: &pg_query.Node_AlterTableCmd{AlterTableCmd: &pg_query.AlterTableCmd{
+		Subtype:  subtype,
+		Name:     name,
+		Def:      def,
+		Behavior: pg_query.DropBehavior_DROP_RESTRICT,
+	}}}
+}
+
+// notNullCheck builds CONSTRAINT name CHECK (column IS NOT NULL) NOT VALID.
+func notNullCheck(name, column string) *pg_query.Node {
+	test := &pg_query.Node{Node: &pg_query.Node_NullTest{NullTest: &pg_query.NullTest{
+		Arg:          pg_query.MakeColumnRefNode([]*pg_query.Node{pg_query.MakeStrNode(column)}, -1),
+		Nulltesttype: pg_query.NullTestType_IS_NOT_NULL,
+		Location:     -1,
+	}}}
+
+	return &pg_query.Node{Node: &pg_query.Node_Constraint{Constraint: &pg_query.Constraint{
+		Contype:        pg_query.ConstrType_CONSTR_CHECK,
+		Conname:        name,
+		RawExpr:        test,
+		SkipValidation: true,
+		Location:       -1,
+	}}}
+}
+
+// identifier joins parts with underscores into a name PostgreSQL keeps
+// whole, e.g. users_email_not_null.
+func identifier(parts ...string) string {
+	name := strings.Join(parts, "_")
+	if len(name) > maxIdentifierLength {
+		name = name[:maxIdentifierLength]
+	}
+
+	return name
+}
@@ -0,0 +1,34 @@
+package rules_test
+
+import (
+	"testing"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/analyzer"
+	"github.com/aqasim81/database-migration-engine/internal/parser"
+)
+
+// fixSQL runs fixer over the single statement in sql and returns the
+// deparsed statements with the fixed statements they came from.
+func fixSQL(t *testing.T, fixer analyzer.Fixer, sql string, pgVersion int) ([]string, []analyzer.FixedStmt) {
+	t.Helper()
+
+	result, err := parser.Parse(sql)
+	require.NoError(t, err)
+	require.Len(t, result.Stmts, 1)
+
+	fixed := fixer.Fix(result.Stmts[0], &analyzer.RuleContext{TargetPGVersion: pgVersion})
+
+	stmts := make([]string, 0, len(fixed))
+
+	for _, f := range fixed {
+		out, err := pg_query.Deparse(&pg_query.ParseResult{Stmts: []*pg_query.RawStmt{{Stmt: f.Stmt}}})
+		require.NoError(t, err)
+
+		stmts = append(stmts, out)
+	}
+
+	return stmts, fixed
+}
@@ -12,17 +12,38 @@ import (
 )
 
 var analyzeCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pattern
-	Use:   "analyze [migration-dir]",
+	Use:   "analyze [migration-dir...]",
 	Short: "Analyze migrations for dangerous operations",
 	Long: `Analyze SQL migration files for dangerous DDL operations that could
 cause table locks, downtime, or data loss. Reports findings with severity
-levels and suggests safe alternatives.`,
+levels and suggests safe alternatives.
+
+With --fix, statements with a safe equivalent are rewritten in place:
+
+  - CREATE INDEX becomes CREATE INDEX CONCURRENTLY;
+  - ADD CONSTRAINT for a CHECK or foreign key is added NOT VALID, then
+    validated by a separate VALIDATE CONSTRAINT;
+  - on PostgreSQL 12 and later, SET NOT NULL is preceded by a validated
+    CHECK (column IS NOT NULL) constraint, dropped once it is set.
+
+Only up SQL is fixed, including the -- migrate:up section of single-file
+migrations. Rewritten statements are deparsed from the syntax tree, so they
+lose their original formatting; the rest of the file is kept as written.
+Statements that cannot share a transaction with the ones before them, such
+as CREATE INDEX CONCURRENTLY, are moved to new migrations numbered after
+the latest one, which is why only the latest migration can be split. The
+new migrations undo what they do, e.g. with DROP INDEX CONCURRENTLY, or have
+no down migration if they hold statements that were not rewritten. Fix
+only migrations that have not been applied: applied ones would fail
+checksum validation. --diff prints the changes as a unified diff instead.`,
 	RunE: runAnalyze,
 }
 
 func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
 	analyzeCmd.Flags().String("format", "text", "output format (text, json, github-actions)")
 	analyzeCmd.Flags().Bool("fail-on-high", false, "exit with non-zero code if high/critical findings exist")
+	analyzeCmd.Flags().Bool("fix", false, "rewrite dangerous statements into safe equivalents")
+	analyzeCmd.Flags().Bool("diff", false, "with --fix, print the changes as a unified diff instead of writing them")
 	rootCmd.AddCommand(analyzeCmd)
 }
 
@@ -30,12 +51,12 @@ func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registr
 var errHighSeverityFindings = errors.New("high or critical severity findings detected")
 
 func runAnalyze(cmd *cobra.Command, args []string) error {
-	dir := AppConfig.MigrationsDir
+	dirs := AppConfig.MigrationsDirs
 	if len(args) > 0 {
-		dir = args[0]
+		dirs = args
 	}
 
-	migrations, err := migration.LoadFromDir(dir)
+	migrations, err := migration.LoadFromDirs(dirs, loadOptions(AppConfig)...)
 	if err != nil {
 		return fmt.Errorf("loading migrations: %w", err)
 	}
@@ -52,6 +73,23 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		analyzer.WithPGVersion(AppConfig.TargetPGVersion),
 	)
 
+	if fix, _ := cmd.Flags().GetBool("fix"); fix {
+		diff, _ := cmd.Flags().GetBool("diff")
+
+		changed, err := fixMigrations(cmd, a, sorted, diff)
+		if err != nil || diff {
+			return err
+		}
+
+		if changed {
+			if migrations, err = migration.LoadFromDirs(dirs, loadOptions(AppConfig)...); err != nil {
+				return fmt.Errorf("loading fixed migrations: %w", err)
+			}
+
+			sorted = migration.Sort(migrations)
+		}
+	}
+
 	results, err := a.AnalyzeAll(sorted)
 	if err != nil {
 		return fmt.Errorf("analyzing migrations: %w", err)
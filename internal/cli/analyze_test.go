@@ -20,7 +20,7 @@ func setupTestConfig(t *testing.T, migrationsDir string) {
 
 	old := AppConfig
 	AppConfig = &config.Config{
-		MigrationsDir:   migrationsDir,
+		MigrationsDirs:  []string{migrationsDir},
 		TargetPGVersion: config.DefaultTargetPGVersion,
 	}
 
@@ -37,6 +37,8 @@ func newAnalyzeCmd(t *testing.T) (*cobra.Command, *bytes.Buffer) {
 		RunE: runAnalyze,
 	}
 	cmd.Flags().Bool("fail-on-high", false, "exit with non-zero code if high/critical findings exist")
+	cmd.Flags().Bool("fix", false, "rewrite dangerous statements into safe equivalents")
+	cmd.Flags().Bool("diff", false, "with --fix, print the changes as a unified diff instead of writing them")
 	cmd.SetOut(buf)
 	cmd.SetErr(buf)
 
@@ -31,7 +31,18 @@ var applyCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra patte
 	Use:   "apply",
 	Short: "Apply pending migrations",
 	Long: `Apply pending database migrations with configurable lock and
-statement timeouts. Supports dry-run mode and force execution.`,
+statement timeouts. Supports dry-run mode and force execution.
+
+Repeatable migrations (R__<name>.sql) run after all versioned migrations
+whenever their contents changed since they were last applied. With
+--target or --steps they run only if no versioned migration is left
+pending.
+
+Callback files in the migrations directory run at fixed points of every
+apply and rollback: beforeAll.sql and afterAll.sql once per run, each in
+its own transaction, and beforeEach.sql and afterEach.sql inside the
+transaction of every migration that runs. A failing callback fails the
+run like a failing migration.`,
 	RunE: runApply,
 }
 
@@ -40,6 +51,12 @@ func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registr
 	applyCmd.Flags().Bool("force", false, "skip safety checks and confirmation prompts")
 	applyCmd.Flags().Duration("lock-timeout", 0, "override lock timeout (e.g., 10s, 1m)")
 	applyCmd.Flags().Duration("statement-timeout", 0, "override statement timeout (e.g., 30s, 5m)")
+	applyCmd.Flags().String("ticket", "", "change-ticket reference recorded in the migration history")
+	addLockWaitFlag(applyCmd)
+	addGracePeriodFlag(applyCmd)
+	addApplyLimitFlags(applyCmd)
+	applyCmd.Flags().Bool("allow-out-of-order", false,
+		"apply pending migrations that sort before the latest applied migration")
 	rootCmd.AddCommand(applyCmd)
 }
 
@@ -52,6 +69,14 @@ func runApply(cmd *cobra.Command, _ []string) error {
 
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	force, _ := cmd.Flags().GetBool("force")
+	ticket, _ := cmd.Flags().GetString("ticket")
+	target, _ := cmd.Flags().GetString("target")
+	steps, _ := cmd.Flags().GetInt("steps")
+
+	allowOutOfOrder := cfg.AllowOutOfOrder
+	if cmd.Flags().Changed("allow-out-of-order") {
+		allowOutOfOrder, _ = cmd.Flags().GetBool("allow-out-of-order")
+	}
 
 	lockTimeout := cfg.LockTimeout
 	if cmd.Flags().Changed("lock-timeout") {
@@ -63,13 +88,32 @@ func runApply(cmd *cobra.Command, _ []string) error {
 		stmtTimeout, _ = cmd.Flags().GetDuration("statement-timeout")
 	}
 
-	sorted, err := loadAndSortMigrations(cfg.MigrationsDir, cmd.OutOrStdout())
+	sorted, err := loadAndSortMigrations(cfg.MigrationsDirs, cmd.OutOrStdout(), loadOptions(cfg)...)
 	if err != nil || sorted == nil {
 		return err
 	}
 
+	repeatables, err := migration.LoadRepeatables(cfg.MigrationsDirs, loadOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("loading repeatable migrations: %w", err)
+	}
+
+	hooks, err := migration.LoadHooks(cfg.MigrationsDirs, loadOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("loading callback files: %w", err)
+	}
+
+	// The executor needs the full list to check ordering against applied
+	// versions; only the safety checks are limited to what will run.
+	toCheck := sorted
+	if target != "" {
+		if toCheck, err = migration.UpTo(sorted, target); err != nil {
+			return err
+		}
+	}
+
 	if !force && !dryRun {
-		if blocked, analyzeErr := checkDangerousMigrations(cmd, sorted, cfg); analyzeErr != nil {
+		if blocked, analyzeErr := checkDangerousMigrations(cmd, toCheck, cfg); analyzeErr != nil {
 			return analyzeErr
 		} else if blocked {
 			return errDangerousMigrations
@@ -87,21 +131,47 @@ func runApply(cmd *cobra.Command, _ []string) error {
 	}
 	defer pool.Close()
 
-	return executeMigrations(ctx, cmd.OutOrStdout(), pool, sorted, applyOpts{
-		lockTimeout: lockTimeout,
-		stmtTimeout: stmtTimeout,
-		dryRun:      dryRun,
+	return executeMigrations(ctx, cmd.OutOrStdout(), pool, newTracker(pool, cfg), sorted, applyOpts{
+		lockTimeout:     lockTimeout,
+		stmtTimeout:     stmtTimeout,
+		dryRun:          dryRun,
+		ticket:          ticket,
+		lockKey:         resolveLockKey(pool, cfg),
+		lockWait:        lockWait(cmd, cfg),
+		gracePeriod:     gracePeriod(cmd, cfg),
+		target:          target,
+		steps:           steps,
+		allowOutOfOrder: allowOutOfOrder,
+		repeatables:     repeatables,
+		hooks:           hooks,
 	})
 }
 
 type applyOpts struct {
-	lockTimeout time.Duration
-	stmtTimeout time.Duration
-	dryRun      bool
+	lockTimeout     time.Duration
+	stmtTimeout     time.Duration
+	dryRun          bool
+	ticket          string
+	lockKey         int64
+	lockWait        time.Duration
+	gracePeriod     time.Duration
+	target          string
+	steps           int
+	allowOutOfOrder bool
+	repeatables     []migration.Migration
+	hooks           []migration.Hook
 }
 
-func loadAndSortMigrations(dir string, out io.Writer) ([]migration.Migration, error) {
-	migrations, err := migration.LoadFromDir(dir)
+// addApplyLimitFlags registers --target and --steps on commands that apply
+// or preview a subset of pending migrations.
+func addApplyLimitFlags(cmd *cobra.Command) {
+	cmd.Flags().String("target", "", "apply pending migrations up to and including this version")
+	cmd.Flags().Int("steps", 0, "apply at most this many pending migrations (0 for all)")
+	cmd.MarkFlagsMutuallyExclusive("target", "steps")
+}
+
+func loadAndSortMigrations(dirs []string, out io.Writer, opts ...migration.LoadOption) ([]migration.Migration, error) {
+	migrations, err := migration.LoadFromDirs(dirs, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("loading migrations: %w", err)
 	}
@@ -114,6 +184,15 @@ func loadAndSortMigrations(dir string, out io.Writer) ([]migration.Migration, er
 	return migration.Sort(migrations), nil
 }
 
+// loadOptions returns the loader options for the configuration.
+func loadOptions(cfg *config.Config) []migration.LoadOption {
+	return []migration.LoadOption{
+		migration.WithAllowMixedSchemes(cfg.AllowMixedVersions),
+		migration.WithRecursive(cfg.Recursive),
+		migration.WithVars(cfg.Vars),
+	}
+}
+
 func connectDB(ctx context.Context, cfg *config.Config, out io.Writer) (*pgxpool.Pool, error) {
 	fmt.Fprintf(out, "Connecting to %s\n", config.RedactURL(cfg.DatabaseURL))
 
@@ -125,34 +204,64 @@ func connectDB(ctx context.Context, cfg *config.Config, out io.Writer) (*pgxpool
 	return pool, nil
 }
 
+// newTracker creates a tracker for the configured tracking table.
+func newTracker(pool *pgxpool.Pool, cfg *config.Config) *tracker.Tracker {
+	return tracker.New(pool,
+		tracker.WithToolVersion(version),
+		tracker.WithTable(cfg.TrackingSchema, cfg.TrackingTable),
+	)
+}
+
 func executeMigrations(
 	ctx context.Context,
 	out io.Writer,
 	pool *pgxpool.Pool,
+	t *tracker.Tracker,
 	sorted []migration.Migration,
 	opts applyOpts,
 ) error {
-	t := tracker.New(pool)
-
 	applied := 0
 	skipped := 0
+	started := 0
+	wouldApply := 0
+
+	var interrupted *migration.Migration
 
 	exec := executor.New(pool, t,
 		executor.WithLockTimeout(opts.lockTimeout),
 		executor.WithStatementTimeout(opts.stmtTimeout),
 		executor.WithDryRun(opts.dryRun),
+		executor.WithTicket(opts.ticket),
+		executor.WithLockKey(opts.lockKey),
+		executor.WithLockWait(opts.lockWait),
+		executor.WithLockWaitCallback(printLockWait(out)),
+		executor.WithGracePeriod(opts.gracePeriod),
+		executor.WithAllowOutOfOrder(opts.allowOutOfOrder),
+		executor.WithRepeatables(opts.repeatables),
+		executor.WithHooks(opts.hooks),
 		executor.WithProgressCallback(func(event executor.ProgressEvent) {
+			if event.Hook != "" {
+				printHookProgress(out, event)
+				return
+			}
+
 			switch event.Status {
 			case executor.StatusStarting:
-				fmt.Fprintf(out, "  Applying %s_%s ... ", event.Migration.Version, event.Migration.Name)
+				fmt.Fprintf(out, "  Applying %s ... ", event.Migration.Label())
+				started++
 			case executor.StatusCompleted:
 				fmt.Fprintf(out, "done (%s)\n", event.Duration.Truncate(time.Millisecond))
 				applied++
 			case executor.StatusSkipped:
 				skipped++
+			case executor.StatusDryRun:
+				wouldApply++
 			case executor.StatusFailed:
 				fmt.Fprintf(out, "FAILED\n")
 				fmt.Fprintf(out, "    Error: %v\n", event.Error)
+			case executor.StatusInterrupted:
+				fmt.Fprintf(out, "INTERRUPTED\n")
+				interrupted = event.Migration
 			}
 		}),
 	)
@@ -161,13 +270,37 @@ func executeMigrations(
 		fmt.Fprintln(out, "\n--- DRY RUN (no changes will be made) ---")
 	}
 
-	if err := exec.Apply(ctx, sorted); err != nil {
+	var err error
+
+	switch {
+	case opts.target != "":
+		err = exec.ApplyToVersion(ctx, sorted, opts.target)
+	case opts.steps > 0:
+		err = exec.ApplySteps(ctx, sorted, opts.steps)
+	default:
+		err = exec.Apply(ctx, sorted)
+	}
+
+	if err != nil {
+		if errors.Is(err, executor.ErrInterrupted) {
+			notStarted := applyScope(sorted, opts) - skipped - started - wouldApply
+			if opts.steps > 0 {
+				notStarted = min(notStarted, opts.steps-started-wouldApply)
+			}
+
+			printApplyInterrupted(out, applied, skipped, notStarted, interrupted)
+		}
+
+		if errors.Is(err, executor.ErrOutOfOrder) {
+			return fmt.Errorf("%w (rerun with --allow-out-of-order to apply them)", err)
+		}
+
 		return err
 	}
 
 	if opts.dryRun {
 		fmt.Fprintf(out, "\nDry run complete: %d migration(s) would be applied, %d already applied.\n",
-			len(sorted)-skipped, skipped)
+			wouldApply, skipped)
 	} else {
 		fmt.Fprintf(out, "\nApply complete: %d applied, %d skipped.\n", applied, skipped)
 	}
@@ -175,6 +308,46 @@ func executeMigrations(
 	return nil
 }
 
+// printHookProgress prints progress of the beforeAll and afterAll hooks.
+func printHookProgress(out io.Writer, event executor.ProgressEvent) {
+	switch event.Status {
+	case executor.StatusStarting:
+		fmt.Fprintf(out, "  Running %s hooks ... ", event.Hook)
+	case executor.StatusCompleted:
+		fmt.Fprintf(out, "done (%s)\n", event.Duration.Truncate(time.Millisecond))
+	case executor.StatusFailed:
+		fmt.Fprintf(out, "FAILED\n")
+		fmt.Fprintf(out, "    Error: %v\n", event.Error)
+	case executor.StatusInterrupted:
+		fmt.Fprintf(out, "INTERRUPTED\n")
+	}
+}
+
+// applyScope returns how many migrations an apply run covers: those up to
+// the target, or every versioned and repeatable migration. Repeatable
+// migrations are left out when a target stops short of the latest version,
+// because they only run once nothing is left pending.
+func applyScope(sorted []migration.Migration, opts applyOpts) int {
+	if opts.target != "" {
+		if upTo, err := migration.UpTo(sorted, opts.target); err == nil && len(upTo) < len(sorted) {
+			return len(upTo)
+		}
+	}
+
+	return len(sorted) + len(opts.repeatables)
+}
+
+// printApplyInterrupted summarizes an apply run stopped by a signal or lost lock.
+func printApplyInterrupted(out io.Writer, applied, skipped, notStarted int, inFlight *migration.Migration) {
+	fmt.Fprintf(out, "\nApply interrupted: %d applied, %d skipped, %d not started.\n",
+		applied, skipped, notStarted)
+
+	if inFlight != nil {
+		fmt.Fprintf(out, "  %s was cancelled before completing and is not recorded as applied.\n",
+			inFlight.Label())
+	}
+}
+
 // checkDangerousMigrations runs the analyzer and returns true if
 // HIGH/CRITICAL findings were found (blocking apply).
 func checkDangerousMigrations(cmd *cobra.Command, sorted []migration.Migration, cfg *config.Config) (bool, error) {
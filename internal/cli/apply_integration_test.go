@@ -0,0 +1,100 @@
+//go:build integration
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/integration"
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/executor"
+)
+
+// setupApplyDB points AppConfig at a fresh PostgreSQL container and an
+// empty migrations directory, which it returns.
+func setupApplyDB(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	old := AppConfig
+	AppConfig = config.New()
+	AppConfig.DatabaseURL = integration.SetupPostgresDSN(t)
+	AppConfig.MigrationsDirs = []string{dir}
+
+	t.Cleanup(func() { AppConfig = old })
+
+	return dir
+}
+
+// runApplyCmd runs apply with the given flags set.
+func runApplyCmd(t *testing.T, flags map[string]string) (string, error) {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	addApplyLimitFlags(cmd)
+	cmd.Flags().Bool("dry-run", false, "")
+	cmd.SetOut(buf)
+
+	for name, value := range flags {
+		require.NoError(t, cmd.Flags().Set(name, value))
+	}
+
+	err := runApply(cmd, nil)
+
+	return buf.String(), err
+}
+
+func TestRunApply_target_checksOrderAgainstAllMigrations(t *testing.T) { //nolint:paralleltest // writes global AppConfig
+	dir := setupApplyDB(t)
+
+	writeFile(t, dir, "V005_orders.up.sql", "CREATE TABLE orders (id int);")
+
+	_, err := runApplyCmd(t, nil)
+	require.NoError(t, err)
+
+	writeFile(t, dir, "V002_users.up.sql", "CREATE TABLE users (id int);")
+	writeFile(t, dir, "V003_posts.up.sql", "CREATE TABLE posts (id int);")
+
+	_, err = runApplyCmd(t, map[string]string{"target": "003"})
+	require.ErrorIs(t, err, executor.ErrOutOfOrder)
+}
+
+func TestRunApply_target_defersRepeatablesWhileLaterPending(t *testing.T) { //nolint:paralleltest // writes global AppConfig
+	dir := setupApplyDB(t)
+
+	writeFile(t, dir, "V001_users.up.sql", "CREATE TABLE users (id int);")
+	writeFile(t, dir, "V002_posts.up.sql", "CREATE TABLE posts (id int);")
+	writeFile(t, dir, "R__user_ids.sql", "CREATE OR REPLACE VIEW user_ids AS SELECT id FROM users;")
+
+	out, err := runApplyCmd(t, map[string]string{"target": "001"})
+	require.NoError(t, err)
+	assert.Contains(t, out, "Applying 001_users")
+	assert.NotContains(t, out, "R__user_ids")
+
+	out, err = runApplyCmd(t, nil)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Applying 002_posts")
+	assert.Contains(t, out, "Applying R__user_ids")
+}
+
+func TestRunApply_dryRunTarget_countsOnlyWhatWouldRun(t *testing.T) { //nolint:paralleltest // writes global AppConfig
+	dir := setupApplyDB(t)
+
+	writeFile(t, dir, "V001_users.up.sql", "CREATE TABLE users (id int);")
+	writeFile(t, dir, "V002_posts.up.sql", "CREATE TABLE posts (id int);")
+	writeFile(t, dir, "V003_tags.up.sql", "CREATE TABLE tags (id int);")
+
+	_, err := runApplyCmd(t, map[string]string{"target": "001"})
+	require.NoError(t, err)
+
+	out, err := runApplyCmd(t, map[string]string{"target": "002", "dry-run": "true"})
+	require.NoError(t, err)
+	assert.Contains(t, out, "Dry run complete: 1 migration(s) would be applied, 1 already applied.")
+}
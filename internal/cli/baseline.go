@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/executor"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+var baselineCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pattern
+	Use:   "baseline",
+	Short: "Mark migrations as applied without running them",
+	Long: `Adopt an existing database whose schema predates its migrations. baseline
+marks every migration up to and including --version as applied without
+executing it, recording status baseline in the tracking table and an entry
+in the migration history. Migrations already applied are left alone, and
+later ones are applied by apply as usual.
+
+status shows baselined migrations as baseline. rollback refuses to roll
+back a baselined migration, since its changes were never applied by this
+tool.`,
+	RunE: runBaseline,
+}
+
+func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
+	baselineCmd.Flags().String("version", "", "last migration version to mark as applied")
+	baselineCmd.Flags().Bool("dry-run", false, "show the migrations that would be baselined")
+	baselineCmd.Flags().String("ticket", "", "change-ticket reference recorded in the migration history")
+	addLockWaitFlag(baselineCmd)
+	_ = baselineCmd.MarkFlagRequired("version")
+	rootCmd.AddCommand(baselineCmd)
+}
+
+func runBaseline(cmd *cobra.Command, _ []string) error {
+	cfg := AppConfig
+
+	if cfg.DatabaseURL == "" {
+		return errDatabaseURLRequired
+	}
+
+	target, _ := cmd.Flags().GetString("version")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	ticket, _ := cmd.Flags().GetString("ticket")
+
+	out := cmd.OutOrStdout()
+
+	sorted, err := loadAndSortMigrations(cfg.MigrationsDirs, out, loadOptions(cfg)...)
+	if err != nil || sorted == nil {
+		return err
+	}
+
+	// Validate the target before connecting.
+	if _, err := migration.UpTo(sorted, target); err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	pool, err := connectDB(ctx, cfg, cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	if dryRun {
+		fmt.Fprintln(out, "\n--- DRY RUN (no changes will be made) ---")
+	}
+
+	count := 0
+
+	exec := executor.New(pool, newTracker(pool, cfg),
+		executor.WithDryRun(dryRun),
+		executor.WithTicket(ticket),
+		executor.WithLockKey(resolveLockKey(pool, cfg)),
+		executor.WithLockWait(lockWait(cmd, cfg)),
+		executor.WithLockWaitCallback(printLockWait(out)),
+		executor.WithProgressCallback(func(event executor.ProgressEvent) {
+			printBaselineProgress(out, event, dryRun)
+			count++
+		}),
+	)
+
+	if err := exec.Baseline(ctx, sorted, target); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Fprintf(out, "\nDry run complete: %d migration(s) would be baselined.\n", count)
+	} else {
+		fmt.Fprintf(out, "\nBaseline complete: %d migration(s) marked as applied up to %s.\n", count, target)
+	}
+
+	return nil
+}
+
+// printBaselineProgress prints one baselined migration.
+func printBaselineProgress(out io.Writer, event executor.ProgressEvent, dryRun bool) {
+	verb := "Baselined"
+	if dryRun {
+		verb = "Would baseline"
+	}
+
+	fmt.Fprintf(out, "  %s %s\n", verb, event.Migration.Label())
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/database"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/parser"
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+// errDangerousDiff is returned when the generated migrations have high or
+// critical findings and --force is not set.
+var errDangerousDiff = errors.New("generated migrations have dangerous operations (use --force to write them anyway)")
+
+// errUnsupportedSource is returned for an unknown --from value.
+var errUnsupportedSource = errors.New("unsupported source (use migrations or database)")
+
+var diffCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pattern
+	Use:   "diff <schema.sql>",
+	Short: "Generate migrations that bring the schema in line with a schema file",
+	Long: `Compare the desired schema in a SQL file with the current schema and
+write the migrations that change one into the other, as .up.sql and
+.down.sql pairs numbered after the latest migration.
+
+The desired schema is built by running the file in a temporary database on
+the scratch server (--scratch-url). The current schema is the one the
+migrations define, replayed there as well (--from migrations, the
+default), or the schema of the database (--from database).
+
+The migrations prefer changes that do not block the table:
+
+  - indexes are created and dropped CONCURRENTLY, each in a migration of
+    its own, as such statements cannot run in a transaction block;
+  - CHECK and foreign key constraints are added NOT VALID, then validated
+    in a later migration;
+  - primary keys and unique constraints of existing tables are built as a
+    unique index CONCURRENTLY, then attached with USING INDEX;
+  - SET NOT NULL is preceded by a validated CHECK (column IS NOT NULL)
+    constraint, which PostgreSQL 12 and later use instead of a table scan;
+  - enum labels are added in a migration before the others, as a label
+    cannot be used in the transaction that adds it.
+
+The generated SQL is analyzed like migrate analyze does before anything is
+written; high or critical findings, such as a column type change that
+rewrites the table or a dropped table, stop diff unless --force is set.
+Differences diff cannot migrate, such as removed enum labels or changed
+partitioning, are reported as warnings. Grants are not compared.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDiff,
+}
+
+func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
+	addScratchURLFlag(diffCmd)
+	diffCmd.Flags().String("from", "migrations", "current schema: migrations (replayed on the scratch server) or database")
+	diffCmd.Flags().String("name", "schema_diff", "name of the generated migrations")
+	diffCmd.Flags().String("output", "", "directory to write to (default: the first migrations directory)")
+	diffCmd.Flags().StringSlice("schema", nil, "schema to compare (repeatable; default: all but the system schemas)")
+	diffCmd.Flags().Bool("dry-run", false, "print the migrations instead of writing them")
+	diffCmd.Flags().Bool("force", false, "write the migrations even if the analyzer finds dangerous operations")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// generatedMigration is a migration generated by diff.
+type generatedMigration struct {
+	version, name    string
+	upFile, downFile string
+	up, down         string
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	cfg := AppConfig
+
+	from, _ := cmd.Flags().GetString("from")
+	name, _ := cmd.Flags().GetString("name")
+	output, _ := cmd.Flags().GetString("output")
+	schemas, _ := cmd.Flags().GetStringSlice("schema")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+
+	var err error
+
+	if from != "migrations" && from != "database" {
+		return fmt.Errorf("%w: %q", errUnsupportedSource, from)
+	}
+
+	if output == "" && !dryRun {
+		if output, err = firstMigrationsDir(cfg); err != nil {
+			return err
+		}
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	desired, err := loadSchemaFile(ctx, cmd, cfg, args[0], schemas)
+	if err != nil {
+		return err
+	}
+
+	current, err := currentSchema(ctx, cmd, cfg, from, schemas)
+	if err != nil {
+		return err
+	}
+
+	plan := schema.Diff(current, desired)
+
+	for _, w := range plan.Warnings {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: not migrated: %s\n", w)
+	}
+
+	if len(plan.Steps) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No changes: the schema matches %s.\n", args[0])
+		return nil
+	}
+
+	generated, err := generateMigrations(cfg, plan, name, args[0])
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		if blocked, err := checkDangerousMigrations(cmd, analyzedMigrations(generated), cfg); err != nil {
+			return err
+		} else if blocked {
+			return errDangerousDiff
+		}
+	}
+
+	return writeGeneratedMigrations(cmd.OutOrStdout(), generated, output, dryRun)
+}
+
+// firstMigrationsDir returns the directory new migrations are written to.
+func firstMigrationsDir(cfg *config.Config) (string, error) {
+	if len(cfg.MigrationsDirs) == 0 {
+		return "", errNoMigrationsDir
+	}
+
+	return cfg.MigrationsDirs[0], nil
+}
+
+// currentSchema returns the schema the migrations define, or that of the
+// database for from "database".
+func currentSchema(ctx context.Context, cmd *cobra.Command, cfg *config.Config, from string, schemas []string) (*schema.Schema, error) {
+	if from == "migrations" {
+		return replaySchema(ctx, cmd, cfg, schemas)
+	}
+
+	if cfg.DatabaseURL == "" {
+		return nil, errDatabaseURLRequired
+	}
+
+	return inspectDatabase(ctx, cmd, cfg, schemas)
+}
+
+// loadSchemaFile runs the schema file in a temporary database on the
+// scratch server and returns its schema.
+func loadSchemaFile(ctx context.Context, cmd *cobra.Command, cfg *config.Config, path string, schemas []string) (*schema.Schema, error) {
+	sql, err := os.ReadFile(path) //nolint:gosec // the schema file is named by the user
+	if err != nil {
+		return nil, fmt.Errorf("reading schema file: %w", err)
+	}
+
+	serverURL, err := scratchURL(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	out := cmd.ErrOrStderr()
+	fmt.Fprintf(out, "Loading %s in a scratch database on %s\n", path, config.RedactURL(serverURL))
+
+	scratch, err := database.NewScratch(ctx, serverURL)
+	if err != nil {
+		return nil, err
+	}
+	defer dropScratch(ctx, out, scratch)
+
+	if _, err := scratch.Pool.Exec(ctx, string(sql)); err != nil {
+		return nil, fmt.Errorf("running schema file %s: %w", path, err)
+	}
+
+	s, err := schema.Inspect(ctx, scratch.Pool, schema.WithSchemas(schemas...))
+	if err != nil {
+		return nil, fmt.Errorf("introspecting scratch database: %w", err)
+	}
+
+	return s, nil
+}
+
+// generateMigrations renders the steps of plan as migrations numbered after
+// the existing ones. The main step is named name and the others name
+// followed by what they do, e.g. add_email_validate.
+func generateMigrations(cfg *config.Config, plan *schema.Plan, name, source string) ([]generatedMigration, error) {
+	existing, err := migration.LoadFromDirs(cfg.MigrationsDirs, loadOptions(cfg)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading migrations: %w", err)
+	}
+
+	scheme, versions := migration.NextVersions(existing, len(plan.Steps), time.Now())
+	generated := make([]generatedMigration, 0, len(plan.Steps))
+
+	for i, step := range plan.Steps {
+		stepName := name
+		if step.Name != "" {
+			stepName += "_" + step.Name
+		}
+
+		g := generatedMigration{
+			version:  versions[i],
+			name:     stepName,
+			upFile:   scheme.Filename(versions[i], stepName, "up"),
+			downFile: scheme.Filename(versions[i], stepName, "down"),
+			up:       renderStatements(source, step.Up),
+			down:     renderStatements(source, step.Down),
+		}
+
+		for _, sql := range []string{g.up, g.down} {
+			if _, err := parser.Parse(sql); err != nil {
+				return nil, fmt.Errorf("generated migration %s does not parse: %w", g.upFile, err)
+			}
+		}
+
+		generated = append(generated, g)
+	}
+
+	return generated, nil
+}
+
+// renderStatements renders the statements of a generated migration after a
+// comment naming the schema file. With no statements, the comment says
+// there is nothing to undo.
+func renderStatements(source string, stmts []string) string {
+	header := "-- Generated by migrate diff from " + filepath.Base(source) + ".\n"
+	if len(stmts) == 0 {
+		return header + "-- Nothing to undo.\n"
+	}
+
+	return header + "\n" + strings.Join(stmts, ";\n\n") + ";\n"
+}
+
+// analyzedMigrations returns the generated migrations as the analyzer
+// takes them.
+func analyzedMigrations(generated []generatedMigration) []migration.Migration {
+	migrations := make([]migration.Migration, 0, len(generated))
+	for _, g := range generated {
+		migrations = append(migrations, migration.Migration{Version: g.version, Name: g.name, UpSQL: g.up})
+	}
+
+	return migrations
+}
+
+// writeGeneratedMigrations writes the migrations to dir, or prints them
+// with dryRun.
+func writeGeneratedMigrations(out io.Writer, generated []generatedMigration, dir string, dryRun bool) error {
+	for _, g := range generated {
+		if dryRun {
+			fmt.Fprintf(out, "\n-- %s\n%s\n-- %s\n%s", g.upFile, g.up, g.downFile, g.down)
+			continue
+		}
+
+		for _, f := range [][2]string{{g.upFile, g.up}, {g.downFile, g.down}} {
+			path := filepath.Join(dir, f[0])
+			if err := os.WriteFile(path, []byte(f[1]), migrationFileMode); err != nil { //nolint:gosec // migrations are source files, readable like the rest of the repository
+				return fmt.Errorf("writing %s: %w", path, err)
+			}
+		}
+
+		fmt.Fprintf(out, "Wrote %s and %s\n", g.upFile, g.downFile)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+func TestGenerateMigrations(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "V004_users.up.sql"), []byte("SELECT 1;"), 0o600))
+
+	cfg := &config.Config{MigrationsDirs: []string{dir}}
+	plan := &schema.Plan{Steps: []schema.Step{
+		{Up: []string{"ALTER TABLE users ADD COLUMN email text"}, Down: []string{"ALTER TABLE users DROP COLUMN email"}},
+		{Name: "validate", Up: []string{"ALTER TABLE users VALIDATE CONSTRAINT users_email_check"}},
+	}}
+
+	generated, err := generateMigrations(cfg, plan, "add_email", "db/schema.sql")
+	require.NoError(t, err)
+	require.Len(t, generated, 2)
+
+	assert.Equal(t, "V005_add_email.up.sql", generated[0].upFile)
+	assert.Equal(t, "V005_add_email.down.sql", generated[0].downFile)
+	assert.Equal(t, "-- Generated by migrate diff from schema.sql.\n\nALTER TABLE users ADD COLUMN email text;\n", generated[0].up)
+	assert.Equal(t, "V006_add_email_validate.up.sql", generated[1].upFile)
+	assert.Equal(t, "-- Generated by migrate diff from schema.sql.\n-- Nothing to undo.\n", generated[1].down)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, writeGeneratedMigrations(buf, generated, dir, false))
+	assert.Contains(t, buf.String(), "Wrote V005_add_email.up.sql and V005_add_email.down.sql\n")
+
+	written, err := os.ReadFile(filepath.Join(dir, "V006_add_email_validate.up.sql"))
+	require.NoError(t, err)
+	assert.Equal(t, generated[1].up, string(written))
+
+	_, err = generateMigrations(cfg, &schema.Plan{Steps: []schema.Step{{Up: []string{"ALTER TABL users"}}}}, "bad", "schema.sql")
+	require.ErrorContains(t, err, "does not parse")
+}
+
+func TestAnalyzedMigrations_flagsDangerousSQL(t *testing.T) {
+	t.Parallel()
+
+	migrations := analyzedMigrations([]generatedMigration{
+		{version: "005", name: "idx", up: "CREATE INDEX CONCURRENTLY users_email_idx ON users (email);"},
+		{version: "006", name: "type", up: "ALTER TABLE users ALTER COLUMN email TYPE varchar(100);"},
+	})
+	require.Len(t, migrations, 2)
+	assert.Equal(t, "006_type", migrations[1].Label())
+
+	cmd := &cobra.Command{}
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+
+	blocked, err := checkDangerousMigrations(cmd, migrations, &config.Config{TargetPGVersion: 14})
+	require.NoError(t, err)
+	assert.True(t, blocked)
+	assert.Contains(t, buf.String(), "=== 006_type ===")
+	assert.NotContains(t, buf.String(), "=== 005_idx ===")
+}
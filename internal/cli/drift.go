@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/database"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+// errDriftDetected is returned when the database differs from the expected schema.
+var errDriftDetected = errors.New("schema drift detected")
+
+// errScratchURLRequired is returned when migrations must be replayed but no scratch server is configured.
+var errScratchURLRequired = errors.New( //nolint:gochecknoglobals // sentinel error
+	"scratch database URL is required (set --scratch-url, MIGRATE_SCRATCH_DATABASE_URL, or scratch_database_url in config)",
+)
+
+// scratchDropTimeout bounds dropping the scratch database after a replay.
+const scratchDropTimeout = 30 * time.Second
+
+// schemaModelFileMode is the mode of schema model files written by drift.
+const schemaModelFileMode = 0o644
+
+var driftCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pattern
+	Use:   "drift",
+	Short: "Compare the database schema with the schema the migrations define",
+	Long: `Detect changes made to the database outside of migrations, such as a
+hotfix applied by hand. drift builds the expected schema by applying every
+migration, repeatable migration and callback to a temporary database
+created on the scratch server (--scratch-url), reads the schema of the
+database, and reports tables, columns, constraints, indexes, views,
+sequences, functions and enum types that are missing, extra or different,
+including column type, default and nullability changes. Grants are not
+compared. The tracking tables are left out of both schemas.
+
+The scratch server needs a role with CREATEDB; the temporary database is
+dropped afterwards. To compare without one, save the expected schema where
+one is available with --write-model and pass the file with --model later.
+
+drift exits with a non-zero status when it finds differences. Use
+--format json for alerting.`,
+	RunE: runDrift,
+}
+
+func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
+	addScratchURLFlag(driftCmd)
+	driftCmd.Flags().String("model", "", "read the expected schema from this model file instead of replaying migrations")
+	driftCmd.Flags().String("write-model", "", "save the expected schema to this model file")
+	driftCmd.Flags().StringSlice("schema", nil, "schema to compare (repeatable; default: all but the system schemas)")
+	driftCmd.Flags().String("format", "text", "output format (text, json)")
+	driftCmd.MarkFlagsMutuallyExclusive("model", "scratch-url")
+	rootCmd.AddCommand(driftCmd)
+}
+
+func runDrift(cmd *cobra.Command, _ []string) error {
+	cfg := AppConfig
+
+	modelPath, _ := cmd.Flags().GetString("model")
+	writeModel, _ := cmd.Flags().GetString("write-model")
+	schemas, _ := cmd.Flags().GetStringSlice("schema")
+	format, _ := cmd.Flags().GetString("format")
+
+	if cfg.DatabaseURL == "" && writeModel == "" {
+		return errDatabaseURLRequired
+	}
+
+	if format != "text" && format != "json" {
+		return fmt.Errorf("%w: %q", errUnsupportedFormat, format)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var (
+		expected *schema.Schema
+		err      error
+	)
+
+	if modelPath != "" {
+		expected, err = readSchemaModel(modelPath)
+	} else {
+		expected, err = replaySchema(ctx, cmd, cfg, schemas)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if writeModel != "" {
+		if err := writeSchemaModel(writeModel, expected); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "Wrote expected schema to %s\n", writeModel)
+
+		if cfg.DatabaseURL == "" {
+			return nil
+		}
+	}
+
+	actual, err := inspectDatabase(ctx, cmd, cfg, schemas)
+	if err != nil {
+		return err
+	}
+
+	diffs := schema.Compare(expected, actual)
+
+	if err := printDrift(cmd.OutOrStdout(), diffs, format); err != nil {
+		return err
+	}
+
+	if len(diffs) > 0 {
+		return errDriftDetected
+	}
+
+	return nil
+}
+
+// addScratchURLFlag registers --scratch-url on commands that replay migrations.
+func addScratchURLFlag(cmd *cobra.Command) {
+	cmd.Flags().String("scratch-url", "",
+		"PostgreSQL server to replay migrations on, in a temporary database (overrides scratch_database_url)")
+}
+
+// inspectDatabase reads the schema of the configured database, leaving out
+// the tracking tables.
+func inspectDatabase(ctx context.Context, cmd *cobra.Command, cfg *config.Config, schemas []string) (*schema.Schema, error) {
+	pool, err := connectDB(ctx, cfg, cmd.ErrOrStderr())
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	s, err := schema.Inspect(ctx, pool,
+		schema.WithSchemas(schemas...),
+		schema.WithExcludedTables(newTracker(pool, cfg).Tables()...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("introspecting database: %w", err)
+	}
+
+	return s, nil
+}
+
+// replaySchema applies every migration to a temporary database on the
+// scratch server and returns its schema. Progress goes to stderr.
+func replaySchema(ctx context.Context, cmd *cobra.Command, cfg *config.Config, schemas []string) (*schema.Schema, error) {
+	serverURL, err := scratchURL(cmd, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	out := cmd.ErrOrStderr()
+
+	sorted, err := loadAndSortMigrations(cfg.MigrationsDirs, out, loadOptions(cfg)...)
+	if err != nil {
+		return nil, err
+	}
+
+	repeatables, err := migration.LoadRepeatables(cfg.MigrationsDirs, loadOptions(cfg)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading repeatable migrations: %w", err)
+	}
+
+	hooks, err := migration.LoadHooks(cfg.MigrationsDirs, loadOptions(cfg)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading callback files: %w", err)
+	}
+
+	fmt.Fprintf(out, "Replaying migrations in a scratch database on %s\n", config.RedactURL(serverURL))
+
+	scratch, err := database.NewScratch(ctx, serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	defer dropScratch(ctx, out, scratch)
+
+	t := newTracker(scratch.Pool, cfg)
+
+	if err := executeMigrations(ctx, out, scratch.Pool, t, sorted, applyOpts{
+		lockTimeout: cfg.LockTimeout,
+		stmtTimeout: cfg.StatementTimeout,
+		lockKey:     resolveLockKey(scratch.Pool, cfg),
+		repeatables: repeatables,
+		hooks:       hooks,
+	}); err != nil {
+		return nil, fmt.Errorf("replaying migrations: %w", err)
+	}
+
+	s, err := schema.Inspect(ctx, scratch.Pool, schema.WithSchemas(schemas...), schema.WithExcludedTables(t.Tables()...))
+	if err != nil {
+		return nil, fmt.Errorf("introspecting scratch database: %w", err)
+	}
+
+	return s, nil
+}
+
+// scratchURL returns the scratch server from --scratch-url or the config.
+func scratchURL(cmd *cobra.Command, cfg *config.Config) (string, error) {
+	url := cfg.ScratchDatabaseURL
+	if cmd.Flags().Changed("scratch-url") {
+		url, _ = cmd.Flags().GetString("scratch-url")
+	}
+
+	if url == "" {
+		return "", errScratchURLRequired
+	}
+
+	return url, nil
+}
+
+// dropScratch drops a scratch database, reporting a failure as a warning.
+func dropScratch(ctx context.Context, out io.Writer, scratch *database.Scratch) {
+	dropCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), scratchDropTimeout)
+	defer cancel()
+
+	if err := scratch.Drop(dropCtx); err != nil {
+		fmt.Fprintf(out, "Warning: %v\n", err)
+	}
+}
+
+// readSchemaModel reads a schema saved with writeSchemaModel.
+func readSchemaModel(path string) (*schema.Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema model: %w", err)
+	}
+
+	var s schema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing schema model %s: %w", path, err)
+	}
+
+	return &s, nil
+}
+
+// writeSchemaModel saves s as indented JSON.
+func writeSchemaModel(path string, s *schema.Schema) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding schema model: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), schemaModelFileMode); err != nil { //nolint:gosec // the model is a build artifact, readable like the migrations
+		return fmt.Errorf("writing schema model: %w", err)
+	}
+
+	return nil
+}
+
+// differenceJSON is the JSON representation of a schema difference.
+type differenceJSON struct {
+	Kind     string `json:"kind"`
+	Object   string `json:"object"`
+	Name     string `json:"name"`
+	Property string `json:"property,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// driftJSON is the JSON representation of a drift report.
+type driftJSON struct {
+	Drift       bool             `json:"drift"`
+	Differences []differenceJSON `json:"differences"`
+}
+
+// printDrift writes the differences in the requested format.
+func printDrift(out io.Writer, diffs []schema.Difference, format string) error {
+	if format == "json" {
+		report := driftJSON{Drift: len(diffs) > 0, Differences: make([]differenceJSON, 0, len(diffs))}
+
+		for _, d := range diffs {
+			report.Differences = append(report.Differences, differenceJSON{
+				Kind:     string(d.Kind),
+				Object:   d.Object,
+				Name:     d.Name,
+				Property: d.Property,
+				Expected: d.Expected,
+				Actual:   d.Actual,
+			})
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encoding drift report: %w", err)
+		}
+
+		return nil
+	}
+
+	if len(diffs) == 0 {
+		fmt.Fprintln(out, "No drift: the database matches the expected schema.")
+		return nil
+	}
+
+	fmt.Fprintf(out, "Schema drift: %d difference(s)\n\n", len(diffs))
+
+	for _, d := range diffs {
+		if d.Kind != schema.Changed {
+			fmt.Fprintf(out, "  %-8s %-10s %s\n", d.Kind, d.Object, d.Name)
+			continue
+		}
+
+		fmt.Fprintf(out, "  %-8s %-10s %s (%s)\n", d.Kind, d.Object, d.Name, d.Property)
+		fmt.Fprintf(out, "      expected: %s\n", orNone(d.Expected))
+		fmt.Fprintf(out, "      actual:   %s\n", orNone(d.Actual))
+	}
+
+	return nil
+}
+
+// orNone returns s, or "(none)" if it is empty.
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+
+	return s
+}
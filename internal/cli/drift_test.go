@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+func TestPrintDrift(t *testing.T) {
+	t.Parallel()
+
+	diffs := []schema.Difference{
+		{Kind: schema.Extra, Object: "column", Name: "public.users.nickname"},
+		{Kind: schema.Changed, Object: "column", Name: "public.users.status", Property: "default", Expected: "'active'::text"},
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, printDrift(buf, diffs, "text"))
+
+	out := buf.String()
+	assert.Contains(t, out, "Schema drift: 2 difference(s)")
+	assert.Contains(t, out, "  extra    column     public.users.nickname\n")
+	assert.Contains(t, out, "  changed  column     public.users.status (default)\n")
+	assert.Contains(t, out, "      expected: 'active'::text\n      actual:   (none)\n")
+
+	buf.Reset()
+	require.NoError(t, printDrift(buf, diffs, "json"))
+
+	var report driftJSON
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.True(t, report.Drift)
+	require.Len(t, report.Differences, 2)
+	assert.Equal(t, differenceJSON{Kind: "extra", Object: "column", Name: "public.users.nickname"}, report.Differences[0])
+
+	buf.Reset()
+	require.NoError(t, printDrift(buf, nil, "json"))
+	assert.JSONEq(t, `{"drift": false, "differences": []}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, printDrift(buf, nil, "text"))
+	assert.Contains(t, buf.String(), "No drift")
+}
+
+func TestSchemaModel_roundTrips(t *testing.T) {
+	t.Parallel()
+
+	s := &schema.Schema{Tables: []schema.Table{{
+		Name:        "public.users",
+		Columns:     []schema.Column{{Name: "id", Type: "bigint", NotNull: true, Identity: "ALWAYS"}},
+		Constraints: []schema.Constraint{{Name: "users_pkey", Kind: schema.PrimaryKey, Definition: "PRIMARY KEY (id)"}},
+	}}}
+
+	path := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, writeSchemaModel(path, s))
+
+	read, err := readSchemaModel(path)
+	require.NoError(t, err)
+	assert.Equal(t, s, read)
+	assert.Empty(t, schema.Compare(s, read))
+}
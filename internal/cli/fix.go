@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/analyzer"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+// fixedFile is a file written by analyze --fix: a rewritten migration or a
+// new one holding statements split from it.
+type fixedFile struct {
+	path     string
+	old, new string // old is empty for a new file
+}
+
+// fixMigrations rewrites the dangerous statements of the SQL migrations in
+// sorted into safe equivalents, and writes the rewritten files, or prints
+// them as a unified diff with diff. Only the up SQL is fixed, the up
+// section of single-file migrations. Statements that need a transaction of
+// their own are split into new migrations numbered after the latest one,
+// so only the latest migration can be split. Reports whether any file
+// changed.
+func fixMigrations(cmd *cobra.Command, a *analyzer.Analyzer, sorted []migration.Migration, diff bool) (bool, error) {
+	out, errOut := cmd.OutOrStdout(), cmd.ErrOrStderr()
+	latest := latestSQLMigration(sorted)
+
+	var files []fixedFile
+
+	for i := range sorted {
+		m := &sorted[i]
+		if m.IsGo() {
+			continue
+		}
+
+		res, fixed, err := fixMigration(a, m)
+		if err != nil {
+			fmt.Fprintf(errOut, "Warning: not fixing %s: %v\n", m.Label(), err)
+			continue
+		}
+
+		if !res.Changed() {
+			continue
+		}
+
+		if len(res.Parts) > 1 && m != latest {
+			fmt.Fprintf(errOut, "Warning: not fixing %s: its fix needs migrations of its own, "+
+				"which can only follow the latest migration\n", m.Label())
+
+			continue
+		}
+
+		fmt.Fprintf(out, "Fixed %s (%s)\n", m.Label(), strings.Join(res.Rules, ", "))
+
+		files = append(files, fixed)
+		files = append(files, splitMigrations(m, res, sorted)...)
+	}
+
+	if len(files) == 0 {
+		fmt.Fprintln(out, "Nothing to fix.")
+		return false, nil
+	}
+
+	if diff {
+		return true, printFixDiff(out, files)
+	}
+
+	for _, f := range files {
+		if err := os.WriteFile(f.path, []byte(f.new), migrationFileMode); err != nil { //nolint:gosec // migrations are source files, readable like the rest of the repository
+			return false, fmt.Errorf("writing %s: %w", f.path, err)
+		}
+
+		fmt.Fprintf(out, "Wrote %s\n", f.path)
+	}
+
+	return true, nil
+}
+
+// fixMigration fixes the up SQL of m as written, with any ${name}
+// placeholders left in place, and returns the result with m's file
+// rewritten to hold its first part.
+func fixMigration(a *analyzer.Analyzer, m *migration.Migration) (*analyzer.FixResult, fixedFile, error) {
+	raw, err := os.ReadFile(m.FilePath) //nolint:gosec // the path comes from the migrations directory
+	if err != nil {
+		return nil, fixedFile{}, fmt.Errorf("reading %s: %w", m.FilePath, err)
+	}
+
+	text := string(raw)
+	start, end := 0, len(text)
+
+	if migration.IsSingleFile(m.FilePath) {
+		if start, end, err = migration.UpSection(text); err != nil {
+			return nil, fixedFile{}, fmt.Errorf("%s: %w", m.FilePath, err)
+		}
+	}
+
+	written := *m
+	written.UpSQL = text[start:end]
+
+	res, err := a.Fix(&written)
+	if err != nil {
+		return nil, fixedFile{}, err //nolint:wrapcheck // Fix names the migration
+	}
+
+	// Keep the whitespace that ended the up SQL, such as the blank line
+	// before a -- migrate:down marker.
+	section := text[start:end]
+	trailing := section[len(strings.TrimRightFunc(section, unicode.IsSpace)):]
+	up := strings.TrimRightFunc(res.Parts[0], unicode.IsSpace) + trailing
+
+	return res, fixedFile{path: m.FilePath, old: text, new: text[:start] + up + text[end:]}, nil
+}
+
+// latestSQLMigration returns the last versioned migration in sorted, or
+// nil if it is a Go migration or there is none.
+func latestSQLMigration(sorted []migration.Migration) *migration.Migration {
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if sorted[i].Repeatable {
+			continue
+		}
+
+		if sorted[i].IsGo() {
+			return nil
+		}
+
+		return &sorted[i]
+	}
+
+	return nil
+}
+
+// splitMigrations returns the files of the migrations that hold the parts
+// split from m, named after it with a _part2, _part3, ... suffix, in the
+// same single-file or two-file form. Each repeats m's directives. Parts
+// that Fix cannot undo get no down migration, so rollback stops at them.
+func splitMigrations(m *migration.Migration, res *analyzer.FixResult, sorted []migration.Migration) []fixedFile {
+	parts := res.Parts[1:]
+	scheme, versions := migration.NextVersions(sorted, len(parts), time.Now())
+	dir := filepath.Dir(m.FilePath)
+	base := filepath.Base(m.FilePath)
+	single := migration.IsSingleFile(m.FilePath)
+
+	origin := "-- Split from " + base + " by migrate analyze --fix.\n"
+	irreversible := "-- No down migration: it holds statements analyze --fix cannot undo.\n"
+
+	directives := ""
+	if d := m.Directives.String(); d != "" {
+		for _, directive := range strings.Split(d, ", ") {
+			directives += "-- migrate:" + directive + "\n"
+		}
+	}
+
+	files := make([]fixedFile, 0, 2*len(parts))
+
+	for i, part := range parts {
+		name := m.Name + "_part" + strconv.Itoa(i+2)
+
+		undo := res.Undo[i].SQL
+		if undo == "" {
+			undo = "-- Nothing to undo.\n"
+		}
+
+		if single {
+			content := origin
+			if !res.Undo[i].Reversible {
+				content += irreversible
+			}
+
+			content += "\n-- migrate:up\n" + directives + "\n" + part
+			if res.Undo[i].Reversible {
+				content += "\n-- migrate:down\n" + undo
+			}
+
+			files = append(files, fixedFile{path: filepath.Join(dir, scheme.Filename(versions[i], name, "")), new: content})
+
+			continue
+		}
+
+		if !res.Undo[i].Reversible {
+			files = append(files, fixedFile{
+				path: filepath.Join(dir, scheme.Filename(versions[i], name, "up")),
+				new:  origin + irreversible + directives + "\n" + part,
+			})
+
+			continue
+		}
+
+		files = append(files,
+			fixedFile{path: filepath.Join(dir, scheme.Filename(versions[i], name, "up")), new: origin + directives + "\n" + part},
+			fixedFile{path: filepath.Join(dir, scheme.Filename(versions[i], name, "down")), new: origin + undo},
+		)
+	}
+
+	return files
+}
+
+// printFixDiff prints the changes to files as a unified diff, new files
+// as diffs against /dev/null.
+func printFixDiff(out io.Writer, files []fixedFile) error {
+	for _, f := range files {
+		from := f.path
+		if f.old == "" {
+			from = os.DevNull
+		}
+
+		text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(f.old),
+			B:        splitLines(f.new),
+			FromFile: from,
+			ToFile:   f.path,
+			Context:  3, //nolint:mnd // the usual diff context
+		})
+		if err != nil {
+			return fmt.Errorf("diffing %s: %w", f.path, err)
+		}
+
+		fmt.Fprint(out, text)
+	}
+
+	return nil
+}
+
+// splitLines splits text into lines for difflib, each ending in "\n".
+// Unlike difflib.SplitLines, it adds no empty line after the last newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+
+	last := len(lines) - 1
+	if lines[last] == "" {
+		return lines[:last]
+	}
+
+	lines[last] += "\n"
+
+	return lines
+}
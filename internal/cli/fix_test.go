@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAnalyze_fix_rewritesAndSplitsLatestMigration(t *testing.T) { // not parallel: mutates global AppConfig
+	dir := t.TempDir()
+	writeFile(t, dir, "V001_users.up.sql", "CREATE TABLE users (id bigint, email text);\n")
+	writeFile(t, dir, "V001_users.down.sql", "DROP TABLE users;\n")
+	writeFile(t, dir, "V002_email.up.sql",
+		"-- migrate:lock-timeout 2s\nALTER TABLE users ADD CHECK (email <> '');\n\n-- Look up users by email.\nCREATE INDEX users_email_idx ON users (email);\n")
+	writeFile(t, dir, "V002_email.down.sql", "DROP INDEX users_email_idx;\n")
+	setupTestConfig(t, dir)
+
+	cmd, buf := newAnalyzeCmd(t)
+	cmd.SetArgs([]string{"--fix", dir})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "Fixed 002_email (add-constraint-without-not-valid, create-index-not-concurrent)\n")
+
+	assert.Equal(t, "-- migrate:lock-timeout 2s\nALTER TABLE users ADD CONSTRAINT users_email_check CHECK (email <> '') NOT VALID;\n",
+		readFile(t, dir, "V002_email.up.sql"))
+	assert.Equal(t, "-- Split from V002_email.up.sql by migrate analyze --fix.\n-- migrate:lock-timeout 2s\n\n"+
+		"ALTER TABLE users VALIDATE CONSTRAINT users_email_check;\n", readFile(t, dir, "V003_email_part2.up.sql"))
+	assert.Equal(t, "-- Split from V002_email.up.sql by migrate analyze --fix.\n-- migrate:lock-timeout 2s\n\n"+
+		"-- Look up users by email.\nCREATE INDEX CONCURRENTLY users_email_idx ON users USING btree (email);\n",
+		readFile(t, dir, "V004_email_part3.up.sql"))
+	assert.Equal(t, "-- Split from V002_email.up.sql by migrate analyze --fix.\n"+
+		"DROP INDEX CONCURRENTLY IF EXISTS users_email_idx;\n", readFile(t, dir, "V004_email_part3.down.sql"))
+
+	cmd, buf = newAnalyzeCmd(t)
+	cmd.SetArgs([]string{"--fix", dir})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "Nothing to fix.\n")
+}
+
+func TestRunAnalyze_fix_singleFileMigration(t *testing.T) { // not parallel: mutates global AppConfig
+	dir := t.TempDir()
+	writeFile(t, dir, "V001_users.sql", "-- Users.\n-- migrate:up\n-- migrate:lock-timeout 2s\n"+
+		"CREATE TABLE users (id bigint, email text);\nALTER TABLE users ADD CHECK (email <> '');\n\n"+
+		"-- migrate:down\nDROP TABLE users;\n")
+	setupTestConfig(t, dir)
+
+	cmd, buf := newAnalyzeCmd(t)
+	cmd.SetArgs([]string{"--fix", dir})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "Fixed 001_users (add-constraint-without-not-valid)\n")
+
+	assert.Equal(t, "-- Users.\n-- migrate:up\n-- migrate:lock-timeout 2s\n"+
+		"CREATE TABLE users (id bigint, email text);\n"+
+		"ALTER TABLE users ADD CONSTRAINT users_email_check CHECK (email <> '') NOT VALID;\n\n"+
+		"-- migrate:down\nDROP TABLE users;\n", readFile(t, dir, "V001_users.sql"))
+	assert.Equal(t, "-- Split from V001_users.sql by migrate analyze --fix.\n\n"+
+		"-- migrate:up\n-- migrate:lock-timeout 2s\n\n"+
+		"ALTER TABLE users VALIDATE CONSTRAINT users_email_check;\n\n"+
+		"-- migrate:down\n-- Nothing to undo.\n",
+		readFile(t, dir, "V002_users_part2.sql"))
+
+	cmd, buf = newAnalyzeCmd(t)
+	cmd.SetArgs([]string{"--fix", dir})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, buf.String(), "Nothing to fix.\n")
+}
+
+func TestRunAnalyze_fix_irreversiblePartHasNoDown(t *testing.T) { // not parallel: mutates global AppConfig
+	dir := t.TempDir()
+	writeFile(t, dir, "V001_users.up.sql",
+		"CREATE INDEX users_email_idx ON users (email);\nUPDATE users SET email = lower(email);\n")
+	setupTestConfig(t, dir)
+
+	cmd, _ := newAnalyzeCmd(t)
+	cmd.SetArgs([]string{"--fix", dir})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, "-- Split from V001_users.up.sql by migrate analyze --fix.\n"+
+		"-- No down migration: it holds statements analyze --fix cannot undo.\n\n"+
+		"UPDATE users SET email = lower(email);\n", readFile(t, dir, "V002_users_part2.up.sql"))
+	assert.NoFileExists(t, filepath.Join(dir, "V002_users_part2.down.sql"))
+}
+
+func TestRunAnalyze_fixDiff_printsWithoutWriting(t *testing.T) { // not parallel: mutates global AppConfig
+	dir := t.TempDir()
+	up := "CREATE TABLE users (id bigint);\nCREATE INDEX users_id_idx ON users (id);\n"
+	writeFile(t, dir, "V001_users.up.sql", up)
+	writeFile(t, dir, "V002_orders.up.sql", "ALTER TABLE users ADD CONSTRAINT users_id_check CHECK (id > 0);\n")
+	setupTestConfig(t, dir)
+
+	cmd, buf := newAnalyzeCmd(t)
+	cmd.SetArgs([]string{"--fix", "--diff", dir})
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, buf.String(), "Warning: not fixing 001_users")
+	assert.Contains(t, buf.String(), "--- "+filepath.Join(dir, "V002_orders.up.sql")+"\n")
+	assert.Contains(t, buf.String(), "-ALTER TABLE users ADD CONSTRAINT users_id_check CHECK (id > 0);\n"+
+		"+ALTER TABLE users ADD CONSTRAINT users_id_check CHECK (id > 0) NOT VALID;\n")
+	assert.Contains(t, buf.String(), "--- /dev/null\n+++ "+filepath.Join(dir, "V003_orders_part2.up.sql")+"\n")
+	assert.Equal(t, up, readFile(t, dir, "V001_users.up.sql"))
+	assert.NoFileExists(t, filepath.Join(dir, "V003_orders_part2.up.sql"))
+}
+
+func TestSplitLines(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, splitLines(""))
+	assert.Equal(t, []string{"a\n", "b\n"}, splitLines("a\nb\n"))
+	assert.Equal(t, []string{"a\n", "b\n"}, splitLines("a\nb"))
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+
+	return string(data)
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// errUnsupportedFormat is returned when --format names an unknown output format.
+var errUnsupportedFormat = errors.New("unsupported output format")
+
+const defaultHistoryLimit = 50
+
+var historyCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pattern
+	Use:   "history",
+	Short: "Show the migration audit history",
+	Long: `Display the append-only audit log of every apply and rollback attempt,
+including who ran it, the outcome, and any change-ticket reference.`,
+	RunE: runHistory,
+}
+
+func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
+	historyCmd.Flags().String("version", "", "show history for a single migration version")
+	historyCmd.Flags().Int("limit", defaultHistoryLimit, "maximum number of entries to show (0 for all)")
+	historyCmd.Flags().String("format", "text", "output format (text, json)")
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, _ []string) error {
+	cfg := AppConfig
+
+	if cfg.DatabaseURL == "" {
+		return errDatabaseURLRequired
+	}
+
+	filterVersion, _ := cmd.Flags().GetString("version")
+	limit, _ := cmd.Flags().GetInt("limit")
+	format, _ := cmd.Flags().GetString("format")
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	pool, err := connectDB(ctx, cfg, cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	t := newTracker(pool, cfg)
+
+	exists, err := t.Exists(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return printHistory(cmd.OutOrStdout(), nil, format)
+	}
+
+	entries, err := t.GetHistory(ctx, tracker.HistoryQuery{Version: filterVersion, Limit: limit})
+	if err != nil {
+		return fmt.Errorf("getting migration history: %w", err)
+	}
+
+	return printHistory(cmd.OutOrStdout(), entries, format)
+}
+
+// historyJSON is the JSON representation of a history entry.
+type historyJSON struct {
+	ID          int64     `json:"id"`
+	Version     string    `json:"version"`
+	Filename    string    `json:"filename"`
+	Direction   string    `json:"direction"`
+	Checksum    string    `json:"checksum"`
+	DurationMs  int       `json:"duration_ms"`
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+	DBUser      string    `json:"db_user"`
+	OSUser      string    `json:"os_user"`
+	Hostname    string    `json:"hostname"`
+	ToolVersion string    `json:"tool_version"`
+	Ticket      string    `json:"ticket,omitempty"`
+	ExecutedAt  time.Time `json:"executed_at"`
+}
+
+// printHistory writes history entries in the requested format.
+func printHistory(out io.Writer, entries []tracker.HistoryEntry, format string) error {
+	switch format {
+	case "json":
+		rows := make([]historyJSON, 0, len(entries))
+		for i := range entries {
+			rows = append(rows, historyJSON(entries[i]))
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(rows); err != nil {
+			return fmt.Errorf("encoding history: %w", err)
+		}
+
+		return nil
+	case "text":
+		printHistoryText(out, entries)
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", errUnsupportedFormat, format)
+	}
+}
+
+func printHistoryText(out io.Writer, entries []tracker.HistoryEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No migration history recorded.")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+	fmt.Fprintln(w, "EXECUTED AT\tVERSION\tDIRECTION\tOUTCOME\tDURATION\tDB USER\tOS USER\tHOST\tTICKET")
+
+	for _, h := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%dms\t%s\t%s\t%s\t%s\n",
+			h.ExecutedAt.UTC().Format(time.RFC3339), h.Version, h.Direction, h.Outcome,
+			h.DurationMs, h.DBUser, h.OSUser, h.Hostname, h.Ticket)
+	}
+
+	w.Flush()
+
+	for _, h := range entries {
+		if h.Error != "" {
+			fmt.Fprintf(out, "\n%s %s failed: %s\n", h.Version, h.Direction, h.Error)
+		}
+	}
+}
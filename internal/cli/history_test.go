@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+func testHistoryEntries() []tracker.HistoryEntry {
+	executedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	return []tracker.HistoryEntry{
+		{
+			ID: 2, Version: "001", Filename: "V001_create_users.up.sql",
+			Direction: tracker.DirectionDown, Outcome: tracker.OutcomeFailed, Error: "relation does not exist",
+			DurationMs: 3, DBUser: "app", OSUser: "alice", Hostname: "ci-1", ToolVersion: "0.1.0",
+			ExecutedAt: executedAt,
+		},
+		{
+			ID: 1, Version: "001", Filename: "V001_create_users.up.sql",
+			Direction: tracker.DirectionUp, Outcome: tracker.OutcomeSucceeded,
+			DurationMs: 12, DBUser: "app", OSUser: "alice", Hostname: "ci-1", ToolVersion: "0.1.0",
+			Ticket: "CHG-7", ExecutedAt: executedAt,
+		},
+	}
+}
+
+func TestPrintHistory_text_formatsTable(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+
+	err := printHistory(buf, testHistoryEntries(), "text")
+
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.Contains(t, output, "EXECUTED AT")
+	assert.Contains(t, output, "2024-01-02T03:04:05Z")
+	assert.Contains(t, output, "CHG-7")
+	assert.Contains(t, output, "12ms")
+	assert.Contains(t, output, "001 down failed: relation does not exist")
+}
+
+func TestPrintHistory_textEmpty_printsMessage(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+
+	err := printHistory(buf, nil, "text")
+
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "No migration history recorded.")
+}
+
+func TestPrintHistory_json_encodesEntries(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+
+	err := printHistory(buf, testHistoryEntries(), "json")
+
+	require.NoError(t, err)
+
+	var rows []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rows))
+	require.Len(t, rows, 2)
+	assert.Equal(t, "down", rows[0]["direction"])
+	assert.Equal(t, "relation does not exist", rows[0]["error"])
+	assert.Equal(t, "CHG-7", rows[1]["ticket"])
+	assert.NotContains(t, rows[1], "error")
+}
+
+func TestPrintHistory_unknownFormat_returnsError(t *testing.T) {
+	t.Parallel()
+
+	err := printHistory(new(bytes.Buffer), nil, "xml")
+
+	require.ErrorIs(t, err, errUnsupportedFormat)
+}
+
+func TestRunHistory_noDatabaseURL_returnsError(t *testing.T) { //nolint:paralleltest // writes global AppConfig
+	AppConfig = &config.Config{}
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(new(bytes.Buffer))
+
+	err := runHistory(cmd, nil)
+
+	require.ErrorIs(t, err, errDatabaseURLRequired)
+}
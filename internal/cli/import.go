@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/database"
+	"github.com/aqasim81/database-migration-engine/internal/importer"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// errNoMigrationsDir is returned when import has no migrations directory to convert files into.
+var errNoMigrationsDir = errors.New("no migrations directory configured")
+
+// importLockReleaseTimeout bounds releasing the advisory lock after import.
+const importLockReleaseTimeout = 5 * time.Second
+
+var importCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pattern
+	Use:   "import",
+	Short: "Import the migration history of another tool",
+	Long: `Move a database managed by golang-migrate, goose, Flyway or Rails onto
+this tool without running its migrations again. import reads the other
+tool's tracking table (schema_migrations, goose_db_version or
+flyway_schema_history) and its migration files, converts the files to this
+tool's layout and records every migration the other tool applied in the
+tracking table, with checksums of the converted files.
+
+golang-migrate and Flyway migrations become .up.sql and .down.sql files with
+the same content; goose migrations become single files with -- migrate:up
+and -- migrate:down sections, keeping NO TRANSACTION as a directive. When
+--source-dir is a migrations directory, the converted files replace the
+originals; otherwise they are written to the first migrations directory.
+Rails migrations are Ruby, so each applied one needs an SQL port with the
+same version in the migrations directory before importing.
+
+golang-migrate and Rails also name their table schema_migrations: set
+tracking_table to another name first. Run with --dry-run to review the
+files and rows before anything is written; running import again only
+records what is missing.`,
+	RunE: runImport,
+}
+
+func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
+	importCmd.Flags().String("from", "", "tool to import from (golang-migrate, goose, flyway, rails)")
+	importCmd.Flags().String("source-dir", "", "directory of the tool's migration files (default: the first migrations directory)")
+	importCmd.Flags().String("source-schema", "", "schema of the tool's tracking table (default: search_path)")
+	importCmd.Flags().String("source-table", "", "name of the tool's tracking table (default: the tool's own)")
+	importCmd.Flags().Bool("dry-run", false, "show the files and rows to import without changing anything")
+	importCmd.Flags().String("ticket", "", "change-ticket reference recorded in the migration history")
+	addLockWaitFlag(importCmd)
+	_ = importCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, _ []string) error {
+	cfg := AppConfig
+
+	if cfg.DatabaseURL == "" {
+		return errDatabaseURLRequired
+	}
+
+	from, _ := cmd.Flags().GetString("from")
+	sourceDir, _ := cmd.Flags().GetString("source-dir")
+	sourceSchema, _ := cmd.Flags().GetString("source-schema")
+	sourceTable, _ := cmd.Flags().GetString("source-table")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	ticket, _ := cmd.Flags().GetString("ticket")
+
+	tool, err := importer.ParseTool(from)
+	if err != nil {
+		return err
+	}
+
+	if sourceTable == "" {
+		sourceTable = tool.DefaultTable()
+	}
+
+	converted, changes, err := convertImport(tool, sourceDir, cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	pool, err := connectDB(ctx, cfg, cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	source := importer.Table{Schema: sourceSchema, Name: sourceTable}
+
+	plan, err := planImport(ctx, pool, cfg, tool, source, converted, dryRun)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	printImportPlan(out, tool, source, changes, plan)
+
+	if dryRun {
+		fmt.Fprintln(out, "\nDry run: no files written and nothing recorded.")
+		return nil
+	}
+
+	// Record first: if recording fails, the other tool's files are still in
+	// place and import can be run again.
+	if err := recordImport(ctx, cmd, pool, cfg, plan.Record(), ticket); err != nil {
+		return err
+	}
+
+	if err := importer.ApplyChanges(changes); err != nil {
+		return fmt.Errorf("converting migration files (the history is recorded; run import again to finish): %w", err)
+	}
+
+	fmt.Fprintf(out, "\nImported %d migration(s).\n", len(plan.Record()))
+
+	return nil
+}
+
+// convertImport reads the tool's migration files and returns them converted,
+// with the file operations that put them in place. Files in a migrations
+// directory are converted in place; files elsewhere are converted into the
+// first migrations directory.
+func convertImport(tool importer.Tool, sourceDir string, cfg *config.Config) ([]importer.Migration, []importer.Change, error) {
+	if !tool.HasSQLFiles() {
+		return nil, nil, nil
+	}
+
+	if len(cfg.MigrationsDirs) == 0 {
+		return nil, nil, errNoMigrationsDir
+	}
+
+	outDir := ""
+	if sourceDir == "" {
+		sourceDir = cfg.MigrationsDirs[0]
+	} else if !slices.ContainsFunc(cfg.MigrationsDirs, func(dir string) bool {
+		return filepath.Clean(dir) == filepath.Clean(sourceDir)
+	}) {
+		outDir = cfg.MigrationsDirs[0]
+	}
+
+	converted, err := importer.Read(tool, sourceDir, cfg.Recursive)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s migrations: %w", tool, err)
+	}
+
+	changes, err := importer.Changes(converted, outDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("converting %s migrations: %w", tool, err)
+	}
+
+	return converted, changes, nil
+}
+
+// planImport matches the history in the tool's tracking table with the
+// converted migrations and those already in this tool's layout. With dryRun
+// the tracking table is only read, never created. Returns
+// ErrMissingMigration if an applied version has no migration.
+func planImport(
+	ctx context.Context,
+	pool *pgxpool.Pool,
+	cfg *config.Config,
+	tool importer.Tool,
+	source importer.Table,
+	converted []importer.Migration,
+	dryRun bool,
+) (importer.Plan, error) {
+	tracking := importer.Table{Schema: cfg.TrackingSchema, Name: cfg.TrackingTable}
+	if tracking.Name == "" {
+		tracking.Name = tracker.DefaultTable
+	}
+
+	if err := importer.CheckTables(ctx, pool, source, tracking); err != nil {
+		return importer.Plan{}, err
+	}
+
+	applied, err := importer.ReadApplied(ctx, pool, tool, source)
+	if err != nil {
+		return importer.Plan{}, err
+	}
+
+	recorded, err := recordedMigrations(ctx, newTracker(pool, cfg), dryRun)
+	if err != nil {
+		return importer.Plan{}, err
+	}
+
+	ms := converted
+
+	if plan := importer.BuildPlan(ms, applied, recorded); len(plan.Missing) > 0 {
+		existing, err := migration.LoadFromDirs(cfg.MigrationsDirs, loadOptions(cfg)...)
+		if err != nil {
+			return importer.Plan{}, fmt.Errorf("loading migrations: %w", err)
+		}
+
+		ms = importer.Merge(converted, importer.FromLoaded(existing))
+	}
+
+	plan := importer.BuildPlan(ms, applied, recorded)
+	if len(plan.Missing) > 0 {
+		return importer.Plan{}, fmt.Errorf("%w: %s applied %s", importer.ErrMissingMigration, tool, strings.Join(plan.Missing, ", "))
+	}
+
+	return plan, nil
+}
+
+// recordedMigrations returns the migrations the tracking table records. With
+// dryRun a missing tracking table records nothing instead of being created.
+func recordedMigrations(ctx context.Context, t *tracker.Tracker, dryRun bool) ([]tracker.AppliedMigration, error) {
+	if dryRun {
+		exists, err := t.Exists(ctx)
+		if err != nil || !exists {
+			return nil, err
+		}
+	} else if err := t.EnsureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	recorded, err := t.GetApplied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting applied migrations: %w", err)
+	}
+
+	return recorded, nil
+}
+
+// printImportPlan prints the file operations and the migrations to record.
+func printImportPlan(out io.Writer, tool importer.Tool, source importer.Table, changes []importer.Change, plan importer.Plan) {
+	fmt.Fprintf(out, "Importing %s history from %s\n", tool, source)
+
+	if len(changes) > 0 {
+		fmt.Fprintln(out, "\nFiles:")
+
+		for _, c := range changes {
+			fmt.Fprintf(out, "  %-8s %s\n", c.Op, c.Path)
+		}
+	}
+
+	var pending []importer.Entry
+
+	recorded := 0
+
+	fmt.Fprintln(out, "\nMigrations:")
+
+	for _, e := range plan.Entries {
+		switch {
+		case !e.Applied:
+			pending = append(pending, e)
+		case e.Recorded:
+			recorded++
+		default:
+			fmt.Fprintf(out, "  record   %s  %s\n", e.Version, e.Filename)
+		}
+	}
+
+	for _, e := range pending {
+		fmt.Fprintf(out, "  pending  %s  %s (not applied by %s)\n", e.Version, e.Filename, tool)
+	}
+
+	fmt.Fprintf(out, "\n%d to record, %d already recorded, %d pending\n", len(plan.Record()), recorded, len(pending))
+}
+
+// recordImport records entries as applied under the migration lock, with a
+// history entry for each, in a single transaction so a failure records none
+// of them.
+func recordImport(
+	ctx context.Context,
+	cmd *cobra.Command,
+	pool *pgxpool.Pool,
+	cfg *config.Config,
+	entries []importer.Entry,
+	ticket string,
+) error {
+	lock, err := database.AcquireLock(ctx, pool, database.LockOptions{
+		Key:    resolveLockKey(pool, cfg),
+		Wait:   lockWait(cmd, cfg),
+		OnWait: printLockWait(cmd.ErrOrStderr()),
+	})
+	if err != nil {
+		return fmt.Errorf("acquiring migration lock: %w", err)
+	}
+
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), importLockReleaseTimeout)
+		defer cancel()
+
+		lock.Release(releaseCtx) //nolint:errcheck // best-effort release on return
+	}()
+
+	return newTracker(pool, cfg).InTx(ctx, func(t *tracker.Tracker) error {
+		for _, e := range entries {
+			if err := t.RecordApplied(ctx, tracker.RecordParams{
+				Version:      e.Version,
+				Filename:     e.Filename,
+				Checksum:     e.Checksum,
+				DownChecksum: e.DownChecksum,
+			}); err != nil {
+				return err
+			}
+
+			if err := t.RecordHistory(ctx, tracker.HistoryParams{
+				Version:   e.Version,
+				Filename:  e.Filename,
+				Direction: tracker.DirectionImport,
+				Checksum:  e.Checksum,
+				Outcome:   tracker.OutcomeSucceeded,
+				Ticket:    ticket,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aqasim81/database-migration-engine/internal/importer"
+)
+
+func TestPrintImportPlan(t *testing.T) {
+	t.Parallel()
+
+	changes := []importer.Change{
+		{Op: importer.ChangeCreate, Path: "migrations/V00002_posts.sql"},
+		{Op: importer.ChangeRemove, Path: "migrations/00002_posts.sql"},
+	}
+
+	plan := importer.Plan{Entries: []importer.Entry{
+		{Migration: importer.Migration{Version: "00001", Filename: "V00001_users.sql"}, Applied: true, Recorded: true},
+		{Migration: importer.Migration{Version: "00002", Filename: "V00002_posts.sql"}, Applied: true},
+		{Migration: importer.Migration{Version: "00003", Filename: "V00003_index.sql"}},
+	}}
+
+	buf := new(bytes.Buffer)
+	printImportPlan(buf, importer.Goose, importer.Table{Name: "goose_db_version"}, changes, plan)
+
+	out := buf.String()
+	assert.Contains(t, out, `Importing goose history from "goose_db_version"`)
+	assert.Contains(t, out, "  create   migrations/V00002_posts.sql")
+	assert.Contains(t, out, "  remove   migrations/00002_posts.sql")
+	assert.Contains(t, out, "  record   00002  V00002_posts.sql")
+	assert.Contains(t, out, "  pending  00003  V00003_index.sql (not applied by goose)")
+	assert.NotContains(t, out, "00001")
+	assert.Contains(t, out, "1 to record, 1 already recorded, 1 pending")
+}
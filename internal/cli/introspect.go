@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/parser"
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+// errOutputExists is returned when introspect would overwrite a file without --force.
+var errOutputExists = errors.New("output file already exists (use --force to overwrite)")
+
+// baselineFilename is the migration introspect writes by default.
+const baselineFilename = "V000_baseline.up.sql"
+
+var introspectCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pattern
+	Use:   "introspect",
+	Short: "Generate an initial migration from a live database schema",
+	Long: `Read the schema of the database from pg_catalog and write it as a
+migration, ` + baselineFilename + ` in the first migrations directory. The
+migration holds the schemas, extensions, enum types, sequences, tables with
+their columns, defaults and constraints, functions, views, indexes and
+grants, in an order that creates each object after those it depends on.
+The tracking tables are left out.
+
+The output is sorted by name and holds no timestamps, so introspecting the
+same schema again produces the same file. Mark the migration as applied on
+the database it was read from with:
+
+  migrate baseline --version 000`,
+	RunE: runIntrospect,
+}
+
+func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
+	introspectCmd.Flags().String("output", "", "file to write, or - for stdout (default: "+baselineFilename+
+		" in the first migrations directory)")
+	introspectCmd.Flags().StringSlice("schema", nil, "schema to read (repeatable; default: all but the system schemas)")
+	introspectCmd.Flags().Bool("force", false, "overwrite the output file if it exists")
+	rootCmd.AddCommand(introspectCmd)
+}
+
+func runIntrospect(cmd *cobra.Command, _ []string) error {
+	cfg := AppConfig
+
+	if cfg.DatabaseURL == "" {
+		return errDatabaseURLRequired
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	schemas, _ := cmd.Flags().GetStringSlice("schema")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if output == "" {
+		if len(cfg.MigrationsDirs) == 0 {
+			return errNoMigrationsDir
+		}
+
+		output = filepath.Join(cfg.MigrationsDirs[0], baselineFilename)
+	}
+
+	if output != "-" && !force {
+		if _, err := os.Stat(output); err == nil {
+			return fmt.Errorf("%w: %s", errOutputExists, output)
+		}
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	pool, err := connectDB(ctx, cfg, cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	s, err := schema.Inspect(ctx, pool,
+		schema.WithSchemas(schemas...),
+		schema.WithExcludedTables(newTracker(pool, cfg).Tables()...),
+	)
+	if err != nil {
+		return fmt.Errorf("introspecting database: %w", err)
+	}
+
+	sql, err := renderBaseline(s)
+	if err != nil {
+		return err
+	}
+
+	if output == "-" {
+		fmt.Fprint(cmd.OutOrStdout(), sql)
+		return nil
+	}
+
+	if err := os.WriteFile(output, []byte(sql), migrationFileMode); err != nil { //nolint:gosec // migrations are source files, readable like the rest of the repository
+		return fmt.Errorf("writing %s: %w", output, err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s: %d table(s), %d view(s), %d function(s).\n",
+		output, len(s.Tables), len(s.Views), len(s.Functions))
+
+	return nil
+}
+
+// renderBaseline renders s as a migration and checks that it parses, so a
+// definition PostgreSQL returned that this tool cannot read is reported
+// before the file is written.
+func renderBaseline(s *schema.Schema) (string, error) {
+	sql := "-- Schema read from the database by migrate introspect.\n" +
+		"-- Mark it applied there with: migrate baseline --version 000\n\n" + s.SQL()
+
+	if _, err := parser.Parse(sql); err != nil {
+		return "", fmt.Errorf("generated migration does not parse: %w", err)
+	}
+
+	return sql, nil
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+func TestRenderBaseline(t *testing.T) {
+	t.Parallel()
+
+	sql, err := renderBaseline(&schema.Schema{Tables: []schema.Table{{
+		Name:    "public.users",
+		Columns: []schema.Column{{Name: "id", Type: "bigint", NotNull: true}},
+	}}})
+	require.NoError(t, err)
+	assert.Contains(t, sql, "-- Mark it applied there with: migrate baseline --version 000\n")
+	assert.Contains(t, sql, "CREATE TABLE public.users (\n    id bigint NOT NULL\n);\n")
+
+	_, err = renderBaseline(&schema.Schema{Views: []schema.View{{Name: "public.v", Definition: "SELEC 1"}}})
+	require.Error(t, err)
+}
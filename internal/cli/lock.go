@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/database"
+)
+
+// addLockWaitFlag registers the --lock-wait flag on a command that takes the advisory lock.
+func addLockWaitFlag(cmd *cobra.Command) {
+	cmd.Flags().Duration("lock-wait", 0, "wait up to this long for another migration run to release the lock (e.g., 30s, 5m)")
+}
+
+// lockWait returns the --lock-wait flag value if set, otherwise the configured value.
+func lockWait(cmd *cobra.Command, cfg *config.Config) time.Duration {
+	if cmd.Flags().Changed("lock-wait") {
+		d, _ := cmd.Flags().GetDuration("lock-wait")
+		return d
+	}
+
+	return cfg.LockWait
+}
+
+// resolveLockKey returns the configured advisory lock key, or one derived
+// from the database name and tracking table when none is configured.
+func resolveLockKey(pool *pgxpool.Pool, cfg *config.Config) int64 {
+	if cfg.LockKey != 0 {
+		return cfg.LockKey
+	}
+
+	return database.DeriveLockKey(pool.Config().ConnConfig.Database, cfg.TrackingSchema, cfg.TrackingTable)
+}
+
+// printLockWait returns a callback that reports which session holds the lock while waiting.
+func printLockWait(out io.Writer) func(database.LockHolder) {
+	return func(h database.LockHolder) {
+		fmt.Fprintf(out, "Waiting for migration lock held by %s ...\n", h)
+	}
+}
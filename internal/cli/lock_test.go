@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/database"
+)
+
+func TestLockWait_flagOverridesConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.New()
+	cfg.LockWait = time.Minute
+
+	cmd := &cobra.Command{}
+	addLockWaitFlag(cmd)
+
+	assert.Equal(t, time.Minute, lockWait(cmd, cfg))
+
+	require.NoError(t, cmd.Flags().Set("lock-wait", "5s"))
+	assert.Equal(t, 5*time.Second, lockWait(cmd, cfg))
+}
+
+func TestResolveLockKey(t *testing.T) {
+	t.Parallel()
+
+	// pgxpool.New does not connect until the pool is used.
+	pool, err := pgxpool.New(context.Background(), "postgres://app@localhost:5432/orders")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	cfg := config.New()
+	assert.Equal(t,
+		database.DeriveLockKey("orders", "", config.DefaultTrackingTable),
+		resolveLockKey(pool, cfg),
+	)
+
+	cfg.LockKey = 42
+	assert.Equal(t, int64(42), resolveLockKey(pool, cfg))
+}
+
+func TestPrintLockWait_describesHolder(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+
+	printLockWait(buf)(database.LockHolder{PID: 7, ApplicationName: "deploy"})
+
+	assert.Equal(t, "Waiting for migration lock held by pid 7 (application \"deploy\") ...\n", buf.String())
+}
@@ -1,26 +1,281 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/analyzer"
+	"github.com/aqasim81/database-migration-engine/internal/analyzer/rules"
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+// Plan entry states.
+const (
+	planApplied  = "applied"
+	planPending  = "pending"
+	planDeferred = "deferred"
 )
 
 var planCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pattern
 	Use:   "plan",
 	Short: "Show execution plan for pending migrations",
 	Long: `Display the execution plan for pending migrations including
-analysis results, estimated impact, and execution order.`,
+analysis results, estimated impact, and execution order.
+
+With --target or --steps, only the migrations that the same apply
+command would run are marked pending. Pending migrations that sort before
+the latest applied one are flagged as out of order. Repeatable migrations
+that changed since they were last applied follow the versioned ones.
+Header directives such as -- migrate:no-transaction are shown in brackets.`,
 	RunE: runPlan,
 }
 
 func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
 	planCmd.Flags().Bool("pending-only", false, "show only pending migrations")
+	addApplyLimitFlags(planCmd)
 	rootCmd.AddCommand(planCmd)
 }
 
+// planEntry is a migration with its state in the execution plan.
+type planEntry struct {
+	migration  *migration.Migration
+	state      string
+	outOfOrder bool
+}
+
 func runPlan(cmd *cobra.Command, _ []string) error {
-	fmt.Fprintln(cmd.OutOrStdout(), "plan: not yet implemented")
+	cfg := AppConfig
+	out := cmd.OutOrStdout()
+
+	pendingOnly, _ := cmd.Flags().GetBool("pending-only")
+	target, _ := cmd.Flags().GetString("target")
+	steps, _ := cmd.Flags().GetInt("steps")
+
+	all, err := loadAndSortMigrations(cfg.MigrationsDirs, out, loadOptions(cfg)...)
+	if err != nil || all == nil {
+		return err
+	}
+
+	repeatables, err := migration.LoadRepeatables(cfg.MigrationsDirs, loadOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("loading repeatable migrations: %w", err)
+	}
+
+	sorted := all
+
+	if target != "" {
+		if sorted, err = migration.UpTo(all, target); err != nil {
+			return err
+		}
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	applied, checksums, err := appliedState(ctx, cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	entries := buildPlan(sorted, applied, steps)
+	latest := markOutOfOrder(entries, all, applied)
+	entries = append(entries, buildRepeatablePlan(repeatables, checksums, !planComplete(entries, all))...)
+	printPlan(out, entries, pendingOnly, latest)
+
+	return analyzePlan(cmd, entries, cfg)
+}
+
+// appliedState returns the set of applied versions and the recorded checksum
+// of each applied repeatable migration, or nil maps when no database is
+// configured so that every migration is shown as pending.
+func appliedState(ctx context.Context, cmd *cobra.Command, cfg *config.Config) (map[string]bool, map[string]string, error) {
+	if cfg.DatabaseURL == "" {
+		fmt.Fprintln(cmd.ErrOrStderr(), "No database configured; treating all migrations as pending.")
+		return nil, nil, nil
+	}
+
+	pool, err := connectDB(ctx, cfg, cmd.ErrOrStderr())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer pool.Close()
+
+	rows, repeatables, err := readTracker(ctx, newTracker(pool, cfg))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	applied := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = true
+	}
+
+	checksums := make(map[string]string, len(repeatables))
+	for _, r := range repeatables {
+		checksums[r.Name] = r.Checksum
+	}
+
+	return applied, checksums, nil
+}
+
+// buildPlan assigns each migration a state. Pending migrations beyond the
+// first `steps` (when positive) are deferred.
+func buildPlan(sorted []migration.Migration, applied map[string]bool, steps int) []planEntry {
+	entries := make([]planEntry, 0, len(sorted))
+	pending := 0
+
+	for i := range sorted {
+		state := planPending
+
+		switch {
+		case applied[sorted[i].Version]:
+			state = planApplied
+		case steps > 0 && pending == steps:
+			state = planDeferred
+		default:
+			pending++
+		}
+
+		entries = append(entries, planEntry{migration: &sorted[i], state: state})
+	}
+
+	return entries
+}
+
+// planComplete reports whether the plan covers every migration in all with
+// none deferred, which is when apply goes on to run repeatable migrations.
+func planComplete(entries []planEntry, all []migration.Migration) bool {
+	if len(entries) != len(all) {
+		return false
+	}
+
+	for _, e := range entries {
+		if e.state == planDeferred {
+			return false
+		}
+	}
+
+	return true
+}
+
+// buildRepeatablePlan marks repeatable migrations whose checksum differs from
+// the recorded one as pending, or deferred when versioned migrations are
+// left pending; unchanged ones are applied.
+func buildRepeatablePlan(repeatables []migration.Migration, checksums map[string]string, deferred bool) []planEntry {
+	entries := make([]planEntry, 0, len(repeatables))
+
+	for i := range repeatables {
+		state := planPending
+
+		switch {
+		case checksums[repeatables[i].Name] == repeatables[i].Checksum:
+			state = planApplied
+		case deferred:
+			state = planDeferred
+		}
+
+		entries = append(entries, planEntry{migration: &repeatables[i], state: state})
+	}
+
+	return entries
+}
+
+// markOutOfOrder flags plan entries that sort before the latest applied
+// migration among all migrations on disk, and returns that version.
+func markOutOfOrder(entries []planEntry, all []migration.Migration, applied map[string]bool) string {
+	gaps, latest := migration.OutOfOrder(all, applied)
+
+	outOfOrder := make(map[string]bool, len(gaps))
+	for _, g := range gaps {
+		outOfOrder[g.Version] = true
+	}
+
+	for i := range entries {
+		entries[i].outOfOrder = outOfOrder[entries[i].migration.Version]
+	}
+
+	return latest
+}
+
+func printPlan(out io.Writer, entries []planEntry, pendingOnly bool, latestApplied string) {
+	pending := 0
+	outOfOrder := 0
+
+	for _, e := range entries {
+		if e.state == planPending {
+			pending++
+		}
+
+		if e.outOfOrder {
+			outOfOrder++
+		}
+	}
+
+	fmt.Fprintf(out, "Execution plan: %d migration(s) to apply\n\n", pending)
+
+	step := 0
+
+	for _, e := range entries {
+		if pendingOnly && e.state != planPending {
+			continue
+		}
+
+		label := e.state
+		if e.state == planPending {
+			step++
+			label = fmt.Sprintf("%d.", step)
+		}
+
+		suffix := ""
+		if e.outOfOrder {
+			suffix = "  (out of order)"
+		}
+
+		if !e.migration.Directives.IsZero() {
+			suffix += "  [" + e.migration.Directives.String() + "]"
+		}
+
+		fmt.Fprintf(out, "  %-9s %s%s\n", label, e.migration.Label(), suffix)
+	}
+
+	if outOfOrder > 0 {
+		fmt.Fprintf(out, "\nWarning: %d migration(s) sort before the latest applied migration %s; "+
+			"apply refuses them unless --allow-out-of-order is set.\n", outOfOrder, latestApplied)
+	}
+}
+
+// analyzePlan runs the analyzer over the pending versioned migrations in
+// the plan.
+func analyzePlan(cmd *cobra.Command, entries []planEntry, cfg *config.Config) error {
+	var pending []migration.Migration
+
+	for _, e := range entries {
+		if e.state == planPending && !e.migration.Repeatable {
+			pending = append(pending, *e.migration)
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	a := analyzer.New(
+		analyzer.WithRegistry(rules.NewDefaultRegistry()),
+		analyzer.WithPGVersion(cfg.TargetPGVersion),
+	)
+
+	results, err := a.AnalyzeAll(pending)
+	if err != nil {
+		return fmt.Errorf("analyzing migrations: %w", err)
+	}
+
+	printAnalysisResults(cmd, results)
 
 	return nil
 }
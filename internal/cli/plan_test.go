@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+func planStates(entries []planEntry) []string {
+	states := make([]string, len(entries))
+	for i, e := range entries {
+		states[i] = e.migration.Version + ":" + e.state
+	}
+
+	return states
+}
+
+func TestBuildPlan(t *testing.T) {
+	t.Parallel()
+
+	sorted := []migration.Migration{{Version: "001"}, {Version: "002"}, {Version: "003"}, {Version: "004"}}
+	applied := map[string]bool{"001": true}
+
+	tests := []struct {
+		name     string
+		applied  map[string]bool
+		steps    int
+		expected []string
+	}{
+		{
+			name:     "no database marks all pending",
+			expected: []string{"001:pending", "002:pending", "003:pending", "004:pending"},
+		},
+		{
+			name:     "applied migrations are not pending",
+			applied:  applied,
+			expected: []string{"001:applied", "002:pending", "003:pending", "004:pending"},
+		},
+		{
+			name:     "steps defers the rest",
+			applied:  applied,
+			steps:    2,
+			expected: []string{"001:applied", "002:pending", "003:pending", "004:deferred"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, planStates(buildPlan(sorted, tt.applied, tt.steps)))
+		})
+	}
+}
+
+func newPlanCmd(t *testing.T, out *bytes.Buffer, flags map[string]string) *cobra.Command {
+	t.Helper()
+
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("pending-only", false, "")
+	addApplyLimitFlags(cmd)
+	cmd.SetOut(out)
+	cmd.SetErr(new(bytes.Buffer))
+
+	for k, v := range flags {
+		require.NoError(t, cmd.Flags().Set(k, v))
+	}
+
+	return cmd
+}
+
+func TestRunPlan_noDatabase_targetLimitsPlan(t *testing.T) { //nolint:paralleltest // writes global AppConfig
+	AppConfig = &config.Config{MigrationsDirs: []string{"./testdata/migrations"}, TargetPGVersion: 14}
+
+	buf := new(bytes.Buffer)
+	cmd := newPlanCmd(t, buf, map[string]string{"target": "001"})
+
+	require.NoError(t, runPlan(cmd, nil))
+	assert.Contains(t, buf.String(), "Execution plan: 1 migration(s) to apply")
+	assert.Contains(t, buf.String(), "1.        001_safe_create")
+	assert.NotContains(t, buf.String(), "002_dangerous_index")
+}
+
+func TestRunPlan_noDatabase_stepsDefers(t *testing.T) { //nolint:paralleltest // writes global AppConfig
+	AppConfig = &config.Config{MigrationsDirs: []string{"./testdata/migrations"}, TargetPGVersion: 14}
+
+	buf := new(bytes.Buffer)
+	cmd := newPlanCmd(t, buf, map[string]string{"steps": "1"})
+
+	require.NoError(t, runPlan(cmd, nil))
+	assert.Contains(t, buf.String(), "Execution plan: 1 migration(s) to apply")
+	assert.Contains(t, buf.String(), "deferred  002_dangerous_index")
+}
+
+func TestRunPlan_unknownTarget_returnsError(t *testing.T) { //nolint:paralleltest // writes global AppConfig
+	AppConfig = &config.Config{MigrationsDirs: []string{"./testdata/migrations"}}
+
+	cmd := newPlanCmd(t, new(bytes.Buffer), map[string]string{"target": "999"})
+
+	err := runPlan(cmd, nil)
+	require.ErrorIs(t, err, migration.ErrVersionNotFound)
+}
+
+func TestMarkOutOfOrder_flagsGapsAndPrintsWarning(t *testing.T) {
+	t.Parallel()
+
+	all := []migration.Migration{{Version: "001", Name: "a"}, {Version: "002", Name: "b"}, {Version: "003", Name: "c"}}
+	applied := map[string]bool{"001": true, "003": true}
+
+	entries := buildPlan(all, applied, 0)
+	latest := markOutOfOrder(entries, all, applied)
+
+	assert.Equal(t, "003", latest)
+	assert.True(t, entries[1].outOfOrder)
+
+	var buf bytes.Buffer
+
+	printPlan(&buf, entries, true, latest)
+
+	assert.Contains(t, buf.String(), "002_b  (out of order)")
+	assert.Contains(t, buf.String(), "1 migration(s) sort before the latest applied migration 003")
+}
+
+func TestBuildRepeatablePlan(t *testing.T) {
+	t.Parallel()
+
+	repeatables := []migration.Migration{
+		{Name: "functions", Checksum: "f1", Repeatable: true},
+		{Name: "views", Checksum: "edited", Repeatable: true},
+	}
+	checksums := map[string]string{"functions": "f1", "views": "v1"}
+
+	states := func(entries []planEntry) []string {
+		var s []string
+		for _, e := range entries {
+			s = append(s, e.migration.Label()+":"+e.state)
+		}
+
+		return s
+	}
+
+	assert.Equal(t, []string{"R__functions:applied", "R__views:pending"},
+		states(buildRepeatablePlan(repeatables, checksums, false)))
+	assert.Equal(t, []string{"R__functions:applied", "R__views:deferred"},
+		states(buildRepeatablePlan(repeatables, checksums, true)))
+	assert.Equal(t, []string{"R__functions:pending", "R__views:pending"},
+		states(buildRepeatablePlan(repeatables, nil, false)))
+}
+
+func TestPlanComplete(t *testing.T) {
+	t.Parallel()
+
+	all := []migration.Migration{{Version: "001"}, {Version: "002"}}
+
+	assert.True(t, planComplete(buildPlan(all, nil, 0), all))
+	assert.True(t, planComplete(buildPlan(all, nil, 2), all))
+	assert.False(t, planComplete(buildPlan(all, nil, 1), all))
+	assert.False(t, planComplete(buildPlan(all[:1], nil, 0), all))
+}
+
+func TestPrintPlan_showsDirectives(t *testing.T) {
+	t.Parallel()
+
+	timeout := 2 * time.Second
+	m := migration.Migration{
+		Version:    "001",
+		Name:       "index",
+		Directives: migration.Directives{NoTransaction: true, LockTimeout: &timeout},
+	}
+
+	var buf bytes.Buffer
+
+	printPlan(&buf, []planEntry{{migration: &m, state: planPending}}, false, "")
+
+	assert.Contains(t, buf.String(), "1.        001_index  [no-transaction, lock-timeout 2s]")
+}
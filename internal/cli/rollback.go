@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"time"
@@ -18,13 +19,17 @@ var rollbackCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pa
 	Use:   "rollback",
 	Short: "Roll back applied migrations",
 	Long: `Roll back one or more previously applied migrations using their
-down migration files.`,
+down migration files. Callback files (beforeAll.sql, beforeEach.sql,
+afterEach.sql, afterAll.sql) run as they do for apply.`,
 	RunE: runRollback,
 }
 
 func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
 	rollbackCmd.Flags().Int("steps", 1, "number of migrations to roll back")
 	rollbackCmd.Flags().String("target", "", "roll back to a specific migration version")
+	rollbackCmd.Flags().String("ticket", "", "change-ticket reference recorded in the migration history")
+	addLockWaitFlag(rollbackCmd)
+	addGracePeriodFlag(rollbackCmd)
 	rollbackCmd.MarkFlagsMutuallyExclusive("steps", "target")
 	rootCmd.AddCommand(rollbackCmd)
 }
@@ -38,12 +43,18 @@ func runRollback(cmd *cobra.Command, _ []string) error {
 
 	steps, _ := cmd.Flags().GetInt("steps")
 	target, _ := cmd.Flags().GetString("target")
+	ticket, _ := cmd.Flags().GetString("ticket")
 
-	sorted, err := loadAndSortMigrations(cfg.MigrationsDir, cmd.OutOrStdout())
+	sorted, err := loadAndSortMigrations(cfg.MigrationsDirs, cmd.OutOrStdout(), loadOptions(cfg)...)
 	if err != nil || sorted == nil {
 		return err
 	}
 
+	hooks, err := migration.LoadHooks(cfg.MigrationsDirs, loadOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("loading callback files: %w", err)
+	}
+
 	ctx := cmd.Context()
 	if ctx == nil {
 		ctx = context.Background()
@@ -55,11 +66,16 @@ func runRollback(cmd *cobra.Command, _ []string) error {
 	}
 	defer pool.Close()
 
-	return executeRollback(ctx, cmd.OutOrStdout(), pool, sorted, rollbackOpts{
+	return executeRollback(ctx, cmd.OutOrStdout(), pool, newTracker(pool, cfg), sorted, rollbackOpts{
 		steps:            steps,
 		target:           target,
 		lockTimeout:      cfg.LockTimeout,
 		statementTimeout: cfg.StatementTimeout,
+		ticket:           ticket,
+		lockKey:          resolveLockKey(pool, cfg),
+		lockWait:         lockWait(cmd, cfg),
+		gracePeriod:      gracePeriod(cmd, cfg),
+		hooks:            hooks,
 	})
 }
 
@@ -68,23 +84,40 @@ type rollbackOpts struct {
 	target           string
 	lockTimeout      time.Duration
 	statementTimeout time.Duration
+	ticket           string
+	lockKey          int64
+	lockWait         time.Duration
+	gracePeriod      time.Duration
+	hooks            []migration.Hook
 }
 
 func executeRollback(
 	ctx context.Context,
 	out io.Writer,
 	pool *pgxpool.Pool,
+	t *tracker.Tracker,
 	sorted []migration.Migration,
 	opts rollbackOpts,
 ) error {
-	t := tracker.New(pool)
-
 	rolledBack := 0
 
+	var interrupted *migration.Migration
+
 	exec := executor.New(pool, t,
 		executor.WithLockTimeout(opts.lockTimeout),
 		executor.WithStatementTimeout(opts.statementTimeout),
+		executor.WithTicket(opts.ticket),
+		executor.WithLockKey(opts.lockKey),
+		executor.WithLockWait(opts.lockWait),
+		executor.WithLockWaitCallback(printLockWait(out)),
+		executor.WithGracePeriod(opts.gracePeriod),
+		executor.WithHooks(opts.hooks),
 		executor.WithProgressCallback(func(event executor.ProgressEvent) {
+			if event.Hook != "" {
+				printHookProgress(out, event)
+				return
+			}
+
 			switch event.Status {
 			case executor.StatusRollingBack:
 				fmt.Fprintf(out, "  Rolling back %s_%s ... ",
@@ -96,7 +129,10 @@ func executeRollback(
 			case executor.StatusFailed:
 				fmt.Fprintf(out, "FAILED\n")
 				fmt.Fprintf(out, "    Error: %v\n", event.Error)
-			case executor.StatusSkipped:
+			case executor.StatusInterrupted:
+				fmt.Fprintf(out, "INTERRUPTED\n")
+				interrupted = event.Migration
+			case executor.StatusDryRun:
 				// dry-run placeholder
 			}
 		}),
@@ -111,6 +147,10 @@ func executeRollback(
 	}
 
 	if err != nil {
+		if errors.Is(err, executor.ErrInterrupted) {
+			printRollbackInterrupted(out, rolledBack, interrupted)
+		}
+
 		return err
 	}
 
@@ -118,3 +158,13 @@ func executeRollback(
 
 	return nil
 }
+
+// printRollbackInterrupted summarizes a rollback stopped by a signal or lost lock.
+func printRollbackInterrupted(out io.Writer, rolledBack int, inFlight *migration.Migration) {
+	fmt.Fprintf(out, "\nRollback interrupted: %d migration(s) rolled back.\n", rolledBack)
+
+	if inFlight != nil {
+		fmt.Fprintf(out, "  %s_%s was cancelled before completing and is still recorded as applied.\n",
+			inFlight.Version, inFlight.Name)
+	}
+}
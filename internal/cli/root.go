@@ -1,16 +1,23 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/executor"
 )
 
 const version = "0.1.0"
 
+// migrationFileMode is the mode of the migrations introspect, diff and
+// analyze --fix write, readable like the rest of the repository.
+const migrationFileMode = 0o644
+
 // AppConfig holds the loaded configuration, set during PersistentPreRunE.
 var AppConfig *config.Config //nolint:gochecknoglobals // standard Cobra pattern for shared config
 
@@ -32,14 +39,30 @@ suggests safe alternatives, and executes migrations with rollback capability.`,
 func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
 	rootCmd.PersistentFlags().String("config", "migrate.yml", "path to configuration file")
 	rootCmd.PersistentFlags().String("database-url", "", "PostgreSQL connection string")
-	rootCmd.PersistentFlags().String("migrations-dir", "", "path to migration files")
+	rootCmd.PersistentFlags().String("migrations-dir", "", "path to migration files (comma-separated for several)")
+	rootCmd.PersistentFlags().Bool("recursive", false, "also load migrations from subdirectories")
+	rootCmd.PersistentFlags().StringToString("var", nil,
+		"value for a ${name} placeholder in migration SQL, as name=value (repeatable)")
 	rootCmd.PersistentFlags().Bool("verbose", false, "enable verbose output")
 }
 
-// Execute runs the root command. Called from main.
+// Execute runs the root command. Called from main. The first SIGINT or
+// SIGTERM cancels the command's context so apply and rollback can stop
+// cleanly; a second one exits immediately.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := notifyContext(context.Background(), os.Stderr)
+
+	err := rootCmd.ExecuteContext(ctx)
+
+	stop()
+
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
+
+		if errors.Is(err, executor.ErrInterrupted) {
+			os.Exit(exitCodeInterrupted)
+		}
+
 		os.Exit(1)
 	}
 }
@@ -69,6 +92,18 @@ func mergeFlags(cmd *cobra.Command, cfg *config.Config) {
 	}
 
 	if cmd.Flags().Changed("migrations-dir") {
-		cfg.MigrationsDir, _ = cmd.Flags().GetString("migrations-dir")
+		dirs, _ := cmd.Flags().GetString("migrations-dir")
+		cfg.MigrationsDirs = config.SplitList(dirs)
+	}
+
+	if cmd.Flags().Changed("recursive") {
+		cfg.Recursive, _ = cmd.Flags().GetBool("recursive")
+	}
+
+	if cmd.Flags().Changed("var") {
+		vars, _ := cmd.Flags().GetStringToString("var")
+		for name, value := range vars {
+			cfg.SetVar(name, value)
+		}
 	}
 }
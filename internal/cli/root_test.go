@@ -37,7 +37,39 @@ func TestMergeFlags_migrationsDir_overridesConfig(t *testing.T) {
 	require.NoError(t, cmd.Flags().Set("migrations-dir", "/custom/migrations"))
 
 	mergeFlags(cmd, cfg)
-	assert.Equal(t, "/custom/migrations", cfg.MigrationsDir)
+	assert.Equal(t, []string{"/custom/migrations"}, cfg.MigrationsDirs)
+}
+
+func TestMergeFlags_migrationsDir_commaSeparated(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.New()
+	cmd := &cobra.Command{}
+	cmd.Flags().String("migrations-dir", "", "")
+	cmd.Flags().Bool("recursive", false, "")
+
+	require.NoError(t, cmd.Flags().Set("migrations-dir", "./core,./billing"))
+	require.NoError(t, cmd.Flags().Set("recursive", "true"))
+
+	mergeFlags(cmd, cfg)
+	assert.Equal(t, []string{"./core", "./billing"}, cfg.MigrationsDirs)
+	assert.True(t, cfg.Recursive)
+}
+
+func TestMergeFlags_vars_overrideConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.New()
+	cfg.Vars = map[string]string{"app_role": "from_yaml", "tablespace": "fast"}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().StringToString("var", nil, "")
+
+	require.NoError(t, cmd.Flags().Set("var", "app_role=from_flag"))
+	require.NoError(t, cmd.Flags().Set("var", "schema=billing"))
+
+	mergeFlags(cmd, cfg)
+	assert.Equal(t, map[string]string{"app_role": "from_flag", "tablespace": "fast", "schema": "billing"}, cfg.Vars)
 }
 
 func TestMergeFlags_unchangedFlags_preserveConfig(t *testing.T) {
@@ -45,7 +77,7 @@ func TestMergeFlags_unchangedFlags_preserveConfig(t *testing.T) {
 
 	cfg := config.New()
 	cfg.DatabaseURL = "postgres://original:5432/db"
-	cfg.MigrationsDir = "/original/dir"
+	cfg.MigrationsDirs = []string{"/original/dir"}
 
 	cmd := &cobra.Command{}
 	cmd.Flags().String("database-url", "", "")
@@ -53,7 +85,7 @@ func TestMergeFlags_unchangedFlags_preserveConfig(t *testing.T) {
 
 	mergeFlags(cmd, cfg)
 	assert.Equal(t, "postgres://original:5432/db", cfg.DatabaseURL)
-	assert.Equal(t, "/original/dir", cfg.MigrationsDir)
+	assert.Equal(t, []string{"/original/dir"}, cfg.MigrationsDirs)
 }
 
 func TestLoadConfig_missingFile_usesDefaults(t *testing.T) { // not parallel: mutates global AppConfig
@@ -68,7 +100,7 @@ func TestLoadConfig_missingFile_usesDefaults(t *testing.T) { // not parallel: mu
 	err := loadConfig(cmd)
 	require.NoError(t, err)
 	require.NotNil(t, AppConfig)
-	assert.Equal(t, config.DefaultMigrationsDir, AppConfig.MigrationsDir)
+	assert.Equal(t, []string{config.DefaultMigrationsDir}, AppConfig.MigrationsDirs)
 	assert.Equal(t, config.DefaultTargetPGVersion, AppConfig.TargetPGVersion)
 }
 
@@ -92,7 +124,7 @@ func TestLoadConfig_validFile_loadsValues(t *testing.T) { // not parallel: mutat
 	err := loadConfig(cmd)
 	require.NoError(t, err)
 	require.NotNil(t, AppConfig)
-	assert.Equal(t, "/from/yaml", AppConfig.MigrationsDir)
+	assert.Equal(t, []string{"/from/yaml"}, AppConfig.MigrationsDirs)
 	assert.Equal(t, 15, AppConfig.TargetPGVersion)
 }
 
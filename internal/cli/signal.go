@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+)
+
+// exitCodeInterrupted is the conventional exit status after SIGINT.
+const exitCodeInterrupted = 130
+
+// errSignalReceived is the cancellation cause when a termination signal arrives.
+var errSignalReceived = errors.New("received signal")
+
+// notifyContext returns a context cancelled on the first SIGINT or SIGTERM.
+// A second signal exits the process immediately. The returned stop function
+// restores default signal handling.
+func notifyContext(parent context.Context, out io.Writer) (context.Context, func()) {
+	signals := make(chan os.Signal, 2) //nolint:mnd // room for both signals
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	ctx, stopWatching := watchSignals(parent, out, signals, os.Exit)
+
+	return ctx, func() {
+		signal.Stop(signals)
+		stopWatching()
+	}
+}
+
+// watchSignals cancels the returned context when the first signal arrives
+// and calls exit when a second one does.
+func watchSignals(
+	parent context.Context,
+	out io.Writer,
+	signals <-chan os.Signal,
+	exit func(int),
+) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(out, "\nReceived %s: finishing the current migration, then stopping "+
+				"(send again to exit immediately)\n", sig)
+			cancel(fmt.Errorf("%w: %s", errSignalReceived, sig))
+		case <-done:
+			return
+		}
+
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(out, "\nReceived %s again: exiting immediately\n", sig)
+			exit(exitCodeInterrupted)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+
+	return ctx, func() {
+		once.Do(func() {
+			close(done)
+			cancel(nil)
+		})
+	}
+}
+
+// addGracePeriodFlag registers the --grace-period flag on a command that runs migrations.
+func addGracePeriodFlag(cmd *cobra.Command) {
+	cmd.Flags().Duration("grace-period", 0,
+		"after an interrupt, how long the in-flight migration may finish before it is cancelled (e.g., 10s)")
+}
+
+// gracePeriod returns the --grace-period flag value if set, otherwise the configured value.
+func gracePeriod(cmd *cobra.Command, cfg *config.Config) time.Duration {
+	if cmd.Flags().Changed("grace-period") {
+		d, _ := cmd.Flags().GetDuration("grace-period")
+		return d
+	}
+
+	return cfg.GracePeriod
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes and reads.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestWatchSignals_firstSignalCancels_secondExits(t *testing.T) {
+	t.Parallel()
+
+	out := &syncBuffer{}
+	signals := make(chan os.Signal, 1)
+	exited := make(chan int, 1)
+
+	ctx, stop := watchSignals(context.Background(), out, signals, func(code int) { exited <- code })
+	defer stop()
+
+	signals <- syscall.SIGTERM
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context not cancelled after first signal")
+	}
+
+	require.ErrorIs(t, context.Cause(ctx), errSignalReceived)
+	assert.Contains(t, out.String(), "finishing the current migration")
+
+	signals <- syscall.SIGINT
+
+	select {
+	case code := <-exited:
+		assert.Equal(t, exitCodeInterrupted, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("second signal did not exit")
+	}
+}
+
+func TestWatchSignals_stopWithoutSignal(t *testing.T) {
+	t.Parallel()
+
+	ctx, stop := watchSignals(context.Background(), &syncBuffer{}, make(chan os.Signal), func(int) {
+		t.Error("exit called without a signal")
+	})
+
+	stop()
+	stop()
+
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.NotErrorIs(t, context.Cause(ctx), errSignalReceived)
+}
+
+func TestGracePeriod_flagOverridesConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.New()
+
+	cmd := &cobra.Command{}
+	addGracePeriodFlag(cmd)
+
+	assert.Equal(t, config.DefaultGracePeriod, gracePeriod(cmd, cfg))
+
+	require.NoError(t, cmd.Flags().Set("grace-period", "0s"))
+	assert.Zero(t, gracePeriod(cmd, cfg))
+}
+
+func TestPrintApplyInterrupted(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	inFlight := &migration.Migration{Version: "003", Name: "backfill"}
+	printApplyInterrupted(&buf, 1, 1, 2, inFlight)
+
+	assert.Contains(t, buf.String(), "Apply interrupted: 1 applied, 1 skipped, 2 not started.")
+	assert.Contains(t, buf.String(), "003_backfill was cancelled before completing and is not recorded as applied.")
+}
+
+func TestPrintRollbackInterrupted_noInFlight(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	printRollbackInterrupted(&buf, 2, nil)
+
+	assert.Equal(t, "\nRollback interrupted: 2 migration(s) rolled back.\n", buf.String())
+}
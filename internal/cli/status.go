@@ -1,16 +1,39 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// Migration states reported by status.
+const (
+	stateApplied    = "applied"
+	stateBaseline   = "baseline"
+	statePending    = "pending"
+	stateOutOfOrder = "out-of-order"
+	stateModified   = "modified"
+	stateMissing    = "missing"
+	stateOutdated   = "outdated"
 )
 
 var statusCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pattern
 	Use:   "status",
 	Short: "Show migration status",
 	Long: `Display the current migration status showing applied and pending
-migrations.`,
+migrations. Pending migrations that sort before the latest applied one are
+flagged as out-of-order; apply refuses them unless --allow-out-of-order is set.
+Migrations marked applied by migrate baseline are shown as baseline.
+Repeatable migrations are listed last with version R, and flagged as
+outdated when they changed since they were last applied.`,
 	RunE: runStatus,
 }
 
@@ -19,8 +42,259 @@ func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registr
 	rootCmd.AddCommand(statusCmd)
 }
 
+// statusRow is the status of one migration, from disk, the tracking table, or both.
+type statusRow struct {
+	Version    string     `json:"version"`
+	Name       string     `json:"name"`
+	State      string     `json:"state"`
+	AppliedAt  *time.Time `json:"applied_at,omitempty"`
+	Repeatable bool       `json:"repeatable,omitempty"`
+}
+
 func runStatus(cmd *cobra.Command, _ []string) error {
-	fmt.Fprintln(cmd.OutOrStdout(), "status: not yet implemented")
+	cfg := AppConfig
+
+	if cfg.DatabaseURL == "" {
+		return errDatabaseURLRequired
+	}
+
+	format, _ := cmd.Flags().GetString("format")
+
+	migrations, err := migration.LoadFromDirs(cfg.MigrationsDirs, loadOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+
+	repeatables, err := migration.LoadRepeatables(cfg.MigrationsDirs, loadOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("loading repeatable migrations: %w", err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	pool, err := connectDB(ctx, cfg, cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	applied, appliedRepeatables, err := readTracker(ctx, newTracker(pool, cfg))
+	if err != nil {
+		return err
+	}
+
+	rows := buildStatus(migration.Sort(migrations), applied)
+	rows = append(rows, buildRepeatableStatus(repeatables, appliedRepeatables)...)
+
+	return printStatus(cmd.OutOrStdout(), rows, format)
+}
+
+// readTracker returns the applied versioned and repeatable migrations, or
+// nothing if the tracking table does not exist. It never writes, so status
+// and plan work with read-only credentials.
+func readTracker(ctx context.Context, t *tracker.Tracker) ([]tracker.AppliedMigration, []tracker.AppliedRepeatable, error) {
+	exists, err := t.Exists(ctx)
+	if err != nil || !exists {
+		return nil, nil, err
+	}
+
+	applied, err := t.GetApplied(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting applied migrations: %w", err)
+	}
+
+	repeatables, err := t.GetRepeatables(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting applied repeatable migrations: %w", err)
+	}
+
+	return applied, repeatables, nil
+}
+
+// buildStatus merges migrations on disk with applied rows. Rows follow the
+// on-disk order; applied versions with no file are appended as missing.
+func buildStatus(sorted []migration.Migration, applied []tracker.AppliedMigration) []statusRow {
+	byVersion := make(map[string]*tracker.AppliedMigration, len(applied))
+	appliedSet := make(map[string]bool, len(applied))
+
+	for i := range applied {
+		byVersion[applied[i].Version] = &applied[i]
+		appliedSet[applied[i].Version] = true
+	}
+
+	gaps, _ := migration.OutOfOrder(sorted, appliedSet)
+
+	outOfOrder := make(map[string]bool, len(gaps))
+	for _, g := range gaps {
+		outOfOrder[g.Version] = true
+	}
+
+	rows := make([]statusRow, 0, len(sorted))
+	onDisk := make(map[string]bool, len(sorted))
+
+	for _, m := range sorted {
+		onDisk[m.Version] = true
+		row := statusRow{Version: m.Version, Name: m.Name, State: statePending}
+
+		switch a := byVersion[m.Version]; {
+		case a != nil && a.Checksum != m.Checksum:
+			row.State = stateModified
+			row.AppliedAt = &a.AppliedAt
+		case a != nil && a.Status == tracker.StatusBaseline:
+			row.State = stateBaseline
+			row.AppliedAt = &a.AppliedAt
+		case a != nil:
+			row.State = stateApplied
+			row.AppliedAt = &a.AppliedAt
+		case outOfOrder[m.Version]:
+			row.State = stateOutOfOrder
+		}
+
+		rows = append(rows, row)
+	}
+
+	for i := range applied {
+		if !onDisk[applied[i].Version] {
+			rows = append(rows, statusRow{
+				Version:   applied[i].Version,
+				Name:      applied[i].Filename,
+				State:     stateMissing,
+				AppliedAt: &applied[i].AppliedAt,
+			})
+		}
+	}
+
+	return rows
+}
+
+// buildRepeatableStatus merges repeatable migrations on disk with their
+// recorded runs, in name order; recorded names with no file are appended
+// as missing.
+func buildRepeatableStatus(repeatables []migration.Migration, applied []tracker.AppliedRepeatable) []statusRow {
+	byName := make(map[string]*tracker.AppliedRepeatable, len(applied))
+	for i := range applied {
+		byName[applied[i].Name] = &applied[i]
+	}
+
+	rows := make([]statusRow, 0, len(repeatables))
+	onDisk := make(map[string]bool, len(repeatables))
+
+	for _, r := range repeatables {
+		onDisk[r.Name] = true
+		row := statusRow{Name: r.Name, State: statePending, Repeatable: true}
+
+		switch a := byName[r.Name]; {
+		case a != nil && a.Checksum != r.Checksum:
+			row.State = stateOutdated
+			row.AppliedAt = &a.AppliedAt
+		case a != nil:
+			row.State = stateApplied
+			row.AppliedAt = &a.AppliedAt
+		}
+
+		rows = append(rows, row)
+	}
+
+	for i := range applied {
+		if !onDisk[applied[i].Name] {
+			rows = append(rows, statusRow{
+				Name:       applied[i].Name,
+				State:      stateMissing,
+				AppliedAt:  &applied[i].AppliedAt,
+				Repeatable: true,
+			})
+		}
+	}
+
+	return rows
+}
+
+// printStatus writes status rows in the requested format.
+func printStatus(out io.Writer, rows []statusRow, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(rows); err != nil {
+			return fmt.Errorf("encoding status: %w", err)
+		}
+
+		return nil
+	case "text":
+		printStatusText(out, rows)
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", errUnsupportedFormat, format)
+	}
+}
+
+func printStatusText(out io.Writer, rows []statusRow) {
+	if len(rows) == 0 {
+		fmt.Fprintln(out, "No migrations found.")
+		return
+	}
+
+	counts := make(map[string]int)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+
+	for _, r := range rows {
+		appliedAt := ""
+		if r.AppliedAt != nil {
+			appliedAt = r.AppliedAt.UTC().Format(time.RFC3339)
+		}
+
+		version := r.Version
+		if r.Repeatable {
+			version = "R"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", version, r.Name, r.State, appliedAt)
+
+		counts[r.State]++
+	}
+
+	w.Flush()
+
+	fmt.Fprintf(out, "\n%d applied", counts[stateApplied]+counts[stateBaseline]+counts[stateModified]+counts[stateMissing])
+
+	if n := counts[stateBaseline]; n > 0 {
+		fmt.Fprintf(out, " (%d baselined)", n)
+	}
+
+	fmt.Fprintf(out, ", %d pending", counts[statePending]+counts[stateOutOfOrder]+counts[stateOutdated])
+
+	if n := counts[stateOutOfOrder]; n > 0 {
+		fmt.Fprintf(out, " (%d out of order)", n)
+	}
+
+	fmt.Fprintln(out, ".")
+
+	if counts[stateOutOfOrder] > 0 {
+		fmt.Fprintln(out, "Out-of-order migrations sort before the latest applied migration; "+
+			"apply refuses them unless --allow-out-of-order is set.")
+	}
+
+	if counts[stateBaseline] > 0 {
+		fmt.Fprintln(out, "Baselined migrations were marked applied without running; rollback stops at them.")
+	}
+
+	if counts[stateModified] > 0 {
+		fmt.Fprintln(out, "Modified migrations were changed on disk after being applied.")
+	}
+
+	if counts[stateMissing] > 0 {
+		fmt.Fprintln(out, "Missing migrations are recorded as applied but have no file on disk.")
+	}
 
-	return nil
+	if counts[stateOutdated] > 0 {
+		fmt.Fprintln(out, "Outdated repeatable migrations changed since they were last applied; "+
+			"apply runs them again.")
+	}
 }
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+func TestRunStatus_noDatabaseURL_returnsError(t *testing.T) { //nolint:paralleltest // writes global AppConfig
+	AppConfig = &config.Config{MigrationsDirs: []string{"./testdata/migrations"}}
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(new(bytes.Buffer))
+
+	err := runStatus(cmd, nil)
+	require.ErrorIs(t, err, errDatabaseURLRequired)
+}
+
+func statusFixture() ([]migration.Migration, []tracker.AppliedMigration) {
+	sorted := []migration.Migration{
+		{Version: "001", Name: "a", Checksum: "c1"},
+		{Version: "002", Name: "b", Checksum: "c2"},
+		{Version: "003", Name: "c", Checksum: "c3"},
+		{Version: "004", Name: "d", Checksum: "c4"},
+	}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	applied := []tracker.AppliedMigration{
+		{Version: "000", Filename: "V000_gone.up.sql", Checksum: "c0", AppliedAt: at},
+		{Version: "001", Filename: "V001_a.up.sql", Checksum: "c1", AppliedAt: at},
+		{Version: "003", Filename: "V003_c.up.sql", Checksum: "changed", AppliedAt: at},
+	}
+
+	return sorted, applied
+}
+
+func TestBuildStatus_flagsGapsModifiedAndMissing(t *testing.T) {
+	t.Parallel()
+
+	rows := buildStatus(statusFixture())
+
+	states := make(map[string]string, len(rows))
+	for _, r := range rows {
+		states[r.Version] = r.State
+	}
+
+	assert.Equal(t, map[string]string{
+		"000": stateMissing,
+		"001": stateApplied,
+		"002": stateOutOfOrder,
+		"003": stateModified,
+		"004": statePending,
+	}, states)
+	assert.Equal(t, "000", rows[len(rows)-1].Version, "missing rows follow on-disk rows")
+}
+
+func TestPrintStatus_text(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, printStatus(&buf, buildStatus(statusFixture()), "text"))
+
+	out := buf.String()
+	assert.Contains(t, out, "VERSION")
+	assert.Contains(t, out, "2024-01-02T03:04:05Z")
+	assert.Contains(t, out, "3 applied, 2 pending (1 out of order).")
+	assert.Contains(t, out, "--allow-out-of-order")
+	assert.Contains(t, out, "Missing migrations")
+}
+
+func TestBuildStatus_distinguishesBaseline(t *testing.T) {
+	t.Parallel()
+
+	sorted := []migration.Migration{
+		{Version: "001", Name: "a", Checksum: "c1"},
+		{Version: "002", Name: "b", Checksum: "c2"},
+		{Version: "003", Name: "c", Checksum: "c3"},
+	}
+	applied := []tracker.AppliedMigration{
+		{Version: "001", Checksum: "c1", Status: tracker.StatusBaseline},
+		{Version: "002", Checksum: "c2", Status: tracker.StatusApplied},
+	}
+
+	rows := buildStatus(sorted, applied)
+	require.Len(t, rows, 3)
+	assert.Equal(t, stateBaseline, rows[0].State)
+	assert.Equal(t, stateApplied, rows[1].State)
+	assert.Equal(t, statePending, rows[2].State)
+
+	var buf bytes.Buffer
+
+	printStatusText(&buf, rows)
+	assert.Contains(t, buf.String(), "2 applied (1 baselined), 1 pending.")
+	assert.Contains(t, buf.String(), "rollback stops at them")
+}
+
+func TestPrintStatus_json(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, printStatus(&buf, buildStatus(statusFixture()), "json"))
+
+	var rows []statusRow
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rows))
+	require.Len(t, rows, 5)
+	assert.Equal(t, stateOutOfOrder, rows[1].State)
+	assert.Nil(t, rows[1].AppliedAt)
+}
+
+func TestPrintStatus_unknownFormat(t *testing.T) {
+	t.Parallel()
+
+	err := printStatus(new(bytes.Buffer), nil, "xml")
+	require.ErrorIs(t, err, errUnsupportedFormat)
+}
+
+func TestBuildRepeatableStatus(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := buildRepeatableStatus(
+		[]migration.Migration{
+			{Name: "functions", Checksum: "f1", Repeatable: true},
+			{Name: "new_view", Checksum: "n1", Repeatable: true},
+			{Name: "views", Checksum: "edited", Repeatable: true},
+		},
+		[]tracker.AppliedRepeatable{
+			{Name: "dropped", Checksum: "d1", AppliedAt: at},
+			{Name: "functions", Checksum: "f1", AppliedAt: at},
+			{Name: "views", Checksum: "v1", AppliedAt: at},
+		},
+	)
+
+	states := make(map[string]string, len(rows))
+	for _, r := range rows {
+		assert.True(t, r.Repeatable)
+		states[r.Name] = r.State
+	}
+
+	assert.Equal(t, map[string]string{
+		"dropped":   stateMissing,
+		"functions": stateApplied,
+		"new_view":  statePending,
+		"views":     stateOutdated,
+	}, states)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, printStatus(&buf, rows, "text"))
+	assert.Contains(t, buf.String(), "R        views")
+	assert.Contains(t, buf.String(), "2 applied, 2 pending.")
+	assert.Contains(t, buf.String(), "Outdated repeatable migrations")
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+var validateCmd = &cobra.Command{ //nolint:gochecknoglobals // standard Cobra pattern
+	Use:   "validate [migration-dir...]",
+	Short: "Check migration files for naming and syntax problems",
+	Long: `Check every .sql file in the migrations directory without connecting to
+a database. Reports misnamed files, orphan down files, duplicate versions,
+empty up files, single-file migrations with missing or repeated
+-- migrate:up / -- migrate:down sections, unresolved ${name} placeholders,
+and SQL that does not parse, with line numbers. Exits non-zero if any problem is found, so it
+can run as a pre-commit gate.`,
+	RunE: runValidate,
+}
+
+func init() { //nolint:gochecknoinits // standard Cobra pattern for flag registration
+	validateCmd.Flags().String("format", "text", "output format (text, json)")
+	rootCmd.AddCommand(validateCmd)
+}
+
+// errValidationFailed is returned when validate finds at least one problem.
+var errValidationFailed = errors.New("migration validation failed")
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	dirs := AppConfig.MigrationsDirs
+	if len(args) > 0 {
+		dirs = args
+	}
+
+	format, _ := cmd.Flags().GetString("format")
+
+	diags, err := migration.ValidateDirs(dirs, loadOptions(AppConfig)...)
+	if err != nil {
+		return fmt.Errorf("validating migrations: %w", err)
+	}
+
+	if err := printDiagnostics(cmd.OutOrStdout(), diags, format); err != nil {
+		return err
+	}
+
+	if len(diags) > 0 {
+		return fmt.Errorf("%w: %d problem(s)", errValidationFailed, len(diags))
+	}
+
+	return nil
+}
+
+// printDiagnostics writes diagnostics in the requested format.
+func printDiagnostics(out io.Writer, diags []migration.Diagnostic, format string) error {
+	switch format {
+	case "json":
+		if diags == nil {
+			diags = []migration.Diagnostic{}
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(diags); err != nil {
+			return fmt.Errorf("encoding diagnostics: %w", err)
+		}
+
+		return nil
+	case "text":
+		printDiagnosticsText(out, diags)
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", errUnsupportedFormat, format)
+	}
+}
+
+func printDiagnosticsText(out io.Writer, diags []migration.Diagnostic) {
+	if len(diags) == 0 {
+		fmt.Fprintln(out, "All migration files are valid.")
+		return
+	}
+
+	for _, d := range diags {
+		if d.Line > 0 {
+			fmt.Fprintf(out, "%s:%d: %s: %s\n", d.File, d.Line, d.Kind, d.Message)
+		} else {
+			fmt.Fprintf(out, "%s: %s: %s\n", d.File, d.Kind, d.Message)
+		}
+	}
+
+	fmt.Fprintf(out, "\n%d problem(s) found.\n", len(diags))
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+func TestRunValidate_validDir(t *testing.T) { //nolint:paralleltest // writes global AppConfig
+	AppConfig = &config.Config{}
+
+	var buf bytes.Buffer
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("format", "text", "")
+	cmd.SetOut(&buf)
+
+	err := runValidate(cmd, []string{filepath.Join("..", "..", "testdata", "migrations")})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "All migration files are valid.")
+}
+
+func TestRunValidate_problemsFailWithLocations(t *testing.T) { //nolint:paralleltest // writes global AppConfig
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "V001_a.up.sql"), []byte("SELECT 1;\nSELEC 2;"), 0o644))
+	AppConfig = &config.Config{MigrationsDirs: []string{dir}}
+
+	var buf bytes.Buffer
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("format", "text", "")
+	cmd.SetOut(&buf)
+
+	err := runValidate(cmd, nil)
+	require.ErrorIs(t, err, errValidationFailed)
+	assert.Contains(t, buf.String(), "V001_a.up.sql:2: syntax-error: column 1:")
+	assert.Contains(t, buf.String(), "1 problem(s) found.")
+}
+
+func TestPrintDiagnostics_json(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	diags := []migration.Diagnostic{{File: "x.sql", Kind: migration.DiagMisnamedFile, Message: "bad"}}
+	require.NoError(t, printDiagnostics(&buf, diags, "json"))
+
+	var got []migration.Diagnostic
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, diags, got)
+
+	buf.Reset()
+	require.NoError(t, printDiagnostics(&buf, nil, "json"))
+	assert.JSONEq(t, "[]", buf.String())
+}
+
+func TestPrintDiagnostics_unknownFormat(t *testing.T) {
+	t.Parallel()
+
+	err := printDiagnostics(new(bytes.Buffer), nil, "xml")
+	require.ErrorIs(t, err, errUnsupportedFormat)
+}
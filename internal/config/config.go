@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -15,36 +17,94 @@ const (
 	DefaultStatementTimeout = 30 * time.Second
 	DefaultTargetPGVersion  = 14
 	DefaultFormat           = "text"
+	DefaultTrackingTable    = "schema_migrations"
+	DefaultGracePeriod      = 10 * time.Second
 )
 
 // Config holds the application configuration loaded from file, environment, and flags.
 type Config struct {
-	DatabaseURL      string
-	MigrationsDir    string
-	LockTimeout      time.Duration
-	StatementTimeout time.Duration
-	TargetPGVersion  int
-	Format           string
+	DatabaseURL        string
+	ScratchDatabaseURL string   // Server where drift and diff replay migrations in a temporary database
+	MigrationsDirs     []string // Migration source directories, merged into one ordered set
+	Recursive          bool     // Also load migrations from subdirectories of each source
+	LockTimeout        time.Duration
+	StatementTimeout   time.Duration
+	TargetPGVersion    int
+	Format             string
+	TrackingSchema     string            // Schema holding the tracking tables (empty uses search_path)
+	TrackingTable      string            // Name of the tracking table
+	LockKey            int64             // Advisory lock key (0 derives one from database and tracking table)
+	LockWait           time.Duration     // How long to wait for the advisory lock (0 fails immediately)
+	GracePeriod        time.Duration     // How long an interrupted migration may finish before it is cancelled
+	AllowOutOfOrder    bool              // Apply pending migrations older than the latest applied one
+	AllowMixedVersions bool              // Allow V-prefixed and timestamp versions in one directory
+	Vars               map[string]string // Values for ${name} placeholders in migration SQL
 }
 
 // yamlConfig is the raw YAML file representation with string durations.
 type yamlConfig struct {
-	DatabaseURL      string `yaml:"database_url"`
-	MigrationsDir    string `yaml:"migrations_dir"`
-	LockTimeout      string `yaml:"lock_timeout"`
-	StatementTimeout string `yaml:"statement_timeout"`
-	TargetPGVersion  int    `yaml:"target_pg_version"`
-	Format           string `yaml:"format"`
+	DatabaseURL        string            `yaml:"database_url"`
+	ScratchDatabaseURL string            `yaml:"scratch_database_url"`
+	MigrationsDir      stringList        `yaml:"migrations_dir"`
+	Recursive          bool              `yaml:"recursive"`
+	LockTimeout        string            `yaml:"lock_timeout"`
+	StatementTimeout   string            `yaml:"statement_timeout"`
+	TargetPGVersion    int               `yaml:"target_pg_version"`
+	Format             string            `yaml:"format"`
+	TrackingSchema     string            `yaml:"tracking_schema"`
+	TrackingTable      string            `yaml:"tracking_table"`
+	LockKey            int64             `yaml:"lock_key"`
+	LockWait           string            `yaml:"lock_wait"`
+	GracePeriod        string            `yaml:"grace_period"`
+	AllowOutOfOrder    bool              `yaml:"allow_out_of_order"`
+	AllowMixedVersions bool              `yaml:"allow_mixed_versions"`
+	Vars               map[string]string `yaml:"vars"`
+}
+
+// stringList is a YAML value given either as a single string or as a list.
+type stringList []string
+
+// UnmarshalYAML accepts a scalar or a sequence of scalars.
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = SplitList(node.Value)
+		return nil
+	}
+
+	var items []string
+	if err := node.Decode(&items); err != nil {
+		return fmt.Errorf("decoding list: %w", err)
+	}
+
+	*l = items
+
+	return nil
+}
+
+// SplitList splits a comma-separated list, trimming spaces and dropping
+// empty entries.
+func SplitList(s string) []string {
+	var items []string
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
 }
 
 // New returns a Config populated with default values.
 func New() *Config {
 	return &Config{
-		MigrationsDir:    DefaultMigrationsDir,
+		MigrationsDirs:   []string{DefaultMigrationsDir},
 		LockTimeout:      DefaultLockTimeout,
 		StatementTimeout: DefaultStatementTimeout,
 		TargetPGVersion:  DefaultTargetPGVersion,
 		Format:           DefaultFormat,
+		TrackingTable:    DefaultTrackingTable,
+		GracePeriod:      DefaultGracePeriod,
 	}
 }
 
@@ -76,26 +136,12 @@ func fromYAML(raw *yamlConfig) (*Config, error) {
 		cfg.DatabaseURL = raw.DatabaseURL
 	}
 
-	if raw.MigrationsDir != "" {
-		cfg.MigrationsDir = raw.MigrationsDir
+	if raw.ScratchDatabaseURL != "" {
+		cfg.ScratchDatabaseURL = raw.ScratchDatabaseURL
 	}
 
-	if raw.LockTimeout != "" {
-		d, err := time.ParseDuration(raw.LockTimeout)
-		if err != nil {
-			return nil, fmt.Errorf("parsing lock_timeout %q: %w", raw.LockTimeout, err)
-		}
-
-		cfg.LockTimeout = d
-	}
-
-	if raw.StatementTimeout != "" {
-		d, err := time.ParseDuration(raw.StatementTimeout)
-		if err != nil {
-			return nil, fmt.Errorf("parsing statement_timeout %q: %w", raw.StatementTimeout, err)
-		}
-
-		cfg.StatementTimeout = d
+	if len(raw.MigrationsDir) > 0 {
+		cfg.MigrationsDirs = raw.MigrationsDir
 	}
 
 	if raw.TargetPGVersion != 0 {
@@ -106,28 +152,151 @@ func fromYAML(raw *yamlConfig) (*Config, error) {
 		cfg.Format = raw.Format
 	}
 
+	if raw.TrackingSchema != "" {
+		cfg.TrackingSchema = raw.TrackingSchema
+	}
+
+	if raw.TrackingTable != "" {
+		cfg.TrackingTable = raw.TrackingTable
+	}
+
+	if raw.LockKey != 0 {
+		cfg.LockKey = raw.LockKey
+	}
+
+	cfg.Recursive = raw.Recursive
+	cfg.AllowOutOfOrder = raw.AllowOutOfOrder
+	cfg.AllowMixedVersions = raw.AllowMixedVersions
+
+	cfg.Vars = raw.Vars
+
+	if err := parseDurations(raw, cfg); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
+// durationField pairs a duration setting's name and raw value with the
+// Config field it populates.
+type durationField struct {
+	name  string
+	value string
+	dst   *time.Duration
+}
+
+// parseDurations parses the YAML duration strings into cfg. Empty values
+// keep the defaults.
+func parseDurations(raw *yamlConfig, cfg *Config) error {
+	fields := []durationField{
+		{"lock_timeout", raw.LockTimeout, &cfg.LockTimeout},
+		{"statement_timeout", raw.StatementTimeout, &cfg.StatementTimeout},
+		{"lock_wait", raw.LockWait, &cfg.LockWait},
+		{"grace_period", raw.GracePeriod, &cfg.GracePeriod},
+	}
+
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(f.value)
+		if err != nil {
+			return fmt.Errorf("parsing %s %q: %w", f.name, f.value, err)
+		}
+
+		*f.dst = d
+	}
+
+	return nil
+}
+
 // MergeEnv overrides config fields from MIGRATE_* environment variables.
 func MergeEnv(cfg *Config) {
 	if v := os.Getenv("MIGRATE_DATABASE_URL"); v != "" {
 		cfg.DatabaseURL = v
 	}
 
+	if v := os.Getenv("MIGRATE_SCRATCH_DATABASE_URL"); v != "" {
+		cfg.ScratchDatabaseURL = v
+	}
+
 	if v := os.Getenv("MIGRATE_MIGRATIONS_DIR"); v != "" {
-		cfg.MigrationsDir = v
+		cfg.MigrationsDirs = SplitList(v)
+	}
+
+	if v := os.Getenv("MIGRATE_TRACKING_SCHEMA"); v != "" {
+		cfg.TrackingSchema = v
 	}
 
-	if v := os.Getenv("MIGRATE_LOCK_TIMEOUT"); v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
-			cfg.LockTimeout = d
+	if v := os.Getenv("MIGRATE_TRACKING_TABLE"); v != "" {
+		cfg.TrackingTable = v
+	}
+
+	if v := os.Getenv("MIGRATE_LOCK_KEY"); v != "" {
+		if k, err := strconv.ParseInt(v, 10, 64); err == nil {
+			cfg.LockKey = k
 		}
 	}
 
-	if v := os.Getenv("MIGRATE_STATEMENT_TIMEOUT"); v != "" {
-		if d, err := time.ParseDuration(v); err == nil {
-			cfg.StatementTimeout = d
+	mergeEnvBool("MIGRATE_RECURSIVE", &cfg.Recursive)
+	mergeEnvBool("MIGRATE_ALLOW_OUT_OF_ORDER", &cfg.AllowOutOfOrder)
+	mergeEnvBool("MIGRATE_ALLOW_MIXED_VERSIONS", &cfg.AllowMixedVersions)
+
+	mergeEnvDurations(cfg)
+	mergeEnvVars(cfg)
+}
+
+// varEnvPrefix prefixes environment variables holding placeholder values:
+// MIGRATE_VAR_app_role sets ${app_role}.
+const varEnvPrefix = "MIGRATE_VAR_"
+
+// SetVar sets the value of the ${name} placeholder.
+func (c *Config) SetVar(name, value string) {
+	if c.Vars == nil {
+		c.Vars = make(map[string]string)
+	}
+
+	c.Vars[name] = value
+}
+
+// mergeEnvVars sets placeholder values from MIGRATE_VAR_<name> variables.
+func mergeEnvVars(cfg *Config) {
+	for _, kv := range os.Environ() {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, varEnvPrefix) || key == varEnvPrefix {
+			continue
+		}
+
+		cfg.SetVar(strings.TrimPrefix(key, varEnvPrefix), value)
+	}
+}
+
+// mergeEnvBool overrides dst from the environment variable key. Values that
+// fail to parse are ignored.
+func mergeEnvBool(key string, dst *bool) {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			*dst = b
+		}
+	}
+}
+
+// mergeEnvDurations overrides duration fields from the environment.
+// Values that fail to parse are ignored.
+func mergeEnvDurations(cfg *Config) {
+	vars := map[string]*time.Duration{
+		"MIGRATE_LOCK_TIMEOUT":      &cfg.LockTimeout,
+		"MIGRATE_STATEMENT_TIMEOUT": &cfg.StatementTimeout,
+		"MIGRATE_LOCK_WAIT":         &cfg.LockWait,
+		"MIGRATE_GRACE_PERIOD":      &cfg.GracePeriod,
+	}
+
+	for key, dst := range vars {
+		if v := os.Getenv(key); v != "" {
+			if d, err := time.ParseDuration(v); err == nil {
+				*dst = d
+			}
 		}
 	}
 }
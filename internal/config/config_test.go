@@ -18,11 +18,14 @@ func TestNew_returnsDefaults(t *testing.T) {
 	cfg := config.New()
 
 	assert.Empty(t, cfg.DatabaseURL)
-	assert.Equal(t, config.DefaultMigrationsDir, cfg.MigrationsDir)
+	assert.Equal(t, []string{config.DefaultMigrationsDir}, cfg.MigrationsDirs)
 	assert.Equal(t, config.DefaultLockTimeout, cfg.LockTimeout)
 	assert.Equal(t, config.DefaultStatementTimeout, cfg.StatementTimeout)
 	assert.Equal(t, config.DefaultTargetPGVersion, cfg.TargetPGVersion)
 	assert.Equal(t, config.DefaultFormat, cfg.Format)
+	assert.Empty(t, cfg.TrackingSchema)
+	assert.Equal(t, config.DefaultTrackingTable, cfg.TrackingTable)
+	assert.Equal(t, config.DefaultGracePeriod, cfg.GracePeriod)
 }
 
 func TestLoad(t *testing.T) {
@@ -37,6 +40,32 @@ func TestLoad(t *testing.T) {
 		errContains  string
 		check        func(t *testing.T, cfg *config.Config)
 	}{
+		{
+			name:      "migrations dir list",
+			writeFile: true,
+			content:   "migrations_dir:\n  - ./core\n  - ./modules/billing\nrecursive: true\n",
+			check: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.Equal(t, []string{"./core", "./modules/billing"}, cfg.MigrationsDirs)
+				assert.True(t, cfg.Recursive)
+			},
+		},
+		{
+			name:      "placeholder vars",
+			writeFile: true,
+			content:   "vars:\n  app_role: app_rw\n  tablespace: fast_ssd\n",
+			check: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.Equal(t, map[string]string{"app_role": "app_rw", "tablespace": "fast_ssd"}, cfg.Vars)
+			},
+		},
+		{
+			name:        "migrations dir of wrong type",
+			writeFile:   true,
+			content:     "migrations_dir:\n  a: b\n",
+			wantErr:     true,
+			errContains: "parsing config file",
+		},
 		{
 			name:      "valid file parses all fields",
 			writeFile: true,
@@ -46,15 +75,29 @@ lock_timeout: "10s"
 statement_timeout: "1m"
 target_pg_version: 15
 format: "json"
+tracking_schema: "migrate"
+tracking_table: "billing_migrations"
+lock_key: 987654321
+lock_wait: "45s"
+grace_period: "20s"
+allow_out_of_order: true
+allow_mixed_versions: true
 `,
 			check: func(t *testing.T, cfg *config.Config) {
 				t.Helper()
 				assert.Equal(t, "postgres://localhost:5432/testdb", cfg.DatabaseURL)
-				assert.Equal(t, "./db/migrations", cfg.MigrationsDir)
+				assert.Equal(t, []string{"./db/migrations"}, cfg.MigrationsDirs)
 				assert.Equal(t, 10*time.Second, cfg.LockTimeout)
 				assert.Equal(t, time.Minute, cfg.StatementTimeout)
 				assert.Equal(t, 15, cfg.TargetPGVersion)
 				assert.Equal(t, "json", cfg.Format)
+				assert.Equal(t, "migrate", cfg.TrackingSchema)
+				assert.Equal(t, "billing_migrations", cfg.TrackingTable)
+				assert.Equal(t, int64(987654321), cfg.LockKey)
+				assert.Equal(t, 45*time.Second, cfg.LockWait)
+				assert.Equal(t, 20*time.Second, cfg.GracePeriod)
+				assert.True(t, cfg.AllowOutOfOrder)
+				assert.True(t, cfg.AllowMixedVersions)
 			},
 		},
 		{
@@ -64,7 +107,7 @@ format: "json"
 			check: func(t *testing.T, cfg *config.Config) {
 				t.Helper()
 				assert.Equal(t, "postgres://localhost/mydb", cfg.DatabaseURL)
-				assert.Equal(t, config.DefaultMigrationsDir, cfg.MigrationsDir)
+				assert.Equal(t, []string{config.DefaultMigrationsDir}, cfg.MigrationsDirs)
 				assert.Equal(t, config.DefaultLockTimeout, cfg.LockTimeout)
 				assert.Equal(t, config.DefaultStatementTimeout, cfg.StatementTimeout)
 				assert.Equal(t, config.DefaultTargetPGVersion, cfg.TargetPGVersion)
@@ -77,7 +120,7 @@ format: "json"
 			content:   "",
 			check: func(t *testing.T, cfg *config.Config) {
 				t.Helper()
-				assert.Equal(t, config.DefaultMigrationsDir, cfg.MigrationsDir)
+				assert.Equal(t, []string{config.DefaultMigrationsDir}, cfg.MigrationsDirs)
 				assert.Equal(t, config.DefaultLockTimeout, cfg.LockTimeout)
 			},
 		},
@@ -87,7 +130,7 @@ format: "json"
 			allowMissing: true,
 			check: func(t *testing.T, cfg *config.Config) {
 				t.Helper()
-				assert.Equal(t, config.DefaultMigrationsDir, cfg.MigrationsDir)
+				assert.Equal(t, []string{config.DefaultMigrationsDir}, cfg.MigrationsDirs)
 				assert.Equal(t, config.DefaultLockTimeout, cfg.LockTimeout)
 			},
 		},
@@ -112,6 +155,20 @@ format: "json"
 			wantErr:     true,
 			errContains: "parsing lock_timeout",
 		},
+		{
+			name:        "invalid lock_wait duration returns error",
+			writeFile:   true,
+			content:     `lock_wait: "forever"`,
+			wantErr:     true,
+			errContains: "parsing lock_wait",
+		},
+		{
+			name:        "invalid grace_period duration returns error",
+			writeFile:   true,
+			content:     `grace_period: "soon"`,
+			wantErr:     true,
+			errContains: "parsing grace_period",
+		},
 		{
 			name:        "invalid statement_timeout duration returns error",
 			writeFile:   true,
@@ -165,12 +222,37 @@ func TestMergeEnv_overridesFields(t *testing.T) {
 				assert.Equal(t, "postgres://env-host/db", cfg.DatabaseURL)
 			},
 		},
+		{
+			name: "overrides scratch database URL",
+			env:  map[string]string{"MIGRATE_SCRATCH_DATABASE_URL": "postgres://env-host/scratch"},
+			check: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.Equal(t, "postgres://env-host/scratch", cfg.ScratchDatabaseURL)
+			},
+		},
 		{
 			name: "overrides migrations dir",
 			env:  map[string]string{"MIGRATE_MIGRATIONS_DIR": "/custom/path"},
 			check: func(t *testing.T, cfg *config.Config) {
 				t.Helper()
-				assert.Equal(t, "/custom/path", cfg.MigrationsDir)
+				assert.Equal(t, []string{"/custom/path"}, cfg.MigrationsDirs)
+			},
+		},
+		{
+			name: "splits comma-separated migrations dirs and sets recursive",
+			env:  map[string]string{"MIGRATE_MIGRATIONS_DIR": "./core, ./billing,", "MIGRATE_RECURSIVE": "true"},
+			check: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.Equal(t, []string{"./core", "./billing"}, cfg.MigrationsDirs)
+				assert.True(t, cfg.Recursive)
+			},
+		},
+		{
+			name: "sets placeholder vars",
+			env:  map[string]string{"MIGRATE_VAR_app_role": "app_rw", "MIGRATE_VAR_": "ignored"},
+			check: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.Equal(t, map[string]string{"app_role": "app_rw"}, cfg.Vars)
 			},
 		},
 		{
@@ -189,6 +271,58 @@ func TestMergeEnv_overridesFields(t *testing.T) {
 				assert.Equal(t, 2*time.Minute, cfg.StatementTimeout)
 			},
 		},
+		{
+			name: "overrides tracking schema and table",
+			env: map[string]string{
+				"MIGRATE_TRACKING_SCHEMA": "migrate",
+				"MIGRATE_TRACKING_TABLE":  "orders_migrations",
+			},
+			check: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.Equal(t, "migrate", cfg.TrackingSchema)
+				assert.Equal(t, "orders_migrations", cfg.TrackingTable)
+			},
+		},
+		{
+			name: "overrides lock key and lock wait",
+			env: map[string]string{
+				"MIGRATE_LOCK_KEY":  "-17",
+				"MIGRATE_LOCK_WAIT": "1m",
+			},
+			check: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.Equal(t, int64(-17), cfg.LockKey)
+				assert.Equal(t, time.Minute, cfg.LockWait)
+			},
+		},
+		{
+			name: "overrides allow out of order and mixed versions",
+			env: map[string]string{
+				"MIGRATE_ALLOW_OUT_OF_ORDER":   "true",
+				"MIGRATE_ALLOW_MIXED_VERSIONS": "1",
+			},
+			check: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.True(t, cfg.AllowOutOfOrder)
+				assert.True(t, cfg.AllowMixedVersions)
+			},
+		},
+		{
+			name: "overrides grace period",
+			env:  map[string]string{"MIGRATE_GRACE_PERIOD": "45s"},
+			check: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.Equal(t, 45*time.Second, cfg.GracePeriod)
+			},
+		},
+		{
+			name: "invalid lock key preserves original",
+			env:  map[string]string{"MIGRATE_LOCK_KEY": "abc"},
+			check: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.Zero(t, cfg.LockKey)
+			},
+		},
 		{
 			name: "invalid duration preserves original",
 			env:  map[string]string{"MIGRATE_LOCK_TIMEOUT": "not-valid"},
@@ -202,7 +336,7 @@ func TestMergeEnv_overridesFields(t *testing.T) {
 			env:  map[string]string{},
 			check: func(t *testing.T, cfg *config.Config) {
 				t.Helper()
-				assert.Equal(t, config.DefaultMigrationsDir, cfg.MigrationsDir)
+				assert.Equal(t, []string{config.DefaultMigrationsDir}, cfg.MigrationsDirs)
 				assert.Equal(t, config.DefaultLockTimeout, cfg.LockTimeout)
 			},
 		},
@@ -2,48 +2,329 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// MigrationLockID is the advisory lock identifier used to prevent
-// concurrent migration runs.
+// MigrationLockID is the default advisory lock identifier used to prevent
+// concurrent migration runs when no key is configured.
 const MigrationLockID int64 = 123456789
 
+// defaultLockPollInterval is how often AcquireLock retries while waiting.
+const defaultLockPollInterval = time.Second
+
+// LockOptions configures AcquireLock.
+type LockOptions struct {
+	// Key is the advisory lock key. Zero uses MigrationLockID.
+	Key int64
+	// Wait is how long to keep retrying while another session holds the
+	// lock. Zero fails immediately with ErrLockNotAcquired.
+	Wait time.Duration
+	// PollInterval is the delay between attempts while waiting. Zero uses
+	// one second.
+	PollInterval time.Duration
+	// OnWait, if set, is called whenever the lock is found held by a
+	// different session than on the previous attempt.
+	OnWait func(LockHolder)
+}
+
+// LockHolder describes the session currently holding an advisory lock,
+// as reported by pg_locks and pg_stat_activity.
+type LockHolder struct {
+	PID             int32
+	ApplicationName string
+	User            string
+	ClientAddr      string
+	BackendStart    time.Time
+}
+
+// String returns a human-readable description of the lock holder.
+func (h LockHolder) String() string {
+	s := fmt.Sprintf("pid %d", h.PID)
+
+	if h.ApplicationName != "" {
+		s += fmt.Sprintf(" (application %q)", h.ApplicationName)
+	}
+
+	if h.User != "" {
+		s += " user " + h.User
+	}
+
+	if h.ClientAddr != "" {
+		s += " from " + h.ClientAddr
+	}
+
+	return s
+}
+
 // LockHandle wraps a dedicated pooled connection that holds a
 // session-level advisory lock. Call Release to unlock and return
 // the connection to the pool.
 type LockHandle struct {
 	conn *pgxpool.Conn
+	key  int64
+}
+
+// DeriveLockKey returns an advisory lock key derived from the database name
+// and tracking table, so independent applications sharing a cluster (or a
+// database, with separate tracking tables) do not block each other.
+func DeriveLockKey(databaseName, trackingSchema, trackingTable string) int64 {
+	h := fnv.New64a()
+
+	for _, part := range []string{databaseName, trackingSchema, trackingTable} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return int64(h.Sum64()) //nolint:gosec // wrap-around is intended; any 64-bit value is a valid key
 }
 
-// TryAcquireLock attempts to acquire a session-level advisory lock.
+// TryAcquireLock attempts to acquire the default session-level advisory lock.
 // Returns a LockHandle if successful, or ErrLockNotAcquired if the
 // lock is already held by another process. The caller must call
 // handle.Release() when done.
 func TryAcquireLock(ctx context.Context, pool *pgxpool.Pool) (*LockHandle, error) {
+	return AcquireLock(ctx, pool, LockOptions{})
+}
+
+// AcquireLock acquires a session-level advisory lock on a dedicated pooled
+// connection. If the lock is held elsewhere and opts.Wait is positive, it
+// retries until the wait expires, reporting the current holder via
+// opts.OnWait. Returns ErrLockNotAcquired if the lock cannot be obtained.
+// The caller must call handle.Release() when done.
+func AcquireLock(ctx context.Context, pool *pgxpool.Pool, opts LockOptions) (*LockHandle, error) {
+	if opts.Key == 0 {
+		opts.Key = MigrationLockID
+	}
+
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultLockPollInterval
+	}
+
 	conn, err := pool.Acquire(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("acquiring connection for advisory lock: %w", err)
 	}
 
+	deadline := time.Now().Add(opts.Wait)
+
+	var lastHolder *LockHolder
+
+	for {
+		acquired, err := tryLock(ctx, conn, opts.Key)
+		if err != nil {
+			conn.Release()
+
+			return nil, err
+		}
+
+		if acquired {
+			return &LockHandle{conn: conn, key: opts.Key}, nil
+		}
+
+		holder, holderErr := FindLockHolder(ctx, conn, opts.Key)
+		if holderErr != nil {
+			conn.Release()
+
+			return nil, holderErr
+		}
+
+		if !time.Now().Before(deadline) {
+			conn.Release()
+
+			return nil, lockNotAcquiredError(holder, opts.Wait)
+		}
+
+		if holder != nil && opts.OnWait != nil && (lastHolder == nil || lastHolder.PID != holder.PID) {
+			opts.OnWait(*holder)
+		}
+
+		lastHolder = holder
+
+		if err := sleepCtx(ctx, min(opts.PollInterval, time.Until(deadline))); err != nil {
+			conn.Release()
+
+			return nil, fmt.Errorf("waiting for advisory lock: %w", err)
+		}
+	}
+}
+
+// tryLock executes pg_try_advisory_lock on the given connection.
+func tryLock(ctx context.Context, conn *pgxpool.Conn, key int64) (bool, error) {
 	var acquired bool
 
-	err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", MigrationLockID).Scan(&acquired)
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		return false, fmt.Errorf("executing pg_try_advisory_lock: %w", err)
+	}
+
+	return acquired, nil
+}
+
+// FindLockHolder returns the session holding the advisory lock for key, or
+// nil if the lock is free. A bigint advisory key appears in pg_locks split
+// into classid (high 32 bits) and objid (low 32 bits) with objsubid = 1.
+func FindLockHolder(ctx context.Context, q Querier, key int64) (*LockHolder, error) {
+	var h LockHolder
+
+	err := q.QueryRow(ctx,
+		`SELECT l.pid,
+		        COALESCE(a.application_name, ''),
+		        COALESCE(a.usename::text, ''),
+		        COALESCE(host(a.client_addr), ''),
+		        COALESCE(a.backend_start, 'epoch'::timestamptz)
+		 FROM pg_locks l
+		 LEFT JOIN pg_stat_activity a ON a.pid = l.pid
+		 WHERE l.locktype = 'advisory'
+		   AND l.granted
+		   AND l.objsubid = 1
+		   AND l.classid = (($1::bigint >> 32) & 4294967295)::oid
+		   AND l.objid = ($1::bigint & 4294967295)::oid
+		 LIMIT 1`,
+		key,
+	).Scan(&h.PID, &h.ApplicationName, &h.User, &h.ClientAddr, &h.BackendStart)
 	if err != nil {
-		conn.Release()
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil //nolint:nilnil // nil,nil signals "lock is free"
+		}
+
+		return nil, fmt.Errorf("querying advisory lock holder: %w", err)
+	}
 
-		return nil, fmt.Errorf("executing pg_try_advisory_lock: %w", err)
+	return &h, nil
+}
+
+// Querier is the subset of pgx connection and pool methods used by FindLockHolder.
+type Querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// lockNotAcquiredError builds an ErrLockNotAcquired error that names the
+// current holder and how long the caller waited.
+func lockNotAcquiredError(holder *LockHolder, waited time.Duration) error {
+	msg := ""
+	if holder != nil {
+		msg = "held by " + holder.String()
+	}
+
+	if waited > 0 {
+		if msg != "" {
+			msg += ", "
+		}
+
+		msg += "gave up after " + waited.String()
+	}
+
+	if msg == "" {
+		return ErrLockNotAcquired
+	}
+
+	return fmt.Errorf("%w: %s", ErrLockNotAcquired, msg)
+}
+
+// sleepCtx sleeps for d or until ctx is cancelled.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
+}
+
+// Verify checks that the lock connection is alive and still holds the
+// advisory lock. Returns an error wrapping ErrLockLost otherwise.
+func (h *LockHandle) Verify(ctx context.Context) error {
+	if h == nil || h.conn == nil {
+		return fmt.Errorf("%w: lock handle released", ErrLockLost)
+	}
+
+	var held bool
+
+	err := h.conn.QueryRow(ctx,
+		`SELECT EXISTS(
+		     SELECT 1 FROM pg_locks
+		     WHERE locktype = 'advisory'
+		       AND pid = pg_backend_pid()
+		       AND granted
+		       AND objsubid = 1
+		       AND classid = (($1::bigint >> 32) & 4294967295)::oid
+		       AND objid = ($1::bigint & 4294967295)::oid)`,
+		h.key,
+	).Scan(&held)
+	if err != nil {
+		return fmt.Errorf("%w: lock connection check failed: %w", ErrLockLost, err)
+	}
+
+	if !held {
+		return fmt.Errorf("%w: advisory lock no longer held by session", ErrLockLost)
+	}
+
+	return nil
+}
+
+// Keep starts a background check that calls Verify every interval. The
+// returned context is cancelled with an ErrLockLost cause (see
+// context.Cause) as soon as a check fails or does not finish within
+// interval, so work running under it stops before another process can take
+// over. The returned stop function ends the check and must be called before
+// Release.
+func (h *LockHandle) Keep(ctx context.Context, interval time.Duration) (context.Context, func()) {
+	keepCtx, cancel := context.WithCancelCause(ctx)
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-keepCtx.Done():
+				return
+			case <-ticker.C:
+				// Bound each check by the interval so a half-open lock
+				// connection is reported as lost instead of blocking forever.
+				checkCtx, checkCancel := context.WithTimeout(keepCtx, interval)
+				err := h.Verify(checkCtx)
+
+				checkCancel()
+
+				if err != nil {
+					if keepCtx.Err() == nil {
+						cancel(err)
+					}
+
+					return
+				}
+			}
+		}
+	}()
 
-	if !acquired {
-		conn.Release()
+	var once sync.Once
 
-		return nil, ErrLockNotAcquired
+	stop := func() {
+		once.Do(func() {
+			close(stopCh)
+			// Cancel first so an in-flight Verify returns promptly.
+			cancel(nil)
+			<-done
+		})
 	}
 
-	return &LockHandle{conn: conn}, nil
+	return keepCtx, stop
 }
 
 // Release unlocks the advisory lock and returns the connection to the pool.
@@ -53,7 +334,7 @@ func (h *LockHandle) Release(ctx context.Context) error {
 		return nil
 	}
 
-	_, err := h.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", MigrationLockID)
+	_, err := h.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", h.key)
 	h.conn.Release()
 	h.conn = nil
 
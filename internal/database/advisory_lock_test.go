@@ -0,0 +1,65 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aqasim81/database-migration-engine/internal/database"
+)
+
+func TestDeriveLockKey_deterministic(t *testing.T) {
+	t.Parallel()
+
+	a := database.DeriveLockKey("orders", "", "schema_migrations")
+	b := database.DeriveLockKey("orders", "", "schema_migrations")
+
+	assert.Equal(t, a, b)
+	assert.NotZero(t, a)
+}
+
+func TestDeriveLockKey_differsByInput(t *testing.T) {
+	t.Parallel()
+
+	base := database.DeriveLockKey("orders", "", "schema_migrations")
+
+	assert.NotEqual(t, base, database.DeriveLockKey("billing", "", "schema_migrations"))
+	assert.NotEqual(t, base, database.DeriveLockKey("orders", "migrate", "schema_migrations"))
+	assert.NotEqual(t, base, database.DeriveLockKey("orders", "", "orders_migrations"))
+
+	// Part boundaries are significant.
+	assert.NotEqual(t,
+		database.DeriveLockKey("ab", "", "c"),
+		database.DeriveLockKey("a", "", "bc"),
+	)
+}
+
+func TestLockHolder_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		holder   database.LockHolder
+		expected string
+	}{
+		{
+			name:     "pid only",
+			holder:   database.LockHolder{PID: 42},
+			expected: "pid 42",
+		},
+		{
+			name: "all fields",
+			holder: database.LockHolder{
+				PID: 42, ApplicationName: "migrate", User: "deploy", ClientAddr: "10.0.0.5",
+			},
+			expected: `pid 42 (application "migrate") user deploy from 10.0.0.5`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, tt.holder.String())
+		})
+	}
+}
@@ -10,3 +10,7 @@ var ErrConnectionFailed = errors.New("database connection failed")
 
 // ErrLockNotAcquired indicates the advisory lock is already held by another process.
 var ErrLockNotAcquired = errors.New("migration lock not acquired")
+
+// ErrLockLost indicates the advisory lock was lost while migrations were running,
+// for example because its database connection dropped.
+var ErrLockLost = errors.New("migration lock lost")
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// scratchPrefix starts the name of every scratch database.
+const scratchPrefix = "migrate_scratch_"
+
+// scratchSuffixBytes is the number of random bytes in a scratch database name.
+const scratchSuffixBytes = 6
+
+// Scratch is a temporary, empty database created to replay migrations in.
+type Scratch struct {
+	Pool  *pgxpool.Pool // connected to the scratch database
+	Name  string
+	admin *pgxpool.Pool
+}
+
+// NewScratch connects to serverURL and creates a database with a unique
+// name from template0 on the same server, so it holds nothing but the
+// system catalogs. The role needs the CREATEDB privilege. Drop removes the
+// database.
+func NewScratch(ctx context.Context, serverURL string) (*Scratch, error) {
+	admin, err := NewPool(ctx, serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, scratchSuffixBytes)
+	_, _ = rand.Read(suffix) // never returns an error
+
+	name := scratchPrefix + hex.EncodeToString(suffix)
+
+	if _, err := admin.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{name}.Sanitize()+" TEMPLATE template0"); err != nil {
+		admin.Close()
+
+		return nil, fmt.Errorf("creating scratch database: %w", err)
+	}
+
+	s := &Scratch{Name: name, admin: admin}
+
+	poolCfg, err := pgxpool.ParseConfig(serverURL)
+	if err != nil {
+		_ = s.Drop(ctx)
+
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDatabaseURL, err)
+	}
+
+	poolCfg.ConnConfig.Database = name
+	poolCfg.MaxConns = defaultMaxConns
+
+	if s.Pool, err = pgxpool.NewWithConfig(ctx, poolCfg); err != nil {
+		_ = s.Drop(ctx)
+
+		return nil, fmt.Errorf("%w: %w", ErrConnectionFailed, err)
+	}
+
+	return s, nil
+}
+
+// Drop closes the connections to the scratch database and drops it.
+func (s *Scratch) Drop(ctx context.Context) error {
+	if s.Pool != nil {
+		s.Pool.Close()
+	}
+
+	defer s.admin.Close()
+
+	if _, err := s.admin.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{s.Name}.Sanitize()); err != nil {
+		return fmt.Errorf("dropping scratch database %s: %w", s.Name, err)
+	}
+
+	return nil
+}
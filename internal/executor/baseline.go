@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// Baseline marks every migration up to and including target as applied
+// without running it, for databases whose schema predates the migrations.
+// Rows get status baseline, and rollback refuses to go past them.
+// Migrations already applied or baselined are left alone. Returns
+// migration.ErrVersionNotFound if no migration has the target version.
+func (e *Executor) Baseline(ctx context.Context, migrations []migration.Migration, target string) error {
+	selected, err := migration.UpTo(migration.Sort(migrations), target)
+	if err != nil {
+		return err
+	}
+
+	return e.withLock(ctx, func(ctx context.Context) error {
+		applied, err := e.baselineApplied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := range selected {
+			m := &selected[i]
+			if slices.ContainsFunc(applied, func(a tracker.AppliedMigration) bool {
+				return migration.CompareVersions(a.Version, m.Version) == 0
+			}) {
+				continue
+			}
+
+			if err := e.baselineOne(ctx, m); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// baselineApplied returns the migrations already recorded. In dry-run mode
+// the tracking table is only read, never created or upgraded.
+func (e *Executor) baselineApplied(ctx context.Context) ([]tracker.AppliedMigration, error) {
+	if e.dryRun {
+		exists, err := e.tracker.Exists(ctx)
+		if err != nil || !exists {
+			return nil, err
+		}
+	} else if err := e.tracker.EnsureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := e.tracker.GetApplied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting applied migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// baselineOne records m with status baseline and a history entry.
+func (e *Executor) baselineOne(ctx context.Context, m *migration.Migration) error {
+	if e.dryRun {
+		e.fireProgress(ProgressEvent{Migration: m, Status: StatusDryRun})
+		return nil
+	}
+
+	if err := e.tracker.RecordBaseline(ctx, tracker.RecordParams{
+		Version:      m.Version,
+		Filename:     filepath.Base(m.FilePath),
+		Checksum:     m.Checksum,
+		DownChecksum: downChecksum(m),
+	}); err != nil {
+		return fmt.Errorf("recording migration %s: %w", m.Version, err)
+	}
+
+	if err := e.recordHistory(ctx, m, tracker.DirectionBaseline, 0, nil); err != nil {
+		return err
+	}
+
+	e.fireProgress(ProgressEvent{Migration: m, Status: StatusBaselined})
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+// directiveSettings returns the statements applying d's timeouts and role,
+// using set: "SET LOCAL" inside a transaction, "SET" for the session.
+func directiveSettings(d migration.Directives, set string) []string {
+	var stmts []string
+
+	if d.LockTimeout != nil {
+		stmts = append(stmts, fmt.Sprintf("%s lock_timeout = '%dms'", set, d.LockTimeout.Milliseconds()))
+	}
+
+	if d.StatementTimeout != nil {
+		stmts = append(stmts, fmt.Sprintf("%s statement_timeout = '%dms'", set, d.StatementTimeout.Milliseconds()))
+	}
+
+	if d.Role != "" {
+		stmts = append(stmts, set+" ROLE "+pgx.Identifier{d.Role}.Sanitize())
+	}
+
+	return stmts
+}
+
+// resetSessionSQL undoes whatever directives and hooks set on a session.
+// RESET ALL leaves the role alone, so it is reset separately. DISCARD ALL
+// would also drop pgx's cached prepared statements.
+const resetSessionSQL = "RESET ALL; RESET ROLE"
+
+// setDirectives applies d's timeouts and role to tx with SET LOCAL. It runs
+// after the configured timeouts are set, so the directives take precedence.
+func setDirectives(ctx context.Context, tx pgx.Tx, d migration.Directives) error {
+	for _, stmt := range directiveSettings(d, "SET LOCAL") {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("applying directives: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// runWithoutTransaction executes sql on conn outside any transaction,
+// between the hooks. d's timeouts and role are set for the session, which
+// is reset before conn returns to the pool along with anything the hooks set.
+func runWithoutTransaction(
+	ctx context.Context,
+	conn *pgxpool.Conn,
+	sql string,
+	d migration.Directives,
+	hooks stepHooks,
+) error {
+	defer resetSession(ctx, conn)
+
+	if settings := directiveSettings(d, "SET"); len(settings) > 0 {
+		if _, err := conn.Exec(ctx, strings.Join(settings, "; ")); err != nil {
+			return fmt.Errorf("applying directives: %w", err)
+		}
+	}
+
+	if err := hooks.run(ctx, conn, migration.HookBeforeEach); err != nil {
+		return err
+	}
+
+	if err := ExecWithoutTransaction(ctx, conn, sql); err != nil {
+		return err
+	}
+
+	return hooks.run(ctx, conn, migration.HookAfterEach)
+}
+
+// resetSession resets the session settings and role of conn. If that fails,
+// the connection is closed so the pool does not hand it out with them applied.
+func resetSession(ctx context.Context, conn *pgxpool.Conn) {
+	resetCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockReleaseTimeout)
+	defer cancel()
+
+	if _, err := conn.Exec(resetCtx, resetSessionSQL); err != nil {
+		conn.Conn().Close(resetCtx) //nolint:errcheck // the pool discards closed connections
+	}
+}
@@ -13,3 +13,15 @@ var ErrNothingToRollback = errors.New("no applied migrations to roll back")
 
 // ErrTargetNotFound indicates the target version was not found among applied migrations.
 var ErrTargetNotFound = errors.New("target version not found in applied migrations")
+
+// ErrInterrupted indicates the run was cancelled, for example by SIGINT or
+// SIGTERM, before all migrations were processed.
+var ErrInterrupted = errors.New("migration run interrupted")
+
+// ErrOutOfOrder indicates pending migrations sort before the latest applied
+// migration.
+var ErrOutOfOrder = errors.New("out-of-order migrations")
+
+// ErrBaselineRollback indicates a rollback would reach a baselined
+// migration, whose changes this tool never applied.
+var ErrBaselineRollback = errors.New("cannot roll back a baselined migration")
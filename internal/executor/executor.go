@@ -2,8 +2,11 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -14,18 +17,25 @@ import (
 	"github.com/aqasim81/database-migration-engine/internal/tracker"
 )
 
-// Progress status constants reported via ProgressEvent.
+// Progress status constants reported via ProgressEvent. StatusSkipped marks
+// a migration left alone because it is already applied; StatusDryRun marks
+// one that would have run without WithDryRun.
 const (
 	StatusStarting    = "starting"
 	StatusCompleted   = "completed"
 	StatusFailed      = "failed"
 	StatusSkipped     = "skipped"
+	StatusDryRun      = "dry_run"
 	StatusRollingBack = "rolling_back"
+	StatusInterrupted = "interrupted"
+	StatusBaselined   = "baselined"
 )
 
-// ProgressEvent is emitted by the executor for each migration processed.
+// ProgressEvent is emitted by the executor for each migration processed,
+// and for the beforeAll and afterAll hooks.
 type ProgressEvent struct {
-	Migration *migration.Migration
+	Migration *migration.Migration // nil for hook events
+	Hook      migration.HookPoint  // set for beforeAll and afterAll hook events
 	Status    string
 	Duration  time.Duration
 	Error     error
@@ -34,11 +44,22 @@ type ProgressEvent struct {
 // MigrationTracker abstracts schema_migrations operations for testability.
 type MigrationTracker interface {
 	EnsureTable(ctx context.Context) error
+	Exists(ctx context.Context) (bool, error)
 	IsApplied(ctx context.Context, version string) (bool, error)
 	GetChecksum(ctx context.Context, version string) (string, error)
 	RecordApplied(ctx context.Context, p tracker.RecordParams) error
+	RecordBaseline(ctx context.Context, p tracker.RecordParams) error
 	GetApplied(ctx context.Context) ([]tracker.AppliedMigration, error)
 	RecordRolledBack(ctx context.Context, version string) error
+	RecordHistory(ctx context.Context, p tracker.HistoryParams) error
+	GetRepeatables(ctx context.Context) ([]tracker.AppliedRepeatable, error)
+	RecordRepeatable(ctx context.Context, p tracker.RepeatableParams) error
+}
+
+// tableNamer is implemented by trackers that report their tracking table,
+// from which the default advisory lock key is derived.
+type tableNamer interface {
+	Table() (schema, table string)
 }
 
 // lockReleaser is returned by lockFn and must be released when done.
@@ -46,23 +67,50 @@ type lockReleaser interface {
 	Release(ctx context.Context) error
 }
 
+// lockKeeper is implemented by locks that can be monitored while held.
+// Keep returns a context that is cancelled if the lock is lost, and a
+// function that stops monitoring.
+type lockKeeper interface {
+	Keep(ctx context.Context, interval time.Duration) (context.Context, func())
+}
+
+// defaultLockCheckInterval is how often the advisory lock is verified while
+// migrations run.
+const defaultLockCheckInterval = 5 * time.Second
+
 // lockFunc acquires an advisory lock and returns a releaser.
 type lockFunc func(ctx context.Context) (lockReleaser, error)
 
-// runSQLFunc executes SQL with a descriptive label for error wrapping.
-type runSQLFunc func(ctx context.Context, sql, label string) error
+// runSQLFunc executes SQL with a descriptive label for error wrapping,
+// honoring its header directives and running hooks before and after it.
+type runSQLFunc func(ctx context.Context, sql, label string, d migration.Directives, hooks stepHooks) error
+
+// runGoFunc runs a Go migration step with a descriptive label for error
+// wrapping, running hooks before and after it.
+type runGoFunc func(ctx context.Context, fn *migration.GoFunc, label string, hooks stepHooks) error
 
 // Executor applies pending migrations with transaction safety, timeouts,
 // and advisory locks to prevent concurrent runs.
 type Executor struct {
-	pool             *pgxpool.Pool
-	tracker          MigrationTracker
-	lockTimeout      time.Duration
-	statementTimeout time.Duration
-	dryRun           bool
-	onProgress       func(ProgressEvent)
-	acquireLock      lockFunc
-	execSQL          runSQLFunc
+	pool              *pgxpool.Pool
+	tracker           MigrationTracker
+	lockTimeout       time.Duration
+	statementTimeout  time.Duration
+	dryRun            bool
+	ticket            string
+	lockKey           int64
+	lockWait          time.Duration
+	onLockWait        func(database.LockHolder)
+	lockCheckInterval time.Duration
+	gracePeriod       time.Duration
+	allowOutOfOrder   bool
+	repeatables       []migration.Migration
+	hooks             []migration.Hook
+	onProgress        func(ProgressEvent)
+	acquireLock       lockFunc
+	execSQL           runSQLFunc
+	execGo            runGoFunc
+	execHooks         runHooksFunc
 }
 
 // Option configures an Executor.
@@ -83,6 +131,65 @@ func WithDryRun(b bool) Option {
 	return func(e *Executor) { e.dryRun = b }
 }
 
+// WithTicket sets the change-ticket reference recorded in the history table.
+func WithTicket(ticket string) Option {
+	return func(e *Executor) { e.ticket = ticket }
+}
+
+// WithLockKey sets the advisory lock key. Zero derives the key from the
+// database name and the tracker's table the way the CLI does (see
+// database.DeriveLockKey), falling back to database.MigrationLockID for
+// trackers that do not report their table.
+func WithLockKey(key int64) Option {
+	return func(e *Executor) { e.lockKey = key }
+}
+
+// WithLockWait sets how long to wait for the advisory lock when another
+// process holds it. Zero fails immediately.
+func WithLockWait(d time.Duration) Option {
+	return func(e *Executor) { e.lockWait = d }
+}
+
+// WithLockWaitCallback sets a function called while waiting for the
+// advisory lock, each time a different session is found holding it.
+func WithLockWaitCallback(fn func(database.LockHolder)) Option {
+	return func(e *Executor) { e.onLockWait = fn }
+}
+
+// WithLockCheckInterval sets how often the advisory lock is verified while
+// migrations run. Zero disables the check.
+func WithLockCheckInterval(d time.Duration) Option {
+	return func(e *Executor) { e.lockCheckInterval = d }
+}
+
+// WithGracePeriod sets how long an in-flight migration may keep running
+// after the context is cancelled before its queries are cancelled with
+// pg_cancel_backend. No further migrations start once the context is done.
+func WithGracePeriod(d time.Duration) Option {
+	return func(e *Executor) { e.gracePeriod = d }
+}
+
+// WithAllowOutOfOrder permits applying pending migrations that sort before
+// the latest applied migration, e.g. timestamped migrations merged late from
+// a parallel branch.
+func WithAllowOutOfOrder(allow bool) Option {
+	return func(e *Executor) { e.allowOutOfOrder = allow }
+}
+
+// WithRepeatables sets the repeatable migrations run after the versioned
+// ones (see migration.LoadRepeatables). Each runs when its checksum differs
+// from the one recorded by its last successful run.
+func WithRepeatables(rs []migration.Migration) Option {
+	return func(e *Executor) { e.repeatables = rs }
+}
+
+// WithHooks sets the hooks run at fixed points of every apply and rollback
+// (see migration.LoadHooks and migration.HookPoint for when each runs).
+// Hooks for the same point run in the given order.
+func WithHooks(hooks []migration.Hook) Option {
+	return func(e *Executor) { e.hooks = hooks }
+}
+
 // WithProgressCallback sets a function called for each migration processed.
 func WithProgressCallback(fn func(ProgressEvent)) Option {
 	return func(e *Executor) { e.onProgress = fn }
@@ -91,8 +198,10 @@ func WithProgressCallback(fn func(ProgressEvent)) Option {
 // New creates an Executor with the given pool, tracker, and options.
 func New(pool *pgxpool.Pool, t MigrationTracker, opts ...Option) *Executor {
 	e := &Executor{
-		pool:    pool,
-		tracker: t,
+		pool:              pool,
+		tracker:           t,
+		lockCheckInterval: defaultLockCheckInterval,
+		gracePeriod:       defaultGracePeriod,
 	}
 
 	for _, opt := range opts {
@@ -103,7 +212,11 @@ func New(pool *pgxpool.Pool, t MigrationTracker, opts ...Option) *Executor {
 	// so tests can override them via options.
 	if e.acquireLock == nil {
 		e.acquireLock = func(ctx context.Context) (lockReleaser, error) {
-			return database.TryAcquireLock(ctx, e.pool)
+			return database.AcquireLock(ctx, e.pool, database.LockOptions{
+				Key:    e.resolveLockKey(),
+				Wait:   e.lockWait,
+				OnWait: e.onLockWait,
+			})
 		}
 	}
 
@@ -111,30 +224,239 @@ func New(pool *pgxpool.Pool, t MigrationTracker, opts ...Option) *Executor {
 		e.execSQL = e.runSQL
 	}
 
+	if e.execGo == nil {
+		e.execGo = e.runGo
+	}
+
+	if e.execHooks == nil {
+		e.execHooks = e.runHooksInTx
+	}
+
 	return e
 }
 
-// Apply executes pending migrations in order. Already-applied migrations
-// are skipped after verifying their checksum. The advisory lock prevents
-// concurrent migration runs.
+// resolveLockKey returns the configured lock key, or one derived from the
+// database name and tracking table when none is set.
+func (e *Executor) resolveLockKey() int64 {
+	if e.lockKey != 0 {
+		return e.lockKey
+	}
+
+	namer, ok := e.tracker.(tableNamer)
+	if !ok || e.pool == nil {
+		return 0
+	}
+
+	schema, table := namer.Table()
+
+	return database.DeriveLockKey(e.pool.Config().ConnConfig.Database, schema, table)
+}
+
+// Apply executes pending migrations in order, followed by any repeatable
+// migrations whose checksum changed. Already-applied migrations are skipped
+// after verifying their checksum. The advisory lock prevents concurrent
+// migration runs. Pending migrations that sort before the latest applied one
+// are rejected with ErrOutOfOrder unless WithAllowOutOfOrder is set.
 func (e *Executor) Apply(ctx context.Context, migrations []migration.Migration) error {
-	lock, err := e.acquireLock(ctx)
-	if err != nil {
-		return fmt.Errorf("acquiring migration lock: %w", err)
+	return e.apply(ctx, migrations, migrations, 0, e.repeatables)
+}
+
+// ApplySteps applies at most `steps` pending migrations, in order.
+// Already-applied migrations do not count towards the limit. Repeatable
+// migrations run only if no versioned migration is left pending.
+func (e *Executor) ApplySteps(ctx context.Context, migrations []migration.Migration, steps int) error {
+	if steps <= 0 {
+		return nil
 	}
-	defer lock.Release(ctx) //nolint:errcheck // best-effort release on return
 
-	if err := e.tracker.EnsureTable(ctx); err != nil {
+	return e.apply(ctx, migrations, migrations, steps, e.repeatables)
+}
+
+// ApplyToVersion applies pending migrations up to and including the target
+// version. The target must be one of the given migrations; migrations that
+// sort after it are left pending, and so are repeatable migrations unless
+// the target is the last migration.
+func (e *Executor) ApplyToVersion(ctx context.Context, migrations []migration.Migration, target string) error {
+	subset, err := migration.UpTo(migrations, target)
+	if err != nil {
 		return err
 	}
 
+	return e.apply(ctx, migrations, subset, 0, e.repeatables)
+}
+
+// ApplyFS loads the migrations in dir within fsys, sorts them, and applies
+// the pending ones, followed by the repeatable migrations in dir (instead of
+// those set with WithRepeatables) whose checksum changed. Callback files in
+// dir run before the hooks set with WithHooks. It is the entry point for
+// services that embed their migrations and apply them on startup instead of
+// running the CLI:
+//
+//	//go:embed migrations/*.sql
+//	var migrationsFS embed.FS
+//
+//	exec := executor.New(pool, tracker.New(pool))
+//	err := exec.ApplyFS(ctx, migrationsFS, "migrations")
+//
+// Unless WithLockKey is set, it locks on the key the CLI derives for the
+// same database and tracking table, so the two never run concurrently.
+func (e *Executor) ApplyFS(ctx context.Context, fsys fs.FS, dir string, opts ...migration.LoadOption) error {
+	migrations, err := migration.LoadFromFS(fsys, dir, opts...)
+	if err != nil {
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+
+	repeatables, err := migration.LoadRepeatablesFromFS(fsys, dir, opts...)
+	if err != nil {
+		return fmt.Errorf("loading repeatable migrations: %w", err)
+	}
+
+	hooks, err := migration.LoadHooksFromFS(fsys, dir, opts...)
+	if err != nil {
+		return fmt.Errorf("loading callback files: %w", err)
+	}
+
+	run := *e
+	run.hooks = append(hooks, e.hooks...)
+
+	sorted := migration.Sort(migrations)
+
+	return run.apply(ctx, sorted, sorted, 0, repeatables)
+}
+
+// apply runs the pending migrations in `run` in order under the advisory
+// lock, stopping after `limit` pending migrations when limit is positive.
+// Ordering is checked against all known migrations, not just `run`. The
+// repeatable migrations run last, once every known migration has been
+// processed. The beforeAll and afterAll hooks run around all of them.
+func (e *Executor) apply(
+	ctx context.Context,
+	all, migrations []migration.Migration,
+	limit int,
+	repeatables []migration.Migration,
+) error {
+	return e.withLock(ctx, func(ctx context.Context) error {
+		if err := e.tracker.EnsureTable(ctx); err != nil {
+			return err
+		}
+
+		if err := e.checkOrder(ctx, all); err != nil {
+			return err
+		}
+
+		if err := e.runAllHooks(ctx, migration.HookBeforeAll, tracker.DirectionUp); err != nil {
+			return err
+		}
+
+		if err := e.applyPending(ctx, len(migrations) == len(all), migrations, limit, repeatables); err != nil {
+			return err
+		}
+
+		return e.runAllHooks(ctx, migration.HookAfterAll, tracker.DirectionUp)
+	})
+}
+
+// applyPending applies the pending migrations, at most limit of them when
+// limit is positive, followed by the repeatable migrations if migrations
+// were complete and none was left pending.
+func (e *Executor) applyPending(
+	ctx context.Context,
+	complete bool,
+	migrations []migration.Migration,
+	limit int,
+	repeatables []migration.Migration,
+) error {
+	pending := 0
+
 	for i := range migrations {
-		if err := e.applyOne(ctx, &migrations[i]); err != nil {
+		if limit > 0 && pending == limit {
+			return nil
+		}
+
+		ran, err := e.applyOne(ctx, &migrations[i])
+		if err != nil {
 			return err
 		}
+
+		if ran {
+			pending++
+		}
 	}
 
-	return nil
+	if !complete {
+		return nil
+	}
+
+	return e.applyRepeatables(ctx, repeatables)
+}
+
+// checkOrder returns ErrOutOfOrder if any pending migration sorts before the
+// latest applied one, unless out-of-order migrations are allowed.
+func (e *Executor) checkOrder(ctx context.Context, migrations []migration.Migration) error {
+	if e.allowOutOfOrder {
+		return nil
+	}
+
+	applied, err := e.tracker.GetApplied(ctx)
+	if err != nil {
+		return fmt.Errorf("getting applied migrations: %w", err)
+	}
+
+	outOfOrder, latest := migration.OutOfOrder(migrations, appliedVersions(applied))
+	if len(outOfOrder) == 0 {
+		return nil
+	}
+
+	versions := make([]string, len(outOfOrder))
+	for i := range outOfOrder {
+		versions[i] = outOfOrder[i].Version
+	}
+
+	return fmt.Errorf("%w: pending %s sorts before latest applied %s",
+		ErrOutOfOrder, strings.Join(versions, ", "), latest)
+}
+
+// withLock acquires the advisory lock, runs fn while the lock is monitored,
+// and releases the lock. If the lock is lost while fn runs, fn's context is
+// cancelled and the returned error wraps database.ErrLockLost. The lock is
+// released even if ctx has been cancelled.
+func (e *Executor) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	lock, err := e.acquireLock(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring migration lock: %w", err)
+	}
+
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockReleaseTimeout)
+		defer cancel()
+
+		lock.Release(releaseCtx) //nolint:errcheck // best-effort release on return
+	}()
+
+	runCtx := ctx
+
+	if keeper, ok := lock.(lockKeeper); ok && e.lockCheckInterval > 0 {
+		var stop func()
+
+		runCtx, stop = keeper.Keep(ctx, e.lockCheckInterval)
+		defer stop()
+	}
+
+	err = fn(runCtx)
+
+	if cause := context.Cause(runCtx); errors.Is(cause, database.ErrLockLost) {
+		if err == nil || errors.Is(err, cause) {
+			return cause
+		}
+
+		return fmt.Errorf("%w (%w)", cause, err)
+	}
+
+	if err != nil && ctx.Err() != nil && !errors.Is(err, ErrInterrupted) {
+		return fmt.Errorf("%w: %w", ErrInterrupted, err)
+	}
+
+	return err
 }
 
 // Rollback reverses the most recent `steps` applied migrations using their
@@ -177,33 +499,42 @@ func (e *Executor) RollbackToVersion(ctx context.Context, migrations []migration
 }
 
 // withRollbackLock handles the shared rollback preamble: advisory lock,
-// ensure table, get applied list, compute targets via selectFn, and execute.
+// ensure table, get applied list, compute targets via selectFn, and execute
+// them between the beforeAll and afterAll hooks.
 func (e *Executor) withRollbackLock(
 	ctx context.Context,
 	migrations []migration.Migration,
 	selectFn func([]tracker.AppliedMigration) ([]tracker.AppliedMigration, error),
 ) error {
-	lock, err := e.acquireLock(ctx)
-	if err != nil {
-		return fmt.Errorf("acquiring migration lock: %w", err)
-	}
-	defer lock.Release(ctx) //nolint:errcheck // best-effort release on return
+	return e.withLock(ctx, func(ctx context.Context) error {
+		if err := e.tracker.EnsureTable(ctx); err != nil {
+			return err
+		}
 
-	if err := e.tracker.EnsureTable(ctx); err != nil {
-		return err
-	}
+		applied, err := e.tracker.GetApplied(ctx)
+		if err != nil {
+			return fmt.Errorf("getting applied migrations: %w", err)
+		}
 
-	applied, err := e.tracker.GetApplied(ctx)
-	if err != nil {
-		return fmt.Errorf("getting applied migrations: %w", err)
-	}
+		targets, err := selectFn(applied)
+		if err != nil {
+			return err
+		}
 
-	targets, err := selectFn(applied)
-	if err != nil {
-		return err
-	}
+		if err := checkNoBaseline(targets); err != nil {
+			return err
+		}
+
+		if err := e.runAllHooks(ctx, migration.HookBeforeAll, tracker.DirectionDown); err != nil {
+			return err
+		}
 
-	return e.rollbackTargets(ctx, targets, migrations)
+		if err := e.rollbackTargets(ctx, targets, migrations); err != nil {
+			return err
+		}
+
+		return e.runAllHooks(ctx, migration.HookAfterAll, tracker.DirectionDown)
+	})
 }
 
 // rollbackTargets executes the down SQL for each target in order.
@@ -230,41 +561,48 @@ func (e *Executor) rollbackOne(
 	applied *tracker.AppliedMigration,
 	lookup map[string]*migration.Migration,
 ) error {
+	if ctx.Err() != nil {
+		return interruptedError(ctx)
+	}
+
 	m, ok := lookup[applied.Version]
 	if !ok {
 		return fmt.Errorf("migration %s: no migration file found for rollback", applied.Version)
 	}
 
-	if m.DownSQL == "" {
+	if !m.HasDown() {
 		return fmt.Errorf("migration %s (%s): %w", m.Version, m.Name, ErrNoDownSQL)
 	}
 
 	if e.dryRun {
-		e.fireProgress(ProgressEvent{Migration: m, Status: StatusSkipped})
+		e.fireProgress(ProgressEvent{Migration: m, Status: StatusDryRun})
 		return nil
 	}
 
 	e.fireProgress(ProgressEvent{Migration: m, Status: StatusRollingBack})
 
 	start := time.Now()
-	execErr := e.execSQL(ctx, m.DownSQL, "executing down SQL")
+	execErr := e.execDown(ctx, m)
 	duration := time.Since(start)
 
+	// The outcome must be recorded even if the run was interrupted meanwhile.
+	recordCtx := context.WithoutCancel(ctx)
+
 	if execErr != nil {
-		e.fireProgress(ProgressEvent{
-			Migration: m,
-			Status:    StatusFailed,
-			Duration:  duration,
-			Error:     execErr,
-		})
+		execErr = e.failed(ctx, m, duration, execErr)
 
-		return fmt.Errorf("rolling back migration %s: %w", m.Version, execErr)
+		return fmt.Errorf("rolling back migration %s: %w", m.Version,
+			e.recordFailure(recordCtx, m, tracker.DirectionDown, duration, execErr))
 	}
 
-	if err := e.tracker.RecordRolledBack(ctx, m.Version); err != nil {
+	if err := e.tracker.RecordRolledBack(recordCtx, m.Version); err != nil {
 		return fmt.Errorf("recording rollback for %s: %w", m.Version, err)
 	}
 
+	if err := e.recordHistory(recordCtx, m, tracker.DirectionDown, duration, nil); err != nil {
+		return err
+	}
+
 	e.fireProgress(ProgressEvent{
 		Migration: m,
 		Status:    StatusCompleted,
@@ -275,81 +613,142 @@ func (e *Executor) rollbackOne(
 }
 
 // runSQL executes a SQL string, choosing between transactional and
-// non-transactional execution based on whether it contains concurrent
-// operations (CREATE/DROP INDEX CONCURRENTLY).
-func (e *Executor) runSQL(ctx context.Context, sql, label string) error {
-	concurrent, err := containsConcurrentOp(sql)
+// non-transactional execution based on its no-transaction directive or
+// whether it contains concurrent operations (CREATE/DROP INDEX
+// CONCURRENTLY). The configured timeouts apply either way, overridden by
+// the directives' timeouts, along with the directives' role. The hooks run
+// in the same transaction, or on the same connection without one.
+//
+// The SQL keeps running if ctx is cancelled; once the grace period expires
+// the backend is cancelled with pg_cancel_backend.
+func (e *Executor) runSQL(ctx context.Context, sql, label string, d migration.Directives, hooks stepHooks) error {
+	concurrent := d.NoTransaction
+
+	if !concurrent {
+		var err error
+		if concurrent, err = containsConcurrentOp(sql); err != nil {
+			return err
+		}
+	}
+
+	conn, err := e.pool.Acquire(ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("acquiring connection: %w", err)
 	}
+	defer conn.Release()
+
+	ctx, stop := e.graceContext(ctx, conn.Conn().PgConn().PID())
+	defer stop()
 
 	if concurrent {
-		return ExecWithoutTransaction(ctx, e.pool, sql)
+		return runWithoutTransaction(ctx, conn, sql, e.withTimeouts(d), hooks)
 	}
 
-	return ExecInTransaction(ctx, e.pool, func(tx pgx.Tx) error {
-		if e.lockTimeout > 0 {
-			if err := SetLockTimeout(ctx, tx, e.lockTimeout); err != nil {
-				return err
-			}
+	return ExecInTransaction(ctx, conn, func(tx pgx.Tx) error {
+		if err := e.setTimeouts(ctx, tx); err != nil {
+			return err
 		}
 
-		if e.statementTimeout > 0 {
-			if err := SetStatementTimeout(ctx, tx, e.statementTimeout); err != nil {
-				return err
-			}
+		if err := setDirectives(ctx, tx, d); err != nil {
+			return err
+		}
+
+		if err := hooks.run(ctx, tx, migration.HookBeforeEach); err != nil {
+			return err
 		}
 
 		if _, err := tx.Exec(ctx, sql); err != nil {
 			return fmt.Errorf("%s: %w", label, err)
 		}
 
-		return nil
+		return hooks.run(ctx, tx, migration.HookAfterEach)
 	})
 }
 
+// setTimeouts applies the configured lock and statement timeouts to tx.
+func (e *Executor) setTimeouts(ctx context.Context, tx pgx.Tx) error {
+	if e.lockTimeout > 0 {
+		if err := SetLockTimeout(ctx, tx, e.lockTimeout); err != nil {
+			return err
+		}
+	}
+
+	if e.statementTimeout > 0 {
+		if err := SetStatementTimeout(ctx, tx, e.statementTimeout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withTimeouts returns d with the configured lock and statement timeouts
+// filled in where d sets none, for statements run outside a transaction.
+func (e *Executor) withTimeouts(d migration.Directives) migration.Directives {
+	if d.LockTimeout == nil && e.lockTimeout > 0 {
+		lockTimeout := e.lockTimeout
+		d.LockTimeout = &lockTimeout
+	}
+
+	if d.StatementTimeout == nil && e.statementTimeout > 0 {
+		stmtTimeout := e.statementTimeout
+		d.StatementTimeout = &stmtTimeout
+	}
+
+	return d
+}
+
 // applyOne handles a single migration: skip if applied, dry-run check,
-// execute, record, and fire progress.
-func (e *Executor) applyOne(ctx context.Context, m *migration.Migration) error {
+// execute, record, and fire progress. Reports whether the migration was
+// pending, i.e. applied (or would be, in dry-run mode).
+func (e *Executor) applyOne(ctx context.Context, m *migration.Migration) (bool, error) {
+	if ctx.Err() != nil {
+		return false, interruptedError(ctx)
+	}
+
 	skip, err := e.shouldSkip(ctx, m)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if skip {
 		e.fireProgress(ProgressEvent{Migration: m, Status: StatusSkipped})
-		return nil
+		return false, nil
 	}
 
 	if e.dryRun {
-		e.fireProgress(ProgressEvent{Migration: m, Status: StatusSkipped})
-		return nil
+		e.fireProgress(ProgressEvent{Migration: m, Status: StatusDryRun})
+		return true, nil
 	}
 
 	e.fireProgress(ProgressEvent{Migration: m, Status: StatusStarting})
 
 	start := time.Now()
-	execErr := e.execSQL(ctx, m.UpSQL, "executing SQL")
+	execErr := e.execUp(ctx, m)
 	duration := time.Since(start)
 
+	// The outcome must be recorded even if the run was interrupted meanwhile.
+	recordCtx := context.WithoutCancel(ctx)
+
 	if execErr != nil {
-		e.fireProgress(ProgressEvent{
-			Migration: m,
-			Status:    StatusFailed,
-			Duration:  duration,
-			Error:     execErr,
-		})
+		execErr = e.failed(ctx, m, duration, execErr)
 
-		return fmt.Errorf("applying migration %s: %w", m.Version, execErr)
+		return false, fmt.Errorf("applying migration %s: %w", m.Version,
+			e.recordFailure(recordCtx, m, tracker.DirectionUp, duration, execErr))
 	}
 
-	if err := e.tracker.RecordApplied(ctx, tracker.RecordParams{
-		Version:    m.Version,
-		Filename:   filepath.Base(m.FilePath),
-		Checksum:   m.Checksum,
-		DurationMs: int(duration.Milliseconds()),
+	if err := e.tracker.RecordApplied(recordCtx, tracker.RecordParams{
+		Version:      m.Version,
+		Filename:     filepath.Base(m.FilePath),
+		Checksum:     m.Checksum,
+		DownChecksum: downChecksum(m),
+		DurationMs:   int(duration.Milliseconds()),
 	}); err != nil {
-		return fmt.Errorf("recording migration %s: %w", m.Version, err)
+		return false, fmt.Errorf("recording migration %s: %w", m.Version, err)
+	}
+
+	if err := e.recordHistory(recordCtx, m, tracker.DirectionUp, duration, nil); err != nil {
+		return false, err
 	}
 
 	e.fireProgress(ProgressEvent{
@@ -358,7 +757,7 @@ func (e *Executor) applyOne(ctx context.Context, m *migration.Migration) error {
 		Duration:  duration,
 	})
 
-	return nil
+	return true, nil
 }
 
 // shouldSkip returns true if the migration is already applied.
@@ -388,6 +787,57 @@ func (e *Executor) shouldSkip(ctx context.Context, m *migration.Migration) (bool
 	return true, nil
 }
 
+// recordHistory appends an audit entry for a single apply or rollback attempt.
+// A nil execErr records a successful outcome.
+func (e *Executor) recordHistory(
+	ctx context.Context,
+	m *migration.Migration,
+	direction string,
+	duration time.Duration,
+	execErr error,
+) error {
+	p := tracker.HistoryParams{
+		Version:    m.Version,
+		Filename:   filepath.Base(m.FilePath),
+		Direction:  direction,
+		Checksum:   m.Checksum,
+		DurationMs: int(duration.Milliseconds()),
+		Outcome:    tracker.OutcomeSucceeded,
+		Ticket:     e.ticket,
+	}
+
+	if execErr != nil {
+		p.Outcome = tracker.OutcomeFailed
+		p.Error = execErr.Error()
+
+		if errors.Is(execErr, ErrInterrupted) {
+			p.Outcome = tracker.OutcomeInterrupted
+		}
+	}
+
+	if err := e.tracker.RecordHistory(ctx, p); err != nil {
+		return fmt.Errorf("recording history for %s: %w", m.Label(), err)
+	}
+
+	return nil
+}
+
+// recordFailure records a failed attempt in the history table and returns
+// execErr, joined with the history error if the audit entry could not be written.
+func (e *Executor) recordFailure(
+	ctx context.Context,
+	m *migration.Migration,
+	direction string,
+	duration time.Duration,
+	execErr error,
+) error {
+	if err := e.recordHistory(ctx, m, direction, duration, execErr); err != nil {
+		return errors.Join(execErr, err)
+	}
+
+	return execErr
+}
+
 func (e *Executor) fireProgress(event ProgressEvent) {
 	if e.onProgress != nil {
 		e.onProgress(event)
@@ -3,11 +3,17 @@ package executor
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/aqasim81/database-migration-engine/internal/database"
 	"github.com/aqasim81/database-migration-engine/internal/migration"
 	"github.com/aqasim81/database-migration-engine/internal/tracker"
 )
@@ -22,12 +28,38 @@ func (m *mockLock) Release(_ context.Context) error {
 	return nil
 }
 
+// keepingLock implements lockReleaser and lockKeeper. Keep returns a context
+// that is already cancelled with lostErr when lostErr is set.
+type keepingLock struct {
+	mockLock
+	lostErr  error
+	interval time.Duration
+	stopped  bool
+}
+
+func (k *keepingLock) Keep(ctx context.Context, interval time.Duration) (context.Context, func()) {
+	k.interval = interval
+	keepCtx, cancel := context.WithCancelCause(ctx)
+
+	if k.lostErr != nil {
+		cancel(k.lostErr)
+	}
+
+	return keepCtx, func() {
+		k.stopped = true
+		cancel(nil)
+	}
+}
+
 // mockTracker implements MigrationTracker for testing.
 type mockTracker struct {
 	ensureErr     error
+	ensured       bool
+	missing       bool
 	applied       map[string]bool
 	checksums     map[string]string
 	recorded      []tracker.RecordParams
+	baselined     []tracker.RecordParams
 	isAppliedErr  error
 	checksumErr   error
 	recordErr     error
@@ -35,6 +67,10 @@ type mockTracker struct {
 	getAppliedErr error
 	rolledBack    []string
 	rollbackErr   error
+	history       []tracker.HistoryParams
+	historyErr    error
+	repeatables   []tracker.RepeatableParams
+	repeatableErr error
 }
 
 func newMockTracker() *mockTracker {
@@ -45,9 +81,14 @@ func newMockTracker() *mockTracker {
 }
 
 func (m *mockTracker) EnsureTable(_ context.Context) error {
+	m.ensured = true
 	return m.ensureErr
 }
 
+func (m *mockTracker) Exists(_ context.Context) (bool, error) {
+	return !m.missing, nil
+}
+
 func (m *mockTracker) IsApplied(_ context.Context, version string) (bool, error) {
 	if m.isAppliedErr != nil {
 		return false, m.isAppliedErr
@@ -81,6 +122,18 @@ func (m *mockTracker) RecordApplied(_ context.Context, p tracker.RecordParams) e
 	return nil
 }
 
+func (m *mockTracker) RecordBaseline(_ context.Context, p tracker.RecordParams) error {
+	if m.recordErr != nil {
+		return m.recordErr
+	}
+
+	m.baselined = append(m.baselined, p)
+	m.applied[p.Version] = true
+	m.checksums[p.Version] = p.Checksum
+
+	return nil
+}
+
 func (m *mockTracker) GetApplied(_ context.Context) ([]tracker.AppliedMigration, error) {
 	if m.getAppliedErr != nil {
 		return nil, m.getAppliedErr
@@ -100,6 +153,39 @@ func (m *mockTracker) RecordRolledBack(_ context.Context, version string) error
 	return nil
 }
 
+func (m *mockTracker) RecordHistory(_ context.Context, p tracker.HistoryParams) error {
+	if m.historyErr != nil {
+		return m.historyErr
+	}
+
+	m.history = append(m.history, p)
+
+	return nil
+}
+
+func (m *mockTracker) GetRepeatables(_ context.Context) ([]tracker.AppliedRepeatable, error) {
+	if m.repeatableErr != nil {
+		return nil, m.repeatableErr
+	}
+
+	var applied []tracker.AppliedRepeatable
+	for _, p := range m.repeatables {
+		applied = append(applied, tracker.AppliedRepeatable{Name: p.Name, Filename: p.Filename, Checksum: p.Checksum})
+	}
+
+	return applied, nil
+}
+
+func (m *mockTracker) RecordRepeatable(_ context.Context, p tracker.RepeatableParams) error {
+	if m.repeatableErr != nil {
+		return m.repeatableErr
+	}
+
+	m.repeatables = append(m.repeatables, p)
+
+	return nil
+}
+
 func testMigration(version, sql string) migration.Migration {
 	return migration.Migration{
 		Version:  version,
@@ -114,7 +200,7 @@ func noopLockFn(_ context.Context) (lockReleaser, error) {
 	return &mockLock{}, nil
 }
 
-func noopExecFn(_ context.Context, _, _ string) error {
+func noopExecFn(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
 	return nil
 }
 
@@ -235,11 +321,11 @@ func TestApplyOne_dryRun_skipsExecution(t *testing.T) {
 
 	m := testMigration("001", "CREATE TABLE t (id INT);")
 
-	err := e.applyOne(context.Background(), &m)
+	_, err := e.applyOne(context.Background(), &m)
 
 	require.NoError(t, err)
 	require.Len(t, events, 1)
-	assert.Equal(t, StatusSkipped, events[0].Status)
+	assert.Equal(t, StatusDryRun, events[0].Status)
 	assert.Empty(t, mt.recorded)
 }
 
@@ -258,7 +344,7 @@ func TestApplyOne_alreadyApplied_skips(t *testing.T) {
 		execSQL:    noopExecFn,
 	}
 
-	err := e.applyOne(context.Background(), &m)
+	_, err := e.applyOne(context.Background(), &m)
 
 	require.NoError(t, err)
 	require.Len(t, events, 1)
@@ -279,7 +365,7 @@ func TestApplyOne_executes_records_andReportsProgress(t *testing.T) {
 
 	m := testMigration("001", "CREATE TABLE t (id INT);")
 
-	err := e.applyOne(context.Background(), &m)
+	_, err := e.applyOne(context.Background(), &m)
 
 	require.NoError(t, err)
 
@@ -304,12 +390,12 @@ func TestApplyOne_execError_reportsFailed(t *testing.T) {
 	e := &Executor{
 		tracker:    mt,
 		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
-		execSQL:    func(_ context.Context, _ string, _ string) error { return execErr },
+		execSQL:    func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error { return execErr },
 	}
 
 	m := testMigration("001", "CREATE TABLE t (id INT);")
 
-	err := e.applyOne(context.Background(), &m)
+	_, err := e.applyOne(context.Background(), &m)
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "applying migration 001")
@@ -320,6 +406,85 @@ func TestApplyOne_execError_reportsFailed(t *testing.T) {
 	assert.ErrorIs(t, events[1].Error, execErr)
 }
 
+func TestApplyOne_success_recordsHistory(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	e := &Executor{tracker: mt, execSQL: noopExecFn, ticket: "CHG-42"}
+
+	m := testMigration("001", "CREATE TABLE t (id INT);")
+
+	_, err := e.applyOne(context.Background(), &m)
+
+	require.NoError(t, err)
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, "001", mt.history[0].Version)
+	assert.Equal(t, "V001_test.up.sql", mt.history[0].Filename)
+	assert.Equal(t, tracker.DirectionUp, mt.history[0].Direction)
+	assert.Equal(t, tracker.OutcomeSucceeded, mt.history[0].Outcome)
+	assert.Equal(t, m.Checksum, mt.history[0].Checksum)
+	assert.Equal(t, "CHG-42", mt.history[0].Ticket)
+	assert.Empty(t, mt.history[0].Error)
+}
+
+func TestApplyOne_execError_recordsFailedHistory(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	e := &Executor{
+		tracker: mt,
+		execSQL: func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
+			return errors.New("syntax error")
+		},
+	}
+
+	m := testMigration("001", "CREATE TABLE t (id INT);")
+
+	_, err := e.applyOne(context.Background(), &m)
+
+	require.Error(t, err)
+	assert.Empty(t, mt.recorded)
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, tracker.OutcomeFailed, mt.history[0].Outcome)
+	assert.Equal(t, "syntax error", mt.history[0].Error)
+}
+
+func TestApplyOne_execAndHistoryError_joinsErrors(t *testing.T) {
+	t.Parallel()
+
+	execErr := errors.New("syntax error")
+	histErr := errors.New("history insert failed")
+
+	mt := newMockTracker()
+	mt.historyErr = histErr
+	e := &Executor{
+		tracker: mt,
+		execSQL: func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error { return execErr },
+	}
+
+	m := testMigration("001", "CREATE TABLE t (id INT);")
+
+	_, err := e.applyOne(context.Background(), &m)
+
+	require.ErrorIs(t, err, execErr)
+	require.ErrorIs(t, err, histErr)
+}
+
+func TestApplyOne_historyError_returnsError(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.historyErr = errors.New("history insert failed")
+	e := &Executor{tracker: mt, execSQL: noopExecFn}
+
+	m := testMigration("001", "CREATE TABLE t (id INT);")
+
+	_, err := e.applyOne(context.Background(), &m)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "recording history for 001")
+}
+
 func TestApplyOne_recordError_returnsError(t *testing.T) {
 	t.Parallel()
 
@@ -333,7 +498,7 @@ func TestApplyOne_recordError_returnsError(t *testing.T) {
 
 	m := testMigration("001", "CREATE TABLE t (id INT);")
 
-	err := e.applyOne(context.Background(), &m)
+	_, err := e.applyOne(context.Background(), &m)
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "recording migration 001")
@@ -370,6 +535,112 @@ func TestApply_fullFlow_appliesAll(t *testing.T) {
 	require.Len(t, events, 4)
 }
 
+func TestApplyFS_loadsSortsAndApplies(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, execSQL: noopExecFn}
+
+	fsys := fstest.MapFS{
+		"migrations/V002_b.up.sql": {Data: []byte("CREATE TABLE b (id INT);")},
+		"migrations/V001_a.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+	}
+
+	require.NoError(t, e.ApplyFS(context.Background(), fsys, "migrations"))
+	require.Len(t, mt.recorded, 2)
+	assert.Equal(t, "001", mt.recorded[0].Version)
+	assert.Equal(t, "V001_a.up.sql", mt.recorded[0].Filename)
+	assert.Equal(t, "002", mt.recorded[1].Version)
+}
+
+func TestApplyFS_loadError_returnsError(t *testing.T) {
+	t.Parallel()
+
+	e := &Executor{tracker: newMockTracker(), acquireLock: noopLockFn, execSQL: noopExecFn}
+
+	err := e.ApplyFS(context.Background(), fstest.MapFS{}, "missing")
+	require.ErrorContains(t, err, "loading migrations")
+}
+
+func TestResolveLockKey_derivesFromTrackerTable(t *testing.T) {
+	t.Parallel()
+
+	// pgxpool.New does not connect until the pool is used.
+	pool, err := pgxpool.New(context.Background(), "postgres://app@localhost:5432/orders")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	e := New(pool, tracker.New(pool, tracker.WithTable("ops", "versions")))
+	assert.Equal(t, database.DeriveLockKey("orders", "ops", "versions"), e.resolveLockKey())
+
+	e = New(pool, tracker.New(pool), WithLockKey(42))
+	assert.Equal(t, int64(42), e.resolveLockKey())
+
+	// Trackers that do not report their table fall back to MigrationLockID.
+	e = New(pool, newMockTracker())
+	assert.Equal(t, int64(0), e.resolveLockKey())
+}
+
+func TestApply_goMigration_runsFuncInSortOrder(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+
+	var ran []string
+
+	up := &migration.GoFunc{Tx: func(context.Context, pgx.Tx) error { return nil }}
+
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL: func(_ context.Context, sql, _ string, _ migration.Directives, _ stepHooks) error {
+			ran = append(ran, sql)
+			return nil
+		},
+		execGo: func(_ context.Context, fn *migration.GoFunc, _ string, _ stepHooks) error {
+			assert.Same(t, up, fn)
+			ran = append(ran, "go")
+
+			return nil
+		},
+	}
+
+	goMigration := migration.Migration{Version: "002", Name: "backfill", Checksum: "h", FilePath: "/src/backfill.go", UpFunc: up}
+
+	err := e.Apply(context.Background(), []migration.Migration{
+		testMigration("001", "SELECT 1;"),
+		goMigration,
+		testMigration("003", "SELECT 3;"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"SELECT 1;", "go", "SELECT 3;"}, ran)
+	require.Len(t, mt.recorded, 3)
+	assert.Equal(t, "backfill.go", mt.recorded[1].Filename)
+	assert.Equal(t, "h", mt.recorded[1].Checksum)
+}
+
+func TestApply_goMigration_errorReportsFailed(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	boom := errors.New("boom")
+
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execGo:      func(context.Context, *migration.GoFunc, string, stepHooks) error { return boom },
+	}
+
+	m := migration.Migration{Version: "001", Name: "go", UpFunc: &migration.GoFunc{}}
+
+	err := e.Apply(context.Background(), []migration.Migration{m})
+	require.ErrorIs(t, err, boom)
+	assert.Empty(t, mt.recorded)
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, tracker.OutcomeFailed, mt.history[0].Outcome)
+}
+
 func TestApply_lockError_returnsError(t *testing.T) {
 	t.Parallel()
 
@@ -440,7 +711,7 @@ func TestApply_dryRun_nothingRecorded(t *testing.T) {
 	require.NoError(t, err)
 	assert.Empty(t, mt.recorded)
 	require.Len(t, events, 1)
-	assert.Equal(t, StatusSkipped, events[0].Status)
+	assert.Equal(t, StatusDryRun, events[0].Status)
 }
 
 func TestApply_skipsAlreadyApplied_andAppliesPending(t *testing.T) {
@@ -496,424 +767,1398 @@ func TestApply_checksumMismatch_stopsEarly(t *testing.T) {
 	assert.Empty(t, mt.recorded)
 }
 
-func TestApply_lockReleased(t *testing.T) {
+func TestApplySteps_appliesOnlyNPending(t *testing.T) {
 	t.Parallel()
 
-	lock := &mockLock{}
+	mt := newMockTracker()
+	m1 := testMigration("001", "SELECT 1")
+	mt.applied["001"] = true
+	mt.checksums["001"] = m1.Checksum
 
-	e := &Executor{
-		tracker: newMockTracker(),
-		acquireLock: func(_ context.Context) (lockReleaser, error) {
-			return lock, nil
-		},
-	}
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, execSQL: noopExecFn}
 
-	err := e.Apply(context.Background(), []migration.Migration{})
+	err := e.ApplySteps(context.Background(), []migration.Migration{
+		m1,
+		testMigration("002", "SELECT 2"),
+		testMigration("003", "SELECT 3"),
+		testMigration("004", "SELECT 4"),
+	}, 2)
 
 	require.NoError(t, err)
-	assert.True(t, lock.released)
+	require.Len(t, mt.recorded, 2)
+	assert.Equal(t, "002", mt.recorded[0].Version)
+	assert.Equal(t, "003", mt.recorded[1].Version)
 }
 
-// --- helper for rollback tests ---
-
-func testMigrationWithDown(version, upSQL, downSQL string) migration.Migration {
-	return migration.Migration{
-		Version:  version,
-		Name:     "test_" + version,
-		UpSQL:    upSQL,
-		DownSQL:  downSQL,
-		Checksum: migration.ComputeChecksum(upSQL),
-		FilePath: "migrations/V" + version + "_test.up.sql",
-	}
-}
+func TestApplySteps_zero_noop(t *testing.T) {
+	t.Parallel()
 
-func makeAppliedList(versions ...string) []tracker.AppliedMigration {
-	var list []tracker.AppliedMigration
-	for _, v := range versions {
-		list = append(list, tracker.AppliedMigration{Version: v, Filename: "V" + v + "_test.up.sql"})
+	e := &Executor{
+		acquireLock: func(_ context.Context) (lockReleaser, error) {
+			t.Error("lock should not be acquired")
+			return &mockLock{}, nil
+		},
 	}
 
-	return list
+	require.NoError(t, e.ApplySteps(context.Background(), []migration.Migration{testMigration("001", "SELECT 1")}, 0))
 }
 
-// --- rollbackOne tests ---
-
-func TestRollbackOne_validDownSQL_executesAndRecords(t *testing.T) {
+func TestApplySteps_dryRun_respectsLimit(t *testing.T) {
 	t.Parallel()
 
-	mt := newMockTracker()
 	var events []ProgressEvent
 
 	e := &Executor{
-		tracker:    mt,
-		execSQL:    noopExecFn,
-		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
+		tracker:     newMockTracker(),
+		acquireLock: noopLockFn,
+		execSQL:     noopExecFn,
+		dryRun:      true,
+		onProgress:  func(ev ProgressEvent) { events = append(events, ev) },
 	}
 
-	m := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "DROP TABLE t;")
-	lookup := buildMigrationLookup([]migration.Migration{m})
-	applied := &tracker.AppliedMigration{Version: "001"}
-
-	err := e.rollbackOne(context.Background(), applied, lookup)
+	err := e.ApplySteps(context.Background(), []migration.Migration{
+		testMigration("001", "SELECT 1"),
+		testMigration("002", "SELECT 2"),
+	}, 1)
 
 	require.NoError(t, err)
-	require.Len(t, events, 2)
-	assert.Equal(t, StatusRollingBack, events[0].Status)
-	assert.Equal(t, StatusCompleted, events[1].Status)
-	require.Len(t, mt.rolledBack, 1)
-	assert.Equal(t, "001", mt.rolledBack[0])
+	require.Len(t, events, 1)
+	assert.Equal(t, "001", events[0].Migration.Version)
 }
 
-func TestRollbackOne_emptyDownSQL_returnsErrNoDownSQL(t *testing.T) {
+func TestApplyToVersion_stopsAtTarget(t *testing.T) {
 	t.Parallel()
 
-	e := &Executor{tracker: newMockTracker(), execSQL: noopExecFn}
-
-	m := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "")
-	lookup := buildMigrationLookup([]migration.Migration{m})
-	applied := &tracker.AppliedMigration{Version: "001"}
+	mt := newMockTracker()
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, execSQL: noopExecFn}
 
-	err := e.rollbackOne(context.Background(), applied, lookup)
+	err := e.ApplyToVersion(context.Background(), []migration.Migration{
+		testMigration("001", "SELECT 1"),
+		testMigration("002", "SELECT 2"),
+		testMigration("003", "SELECT 3"),
+	}, "002")
 
-	require.Error(t, err)
-	assert.ErrorIs(t, err, ErrNoDownSQL)
+	require.NoError(t, err)
+	require.Len(t, mt.recorded, 2)
+	assert.Equal(t, "002", mt.recorded[1].Version)
 }
 
-func TestRollbackOne_migrationNotFound_returnsError(t *testing.T) {
+func TestApplyToVersion_unknownTarget_returnsErrVersionNotFound(t *testing.T) {
 	t.Parallel()
 
-	e := &Executor{tracker: newMockTracker(), execSQL: noopExecFn}
-
-	lookup := make(map[string]*migration.Migration)
-	applied := &tracker.AppliedMigration{Version: "999"}
+	mt := newMockTracker()
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, execSQL: noopExecFn}
 
-	err := e.rollbackOne(context.Background(), applied, lookup)
+	err := e.ApplyToVersion(context.Background(), []migration.Migration{
+		testMigration("001", "SELECT 1"),
+	}, "009")
 
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "migration 999: no migration file found")
+	require.ErrorIs(t, err, migration.ErrVersionNotFound)
+	assert.Empty(t, mt.recorded)
 }
 
-func TestRollbackOne_execError_reportsFailed(t *testing.T) {
+func TestApply_outOfOrder_returnsErrOutOfOrder(t *testing.T) {
 	t.Parallel()
 
 	mt := newMockTracker()
-	var events []ProgressEvent
-	execErr := errors.New("SQL error")
-
-	e := &Executor{
-		tracker:    mt,
-		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
-		execSQL: func(_ context.Context, _ string, _ string) error {
-			return execErr
-		},
-	}
+	m1 := testMigration("001", "SELECT 1")
+	m3 := testMigration("003", "SELECT 3")
+	mt.applied = map[string]bool{"001": true, "003": true}
+	mt.checksums = map[string]string{"001": m1.Checksum, "003": m3.Checksum}
+	mt.appliedList = makeAppliedList("001", "003")
 
-	m := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "DROP TABLE t;")
-	lookup := buildMigrationLookup([]migration.Migration{m})
-	applied := &tracker.AppliedMigration{Version: "001"}
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, execSQL: noopExecFn}
 
-	err := e.rollbackOne(context.Background(), applied, lookup)
+	err := e.Apply(context.Background(), []migration.Migration{m1, testMigration("002", "SELECT 2"), m3})
 
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "rolling back migration 001")
-	require.Len(t, events, 2)
-	assert.Equal(t, StatusRollingBack, events[0].Status)
-	assert.Equal(t, StatusFailed, events[1].Status)
-	assert.Empty(t, mt.rolledBack)
+	require.ErrorIs(t, err, ErrOutOfOrder)
+	assert.Contains(t, err.Error(), "pending 002 sorts before latest applied 003")
+	assert.Empty(t, mt.recorded)
 }
 
-func TestRollbackOne_recordError_returnsError(t *testing.T) {
+func TestApply_outOfOrderAllowed_appliesGap(t *testing.T) {
 	t.Parallel()
 
 	mt := newMockTracker()
-	mt.rollbackErr = errors.New("record failed")
+	m1 := testMigration("001", "SELECT 1")
+	m3 := testMigration("003", "SELECT 3")
+	mt.applied = map[string]bool{"001": true, "003": true}
+	mt.checksums = map[string]string{"001": m1.Checksum, "003": m3.Checksum}
+	mt.appliedList = makeAppliedList("001", "003")
 
-	e := &Executor{tracker: mt, execSQL: noopExecFn}
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, execSQL: noopExecFn, allowOutOfOrder: true}
 
-	m := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "DROP TABLE t;")
-	lookup := buildMigrationLookup([]migration.Migration{m})
+	err := e.Apply(context.Background(), []migration.Migration{m1, testMigration("002", "SELECT 2"), m3})
+
+	require.NoError(t, err)
+	require.Len(t, mt.recorded, 1)
+	assert.Equal(t, "002", mt.recorded[0].Version)
+}
+
+func TestApplyToVersion_outOfOrderCheckedAgainstAllMigrations(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	m1 := testMigration("001", "SELECT 1")
+	m3 := testMigration("003", "SELECT 3")
+	mt.applied = map[string]bool{"001": true, "003": true}
+	mt.checksums = map[string]string{"001": m1.Checksum, "003": m3.Checksum}
+	mt.appliedList = makeAppliedList("001", "003")
+
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, execSQL: noopExecFn}
+
+	err := e.ApplyToVersion(context.Background(), []migration.Migration{m1, testMigration("002", "SELECT 2"), m3}, "002")
+
+	require.ErrorIs(t, err, ErrOutOfOrder)
+}
+
+func TestApply_getAppliedError_returnsError(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.getAppliedErr = errors.New("query failed")
+
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, execSQL: noopExecFn}
+
+	err := e.Apply(context.Background(), []migration.Migration{testMigration("001", "SELECT 1")})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "getting applied migrations")
+}
+
+func TestApply_lockReleased(t *testing.T) {
+	t.Parallel()
+
+	lock := &mockLock{}
+
+	e := &Executor{
+		tracker: newMockTracker(),
+		acquireLock: func(_ context.Context) (lockReleaser, error) {
+			return lock, nil
+		},
+	}
+
+	err := e.Apply(context.Background(), []migration.Migration{})
+
+	require.NoError(t, err)
+	assert.True(t, lock.released)
+}
+
+func TestApply_lockKept_stoppedBeforeRelease(t *testing.T) {
+	t.Parallel()
+
+	lock := &keepingLock{}
+
+	e := &Executor{
+		tracker:           newMockTracker(),
+		lockCheckInterval: time.Second,
+		acquireLock: func(_ context.Context) (lockReleaser, error) {
+			return lock, nil
+		},
+		execSQL: noopExecFn,
+	}
+
+	err := e.Apply(context.Background(), []migration.Migration{testMigration("001", "SELECT 1")})
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, lock.interval)
+	assert.True(t, lock.stopped)
+	assert.True(t, lock.released)
+}
+
+func TestApply_lockCheckDisabled_doesNotKeep(t *testing.T) {
+	t.Parallel()
+
+	lock := &keepingLock{lostErr: database.ErrLockLost}
+
+	e := &Executor{
+		tracker: newMockTracker(),
+		acquireLock: func(_ context.Context) (lockReleaser, error) {
+			return lock, nil
+		},
+		execSQL: noopExecFn,
+	}
+
+	err := e.Apply(context.Background(), []migration.Migration{testMigration("001", "SELECT 1")})
+
+	require.NoError(t, err)
+	assert.Zero(t, lock.interval)
+}
+
+func TestApply_lockLost_stopsAndReturnsErrLockLost(t *testing.T) {
+	t.Parallel()
+
+	lock := &keepingLock{lostErr: fmt.Errorf("%w: connection reset", database.ErrLockLost)}
+	mt := newMockTracker()
+	executed := 0
+
+	e := &Executor{
+		tracker:           mt,
+		lockCheckInterval: time.Second,
+		acquireLock: func(_ context.Context) (lockReleaser, error) {
+			return lock, nil
+		},
+		execSQL: func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
+			executed++
+			return nil
+		},
+	}
+
+	err := e.Apply(context.Background(), []migration.Migration{
+		testMigration("001", "SELECT 1"),
+		testMigration("002", "SELECT 2"),
+	})
+
+	require.ErrorIs(t, err, database.ErrLockLost)
+	assert.Contains(t, err.Error(), "connection reset")
+	assert.Zero(t, executed)
+	assert.Empty(t, mt.recorded)
+	assert.True(t, lock.released)
+}
+
+func TestRollback_lockLost_returnsErrLockLost(t *testing.T) {
+	t.Parallel()
+
+	lock := &keepingLock{lostErr: database.ErrLockLost}
+	mt := newMockTracker()
+	mt.appliedList = makeAppliedList("001")
+
+	e := &Executor{
+		tracker:           mt,
+		lockCheckInterval: time.Second,
+		acquireLock: func(_ context.Context) (lockReleaser, error) {
+			return lock, nil
+		},
+		execSQL: func(ctx context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
+			return ctx.Err()
+		},
+	}
+
+	err := e.Rollback(context.Background(), []migration.Migration{
+		testMigrationWithDown("001", "SELECT 1", "SELECT 2"),
+	}, 1)
+
+	require.ErrorIs(t, err, database.ErrLockLost)
+	assert.True(t, lock.released)
+}
+
+// ctxCheckingTracker fails tracker writes made with a cancelled context.
+type ctxCheckingTracker struct {
+	*mockTracker
+}
+
+func (c ctxCheckingTracker) RecordApplied(ctx context.Context, p tracker.RecordParams) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.mockTracker.RecordApplied(ctx, p)
+}
+
+func (c ctxCheckingTracker) RecordHistory(ctx context.Context, p tracker.HistoryParams) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.mockTracker.RecordHistory(ctx, p)
+}
+
+func TestApply_cancelledBeforeStart_executesNothing(t *testing.T) {
+	t.Parallel()
+
+	lock := &mockLock{}
+	executed := 0
+
+	e := &Executor{
+		tracker: newMockTracker(),
+		acquireLock: func(_ context.Context) (lockReleaser, error) {
+			return lock, nil
+		},
+		execSQL: func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
+			executed++
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := e.Apply(ctx, []migration.Migration{testMigration("001", "SELECT 1")})
+
+	require.ErrorIs(t, err, ErrInterrupted)
+	assert.Zero(t, executed)
+	assert.True(t, lock.released)
+}
+
+func TestApply_interruptedAfterSuccess_recordsAndStops(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	ctx, cancel := context.WithCancel(context.Background())
+	executed := 0
+
+	e := &Executor{
+		tracker:     ctxCheckingTracker{mt},
+		acquireLock: noopLockFn,
+		execSQL: func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
+			executed++
+			cancel()
+
+			return nil
+		},
+	}
+
+	err := e.Apply(ctx, []migration.Migration{
+		testMigration("001", "SELECT 1"),
+		testMigration("002", "SELECT 2"),
+	})
+
+	require.ErrorIs(t, err, ErrInterrupted)
+	assert.Equal(t, 1, executed)
+	require.Len(t, mt.recorded, 1)
+	assert.Equal(t, "001", mt.recorded[0].Version)
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, tracker.OutcomeSucceeded, mt.history[0].Outcome)
+}
+
+func TestApply_interruptedDuringExec_recordsInterrupted(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var events []ProgressEvent
+
+	e := &Executor{
+		tracker:     ctxCheckingTracker{mt},
+		acquireLock: noopLockFn,
+		execSQL: func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
+			cancel()
+			return errors.New("canceling statement due to user request")
+		},
+		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
+	}
+
+	err := e.Apply(ctx, []migration.Migration{testMigration("001", "SELECT 1")})
+
+	require.ErrorIs(t, err, ErrInterrupted)
+	assert.Empty(t, mt.recorded)
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, tracker.OutcomeInterrupted, mt.history[0].Outcome)
+	assert.Contains(t, mt.history[0].Error, "canceling statement")
+	require.Len(t, events, 2)
+	assert.Equal(t, StatusInterrupted, events[1].Status)
+}
+
+func TestRollback_interruptedDuringExec_recordsInterrupted(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.appliedList = makeAppliedList("001", "002")
+	ctx, cancel := context.WithCancel(context.Background())
+	executed := 0
+
+	e := &Executor{
+		tracker:     ctxCheckingTracker{mt},
+		acquireLock: noopLockFn,
+		execSQL: func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
+			executed++
+			cancel()
+
+			return errors.New("canceling statement due to user request")
+		},
+	}
+
+	err := e.Rollback(ctx, []migration.Migration{
+		testMigrationWithDown("001", "SELECT 1", "SELECT 2"),
+		testMigrationWithDown("002", "SELECT 3", "SELECT 4"),
+	}, 2)
+
+	require.ErrorIs(t, err, ErrInterrupted)
+	assert.Equal(t, 1, executed)
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, tracker.OutcomeInterrupted, mt.history[0].Outcome)
+	assert.Equal(t, tracker.DirectionDown, mt.history[0].Direction)
+}
+
+func TestGraceContext_outlivesCancelledParentUntilStopped(t *testing.T) {
+	t.Parallel()
+
+	e := &Executor{gracePeriod: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	execCtx, stop := e.graceContext(ctx, 1)
+	cancel()
+
+	assert.NoError(t, execCtx.Err())
+
+	stop()
+	stop()
+
+	require.ErrorIs(t, execCtx.Err(), context.Canceled)
+}
+
+// --- helper for rollback tests ---
+
+func testMigrationWithDown(version, upSQL, downSQL string) migration.Migration {
+	return migration.Migration{
+		Version:  version,
+		Name:     "test_" + version,
+		UpSQL:    upSQL,
+		DownSQL:  downSQL,
+		Checksum: migration.ComputeChecksum(upSQL),
+		FilePath: "migrations/V" + version + "_test.up.sql",
+	}
+}
+
+func makeAppliedList(versions ...string) []tracker.AppliedMigration {
+	var list []tracker.AppliedMigration
+	for _, v := range versions {
+		list = append(list, tracker.AppliedMigration{Version: v, Filename: "V" + v + "_test.up.sql"})
+	}
+
+	return list
+}
+
+// --- rollbackOne tests ---
+
+func TestRollbackOne_validDownSQL_executesAndRecords(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	var events []ProgressEvent
+
+	e := &Executor{
+		tracker:    mt,
+		execSQL:    noopExecFn,
+		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
+	}
+
+	m := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "DROP TABLE t;")
+	lookup := buildMigrationLookup([]migration.Migration{m})
+	applied := &tracker.AppliedMigration{Version: "001"}
+
+	err := e.rollbackOne(context.Background(), applied, lookup)
+
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, StatusRollingBack, events[0].Status)
+	assert.Equal(t, StatusCompleted, events[1].Status)
+	require.Len(t, mt.rolledBack, 1)
+	assert.Equal(t, "001", mt.rolledBack[0])
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, tracker.DirectionDown, mt.history[0].Direction)
+	assert.Equal(t, tracker.OutcomeSucceeded, mt.history[0].Outcome)
+}
+
+func TestRollbackOne_emptyDownSQL_returnsErrNoDownSQL(t *testing.T) {
+	t.Parallel()
+
+	e := &Executor{tracker: newMockTracker(), execSQL: noopExecFn}
+
+	m := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "")
+	lookup := buildMigrationLookup([]migration.Migration{m})
 	applied := &tracker.AppliedMigration{Version: "001"}
 
-	err := e.rollbackOne(context.Background(), applied, lookup)
+	err := e.rollbackOne(context.Background(), applied, lookup)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrNoDownSQL)
+}
+
+func TestRollbackOne_goDownFunc_runsFunc(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	down := &migration.GoFunc{Tx: func(context.Context, pgx.Tx) error { return nil }}
+
+	var got *migration.GoFunc
+
+	e := &Executor{
+		tracker: mt,
+		execGo: func(_ context.Context, fn *migration.GoFunc, _ string, _ stepHooks) error {
+			got = fn
+			return nil
+		},
+	}
+
+	m := migration.Migration{Version: "001", Name: "go", UpFunc: &migration.GoFunc{}, DownFunc: down}
+	lookup := buildMigrationLookup([]migration.Migration{m})
+
+	require.NoError(t, e.rollbackOne(context.Background(), &tracker.AppliedMigration{Version: "001"}, lookup))
+	assert.Same(t, down, got)
+	assert.Equal(t, []string{"001"}, mt.rolledBack)
+}
+
+func TestRollbackOne_goWithoutDown_returnsErrNoDownSQL(t *testing.T) {
+	t.Parallel()
+
+	e := &Executor{tracker: newMockTracker()}
+
+	m := migration.Migration{Version: "001", Name: "go", UpFunc: &migration.GoFunc{}}
+	lookup := buildMigrationLookup([]migration.Migration{m})
+
+	err := e.rollbackOne(context.Background(), &tracker.AppliedMigration{Version: "001"}, lookup)
+	require.ErrorIs(t, err, ErrNoDownSQL)
+}
+
+func TestRollbackOne_migrationNotFound_returnsError(t *testing.T) {
+	t.Parallel()
+
+	e := &Executor{tracker: newMockTracker(), execSQL: noopExecFn}
+
+	lookup := make(map[string]*migration.Migration)
+	applied := &tracker.AppliedMigration{Version: "999"}
+
+	err := e.rollbackOne(context.Background(), applied, lookup)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 999: no migration file found")
+}
+
+func TestRollbackOne_execError_reportsFailed(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	var events []ProgressEvent
+	execErr := errors.New("SQL error")
+
+	e := &Executor{
+		tracker:    mt,
+		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
+		execSQL: func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
+			return execErr
+		},
+	}
+
+	m := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "DROP TABLE t;")
+	lookup := buildMigrationLookup([]migration.Migration{m})
+	applied := &tracker.AppliedMigration{Version: "001"}
+
+	err := e.rollbackOne(context.Background(), applied, lookup)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rolling back migration 001")
+	require.Len(t, events, 2)
+	assert.Equal(t, StatusRollingBack, events[0].Status)
+	assert.Equal(t, StatusFailed, events[1].Status)
+	assert.Empty(t, mt.rolledBack)
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, tracker.DirectionDown, mt.history[0].Direction)
+	assert.Equal(t, tracker.OutcomeFailed, mt.history[0].Outcome)
+}
+
+func TestRollbackOne_recordError_returnsError(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.rollbackErr = errors.New("record failed")
+
+	e := &Executor{tracker: mt, execSQL: noopExecFn}
+
+	m := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "DROP TABLE t;")
+	lookup := buildMigrationLookup([]migration.Migration{m})
+	applied := &tracker.AppliedMigration{Version: "001"}
+
+	err := e.rollbackOne(context.Background(), applied, lookup)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "recording rollback for 001")
+}
+
+func TestRollbackOne_dryRun_skipsExecution(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	var events []ProgressEvent
+
+	e := &Executor{
+		tracker:    mt,
+		dryRun:     true,
+		execSQL:    noopExecFn,
+		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
+	}
+
+	m := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "DROP TABLE t;")
+	lookup := buildMigrationLookup([]migration.Migration{m})
+	applied := &tracker.AppliedMigration{Version: "001"}
+
+	err := e.rollbackOne(context.Background(), applied, lookup)
+
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, StatusDryRun, events[0].Status)
+	assert.Empty(t, mt.rolledBack)
+}
+
+// --- Rollback tests (steps-based) ---
+
+func TestRollback_fullFlow_rollsBackLastN(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.appliedList = makeAppliedList("001", "002", "003")
+
+	var events []ProgressEvent
+
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL:     noopExecFn,
+		onProgress:  func(ev ProgressEvent) { events = append(events, ev) },
+	}
+
+	migrations := []migration.Migration{
+		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
+		testMigrationWithDown("002", "CREATE TABLE b (id INT);", "DROP TABLE b;"),
+		testMigrationWithDown("003", "CREATE TABLE c (id INT);", "DROP TABLE c;"),
+	}
+
+	err := e.Rollback(context.Background(), migrations, 2)
+
+	require.NoError(t, err)
+	require.Len(t, mt.rolledBack, 2)
+	assert.Equal(t, "003", mt.rolledBack[0])
+	assert.Equal(t, "002", mt.rolledBack[1])
+	// 2 rolling_back + 2 completed = 4 events.
+	require.Len(t, events, 4)
+}
+
+func TestRollback_stepsZero_noop(t *testing.T) {
+	t.Parallel()
+
+	e := &Executor{tracker: newMockTracker(), acquireLock: noopLockFn}
+
+	err := e.Rollback(context.Background(), nil, 0)
+
+	require.NoError(t, err)
+}
+
+func TestRollback_noAppliedMigrations_returnsErrNothingToRollback(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.appliedList = nil
+
+	e := &Executor{tracker: mt, acquireLock: noopLockFn}
+
+	err := e.Rollback(context.Background(), nil, 1)
+
+	require.ErrorIs(t, err, ErrNothingToRollback)
+}
+
+func TestRollback_stepsExceedsApplied_rollsBackAll(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.appliedList = makeAppliedList("001", "002")
+
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL:     noopExecFn,
+	}
+
+	migrations := []migration.Migration{
+		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
+		testMigrationWithDown("002", "CREATE TABLE b (id INT);", "DROP TABLE b;"),
+	}
+
+	err := e.Rollback(context.Background(), migrations, 10)
+
+	require.NoError(t, err)
+	require.Len(t, mt.rolledBack, 2)
+	assert.Equal(t, "002", mt.rolledBack[0])
+	assert.Equal(t, "001", mt.rolledBack[1])
+}
+
+func TestRollback_lockError_returnsError(t *testing.T) {
+	t.Parallel()
+
+	e := &Executor{
+		tracker: newMockTracker(),
+		acquireLock: func(_ context.Context) (lockReleaser, error) {
+			return nil, errors.New("lock failed")
+		},
+	}
+
+	err := e.Rollback(context.Background(), nil, 1)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "acquiring migration lock")
+}
+
+func TestRollback_ensureTableError_returnsError(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.ensureErr = errors.New("create table failed")
+
+	e := &Executor{tracker: mt, acquireLock: noopLockFn}
+
+	err := e.Rollback(context.Background(), nil, 1)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "create table failed")
+}
+
+func TestRollback_getAppliedError_returnsError(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.getAppliedErr = errors.New("db error")
+
+	e := &Executor{tracker: mt, acquireLock: noopLockFn}
+
+	err := e.Rollback(context.Background(), nil, 1)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "getting applied migrations")
+}
+
+func TestRollback_lockReleased(t *testing.T) {
+	t.Parallel()
+
+	lock := &mockLock{}
+	mt := newMockTracker()
+	mt.appliedList = makeAppliedList("001")
+
+	e := &Executor{
+		tracker: mt,
+		acquireLock: func(_ context.Context) (lockReleaser, error) {
+			return lock, nil
+		},
+		execSQL: noopExecFn,
+	}
+
+	migrations := []migration.Migration{
+		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
+	}
+
+	err := e.Rollback(context.Background(), migrations, 1)
+
+	require.NoError(t, err)
+	assert.True(t, lock.released)
+}
+
+func TestRollback_partialFailure_earlierRollbacksTracked(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.appliedList = makeAppliedList("001", "002", "003")
+
+	callCount := 0
+	execErr := errors.New("SQL error on second rollback")
+
+	var events []ProgressEvent
+
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL: func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
+			callCount++
+			if callCount == 2 {
+				return execErr
+			}
+
+			return nil
+		},
+		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
+	}
+
+	migrations := []migration.Migration{
+		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
+		testMigrationWithDown("002", "CREATE TABLE b (id INT);", "DROP TABLE b;"),
+		testMigrationWithDown("003", "CREATE TABLE c (id INT);", "DROP TABLE c;"),
+	}
+
+	err := e.Rollback(context.Background(), migrations, 3)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rolling back migration 002")
+
+	// First rollback (003) should have succeeded and been recorded.
+	require.Len(t, mt.rolledBack, 1)
+	assert.Equal(t, "003", mt.rolledBack[0])
+
+	// Events: rolling_back(003), completed(003), rolling_back(002), failed(002).
+	require.Len(t, events, 4)
+	assert.Equal(t, StatusRollingBack, events[0].Status)
+	assert.Equal(t, StatusCompleted, events[1].Status)
+	assert.Equal(t, StatusRollingBack, events[2].Status)
+	assert.Equal(t, StatusFailed, events[3].Status)
+}
+
+// --- RollbackToVersion tests ---
+
+func TestRollbackToVersion_rollsBackAfterTarget(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.appliedList = makeAppliedList("001", "002", "003")
+
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL:     noopExecFn,
+	}
+
+	migrations := []migration.Migration{
+		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
+		testMigrationWithDown("002", "CREATE TABLE b (id INT);", "DROP TABLE b;"),
+		testMigrationWithDown("003", "CREATE TABLE c (id INT);", "DROP TABLE c;"),
+	}
+
+	err := e.RollbackToVersion(context.Background(), migrations, "001")
+
+	require.NoError(t, err)
+	require.Len(t, mt.rolledBack, 2)
+	assert.Equal(t, "003", mt.rolledBack[0])
+	assert.Equal(t, "002", mt.rolledBack[1])
+}
+
+func TestRollbackToVersion_targetNotFound_returnsError(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.appliedList = makeAppliedList("001", "002")
+
+	e := &Executor{tracker: mt, acquireLock: noopLockFn}
+
+	err := e.RollbackToVersion(context.Background(), nil, "999")
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrTargetNotFound)
+}
+
+func TestRollbackToVersion_nothingAfterTarget_returnsErrNothingToRollback(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.appliedList = makeAppliedList("001", "002")
+
+	e := &Executor{tracker: mt, acquireLock: noopLockFn}
+
+	err := e.RollbackToVersion(context.Background(), nil, "002")
+
+	require.ErrorIs(t, err, ErrNothingToRollback)
+}
+
+func testRepeatable(name, sql string) migration.Migration {
+	return migration.Migration{
+		Name:       name,
+		UpSQL:      sql,
+		Checksum:   migration.ComputeChecksum(sql),
+		FilePath:   "migrations/R__" + name + ".sql",
+		Repeatable: true,
+	}
+}
+
+func TestApply_repeatables_runAfterVersionedWhenChanged(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	mt.repeatables = []tracker.RepeatableParams{
+		{Name: "unchanged", Checksum: migration.ComputeChecksum("SELECT 'same';")},
+		{Name: "edited", Checksum: migration.ComputeChecksum("SELECT 'old';")},
+	}
+
+	var ran []string
+
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL: func(_ context.Context, sql, _ string, _ migration.Directives, _ stepHooks) error {
+			ran = append(ran, sql)
+			return nil
+		},
+		repeatables: []migration.Migration{
+			testRepeatable("edited", "SELECT 'new';"),
+			testRepeatable("new", "SELECT 'added';"),
+			testRepeatable("unchanged", "SELECT 'same';"),
+		},
+	}
+
+	require.NoError(t, e.Apply(context.Background(), []migration.Migration{testMigration("001", "SELECT 1;")}))
+
+	assert.Equal(t, []string{"SELECT 1;", "SELECT 'new';", "SELECT 'added';"}, ran)
+	require.Len(t, mt.repeatables, 4)
+	assert.Equal(t, "edited", mt.repeatables[2].Name)
+	assert.Equal(t, "R__edited.sql", mt.repeatables[2].Filename)
+	assert.Equal(t, "new", mt.repeatables[3].Name)
+
+	require.Len(t, mt.history, 3)
+	assert.Equal(t, "R__new.sql", mt.history[2].Filename)
+	assert.Empty(t, mt.history[2].Version)
+}
+
+func TestApplySteps_pendingLeft_skipsRepeatables(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL:     noopExecFn,
+		repeatables: []migration.Migration{testRepeatable("views", "SELECT 1;")},
+	}
+
+	ms := []migration.Migration{testMigration("001", "SELECT 1;"), testMigration("002", "SELECT 2;")}
+
+	require.NoError(t, e.ApplySteps(context.Background(), ms, 1))
+	assert.Empty(t, mt.repeatables)
+
+	require.NoError(t, e.ApplySteps(context.Background(), ms, 1))
+	require.Len(t, mt.repeatables, 1)
+	assert.Equal(t, "views", mt.repeatables[0].Name)
+}
+
+func TestApplyToVersion_pendingLeft_skipsRepeatables(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL:     noopExecFn,
+		repeatables: []migration.Migration{testRepeatable("views", "SELECT 1;")},
+	}
+
+	ms := []migration.Migration{testMigration("001", "SELECT 1;"), testMigration("002", "SELECT 2;")}
 
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "recording rollback for 001")
+	require.NoError(t, e.ApplyToVersion(context.Background(), ms, "001"))
+	assert.Empty(t, mt.repeatables)
+
+	require.NoError(t, e.ApplyToVersion(context.Background(), ms, "002"))
+	require.Len(t, mt.repeatables, 1)
 }
 
-func TestRollbackOne_dryRun_skipsExecution(t *testing.T) {
+func TestApply_repeatableFails_recordsFailureAndStops(t *testing.T) {
+	t.Parallel()
+
+	mt := newMockTracker()
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL: func(_ context.Context, sql, _ string, _ migration.Directives, _ stepHooks) error {
+			if sql == "bad" {
+				return errors.New("syntax error")
+			}
+
+			return nil
+		},
+		repeatables: []migration.Migration{testRepeatable("a", "bad"), testRepeatable("b", "SELECT 1;")},
+	}
+
+	err := e.Apply(context.Background(), nil)
+	require.ErrorContains(t, err, "applying repeatable migration a")
+	assert.Empty(t, mt.repeatables)
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, tracker.OutcomeFailed, mt.history[0].Outcome)
+}
+
+func TestApply_repeatablesDryRun_executesNothing(t *testing.T) {
 	t.Parallel()
 
+	unchanged := testRepeatable("grants", "SELECT 2;")
+
 	mt := newMockTracker()
+	mt.repeatables = []tracker.RepeatableParams{{Name: unchanged.Name, Checksum: unchanged.Checksum}}
+
 	var events []ProgressEvent
 
 	e := &Executor{
-		tracker:    mt,
-		dryRun:     true,
-		execSQL:    noopExecFn,
-		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		dryRun:      true,
+		execSQL: func(context.Context, string, string, migration.Directives, stepHooks) error {
+			t.Fatal("dry run must not execute SQL")
+			return nil
+		},
+		onProgress:  func(ev ProgressEvent) { events = append(events, ev) },
+		repeatables: []migration.Migration{testRepeatable("views", "SELECT 1;"), unchanged},
 	}
 
-	m := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "DROP TABLE t;")
-	lookup := buildMigrationLookup([]migration.Migration{m})
-	applied := &tracker.AppliedMigration{Version: "001"}
+	require.NoError(t, e.Apply(context.Background(), nil))
+	assert.Len(t, mt.repeatables, 1)
+	require.Len(t, events, 2)
+	assert.Equal(t, StatusDryRun, events[0].Status)
+	assert.Equal(t, StatusSkipped, events[1].Status)
+}
 
-	err := e.rollbackOne(context.Background(), applied, lookup)
+func TestApplyFS_appliesRepeatables(t *testing.T) {
+	t.Parallel()
 
-	require.NoError(t, err)
-	require.Len(t, events, 1)
-	assert.Equal(t, StatusSkipped, events[0].Status)
-	assert.Empty(t, mt.rolledBack)
+	mt := newMockTracker()
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, execSQL: noopExecFn}
+
+	fsys := fstest.MapFS{
+		"migrations/V001_a.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+		"migrations/R__a_view.sql": {Data: []byte("CREATE OR REPLACE VIEW v AS SELECT * FROM a;")},
+	}
+
+	require.NoError(t, e.ApplyFS(context.Background(), fsys, "migrations"))
+	require.Len(t, mt.repeatables, 1)
+	assert.Equal(t, "a_view", mt.repeatables[0].Name)
 }
 
-// --- Rollback tests (steps-based) ---
+// hookRecorder records hook invocations as "<point> <direction> <version>".
+type hookRecorder struct {
+	calls []string
+}
 
-func TestRollback_fullFlow_rollsBackLastN(t *testing.T) {
+func (r *hookRecorder) hook(point migration.HookPoint) migration.Hook {
+	return migration.Hook{Point: point, Func: func(_ context.Context, _ migration.DB, ev migration.HookEvent) error {
+		call := string(ev.Point) + " " + ev.Direction
+		if ev.Migration != nil {
+			call += " " + ev.Migration.Version
+		}
+
+		r.calls = append(r.calls, call)
+
+		return nil
+	}}
+}
+
+func (r *hookRecorder) execHooks(ctx context.Context, hooks []migration.Hook, ev migration.HookEvent) error {
+	return runHooks(ctx, nil, hooks, ev)
+}
+
+func (r *hookRecorder) execSQL(ctx context.Context, sql, _ string, _ migration.Directives, hooks stepHooks) error {
+	if err := hooks.run(ctx, nil, migration.HookBeforeEach); err != nil {
+		return err
+	}
+
+	r.calls = append(r.calls, sql)
+
+	return hooks.run(ctx, nil, migration.HookAfterEach)
+}
+
+func TestApply_hooks_runAroundMigrations(t *testing.T) {
 	t.Parallel()
 
-	mt := newMockTracker()
-	mt.appliedList = makeAppliedList("001", "002", "003")
+	r := &hookRecorder{}
 
 	var events []ProgressEvent
 
 	e := &Executor{
-		tracker:     mt,
+		tracker:     newMockTracker(),
 		acquireLock: noopLockFn,
-		execSQL:     noopExecFn,
+		execSQL:     r.execSQL,
+		execHooks:   r.execHooks,
 		onProgress:  func(ev ProgressEvent) { events = append(events, ev) },
+		hooks: []migration.Hook{
+			r.hook(migration.HookAfterAll),
+			r.hook(migration.HookBeforeEach),
+			r.hook(migration.HookAfterEach),
+			r.hook(migration.HookBeforeAll),
+		},
 	}
 
-	migrations := []migration.Migration{
-		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
-		testMigrationWithDown("002", "CREATE TABLE b (id INT);", "DROP TABLE b;"),
-		testMigrationWithDown("003", "CREATE TABLE c (id INT);", "DROP TABLE c;"),
-	}
+	err := e.Apply(context.Background(), []migration.Migration{
+		testMigration("001", "SELECT 1;"),
+		testMigration("002", "SELECT 2;"),
+	})
+	require.NoError(t, err)
 
-	err := e.Rollback(context.Background(), migrations, 2)
+	assert.Equal(t, []string{
+		"beforeAll up",
+		"beforeEach up 001", "SELECT 1;", "afterEach up 001",
+		"beforeEach up 002", "SELECT 2;", "afterEach up 002",
+		"afterAll up",
+	}, r.calls)
 
-	require.NoError(t, err)
-	require.Len(t, mt.rolledBack, 2)
-	assert.Equal(t, "003", mt.rolledBack[0])
-	assert.Equal(t, "002", mt.rolledBack[1])
-	// 2 rolling_back + 2 completed = 4 events.
-	require.Len(t, events, 4)
+	require.Len(t, events, 8)
+	assert.Equal(t, migration.HookBeforeAll, events[0].Hook)
+	assert.Nil(t, events[0].Migration)
+	assert.Equal(t, StatusCompleted, events[1].Status)
+	assert.Equal(t, migration.HookAfterAll, events[7].Hook)
 }
 
-func TestRollback_stepsZero_noop(t *testing.T) {
+func TestApply_hooks_skippedInDryRun(t *testing.T) {
 	t.Parallel()
 
-	e := &Executor{tracker: newMockTracker(), acquireLock: noopLockFn}
+	r := &hookRecorder{}
 
-	err := e.Rollback(context.Background(), nil, 0)
+	e := &Executor{
+		tracker:     newMockTracker(),
+		acquireLock: noopLockFn,
+		dryRun:      true,
+		execHooks:   r.execHooks,
+		hooks:       []migration.Hook{r.hook(migration.HookBeforeAll), r.hook(migration.HookAfterAll)},
+	}
 
-	require.NoError(t, err)
+	require.NoError(t, e.Apply(context.Background(), []migration.Migration{testMigration("001", "SELECT 1;")}))
+	assert.Empty(t, r.calls)
 }
 
-func TestRollback_noAppliedMigrations_returnsErrNothingToRollback(t *testing.T) {
+func TestApply_eachHookFails_failsMigration(t *testing.T) {
 	t.Parallel()
 
 	mt := newMockTracker()
-	mt.appliedList = nil
-
-	e := &Executor{tracker: mt, acquireLock: noopLockFn}
+	boom := errors.New("permission denied to set role")
+	r := &hookRecorder{}
 
-	err := e.Rollback(context.Background(), nil, 1)
+	e := &Executor{
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL:     r.execSQL,
+		hooks: []migration.Hook{{
+			Point:    migration.HookBeforeEach,
+			FilePath: "migrations/beforeEach.sql",
+			Func:     func(context.Context, migration.DB, migration.HookEvent) error { return boom },
+		}},
+	}
 
-	require.ErrorIs(t, err, ErrNothingToRollback)
+	err := e.Apply(context.Background(), []migration.Migration{testMigration("001", "SELECT 1;")})
+	require.ErrorIs(t, err, boom)
+	assert.Contains(t, err.Error(), "applying migration 001")
+	assert.Empty(t, r.calls, "the migration must not run after its beforeEach hook failed")
+	assert.Empty(t, mt.recorded)
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, "001", mt.history[0].Version)
+	assert.Equal(t, tracker.OutcomeFailed, mt.history[0].Outcome)
 }
 
-func TestRollback_stepsExceedsApplied_rollsBackAll(t *testing.T) {
+func TestApply_beforeAllHookFails_reportedLikeMigration(t *testing.T) {
 	t.Parallel()
 
 	mt := newMockTracker()
-	mt.appliedList = makeAppliedList("001", "002")
+	boom := errors.New("boom")
+
+	var events []ProgressEvent
 
 	e := &Executor{
 		tracker:     mt,
 		acquireLock: noopLockFn,
-		execSQL:     noopExecFn,
+		execSQL: func(context.Context, string, string, migration.Directives, stepHooks) error {
+			t.Fatal("no migration may run after beforeAll failed")
+			return nil
+		},
+		execHooks:  func(context.Context, []migration.Hook, migration.HookEvent) error { return boom },
+		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
+		hooks:      []migration.Hook{{Point: migration.HookBeforeAll, SQL: "SELECT 1;", FilePath: "beforeAll.sql"}},
 	}
 
-	migrations := []migration.Migration{
-		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
-		testMigrationWithDown("002", "CREATE TABLE b (id INT);", "DROP TABLE b;"),
-	}
+	err := e.Apply(context.Background(), []migration.Migration{testMigration("001", "SELECT 1;")})
+	require.ErrorIs(t, err, boom)
+	assert.Contains(t, err.Error(), "running beforeAll hooks")
 
-	err := e.Rollback(context.Background(), migrations, 10)
+	require.Len(t, events, 2)
+	assert.Equal(t, StatusFailed, events[1].Status)
+	assert.Equal(t, migration.HookBeforeAll, events[1].Hook)
 
-	require.NoError(t, err)
-	require.Len(t, mt.rolledBack, 2)
-	assert.Equal(t, "002", mt.rolledBack[0])
-	assert.Equal(t, "001", mt.rolledBack[1])
+	require.Len(t, mt.history, 1)
+	assert.Empty(t, mt.history[0].Version)
+	assert.Equal(t, "beforeAll", mt.history[0].Filename)
+	assert.Equal(t, tracker.OutcomeFailed, mt.history[0].Outcome)
+	assert.Equal(t, "boom", mt.history[0].Error)
 }
 
-func TestRollback_lockError_returnsError(t *testing.T) {
+func TestRollback_hooks_runWithDirectionDown(t *testing.T) {
 	t.Parallel()
 
+	mt := newMockTracker()
+	mt.appliedList = makeAppliedList("001", "002")
+	r := &hookRecorder{}
+
 	e := &Executor{
-		tracker: newMockTracker(),
-		acquireLock: func(_ context.Context) (lockReleaser, error) {
-			return nil, errors.New("lock failed")
+		tracker:     mt,
+		acquireLock: noopLockFn,
+		execSQL:     r.execSQL,
+		execHooks:   r.execHooks,
+		hooks: []migration.Hook{
+			r.hook(migration.HookBeforeAll),
+			r.hook(migration.HookAfterEach),
+			r.hook(migration.HookAfterAll),
 		},
 	}
 
-	err := e.Rollback(context.Background(), nil, 1)
+	err := e.Rollback(context.Background(), []migration.Migration{
+		testMigrationWithDown("001", "SELECT 1;", "SELECT -1;"),
+		testMigrationWithDown("002", "SELECT 2;", "SELECT -2;"),
+	}, 1)
+	require.NoError(t, err)
 
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "acquiring migration lock")
+	assert.Equal(t, []string{"beforeAll down", "SELECT -2;", "afterEach down 002", "afterAll down"}, r.calls)
 }
 
-func TestRollback_ensureTableError_returnsError(t *testing.T) {
+func TestApplyFS_runsCallbackFiles(t *testing.T) {
 	t.Parallel()
 
-	mt := newMockTracker()
-	mt.ensureErr = errors.New("create table failed")
+	var ran []string
 
-	e := &Executor{tracker: mt, acquireLock: noopLockFn}
+	e := &Executor{
+		tracker:     newMockTracker(),
+		acquireLock: noopLockFn,
+		execSQL:     noopExecFn,
+		execHooks: func(_ context.Context, hooks []migration.Hook, _ migration.HookEvent) error {
+			for _, h := range hooks {
+				ran = append(ran, h.SQL)
+			}
 
-	err := e.Rollback(context.Background(), nil, 1)
+			return nil
+		},
+	}
 
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "create table failed")
+	fsys := fstest.MapFS{
+		"migrations/V001_a.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+		"migrations/afterAll.sql":  {Data: []byte("ANALYZE a;")},
+	}
+
+	require.NoError(t, e.ApplyFS(context.Background(), fsys, "migrations"))
+	assert.Equal(t, []string{"ANALYZE a;"}, ran)
+	assert.Empty(t, e.hooks, "callback files apply to one ApplyFS call only")
 }
 
-func TestRollback_getAppliedError_returnsError(t *testing.T) {
+func TestWithTimeouts_fillsUnsetDirectives(t *testing.T) {
 	t.Parallel()
 
-	mt := newMockTracker()
-	mt.getAppliedErr = errors.New("db error")
+	e := &Executor{lockTimeout: 5 * time.Second, statementTimeout: 30 * time.Second}
 
-	e := &Executor{tracker: mt, acquireLock: noopLockFn}
+	stmt := time.Minute
+	d := e.withTimeouts(migration.Directives{NoTransaction: true, StatementTimeout: &stmt})
 
-	err := e.Rollback(context.Background(), nil, 1)
+	require.NotNil(t, d.LockTimeout)
+	assert.Equal(t, 5*time.Second, *d.LockTimeout)
+	assert.Equal(t, time.Minute, *d.StatementTimeout)
 
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "getting applied migrations")
+	assert.Nil(t, (&Executor{}).withTimeouts(migration.Directives{}).LockTimeout)
 }
 
-func TestRollback_lockReleased(t *testing.T) {
+func TestDirectiveSettings(t *testing.T) {
 	t.Parallel()
 
-	lock := &mockLock{}
-	mt := newMockTracker()
-	mt.appliedList = makeAppliedList("001")
+	lock, stmt := 2*time.Second, time.Duration(0)
+	d := migration.Directives{LockTimeout: &lock, StatementTimeout: &stmt, Role: `app "owner"`}
+
+	assert.Equal(t, []string{
+		"SET LOCAL lock_timeout = '2000ms'",
+		"SET LOCAL statement_timeout = '0ms'",
+		`SET LOCAL ROLE "app ""owner"""`,
+	}, directiveSettings(d, "SET LOCAL"))
+
+	assert.Empty(t, directiveSettings(migration.Directives{NoTransaction: true}, "SET"))
+}
+
+func TestExecDown_passesDownDirectives(t *testing.T) {
+	t.Parallel()
+
+	var got []migration.Directives
 
 	e := &Executor{
-		tracker: mt,
-		acquireLock: func(_ context.Context) (lockReleaser, error) {
-			return lock, nil
+		execSQL: func(_ context.Context, _, _ string, d migration.Directives, _ stepHooks) error {
+			got = append(got, d)
+			return nil
 		},
-		execSQL: noopExecFn,
 	}
 
-	migrations := []migration.Migration{
-		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
-	}
-
-	err := e.Rollback(context.Background(), migrations, 1)
+	m := testMigrationWithDown("001", "SELECT 1;", "SELECT -1;")
+	m.Directives = migration.Directives{Role: "app_owner"}
+	m.DownDirectives = migration.Directives{NoTransaction: true}
 
-	require.NoError(t, err)
-	assert.True(t, lock.released)
+	require.NoError(t, e.execUp(context.Background(), &m))
+	require.NoError(t, e.execDown(context.Background(), &m))
+	assert.Equal(t, []migration.Directives{m.Directives, m.DownDirectives}, got)
 }
 
-func TestRollback_partialFailure_earlierRollbacksTracked(t *testing.T) {
+// --- Baseline tests ---
+
+func TestBaseline_recordsUpToTargetWithoutRunning(t *testing.T) {
 	t.Parallel()
 
 	mt := newMockTracker()
-	mt.appliedList = makeAppliedList("001", "002", "003")
-
-	callCount := 0
-	execErr := errors.New("SQL error on second rollback")
+	mt.appliedList = makeAppliedList("001")
 
 	var events []ProgressEvent
 
 	e := &Executor{
 		tracker:     mt,
 		acquireLock: noopLockFn,
-		execSQL: func(_ context.Context, _, _ string) error {
-			callCount++
-			if callCount == 2 {
-				return execErr
-			}
-
+		execSQL: func(_ context.Context, _, _ string, _ migration.Directives, _ stepHooks) error {
+			t.Fatal("baseline must not run migrations")
 			return nil
 		},
+		ticket:     "OPS-1",
 		onProgress: func(ev ProgressEvent) { events = append(events, ev) },
 	}
 
 	migrations := []migration.Migration{
-		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
-		testMigrationWithDown("002", "CREATE TABLE b (id INT);", "DROP TABLE b;"),
-		testMigrationWithDown("003", "CREATE TABLE c (id INT);", "DROP TABLE c;"),
+		testMigration("003", "SELECT 3;"),
+		testMigration("001", "SELECT 1;"),
+		testMigrationWithDown("002", "SELECT 2;", "SELECT -2;"),
 	}
 
-	err := e.Rollback(context.Background(), migrations, 3)
+	require.NoError(t, e.Baseline(context.Background(), migrations, "2"))
 
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "rolling back migration 002")
+	require.Len(t, mt.baselined, 1)
+	assert.Equal(t, "002", mt.baselined[0].Version)
+	assert.Equal(t, migrations[2].Checksum, mt.baselined[0].Checksum)
+	assert.Equal(t, migration.ComputeChecksum("SELECT -2;"), mt.baselined[0].DownChecksum)
+	assert.Empty(t, mt.recorded)
 
-	// First rollback (003) should have succeeded and been recorded.
-	require.Len(t, mt.rolledBack, 1)
-	assert.Equal(t, "003", mt.rolledBack[0])
+	require.Len(t, mt.history, 1)
+	assert.Equal(t, tracker.DirectionBaseline, mt.history[0].Direction)
+	assert.Equal(t, "OPS-1", mt.history[0].Ticket)
 
-	// Events: rolling_back(003), completed(003), rolling_back(002), failed(002).
-	require.Len(t, events, 4)
-	assert.Equal(t, StatusRollingBack, events[0].Status)
-	assert.Equal(t, StatusCompleted, events[1].Status)
-	assert.Equal(t, StatusRollingBack, events[2].Status)
-	assert.Equal(t, StatusFailed, events[3].Status)
+	require.Len(t, events, 1)
+	assert.Equal(t, StatusBaselined, events[0].Status)
 }
 
-// --- RollbackToVersion tests ---
-
-func TestRollbackToVersion_rollsBackAfterTarget(t *testing.T) {
+func TestBaseline_dryRun_recordsNothing(t *testing.T) {
 	t.Parallel()
 
 	mt := newMockTracker()
-	mt.appliedList = makeAppliedList("001", "002", "003")
-
-	e := &Executor{
-		tracker:     mt,
-		acquireLock: noopLockFn,
-		execSQL:     noopExecFn,
-	}
-
-	migrations := []migration.Migration{
-		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
-		testMigrationWithDown("002", "CREATE TABLE b (id INT);", "DROP TABLE b;"),
-		testMigrationWithDown("003", "CREATE TABLE c (id INT);", "DROP TABLE c;"),
-	}
+	mt.missing = true
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, dryRun: true}
 
-	err := e.RollbackToVersion(context.Background(), migrations, "001")
-
-	require.NoError(t, err)
-	require.Len(t, mt.rolledBack, 2)
-	assert.Equal(t, "003", mt.rolledBack[0])
-	assert.Equal(t, "002", mt.rolledBack[1])
+	require.NoError(t, e.Baseline(context.Background(), []migration.Migration{testMigration("001", "SELECT 1;")}, "001"))
+	assert.Empty(t, mt.baselined)
+	assert.Empty(t, mt.history)
+	assert.False(t, mt.ensured, "dry run must not create the tracking table")
 }
 
-func TestRollbackToVersion_targetNotFound_returnsError(t *testing.T) {
+func TestBaseline_appliedVersionsCompareNumerically(t *testing.T) {
 	t.Parallel()
 
 	mt := newMockTracker()
-	mt.appliedList = makeAppliedList("001", "002")
-
+	mt.appliedList = makeAppliedList("1")
 	e := &Executor{tracker: mt, acquireLock: noopLockFn}
 
-	err := e.RollbackToVersion(context.Background(), nil, "999")
+	migrations := []migration.Migration{testMigration("001", "SELECT 1;"), testMigration("002", "SELECT 2;")}
 
-	require.Error(t, err)
-	assert.ErrorIs(t, err, ErrTargetNotFound)
+	require.NoError(t, e.Baseline(context.Background(), migrations, "002"))
+	require.Len(t, mt.baselined, 1)
+	assert.Equal(t, "002", mt.baselined[0].Version)
 }
 
-func TestRollbackToVersion_nothingAfterTarget_returnsErrNothingToRollback(t *testing.T) {
+func TestBaseline_unknownVersion_returnsErrVersionNotFound(t *testing.T) {
+	t.Parallel()
+
+	e := &Executor{tracker: newMockTracker(), acquireLock: noopLockFn}
+
+	err := e.Baseline(context.Background(), []migration.Migration{testMigration("001", "SELECT 1;")}, "002")
+	require.ErrorIs(t, err, migration.ErrVersionNotFound)
+}
+
+func TestRollback_reachingBaseline_returnsErrBaselineRollback(t *testing.T) {
 	t.Parallel()
 
 	mt := newMockTracker()
 	mt.appliedList = makeAppliedList("001", "002")
+	mt.appliedList[0].Status = tracker.StatusBaseline
 
-	e := &Executor{tracker: mt, acquireLock: noopLockFn}
+	e := &Executor{tracker: mt, acquireLock: noopLockFn, execSQL: noopExecFn}
 
-	err := e.RollbackToVersion(context.Background(), nil, "002")
+	migrations := []migration.Migration{
+		testMigrationWithDown("001", "CREATE TABLE a (id INT);", "DROP TABLE a;"),
+		testMigrationWithDown("002", "CREATE TABLE b (id INT);", "DROP TABLE b;"),
+	}
 
-	require.ErrorIs(t, err, ErrNothingToRollback)
+	err := e.Rollback(context.Background(), migrations, 2)
+	require.ErrorIs(t, err, ErrBaselineRollback)
+	assert.Empty(t, mt.rolledBack, "nothing is rolled back")
+
+	require.NoError(t, e.Rollback(context.Background(), migrations, 1))
+	assert.Equal(t, []string{"002"}, mt.rolledBack)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/aqasim81/database-migration-engine/internal/database"
 	"github.com/aqasim81/database-migration-engine/internal/executor"
 	"github.com/aqasim81/database-migration-engine/internal/migration"
 )
@@ -30,6 +31,13 @@ func TestNew_withOptions(t *testing.T) {
 		executor.WithLockTimeout(10*time.Second),
 		executor.WithStatementTimeout(30*time.Second),
 		executor.WithDryRun(true),
+		executor.WithTicket("CHG-1"),
+		executor.WithLockKey(42),
+		executor.WithLockWait(time.Minute),
+		executor.WithLockWaitCallback(func(database.LockHolder) {}),
+		executor.WithLockCheckInterval(time.Second),
+		executor.WithGracePeriod(30*time.Second),
+		executor.WithAllowOutOfOrder(true),
 		executor.WithProgressCallback(cb),
 	)
 
@@ -63,6 +71,7 @@ func TestStatusConstants(t *testing.T) {
 	assert.Equal(t, "failed", executor.StatusFailed)
 	assert.Equal(t, "skipped", executor.StatusSkipped)
 	assert.Equal(t, "rolling_back", executor.StatusRollingBack)
+	assert.Equal(t, "interrupted", executor.StatusInterrupted)
 }
 
 func TestErrors_sentinel(t *testing.T) {
@@ -87,4 +96,14 @@ func TestErrors_sentinel(t *testing.T) {
 		t.Parallel()
 		assert.EqualError(t, executor.ErrTargetNotFound, "target version not found in applied migrations")
 	})
+
+	t.Run("ErrInterrupted", func(t *testing.T) {
+		t.Parallel()
+		assert.EqualError(t, executor.ErrInterrupted, "migration run interrupted")
+	})
+
+	t.Run("ErrOutOfOrder", func(t *testing.T) {
+		t.Parallel()
+		assert.EqualError(t, executor.ErrOutOfOrder, "out-of-order migrations")
+	})
 }
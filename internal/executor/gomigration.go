@@ -0,0 +1,82 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// execUp runs a migration's up step: its Go function, or its SQL, between
+// the beforeEach and afterEach hooks.
+func (e *Executor) execUp(ctx context.Context, m *migration.Migration) error {
+	hooks := e.stepHooks(m, tracker.DirectionUp)
+
+	if m.UpFunc != nil {
+		return e.execGo(ctx, m.UpFunc, "running Go migration", hooks)
+	}
+
+	return e.execSQL(ctx, m.UpSQL, "executing SQL", m.Directives, hooks)
+}
+
+// execDown runs a migration's down step: its Go function, or its SQL,
+// between the beforeEach and afterEach hooks.
+func (e *Executor) execDown(ctx context.Context, m *migration.Migration) error {
+	hooks := e.stepHooks(m, tracker.DirectionDown)
+
+	if m.DownFunc != nil {
+		return e.execGo(ctx, m.DownFunc, "running Go rollback", hooks)
+	}
+
+	return e.execSQL(ctx, m.DownSQL, "executing down SQL", m.DownDirectives, hooks)
+}
+
+// runGo runs a Go migration step. Tx functions run in a transaction with
+// the configured timeouts on a dedicated connection, with the same grace
+// period as SQL, and so do the hooks. Pool functions get the pool and a
+// context that is cancelled once the grace period after an interruption
+// expires; their hooks run on a dedicated connection (see runSessionHooks).
+func (e *Executor) runGo(ctx context.Context, fn *migration.GoFunc, label string, hooks stepHooks) error {
+	if fn.Pool != nil {
+		poolCtx, stop := e.graceContext(ctx, 0)
+		defer stop()
+
+		if err := e.runSessionHooks(poolCtx, hooks, migration.HookBeforeEach); err != nil {
+			return err
+		}
+
+		if err := fn.Pool(poolCtx, e.pool); err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+
+		return e.runSessionHooks(poolCtx, hooks, migration.HookAfterEach)
+	}
+
+	conn, err := e.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Release()
+
+	ctx, stop := e.graceContext(ctx, conn.Conn().PgConn().PID())
+	defer stop()
+
+	return ExecInTransaction(ctx, conn, func(tx pgx.Tx) error {
+		if err := e.setTimeouts(ctx, tx); err != nil {
+			return err
+		}
+
+		if err := hooks.run(ctx, tx, migration.HookBeforeEach); err != nil {
+			return err
+		}
+
+		if err := fn.Tx(ctx, tx); err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+
+		return hooks.run(ctx, tx, migration.HookAfterEach)
+	})
+}
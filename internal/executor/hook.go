@@ -0,0 +1,159 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+// stepHooks runs the beforeEach or afterEach hooks of one migration step on
+// db: the step's transaction, or its connection if it runs outside one.
+type stepHooks func(ctx context.Context, db migration.DB, point migration.HookPoint) error
+
+// run runs the hooks for point. A nil stepHooks runs nothing.
+func (h stepHooks) run(ctx context.Context, db migration.DB, point migration.HookPoint) error {
+	if h == nil {
+		return nil
+	}
+
+	return h(ctx, db, point)
+}
+
+// runHooksFunc runs the beforeAll or afterAll hooks described by ev.
+type runHooksFunc func(ctx context.Context, hooks []migration.Hook, ev migration.HookEvent) error
+
+// hooksAt returns the configured hooks for point, in order.
+func (e *Executor) hooksAt(point migration.HookPoint) []migration.Hook {
+	var hooks []migration.Hook
+
+	for _, h := range e.hooks {
+		if h.Point == point {
+			hooks = append(hooks, h)
+		}
+	}
+
+	return hooks
+}
+
+// stepHooks returns the beforeEach and afterEach hooks for running m in the
+// given direction, or nil if there are none.
+func (e *Executor) stepHooks(m *migration.Migration, direction string) stepHooks {
+	before, after := e.hooksAt(migration.HookBeforeEach), e.hooksAt(migration.HookAfterEach)
+	if len(before) == 0 && len(after) == 0 {
+		return nil
+	}
+
+	return func(ctx context.Context, db migration.DB, point migration.HookPoint) error {
+		hooks := before
+		if point == migration.HookAfterEach {
+			hooks = after
+		}
+
+		return runHooks(ctx, db, hooks, migration.HookEvent{Point: point, Direction: direction, Migration: m})
+	}
+}
+
+// runHooks runs hooks in order on db and stops at the first failure.
+func runHooks(ctx context.Context, db migration.DB, hooks []migration.Hook, ev migration.HookEvent) error {
+	for i := range hooks {
+		h := &hooks[i]
+
+		var err error
+		if h.Func != nil {
+			err = h.Func(ctx, db, ev)
+		} else {
+			_, err = db.Exec(ctx, h.SQL)
+		}
+
+		if err != nil {
+			return fmt.Errorf("running hook %s: %w", h.Label(), err)
+		}
+	}
+
+	return nil
+}
+
+// runAllHooks runs the beforeAll or afterAll hooks, if any, and reports
+// them like a migration: progress events carry the hook point, and a
+// failure is recorded in the history table under the hook point's name.
+// Nothing runs in dry-run mode.
+func (e *Executor) runAllHooks(ctx context.Context, point migration.HookPoint, direction string) error {
+	hooks := e.hooksAt(point)
+	if len(hooks) == 0 || e.dryRun {
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		return interruptedError(ctx)
+	}
+
+	e.fireProgress(ProgressEvent{Hook: point, Status: StatusStarting})
+
+	start := time.Now()
+	execErr := e.execHooks(ctx, hooks, migration.HookEvent{Point: point, Direction: direction})
+	duration := time.Since(start)
+
+	if execErr == nil {
+		e.fireProgress(ProgressEvent{Hook: point, Status: StatusCompleted, Duration: duration})
+		return nil
+	}
+
+	status := StatusFailed
+
+	if ctx.Err() != nil {
+		status = StatusInterrupted
+		execErr = fmt.Errorf("%w: %w", ErrInterrupted, execErr)
+	}
+
+	e.fireProgress(ProgressEvent{Hook: point, Status: status, Duration: duration, Error: execErr})
+
+	// The outcome must be recorded even if the run was interrupted meanwhile.
+	// The history entry has no version and the hook point as its filename.
+	hook := &migration.Migration{Name: string(point), FilePath: string(point)}
+
+	return fmt.Errorf("running %s hooks: %w", point,
+		e.recordFailure(context.WithoutCancel(ctx), hook, direction, duration, execErr))
+}
+
+// runSessionHooks runs the hooks for point outside a transaction on a
+// dedicated connection, whose session is reset before it returns to the
+// pool so that settings made by the hooks do not leak into other work.
+func (e *Executor) runSessionHooks(ctx context.Context, hooks stepHooks, point migration.HookPoint) error {
+	if hooks == nil {
+		return nil
+	}
+
+	conn, err := e.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Release()
+	defer resetSession(ctx, conn)
+
+	return hooks.run(ctx, conn, point)
+}
+
+// runHooksInTx runs hooks in one transaction with the configured timeouts on
+// a dedicated connection, with the same grace period as a migration.
+func (e *Executor) runHooksInTx(ctx context.Context, hooks []migration.Hook, ev migration.HookEvent) error {
+	conn, err := e.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Release()
+
+	ctx, stop := e.graceContext(ctx, conn.Conn().PgConn().PID())
+	defer stop()
+
+	return ExecInTransaction(ctx, conn, func(tx pgx.Tx) error {
+		if err := e.setTimeouts(ctx, tx); err != nil {
+			return err
+		}
+
+		return runHooks(ctx, tx, hooks, ev)
+	})
+}
@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aqasim81/database-migration-engine/internal/database"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+// defaultGracePeriod is how long an in-flight migration may keep running
+// after the run is interrupted.
+const defaultGracePeriod = 10 * time.Second
+
+// lockReleaseTimeout bounds releasing the advisory lock and cancelling
+// backends, which must still happen after the run's context is cancelled.
+const lockReleaseTimeout = 5 * time.Second
+
+// interruptedError returns an ErrInterrupted error carrying ctx's cause.
+func interruptedError(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", ErrInterrupted, context.Cause(ctx))
+}
+
+// failed reports a failed migration. If ctx was cancelled while the SQL ran,
+// the failure is reported as an interruption and the returned error wraps
+// ErrInterrupted.
+func (e *Executor) failed(ctx context.Context, m *migration.Migration, duration time.Duration, execErr error) error {
+	status := StatusFailed
+
+	if ctx.Err() != nil {
+		status = StatusInterrupted
+		execErr = fmt.Errorf("%w: %w", ErrInterrupted, execErr)
+	}
+
+	e.fireProgress(ProgressEvent{
+		Migration: m,
+		Status:    status,
+		Duration:  duration,
+		Error:     execErr,
+	})
+
+	return execErr
+}
+
+// graceContext returns a context for SQL running on the backend with the
+// given PID. It is not cancelled with ctx; instead, once ctx is done and the
+// grace period expires, the backend's query is cancelled with
+// pg_cancel_backend. A PID of zero cancels the returned context instead,
+// for Go migrations that use the whole pool. A lost lock skips the grace
+// period. The returned stop function must be called before the connection
+// is released.
+func (e *Executor) graceContext(ctx context.Context, pid uint32) (context.Context, func()) {
+	execCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		select {
+		case <-stopCh:
+			return
+		case <-ctx.Done():
+		}
+
+		grace := e.gracePeriod
+		if errors.Is(context.Cause(ctx), database.ErrLockLost) {
+			grace = 0
+		}
+
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+
+		select {
+		case <-stopCh:
+			return
+		case <-timer.C:
+		}
+
+		if pid == 0 || e.cancelBackend(ctx, pid) != nil {
+			// No single backend to cancel, or cancelling it failed: cancel
+			// the context, which closes the connection if a query is running.
+			cancel()
+		}
+	}()
+
+	var once sync.Once
+
+	stop := func() {
+		once.Do(func() {
+			close(stopCh)
+			<-done
+			cancel()
+		})
+	}
+
+	return execCtx, stop
+}
+
+// cancelBackend cancels the query running on the backend with the given PID.
+func (e *Executor) cancelBackend(ctx context.Context, pid uint32) error {
+	cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockReleaseTimeout)
+	defer cancel()
+
+	if _, err := e.pool.Exec(cancelCtx, "SELECT pg_cancel_backend($1)", int32(pid)); err != nil { //nolint:gosec // PIDs fit in int4
+		return fmt.Errorf("cancelling backend %d: %w", pid, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// applyRepeatables runs, in order, each repeatable migration whose checksum
+// differs from the one recorded by its last successful run.
+func (e *Executor) applyRepeatables(ctx context.Context, repeatables []migration.Migration) error {
+	if len(repeatables) == 0 {
+		return nil
+	}
+
+	applied, err := e.tracker.GetRepeatables(ctx)
+	if err != nil {
+		return fmt.Errorf("getting applied repeatable migrations: %w", err)
+	}
+
+	checksums := make(map[string]string, len(applied))
+	for _, a := range applied {
+		checksums[a.Name] = a.Checksum
+	}
+
+	for i := range repeatables {
+		if err := e.applyRepeatable(ctx, &repeatables[i], checksums[repeatables[i].Name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyRepeatable runs a single repeatable migration unless its checksum
+// matches recorded, then records it and fires progress like applyOne.
+func (e *Executor) applyRepeatable(ctx context.Context, r *migration.Migration, recorded string) error {
+	if ctx.Err() != nil {
+		return interruptedError(ctx)
+	}
+
+	if recorded == r.Checksum {
+		e.fireProgress(ProgressEvent{Migration: r, Status: StatusSkipped})
+		return nil
+	}
+
+	if e.dryRun {
+		e.fireProgress(ProgressEvent{Migration: r, Status: StatusDryRun})
+		return nil
+	}
+
+	e.fireProgress(ProgressEvent{Migration: r, Status: StatusStarting})
+
+	start := time.Now()
+	execErr := e.execUp(ctx, r)
+	duration := time.Since(start)
+
+	// The outcome must be recorded even if the run was interrupted meanwhile.
+	recordCtx := context.WithoutCancel(ctx)
+
+	if execErr != nil {
+		execErr = e.failed(ctx, r, duration, execErr)
+
+		return fmt.Errorf("applying repeatable migration %s: %w", r.Name,
+			e.recordFailure(recordCtx, r, tracker.DirectionUp, duration, execErr))
+	}
+
+	if err := e.tracker.RecordRepeatable(recordCtx, tracker.RepeatableParams{
+		Name:       r.Name,
+		Filename:   filepath.Base(r.FilePath),
+		Checksum:   r.Checksum,
+		DurationMs: int(duration.Milliseconds()),
+	}); err != nil {
+		return fmt.Errorf("recording repeatable migration %s: %w", r.Name, err)
+	}
+
+	if err := e.recordHistory(recordCtx, r, tracker.DirectionUp, duration, nil); err != nil {
+		return err
+	}
+
+	e.fireProgress(ProgressEvent{
+		Migration: r,
+		Status:    StatusCompleted,
+		Duration:  duration,
+	})
+
+	return nil
+}
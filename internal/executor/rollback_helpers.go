@@ -27,13 +27,10 @@ func appliedAfterVersion(applied []tracker.AppliedMigration, target string) ([]t
 	var after []tracker.AppliedMigration
 
 	for _, a := range applied {
-		if a.Version == target {
+		switch cmp := migration.CompareVersions(a.Version, target); {
+		case cmp == 0:
 			found = true
-
-			continue
-		}
-
-		if a.Version > target {
+		case cmp > 0:
 			after = append(after, a)
 		}
 	}
@@ -50,6 +47,18 @@ func appliedAfterVersion(applied []tracker.AppliedMigration, target string) ([]t
 	return after, nil
 }
 
+// checkNoBaseline returns ErrBaselineRollback if any target was baselined,
+// before anything is rolled back.
+func checkNoBaseline(targets []tracker.AppliedMigration) error {
+	for _, t := range targets {
+		if t.Status == tracker.StatusBaseline {
+			return fmt.Errorf("migration %s: %w", t.Version, ErrBaselineRollback)
+		}
+	}
+
+	return nil
+}
+
 // buildMigrationLookup creates a version -> Migration map for O(1) lookups.
 func buildMigrationLookup(migrations []migration.Migration) map[string]*migration.Migration {
 	lookup := make(map[string]*migration.Migration, len(migrations))
@@ -59,3 +68,27 @@ func buildMigrationLookup(migrations []migration.Migration) map[string]*migratio
 
 	return lookup
 }
+
+// downChecksum returns the checksum of a migration's down file as written,
+// or an empty string if it has none.
+func downChecksum(m *migration.Migration) string {
+	if m.DownSQL == "" {
+		return ""
+	}
+
+	if m.DownChecksum != "" {
+		return m.DownChecksum
+	}
+
+	return migration.ComputeChecksum(m.DownSQL)
+}
+
+// appliedVersions returns the set of applied migration versions.
+func appliedVersions(applied []tracker.AppliedMigration) map[string]bool {
+	set := make(map[string]bool, len(applied))
+	for _, a := range applied {
+		set[a.Version] = true
+	}
+
+	return set
+}
@@ -112,6 +112,22 @@ func TestAppliedAfterVersion_targetIsFirst_returnsAllOthers(t *testing.T) {
 	assert.Equal(t, "002", got[1].Version)
 }
 
+func TestAppliedAfterVersion_comparesNumerically(t *testing.T) {
+	t.Parallel()
+
+	applied := []tracker.AppliedMigration{
+		{Version: "8"},
+		{Version: "9"},
+		{Version: "10"},
+	}
+
+	got, err := appliedAfterVersion(applied, "9")
+
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "10", got[0].Version)
+}
+
 func TestBuildMigrationLookup_buildsMap(t *testing.T) {
 	t.Parallel()
 
@@ -147,3 +163,17 @@ func TestBuildMigrationLookup_pointsToOriginal(t *testing.T) {
 	// Should point to the original slice element, preserving DownSQL.
 	assert.Equal(t, "DROP TABLE users;", lookup["001"].DownSQL)
 }
+
+func TestDownChecksum(t *testing.T) {
+	t.Parallel()
+
+	withDown := testMigrationWithDown("001", "CREATE TABLE t (id INT);", "DROP TABLE t;")
+	withoutDown := testMigration("002", "CREATE TABLE u (id INT);")
+
+	assert.Equal(t, migration.ComputeChecksum("DROP TABLE t;"), downChecksum(&withDown))
+	assert.Empty(t, downChecksum(&withoutDown))
+
+	// A loaded migration's down checksum covers the template, not the rendered SQL.
+	withDown.DownChecksum = migration.ComputeChecksum("DROP TABLE ${table};")
+	assert.Equal(t, withDown.DownChecksum, downChecksum(&withDown))
+}
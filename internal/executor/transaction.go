@@ -5,13 +5,24 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// TxBeginner starts transactions. Implemented by *pgxpool.Pool and
+// *pgxpool.Conn.
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Execer executes SQL. Implemented by *pgxpool.Pool and *pgxpool.Conn.
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
 // ExecInTransaction runs fn inside a database transaction.
 // On success the transaction is committed; on error it is rolled back.
-func ExecInTransaction(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
-	tx, err := pool.Begin(ctx)
+func ExecInTransaction(ctx context.Context, db TxBeginner, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("beginning transaction: %w", err)
 	}
@@ -29,11 +40,11 @@ func ExecInTransaction(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.T
 	return nil
 }
 
-// ExecWithoutTransaction executes SQL directly on the pool, outside any
+// ExecWithoutTransaction executes SQL directly on the connection, outside any
 // transaction. Required for statements like CREATE INDEX CONCURRENTLY
 // which cannot run inside a transaction block.
-func ExecWithoutTransaction(ctx context.Context, pool *pgxpool.Pool, sql string) error {
-	_, err := pool.Exec(ctx, sql)
+func ExecWithoutTransaction(ctx context.Context, db Execer, sql string) error {
+	_, err := db.Exec(ctx, sql)
 	if err != nil {
 		return fmt.Errorf("executing outside transaction: %w", err)
 	}
@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// File operations performed by ApplyChanges.
+const (
+	ChangeCreate  = "create"  // write a new file
+	ChangeReplace = "replace" // overwrite a file of the other tool that has this tool's name
+	ChangeRemove  = "remove"  // remove a file of the other tool once converted
+)
+
+// fileMode is the mode of converted migration files.
+const fileMode = 0o644
+
+// Change is a file operation putting converted migrations in place.
+type Change struct {
+	Op      string
+	Path    string
+	Content string // written by create and replace
+}
+
+// Changes returns the file operations that write the converted files of ms.
+// With outDir empty, converted files are written beside the files they were
+// converted from, which are then removed; otherwise they are written to
+// outDir and the originals are kept. Files that already exist with the same
+// content are left alone, so a second run changes nothing. Returns
+// ErrFileExists if a converted file would overwrite a different file that is
+// not one of its sources.
+func Changes(ms []Migration, outDir string) ([]Change, error) {
+	var writes, removes []Change
+
+	for _, m := range ms {
+		dir := outDir
+		if dir == "" {
+			dir = m.Dir
+		}
+
+		var targets []string
+
+		for _, f := range m.Files {
+			path := filepath.Join(dir, f.Name)
+			targets = append(targets, path)
+
+			change, err := writeChange(path, f.Content, m.Sources)
+			if err != nil {
+				return nil, err
+			}
+
+			if change != nil {
+				writes = append(writes, *change)
+			}
+		}
+
+		if outDir != "" {
+			continue
+		}
+
+		for _, src := range m.Sources {
+			if !slices.Contains(targets, src) {
+				removes = append(removes, Change{Op: ChangeRemove, Path: src})
+			}
+		}
+	}
+
+	return append(writes, removes...), nil
+}
+
+// writeChange returns the operation writing content to path, or nil if path
+// already holds it.
+func writeChange(path, content string, sources []string) (*Change, error) {
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Change{Op: ChangeCreate, Path: path, Content: content}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	if string(existing) == content {
+		return nil, nil //nolint:nilnil // nil,nil signals "already up to date"
+	}
+
+	if !slices.Contains(sources, path) {
+		return nil, fmt.Errorf("%w: %s differs from the converted migration", ErrFileExists, path)
+	}
+
+	return &Change{Op: ChangeReplace, Path: path, Content: content}, nil
+}
+
+// ApplyChanges performs changes in order.
+func ApplyChanges(changes []Change) error {
+	for _, c := range changes {
+		switch c.Op {
+		case ChangeCreate, ChangeReplace:
+			if err := os.WriteFile(c.Path, []byte(c.Content), fileMode); err != nil { //nolint:gosec // migrations are source files, readable like the rest of the repository
+				return fmt.Errorf("writing %s: %w", c.Path, err)
+			}
+		case ChangeRemove:
+			if err := os.Remove(c.Path); err != nil {
+				return fmt.Errorf("removing %s: %w", c.Path, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package importer
+
+import "errors"
+
+// ErrUnknownTool indicates --from names a migration tool that cannot be imported.
+var ErrUnknownTool = errors.New("unknown migration tool")
+
+// ErrUnsupportedMigration indicates a migration file of the other tool that
+// cannot be converted, such as a goose Go migration or a dotted Flyway version.
+var ErrUnsupportedMigration = errors.New("unsupported migration")
+
+// ErrDuplicateVersion indicates two of the other tool's migrations share a version.
+var ErrDuplicateVersion = errors.New("duplicate migration version")
+
+// ErrSourceTableNotFound indicates the other tool's tracking table does not exist.
+var ErrSourceTableNotFound = errors.New("source tracking table not found")
+
+// ErrTableConflict indicates the other tool's tracking table is also this
+// tool's tracking table, as both default to schema_migrations.
+var ErrTableConflict = errors.New("source table is the tracking table")
+
+// ErrDirtyHistory indicates the other tool recorded a failed migration that
+// must be resolved with that tool before importing.
+var ErrDirtyHistory = errors.New("source history is dirty")
+
+// ErrMissingMigration indicates a version applied by the other tool has no
+// migration file, so no checksum can be recorded for it.
+var ErrMissingMigration = errors.New("applied migration has no file")
+
+// ErrFileExists indicates a converted migration would overwrite a different file.
+var ErrFileExists = errors.New("migration file already exists")
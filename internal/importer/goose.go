@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// gooseMigration is a goose SQL migration split at its annotations.
+type gooseMigration struct {
+	header        []string // comment lines before -- +goose Up
+	up            []string
+	down          []string
+	noTransaction bool
+}
+
+// gooseAnnotation returns the normalized annotation on line, e.g. "up" or
+// "no transaction", or "" if line is not an annotation.
+func gooseAnnotation(line string) string {
+	comment, ok := strings.CutPrefix(strings.TrimSpace(line), "--")
+	if !ok {
+		return ""
+	}
+
+	annotation, ok := strings.CutPrefix(strings.TrimSpace(comment), "+goose ")
+	if !ok {
+		return ""
+	}
+
+	return strings.ToLower(strings.Join(strings.Fields(annotation), " "))
+}
+
+// parseGoose splits a goose SQL migration at its -- +goose Up and
+// -- +goose Down annotations. StatementBegin, StatementEnd and ENVSUB
+// annotations are dropped, since this tool sends each file to the server
+// whole; NO TRANSACTION is kept as the no-transaction directive. Returns
+// ErrUnsupportedMigration for missing, repeated or unknown annotations and
+// SQL before -- +goose Up, with the 1-based line of the problem (zero for a
+// missing annotation).
+func parseGoose(text string) (gooseMigration, int, error) {
+	var (
+		g       gooseMigration
+		current *[]string
+		seen    = make(map[string]bool)
+	)
+
+	for i, line := range strings.Split(text, "\n") {
+		switch annotation := gooseAnnotation(line); annotation {
+		case "":
+			if current == nil {
+				if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+					return gooseMigration{}, i + 1, fmt.Errorf("%w: SQL before -- +goose Up", ErrUnsupportedMigration)
+				}
+
+				g.header = append(g.header, line)
+
+				continue
+			}
+
+			*current = append(*current, line)
+		case "up", "down":
+			if seen[annotation] {
+				return gooseMigration{}, i + 1, fmt.Errorf("%w: -- +goose %s is repeated", ErrUnsupportedMigration, annotation)
+			}
+
+			seen[annotation] = true
+
+			current = &g.up
+			if annotation == "down" {
+				current = &g.down
+			}
+		case "no transaction":
+			g.noTransaction = true
+		case "statementbegin", "statementend", "envsub on", "envsub off":
+		default:
+			return gooseMigration{}, i + 1, fmt.Errorf("%w: unknown goose annotation %q", ErrUnsupportedMigration, annotation)
+		}
+	}
+
+	if !seen["up"] {
+		return gooseMigration{}, 0, fmt.Errorf("%w: no -- +goose Up annotation", ErrUnsupportedMigration)
+	}
+
+	return g, 0, nil
+}
+
+// singleFile returns g as a single-file migration, with the up and down
+// sections as the loader reads them for their checksums. down is empty if
+// the migration has no down SQL.
+func (g gooseMigration) singleFile() (content, up, down string) {
+	up = g.section(g.up)
+	down = g.section(g.down)
+
+	var b strings.Builder
+
+	if header := strings.TrimSpace(strings.Join(g.header, "\n")); header != "" {
+		b.WriteString(header + "\n\n")
+	}
+
+	b.WriteString("-- migrate:up\n" + up + "\n")
+
+	if down != "" {
+		b.WriteString("\n-- migrate:down\n" + down + "\n")
+	}
+
+	return b.String(), up, down
+}
+
+// section returns the trimmed SQL of lines, headed by the no-transaction
+// directive if the migration has one. Empty if lines hold no SQL.
+func (g gooseMigration) section(lines []string) string {
+	sql := strings.TrimSpace(strings.Join(lines, "\n"))
+	if sql == "" || !g.noTransaction {
+		return sql
+	}
+
+	return "-- migrate:no-transaction\n" + sql
+}
@@ -0,0 +1,259 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Querier is the subset of pgx connection and pool methods used to read
+// tracking tables.
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Table names a tracking table. An empty schema resolves the table through
+// the connection's search_path.
+type Table struct {
+	Schema string
+	Name   string
+}
+
+// String returns the quoted, optionally schema-qualified identifier.
+func (t Table) String() string {
+	if t.Schema == "" {
+		return pgx.Identifier{t.Name}.Sanitize()
+	}
+
+	return pgx.Identifier{t.Schema, t.Name}.Sanitize()
+}
+
+// sql substitutes the quoted table identifier for {{table}} in query.
+func (t Table) sql(query string) string {
+	return strings.ReplaceAll(query, "{{table}}", t.String())
+}
+
+// CheckTables returns ErrSourceTableNotFound if source does not exist and
+// ErrTableConflict if it is the tracking table, which golang-migrate and
+// Rails name schema_migrations like this tool does.
+func CheckTables(ctx context.Context, q Querier, source, tracking Table) error {
+	var exists, same bool
+
+	err := q.QueryRow(ctx,
+		`SELECT to_regclass($1) IS NOT NULL, COALESCE(to_regclass($1) = to_regclass($2), false)`,
+		source.String(), tracking.String(),
+	).Scan(&exists, &same)
+	if err != nil {
+		return fmt.Errorf("looking up table %s: %w", source, err)
+	}
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSourceTableNotFound, source)
+	}
+
+	if same {
+		return fmt.Errorf("%w: %s; configure a different tracking_table (or tracking_schema) to import into",
+			ErrTableConflict, source)
+	}
+
+	return nil
+}
+
+// ReadApplied reads the history tool recorded in table.
+func ReadApplied(ctx context.Context, q Querier, tool Tool, table Table) (Applied, error) {
+	switch tool {
+	case GolangMigrate:
+		return readGolangMigrateHistory(ctx, q, table)
+	case Goose:
+		return readGooseHistory(ctx, q, table)
+	case Flyway:
+		return readFlywayHistory(ctx, q, table)
+	case Rails:
+		return readRailsHistory(ctx, q, table)
+	}
+
+	return Applied{}, fmt.Errorf("%w %q", ErrUnknownTool, tool)
+}
+
+// readGolangMigrateHistory reads golang-migrate's single row, the current
+// version: every version up to it is applied, and its file must exist. Returns ErrDirtyHistory if
+// the last migration failed part-way.
+func readGolangMigrateHistory(ctx context.Context, q Querier, table Table) (Applied, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+
+	err := q.QueryRow(ctx, table.sql(`SELECT version, dirty FROM {{table}} LIMIT 1`)).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Applied{}, nil
+	}
+
+	if err != nil {
+		return Applied{}, fmt.Errorf("reading %s: %w", table, err)
+	}
+
+	if dirty {
+		return Applied{}, fmt.Errorf("%w: golang-migrate marks version %d dirty; fix the schema and run golang-migrate's force command first",
+			ErrDirtyHistory, version)
+	}
+
+	current := strconv.FormatInt(version, 10)
+
+	return Applied{Versions: []string{current}, Through: current}, nil
+}
+
+// readGooseHistory replays goose's log of applies (is_applied) and
+// rollbacks. Version 0 is the row goose inserts when creating the table.
+func readGooseHistory(ctx context.Context, q Querier, table Table) (Applied, error) {
+	rows, err := q.Query(ctx, table.sql(`SELECT version_id, is_applied FROM {{table}} ORDER BY id`))
+	if err != nil {
+		return Applied{}, fmt.Errorf("reading %s: %w", table, err)
+	}
+
+	type row struct {
+		version int64
+		applied bool
+	}
+
+	log, err := pgx.CollectRows(rows, func(r pgx.CollectableRow) (row, error) {
+		var x row
+		if scanErr := r.Scan(&x.version, &x.applied); scanErr != nil {
+			return row{}, fmt.Errorf("scanning goose history row: %w", scanErr)
+		}
+
+		return x, nil
+	})
+	if err != nil {
+		return Applied{}, fmt.Errorf("reading %s: %w", table, err)
+	}
+
+	var h replay
+
+	for _, r := range log {
+		if r.version != 0 {
+			h.set(strconv.FormatInt(r.version, 10), r.applied)
+		}
+	}
+
+	return Applied{Versions: h.applied()}, nil
+}
+
+// Flyway history row types that are not versioned migrations.
+const (
+	flywayBaseline = "BASELINE"
+	flywaySchema   = "SCHEMA"
+	flywayDelete   = "DELETE"
+	flywayUndo     = "UNDO_"
+)
+
+// readFlywayHistory replays Flyway's successful versioned rows. A baseline
+// row means every version up to it is applied; undo and delete rows mark a
+// version as no longer applied. Repeatable rows have no version and are
+// skipped: this tool applies them again on the next run. Returns
+// ErrUnsupportedMigration for versions that are not whole numbers.
+func readFlywayHistory(ctx context.Context, q Querier, table Table) (Applied, error) {
+	rows, err := q.Query(ctx, table.sql(
+		`SELECT version, type FROM {{table}} WHERE success AND version IS NOT NULL ORDER BY installed_rank`,
+	))
+	if err != nil {
+		return Applied{}, fmt.Errorf("reading %s: %w", table, err)
+	}
+
+	type row struct {
+		version string
+		typ     string
+	}
+
+	log, err := pgx.CollectRows(rows, func(r pgx.CollectableRow) (row, error) {
+		var x row
+		if scanErr := r.Scan(&x.version, &x.typ); scanErr != nil {
+			return row{}, fmt.Errorf("scanning Flyway history row: %w", scanErr)
+		}
+
+		return x, nil
+	})
+	if err != nil {
+		return Applied{}, fmt.Errorf("reading %s: %w", table, err)
+	}
+
+	var (
+		h       replay
+		through string
+	)
+
+	for _, r := range log {
+		if r.typ == flywaySchema {
+			continue
+		}
+
+		if strings.Trim(r.version, "0123456789") != "" {
+			return Applied{}, fmt.Errorf("%w: Flyway version %s is not a whole number", ErrUnsupportedMigration, r.version)
+		}
+
+		switch {
+		case r.typ == flywayBaseline:
+			through = r.version
+		case r.typ == flywayDelete || strings.HasPrefix(r.typ, flywayUndo):
+			h.set(r.version, false)
+		default:
+			h.set(r.version, true)
+		}
+	}
+
+	return Applied{Versions: h.applied(), Through: through}, nil
+}
+
+// readRailsHistory reads Rails' one row per applied version.
+func readRailsHistory(ctx context.Context, q Querier, table Table) (Applied, error) {
+	rows, err := q.Query(ctx, table.sql(`SELECT version FROM {{table}} ORDER BY version`))
+	if err != nil {
+		return Applied{}, fmt.Errorf("reading %s: %w", table, err)
+	}
+
+	versions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return Applied{}, fmt.Errorf("reading %s: %w", table, err)
+	}
+
+	return Applied{Versions: versions}, nil
+}
+
+// replay tracks whether each version is applied as a log of applies and
+// rollbacks is replayed, keeping first-seen order.
+type replay struct {
+	order []string
+	state map[string]bool
+}
+
+// set records that version was applied or rolled back.
+func (r *replay) set(version string, applied bool) {
+	if r.state == nil {
+		r.state = make(map[string]bool)
+	}
+
+	key := versionKey(version)
+	if _, ok := r.state[key]; !ok {
+		r.order = append(r.order, version)
+	}
+
+	r.state[key] = applied
+}
+
+// applied returns the versions whose last entry applied them.
+func (r *replay) applied() []string {
+	var out []string
+
+	for _, v := range r.order {
+		if r.state[versionKey(v)] {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
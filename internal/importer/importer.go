@@ -0,0 +1,197 @@
+// Package importer moves migration histories recorded by other tools
+// (golang-migrate, goose, Flyway and Rails) onto this tool. It converts the
+// other tool's migration files to this tool's layout and determines which
+// of them its tracking table records as applied, so they can be recorded in
+// schema_migrations without running them again.
+package importer
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// Tool identifies a migration tool whose history can be imported.
+type Tool string
+
+// Supported tools.
+const (
+	GolangMigrate Tool = "golang-migrate"
+	Goose         Tool = "goose"
+	Flyway        Tool = "flyway"
+	Rails         Tool = "rails"
+)
+
+// Tools returns the supported tools in the order they are documented.
+func Tools() []Tool {
+	return []Tool{GolangMigrate, Goose, Flyway, Rails}
+}
+
+// ParseTool returns the tool named s. Returns ErrUnknownTool for any other name.
+func ParseTool(s string) (Tool, error) {
+	if t := Tool(s); slices.Contains(Tools(), t) {
+		return t, nil
+	}
+
+	names := make([]string, 0, len(Tools()))
+	for _, t := range Tools() {
+		names = append(names, string(t))
+	}
+
+	return "", fmt.Errorf("%w %q (supported: %s)", ErrUnknownTool, s, strings.Join(names, ", "))
+}
+
+// DefaultTable returns the name of the tool's tracking table. golang-migrate
+// and Rails share this tool's default, schema_migrations.
+func (t Tool) DefaultTable() string {
+	switch t {
+	case Goose:
+		return "goose_db_version"
+	case Flyway:
+		return "flyway_schema_history"
+	case GolangMigrate, Rails:
+		return tracker.DefaultTable
+	}
+
+	return ""
+}
+
+// HasSQLFiles reports whether the tool's migrations are SQL files that can be
+// converted. Rails migrations are Ruby, so they must be ported by hand.
+func (t Tool) HasSQLFiles() bool {
+	return t != Rails
+}
+
+// File is a migration file in this tool's layout.
+type File struct {
+	Name    string // base name, e.g. V001_create_users.up.sql
+	Content string
+}
+
+// Migration is a migration of the other tool converted to this tool's
+// layout, or one already in it. Checksums are computed exactly as the
+// loader computes them from Files.
+type Migration struct {
+	Version      string
+	Name         string
+	Filename     string   // base name of the up (or single) file, as recorded in schema_migrations
+	Checksum     string   // checksum of the up SQL
+	DownChecksum string   // checksum of the down SQL (empty if none)
+	Dir          string   // directory of the other tool's files
+	Files        []File   // converted files, nil for migrations already in this tool's layout
+	Sources      []string // paths of the other tool's files the migration was converted from
+}
+
+// FromLoaded returns migrations loaded from this tool's migrations
+// directories, which need no conversion.
+func FromLoaded(ms []migration.Migration) []Migration {
+	out := make([]Migration, 0, len(ms))
+
+	for _, m := range ms {
+		out = append(out, Migration{
+			Version:      m.Version,
+			Name:         m.Name,
+			Filename:     filepath.Base(m.FilePath),
+			Checksum:     m.Checksum,
+			DownChecksum: m.DownChecksum,
+		})
+	}
+
+	return out
+}
+
+// Applied is the history read from the other tool's tracking table.
+type Applied struct {
+	Versions []string // versions recorded as applied
+	Through  string   // every version up to and including this one is applied (empty if none)
+}
+
+// Has reports whether version is applied. Versions compare numerically, so
+// "001" matches goose's version_id 1.
+func (a Applied) Has(version string) bool {
+	if a.Through != "" && migration.CompareVersions(version, a.Through) <= 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(a.Versions, func(v string) bool {
+		return migration.CompareVersions(v, version) == 0
+	})
+}
+
+// Entry is a migration in an import plan.
+type Entry struct {
+	Migration
+
+	Applied  bool // the other tool applied it
+	Recorded bool // schema_migrations already records it as applied
+}
+
+// Plan is the result of matching the other tool's history with its migrations.
+type Plan struct {
+	Entries []Entry  // in version order
+	Missing []string // versions applied by the other tool with no migration
+}
+
+// Record returns the entries to record in schema_migrations: those the
+// other tool applied that are not recorded yet.
+func (p Plan) Record() []Entry {
+	var out []Entry
+
+	for _, e := range p.Entries {
+		if e.Applied && !e.Recorded {
+			out = append(out, e)
+		}
+	}
+
+	return out
+}
+
+// BuildPlan matches the applied history with ms, in version order, and
+// marks the entries recorded already. Versions compare numerically, so a
+// recorded "1" matches migration "001". Applied versions without a migration
+// are listed in Missing.
+func BuildPlan(ms []Migration, applied Applied, recorded []tracker.AppliedMigration) Plan {
+	ms = slices.Clone(ms)
+	slices.SortFunc(ms, func(a, b Migration) int {
+		return migration.CompareVersions(a.Version, b.Version)
+	})
+
+	var p Plan
+
+	for _, m := range ms {
+		p.Entries = append(p.Entries, Entry{
+			Migration: m,
+			Applied:   applied.Has(m.Version),
+			Recorded: slices.ContainsFunc(recorded, func(r tracker.AppliedMigration) bool {
+				return migration.CompareVersions(r.Version, m.Version) == 0
+			}),
+		})
+	}
+
+	for _, v := range applied.Versions {
+		if !slices.ContainsFunc(ms, func(m Migration) bool { return migration.CompareVersions(m.Version, v) == 0 }) {
+			p.Missing = append(p.Missing, v)
+		}
+	}
+
+	return p
+}
+
+// Merge returns converted and the migrations of existing whose versions
+// converted lacks, such as SQL ports of Rails migrations or files converted
+// by an earlier import.
+func Merge(converted, existing []Migration) []Migration {
+	out := slices.Clone(converted)
+
+	for _, m := range existing {
+		if !slices.ContainsFunc(converted, func(c Migration) bool { return versionKey(c.Version) == versionKey(m.Version) }) {
+			out = append(out, m)
+		}
+	}
+
+	return out
+}
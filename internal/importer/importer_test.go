@@ -0,0 +1,292 @@
+package importer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/importer"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
+
+// convertInPlace converts the migrations in dir in place and loads the
+// result with the migration loader.
+func convertInPlace(t *testing.T, tool importer.Tool, dir string) ([]importer.Migration, []migration.Migration) {
+	t.Helper()
+
+	ms, err := importer.Read(tool, dir, false)
+	require.NoError(t, err)
+
+	changes, err := importer.Changes(ms, "")
+	require.NoError(t, err)
+	require.NoError(t, importer.ApplyChanges(changes))
+
+	loaded, err := migration.LoadFromDir(dir, migration.WithAllowMixedSchemes(true))
+	require.NoError(t, err)
+
+	return ms, migration.Sort(loaded)
+}
+
+func TestParseTool(t *testing.T) {
+	t.Parallel()
+
+	tool, err := importer.ParseTool("goose")
+	require.NoError(t, err)
+	assert.Equal(t, importer.Goose, tool)
+	assert.Equal(t, "goose_db_version", tool.DefaultTable())
+	assert.Equal(t, "flyway_schema_history", importer.Flyway.DefaultTable())
+	assert.Equal(t, tracker.DefaultTable, importer.Rails.DefaultTable())
+
+	_, err = importer.ParseTool("liquibase")
+	require.ErrorIs(t, err, importer.ErrUnknownTool)
+}
+
+func TestRead_golangMigrate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "000001_create_users.up.sql", "CREATE TABLE users (id INT);\n")
+	writeFile(t, dir, "000001_create_users.down.sql", "DROP TABLE users;\n")
+	writeFile(t, dir, "000002_add_email.up.sql", "ALTER TABLE users ADD COLUMN email TEXT;\n")
+	writeFile(t, dir, "README.md", "# migrations")
+
+	ms, loaded := convertInPlace(t, importer.GolangMigrate, dir)
+
+	require.Len(t, ms, 2)
+	assert.Equal(t, "000001", ms[0].Version)
+	assert.Equal(t, "V000001_create_users.up.sql", ms[0].Filename)
+
+	require.Len(t, loaded, 2)
+	assert.Equal(t, loaded[0].Checksum, ms[0].Checksum)
+	assert.Equal(t, loaded[0].DownChecksum, ms[0].DownChecksum)
+	assert.Equal(t, loaded[1].Checksum, ms[1].Checksum)
+	assert.Empty(t, ms[1].DownChecksum)
+
+	assert.NoFileExists(t, filepath.Join(dir, "000001_create_users.up.sql"))
+	assert.FileExists(t, filepath.Join(dir, "README.md"))
+}
+
+func TestRead_goose(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "00001_create_users.sql", `-- Users.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (id INT);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE users;
+`)
+	writeFile(t, dir, "20240101120000_index.sql", `-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY idx_users_id ON users (id);
+`)
+
+	ms, loaded := convertInPlace(t, importer.Goose, dir)
+
+	require.Len(t, ms, 2)
+	assert.Equal(t, "V00001_create_users.sql", ms[0].Filename)
+	assert.Equal(t, "-- Users.\n\n-- migrate:up\nCREATE TABLE users (id INT);\n\n-- migrate:down\nDROP TABLE users;\n",
+		ms[0].Files[0].Content)
+	assert.Equal(t, "20240101120000_index.sql", ms[1].Filename)
+
+	require.Len(t, loaded, 2)
+	assert.Equal(t, loaded[0].Checksum, ms[0].Checksum)
+	assert.Equal(t, loaded[0].DownChecksum, ms[0].DownChecksum)
+	assert.Equal(t, loaded[1].Checksum, ms[1].Checksum)
+	assert.True(t, loaded[1].Directives.NoTransaction)
+	assert.False(t, loaded[1].HasDown())
+
+	assert.NoFileExists(t, filepath.Join(dir, "00001_create_users.sql"))
+}
+
+func TestRead_gooseUnsupported(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"00001_sql_before_up.sql": "SELECT 1;\n-- +goose Up\nSELECT 2;",
+		"00001_no_up.sql":         "-- +goose Down\nSELECT 1;",
+		"00001_repeated_up.sql":   "-- +goose Up\n-- +goose Up",
+		"00001_unknown.sql":       "-- +goose Up\n-- +goose Envsub Maybe",
+		"00001_seed.go":           "package migrations",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			writeFile(t, dir, name, content)
+
+			_, err := importer.Read(importer.Goose, dir, false)
+			require.ErrorIs(t, err, importer.ErrUnsupportedMigration)
+		})
+	}
+}
+
+func TestRead_duplicateVersion(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "1_a.sql", "-- +goose Up\nSELECT 1;")
+	writeFile(t, dir, "001_b.sql", "-- +goose Up\nSELECT 2;")
+
+	_, err := importer.Read(importer.Goose, dir, false)
+	require.ErrorIs(t, err, importer.ErrDuplicateVersion)
+}
+
+func TestRead_flyway(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	out := t.TempDir()
+	writeFile(t, dir, "V1__create_users.sql", "CREATE TABLE users (id INT);")
+	writeFile(t, dir, "U1__create_users.sql", "DROP TABLE users;")
+	writeFile(t, dir, "V2__add_email.sql", "ALTER TABLE users ADD COLUMN email TEXT;")
+	writeFile(t, dir, "R__views.sql", "CREATE OR REPLACE VIEW v AS SELECT 1;")
+
+	ms, err := importer.Read(importer.Flyway, dir, false)
+	require.NoError(t, err)
+	require.Len(t, ms, 2)
+	assert.Equal(t, "1", ms[0].Version)
+	assert.Equal(t, "V1_create_users.up.sql", ms[0].Filename)
+	assert.Equal(t, migration.ComputeChecksum("DROP TABLE users;"), ms[0].DownChecksum)
+
+	changes, err := importer.Changes(ms, out)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	require.NoError(t, importer.ApplyChanges(changes))
+
+	assert.FileExists(t, filepath.Join(dir, "V1__create_users.sql"), "originals outside the migrations directory are kept")
+	assert.FileExists(t, filepath.Join(out, "V1_create_users.down.sql"))
+
+	again, err := importer.Changes(ms, out)
+	require.NoError(t, err)
+	assert.Empty(t, again, "a second run changes nothing")
+}
+
+func TestRead_flywayDottedVersion(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "V1.1__a.sql", "SELECT 1;")
+
+	_, err := importer.Read(importer.Flyway, dir, false)
+	require.ErrorIs(t, err, importer.ErrUnsupportedMigration)
+}
+
+func TestRead_railsHasNoSQLFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "20240101120000_create_users.rb", "class CreateUsers < ActiveRecord::Migration[7.1]; end")
+
+	ms, err := importer.Read(importer.Rails, dir, false)
+	require.NoError(t, err)
+	assert.Empty(t, ms)
+}
+
+func TestChanges_refusesToOverwriteDifferentFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	out := t.TempDir()
+	writeFile(t, dir, "1_a.up.sql", "SELECT 1;")
+	writeFile(t, out, "V1_a.up.sql", "SELECT 2;")
+
+	ms, err := importer.Read(importer.GolangMigrate, dir, false)
+	require.NoError(t, err)
+
+	_, err = importer.Changes(ms, out)
+	require.ErrorIs(t, err, importer.ErrFileExists)
+}
+
+func TestBuildPlan(t *testing.T) {
+	t.Parallel()
+
+	ms := []importer.Migration{{Version: "003"}, {Version: "001"}, {Version: "002"}}
+
+	tests := []struct {
+		name     string
+		applied  importer.Applied
+		record   []string
+		recorded []string
+		missing  []string
+	}{
+		{
+			name:    "explicit versions compare numerically",
+			applied: importer.Applied{Versions: []string{"1", "3"}},
+			record:  []string{"001", "003"},
+		},
+		{
+			name:    "through marks earlier versions",
+			applied: importer.Applied{Versions: []string{"2"}, Through: "2"},
+			record:  []string{"001", "002"},
+		},
+		{
+			name:     "recorded versions are skipped",
+			applied:  importer.Applied{Versions: []string{"1", "2"}},
+			record:   []string{"002"},
+			recorded: []string{"001"},
+		},
+		{
+			name:     "recorded versions compare numerically",
+			applied:  importer.Applied{Versions: []string{"1", "2"}},
+			record:   []string{"002"},
+			recorded: []string{"1"},
+		},
+		{
+			name:    "applied versions without files are missing",
+			applied: importer.Applied{Versions: []string{"1", "4"}},
+			record:  []string{"001"},
+			missing: []string{"4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var recorded []tracker.AppliedMigration
+			for _, v := range tt.recorded {
+				recorded = append(recorded, tracker.AppliedMigration{Version: v})
+			}
+
+			plan := importer.BuildPlan(ms, tt.applied, recorded)
+
+			var record []string
+			for _, e := range plan.Record() {
+				record = append(record, e.Version)
+			}
+
+			assert.Equal(t, "001", plan.Entries[0].Version)
+			assert.Equal(t, tt.record, record)
+			assert.Equal(t, tt.missing, plan.Missing)
+		})
+	}
+}
+
+func TestMerge_prefersConverted(t *testing.T) {
+	t.Parallel()
+
+	merged := importer.Merge(
+		[]importer.Migration{{Version: "1", Filename: "V1_a.up.sql"}},
+		[]importer.Migration{{Version: "001", Filename: "V001_a.sql"}, {Version: "002", Filename: "V002_b.sql"}},
+	)
+
+	require.Len(t, merged, 2)
+	assert.Equal(t, "V1_a.up.sql", merged[0].Filename)
+	assert.Equal(t, "V002_b.sql", merged[1].Filename)
+}
@@ -0,0 +1,340 @@
+package importer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+// timestampLen is the length of a timestamp version, e.g. 20240101120000.
+const timestampLen = 14
+
+// golangMigratePattern matches golang-migrate files:
+// {version}_{title}.up.sql and {version}_{title}.down.sql.
+var golangMigratePattern = regexp.MustCompile( //nolint:gochecknoglobals // compiled once, used by Read
+	`^(\d+)_(.+)\.(up|down)\.sql$`,
+)
+
+// goosePattern matches goose migrations, {version}_{name}.sql for SQL and
+// {version}_{name}.go for Go.
+var goosePattern = regexp.MustCompile( //nolint:gochecknoglobals // compiled once, used by Read
+	`^(\d+)_(.+)\.(sql|go)$`,
+)
+
+// flywayPattern matches Flyway versioned (V) and undo (U) migrations:
+// V{version}__{description}.sql. Repeatable R__ migrations already match
+// this tool's layout and need no conversion.
+var flywayPattern = regexp.MustCompile( //nolint:gochecknoglobals // compiled once, used by Read
+	`^([VU])(\d[\d._]*)__(.+)\.sql$`,
+)
+
+// Read reads the migration files of tool in dir, and in its subdirectories
+// if recursive, converted to this tool's layout. golang-migrate and Flyway
+// migrations keep their content in up and down files; goose migrations
+// become single-file migrations. Rails migrations are Ruby, so Read returns
+// none for Rails.
+func Read(tool Tool, dir string, recursive bool) ([]Migration, error) {
+	if !tool.HasSQLFiles() {
+		return nil, nil
+	}
+
+	paths, err := listFiles(dir, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tool {
+	case GolangMigrate:
+		return readPairs(paths, parseGolangMigrate)
+	case Flyway:
+		return readPairs(paths, parseFlyway)
+	case Goose:
+		return readGoose(paths)
+	case Rails:
+	}
+
+	return nil, nil
+}
+
+// listFiles returns the sorted paths of the files in dir.
+func listFiles(dir string, recursive bool) ([]string, error) {
+	var paths []string
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		paths = append(paths, path)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations directory %s: %w", dir, err)
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// fileVersion returns the version prefix of a converted file name: a
+// 14-digit version keeps the timestamp form, any other gets a V prefix.
+func fileVersion(version string) string {
+	if len(version) == timestampLen {
+		return version
+	}
+
+	return "V" + version
+}
+
+// pairFile is one file of a migration kept as separate up and down files.
+type pairFile struct {
+	version string
+	name    string
+	down    bool
+}
+
+// parseGolangMigrate parses a golang-migrate file name. Reports false for
+// other files.
+func parseGolangMigrate(filename string) (pairFile, bool, error) {
+	m := golangMigratePattern.FindStringSubmatch(filename)
+	if m == nil {
+		return pairFile{}, false, nil
+	}
+
+	return pairFile{version: m[1], name: m[2], down: m[3] == "down"}, true, nil
+}
+
+// parseFlyway parses a Flyway file name. Reports false for other files, and
+// returns ErrUnsupportedMigration for versions that are not whole numbers,
+// such as 1.1, which this tool cannot order.
+func parseFlyway(filename string) (pairFile, bool, error) {
+	m := flywayPattern.FindStringSubmatch(filename)
+	if m == nil {
+		return pairFile{}, false, nil
+	}
+
+	if strings.ContainsAny(m[2], "._") {
+		return pairFile{}, false, fmt.Errorf("%w: %s: version %s is not a whole number",
+			ErrUnsupportedMigration, filename, m[2])
+	}
+
+	return pairFile{version: m[2], name: m[3], down: m[1] == "U"}, true, nil
+}
+
+// pair is the up and down files of one migration.
+type pair struct {
+	version string
+	name    string
+	up      string
+	down    string
+}
+
+// readPairs reads migrations kept as up and down files, matched by parse,
+// into up and down files of this tool's layout with the same content.
+func readPairs(paths []string, parse func(string) (pairFile, bool, error)) ([]Migration, error) {
+	pairs := make(map[string]*pair)
+
+	var order []string
+
+	for _, path := range paths {
+		f, ok, err := parse(filepath.Base(path))
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		key := versionKey(f.version)
+
+		p, exists := pairs[key]
+		if !exists {
+			p = &pair{version: f.version, name: f.name}
+			pairs[key] = p
+			order = append(order, key)
+		}
+
+		target := &p.up
+		if f.down {
+			target = &p.down
+		}
+
+		if *target != "" || p.name != f.name || p.version != f.version {
+			return nil, fmt.Errorf("%w: %s conflicts with %s", ErrDuplicateVersion, path, firstNonEmpty(*target, p.up, p.down))
+		}
+
+		*target = path
+	}
+
+	ms := make([]Migration, 0, len(order))
+
+	for _, key := range order {
+		m, err := convertPair(pairs[key])
+		if err != nil {
+			return nil, err
+		}
+
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// convertPair reads the files of p into a migration with up and down files
+// named for this tool.
+func convertPair(p *pair) (Migration, error) {
+	if p.up == "" {
+		return Migration{}, fmt.Errorf("%w: %s has no up migration", ErrUnsupportedMigration, p.down)
+	}
+
+	base := fileVersion(p.version) + "_" + p.name
+
+	up, err := readFile(p.up)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	m := Migration{
+		Version:  p.version,
+		Name:     p.name,
+		Filename: base + ".up.sql",
+		Checksum: migration.ComputeChecksum(strings.TrimSpace(up)),
+		Dir:      filepath.Dir(p.up),
+		Files:    []File{{Name: base + ".up.sql", Content: up}},
+		Sources:  []string{p.up},
+	}
+
+	if p.down == "" {
+		return m, nil
+	}
+
+	down, err := readFile(p.down)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	m.DownChecksum = migration.ComputeChecksum(strings.TrimSpace(down))
+	m.Files = append(m.Files, File{Name: base + ".down.sql", Content: down})
+	m.Sources = append(m.Sources, p.down)
+
+	return m, nil
+}
+
+// readGoose reads goose SQL migrations into single-file migrations. Returns
+// ErrUnsupportedMigration for goose Go migrations, which must be ported to
+// this tool's Go migrations by hand.
+func readGoose(paths []string) ([]Migration, error) {
+	seen := make(map[string]string)
+
+	var ms []Migration
+
+	for _, path := range paths {
+		match := goosePattern.FindStringSubmatch(filepath.Base(path))
+		if match == nil {
+			continue
+		}
+
+		if match[3] == "go" {
+			return nil, fmt.Errorf("%w: %s is a goose Go migration; port it to a registered Go migration",
+				ErrUnsupportedMigration, path)
+		}
+
+		key := versionKey(match[1])
+		if prev, ok := seen[key]; ok {
+			return nil, fmt.Errorf("%w: %s conflicts with %s", ErrDuplicateVersion, path, prev)
+		}
+
+		seen[key] = path
+
+		m, err := convertGoose(path, match[1], match[2])
+		if err != nil {
+			return nil, err
+		}
+
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// convertGoose reads the goose migration at path into a single-file
+// migration.
+func convertGoose(path, version, name string) (Migration, error) {
+	text, err := readFile(path)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	g, line, err := parseGoose(text)
+	if err != nil {
+		return Migration{}, fmt.Errorf("%s:%d: %w", path, line, err)
+	}
+
+	filename := fileVersion(version) + "_" + name + ".sql"
+
+	content, up, down := g.singleFile()
+
+	m := Migration{
+		Version:  version,
+		Name:     name,
+		Filename: filename,
+		Checksum: migration.ComputeChecksum(up),
+		Dir:      filepath.Dir(path),
+		Files:    []File{{Name: filename, Content: content}},
+		Sources:  []string{path},
+	}
+
+	if down != "" {
+		m.DownChecksum = migration.ComputeChecksum(down)
+	}
+
+	return m, nil
+}
+
+// versionKey returns the key two versions share if they compare equal, so
+// 001 and 1 are one version.
+func versionKey(version string) string {
+	if trimmed := strings.TrimLeft(version, "0"); trimmed != "" {
+		return trimmed
+	}
+
+	return "0"
+}
+
+// readFile returns the content of the file at path.
+func readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading migration file %s: %w", path, err)
+	}
+
+	return string(data), nil
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,318 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aqasim81/database-migration-engine/internal/parser"
+)
+
+// Diagnostic kinds reported by Validate.
+const (
+	DiagMisnamedFile          = "misnamed-file"
+	DiagOrphanDown            = "orphan-down"
+	DiagDuplicateVersion      = "duplicate-version"
+	DiagMixedSchemes          = "mixed-schemes"
+	DiagEmptyUp               = "empty-up"
+	DiagSyntaxError           = "syntax-error"
+	DiagInvalidDown           = "invalid-down"
+	DiagDuplicateRepeatable   = "duplicate-repeatable"
+	DiagUnresolvedPlaceholder = "unresolved-placeholder"
+	DiagInvalidDirective      = "invalid-directive"
+	DiagInvalidSections       = "invalid-sections"
+)
+
+// Diagnostic is a problem found in a migrations directory.
+// Line is 1-based, or zero when the problem is not tied to a line.
+type Diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// Validate checks every .sql file in dir and reports the problems LoadFromDir
+// would skip, reject, or leave for later: misnamed files, orphan down files,
+// duplicate versions, mixed version schemes, empty up files, and SQL that
+// does not parse. Repeatable migrations (R__<name>.sql) and callback files
+// (beforeEach.sql and the like) are checked too.
+// SQL is parsed with the WithVars values substituted; placeholders without
+// a value and malformed -- migrate: header directives are reported. The error is non-nil only if the directory or a file
+// cannot be read.
+func Validate(dir string, opts ...LoadOption) ([]Diagnostic, error) {
+	return ValidateDirs([]string{dir}, opts...)
+}
+
+// ValidateDirs is Validate for several source directories. Versions must be
+// unique across all of them.
+func ValidateDirs(dirs []string, opts ...LoadOption) ([]Diagnostic, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	src := osSource{}
+
+	paths, err := listFiles(src, dirs, o.recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped, repeatables, diags := scanDiagnostics(paths)
+
+	if !o.allowMixedSchemes {
+		if err := checkSchemes(grouped); err != nil {
+			diags = append(diags, Diagnostic{File: strings.Join(dirs, ","), Kind: DiagMixedSchemes, Message: err.Error()})
+		}
+	}
+
+	for _, mf := range grouped {
+		fileDiags, err := validateFiles(src, mf, o.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		diags = append(diags, fileDiags...)
+	}
+
+	for _, path := range repeatables {
+		stmts, d, err := parseFile(src, path, DiagSyntaxError, o.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case d != nil:
+			diags = append(diags, *d)
+		case stmts == 0:
+			diags = append(diags, Diagnostic{File: path, Kind: DiagEmptyUp, Message: "repeatable migration contains no SQL statements"})
+		}
+	}
+
+	for _, path := range paths {
+		if _, ok := parseHook(filepath.Base(path)); !ok {
+			continue
+		}
+
+		_, d, err := parseFile(src, path, DiagSyntaxError, o.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if d != nil {
+			diags = append(diags, *d)
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].File != diags[j].File {
+			return diags[i].File < diags[j].File
+		}
+
+		return diags[i].Line < diags[j].Line
+	})
+
+	return diags, nil
+}
+
+// scanDiagnostics groups paths like scanEntries and collects the paths of
+// repeatable migrations, but reports misnamed files and duplicate versions
+// or repeatable names instead of skipping or failing on them. Callback files
+// are left to the caller.
+func scanDiagnostics(paths []string) (map[string]*migrationFile, []string, []Diagnostic) {
+	grouped := make(map[string]*migrationFile)
+	repeatableNames := make(map[string]string)
+
+	var (
+		repeatables []string
+		diags       []Diagnostic
+	)
+
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".sql") {
+			continue
+		}
+
+		if _, ok := parseHook(filepath.Base(path)); ok {
+			continue
+		}
+
+		if name, ok := parseRepeatable(filepath.Base(path)); ok {
+			if prev, dup := repeatableNames[name]; dup {
+				diags = append(diags, Diagnostic{
+					File:    path,
+					Kind:    DiagDuplicateRepeatable,
+					Message: fmt.Sprintf("repeatable migration %s is already defined by %s", name, prev),
+				})
+
+				continue
+			}
+
+			repeatableNames[name] = path
+			repeatables = append(repeatables, path)
+
+			continue
+		}
+
+		fn, ok := parseFilename(filepath.Base(path))
+		if !ok {
+			diags = append(diags, Diagnostic{
+				File: path,
+				Kind: DiagMisnamedFile,
+				Message: "does not match V<version>_<name>[.up|.down].sql, <timestamp>_<name>[.up|.down].sql, " +
+					"R__<name>.sql or a callback file such as beforeEach.sql",
+			})
+
+			continue
+		}
+
+		key := normalizeVersion(fn.version)
+
+		mf, ok := grouped[key]
+		if !ok {
+			mf = &migrationFile{version: fn.version, name: fn.name, scheme: fn.scheme}
+			grouped[key] = mf
+		} else if mf.conflicts(fn, path) {
+			diags = append(diags, Diagnostic{
+				File:    path,
+				Kind:    DiagDuplicateVersion,
+				Message: fmt.Sprintf("version %s is already used by %s", fn.version, mf.firstFile()),
+			})
+
+			continue
+		}
+
+		mf.add(fn, path)
+	}
+
+	return grouped, repeatables, diags
+}
+
+// validateFiles parses the up and down files of one migration.
+func validateFiles(src source, mf *migrationFile, vars map[string]string) ([]Diagnostic, error) {
+	if mf.single {
+		return validateSingleFile(src, mf.upFile, vars)
+	}
+
+	if mf.upFile == "" {
+		return []Diagnostic{{
+			File:    mf.downFile,
+			Kind:    DiagOrphanDown,
+			Message: "no matching .up.sql file; the down migration is ignored",
+		}}, nil
+	}
+
+	var diags []Diagnostic
+
+	stmts, d, err := parseFile(src, mf.upFile, DiagSyntaxError, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case d != nil:
+		diags = append(diags, *d)
+	case stmts == 0:
+		diags = append(diags, Diagnostic{File: mf.upFile, Kind: DiagEmptyUp, Message: "up migration contains no SQL statements"})
+	}
+
+	if mf.downFile != "" {
+		_, d, err := parseFile(src, mf.downFile, DiagInvalidDown, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if d != nil {
+			diags = append(diags, *d)
+		}
+	}
+
+	return diags, nil
+}
+
+// validateSingleFile parses the up and down sections of a single-file
+// migration. Line numbers refer to the whole file.
+func validateSingleFile(src source, path string, vars map[string]string) ([]Diagnostic, error) {
+	data, err := src.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading migration file %s: %w", path, err)
+	}
+
+	s, line, err := splitSections(string(data))
+	if err != nil {
+		return []Diagnostic{{File: path, Line: line, Kind: DiagInvalidSections, Message: err.Error()}}, nil
+	}
+
+	var diags []Diagnostic
+
+	stmts, d := checkSQL(path, s.up.sql, s.up.offset, DiagSyntaxError, vars)
+
+	switch {
+	case d != nil:
+		diags = append(diags, *d)
+	case stmts == 0:
+		diags = append(diags, Diagnostic{File: path, Line: s.up.offset, Kind: DiagEmptyUp, Message: "up section contains no SQL statements"})
+	}
+
+	if s.down != nil {
+		if _, d := checkSQL(path, s.down.sql, s.down.offset, DiagInvalidDown, vars); d != nil {
+			diags = append(diags, *d)
+		}
+	}
+
+	return diags, nil
+}
+
+// parseFile renders and parses the SQL in path and returns its statement
+// count, or a diagnostic of the given kind if it does not parse. Line
+// numbers refer to the file as written, as long as no value spans lines.
+func parseFile(src source, path, kind string, vars map[string]string) (int, *Diagnostic, error) {
+	data, err := src.readFile(path)
+	if err != nil {
+		return 0, nil, fmt.Errorf("reading migration file %s: %w", path, err)
+	}
+
+	stmts, d := checkSQL(path, string(data), 0, kind, vars)
+
+	return stmts, d, nil
+}
+
+// checkSQL renders and parses sql, read from path after offset lines, and
+// returns its statement count or a diagnostic of the given kind.
+func checkSQL(path, sql string, offset int, kind string, vars map[string]string) (int, *Diagnostic) {
+	rendered, err := render(sql, vars)
+	if err != nil {
+		return 0, &Diagnostic{
+			File:    path,
+			Line:    offset + placeholderLine(sql, vars),
+			Kind:    DiagUnresolvedPlaceholder,
+			Message: err.Error(),
+		}
+	}
+
+	if _, line, err := parseDirectives(rendered); err != nil {
+		return 0, &Diagnostic{File: path, Line: offset + line, Kind: DiagInvalidDirective, Message: err.Error()}
+	}
+
+	result, err := parser.Parse(rendered)
+	if err != nil {
+		d := &Diagnostic{File: path, Kind: kind, Message: err.Error()}
+
+		var syntaxErr *parser.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			d.Line = offset + syntaxErr.Line
+			d.Message = syntaxErr.Message
+
+			if syntaxErr.Column > 0 {
+				d.Message = fmt.Sprintf("column %d: %s", syntaxErr.Column, syntaxErr.Message)
+			}
+		}
+
+		return 0, d
+	}
+
+	return len(result.Stmts), nil
+}
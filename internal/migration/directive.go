@@ -0,0 +1,148 @@
+package migration
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Header directive names, written as "-- migrate:<name> [value]".
+const (
+	directiveNoTransaction    = "no-transaction"
+	directiveLockTimeout      = "lock-timeout"
+	directiveStatementTimeout = "statement-timeout"
+	directiveRole             = "role"
+)
+
+// Directives are per-migration settings declared in the header of a
+// migration file, the comment lines before its first statement:
+//
+//	-- migrate:no-transaction
+//	-- migrate:lock-timeout 2s
+//	-- migrate:statement-timeout 0
+//	-- migrate:role app_owner
+//
+// Timeouts override the configured ones for this migration only; zero
+// disables the timeout.
+type Directives struct {
+	NoTransaction    bool           // run outside a transaction, like CREATE INDEX CONCURRENTLY
+	LockTimeout      *time.Duration // nil uses the configured lock_timeout
+	StatementTimeout *time.Duration // nil uses the configured statement_timeout
+	Role             string         // role the migration runs as (SET ROLE), empty for the connecting role
+}
+
+// IsZero reports whether no directive is set.
+func (d Directives) IsZero() bool {
+	return !d.NoTransaction && d.LockTimeout == nil && d.StatementTimeout == nil && d.Role == ""
+}
+
+// String returns the directives as written, without the "-- migrate:"
+// prefix, e.g. "no-transaction, lock-timeout 2s". Empty if none is set.
+func (d Directives) String() string {
+	var parts []string
+
+	if d.NoTransaction {
+		parts = append(parts, directiveNoTransaction)
+	}
+
+	if d.LockTimeout != nil {
+		parts = append(parts, directiveLockTimeout+" "+d.LockTimeout.String())
+	}
+
+	if d.StatementTimeout != nil {
+		parts = append(parts, directiveStatementTimeout+" "+d.StatementTimeout.String())
+	}
+
+	if d.Role != "" {
+		parts = append(parts, directiveRole+" "+d.Role)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// parseDirectives parses the -- migrate: directives in the header of sql:
+// its leading blank and "--" comment lines. Directives after the first
+// statement are ordinary comments. Returns ErrInvalidDirective for unknown
+// or repeated directives and missing or malformed values, with the 1-based
+// line of the offending directive.
+func parseDirectives(sql string) (Directives, int, error) {
+	var d Directives
+
+	seen := make(map[string]bool)
+
+	for i, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		comment, ok := strings.CutPrefix(line, "--")
+		if !ok {
+			break
+		}
+
+		directive, ok := strings.CutPrefix(strings.TrimSpace(comment), "migrate:")
+		if !ok {
+			continue
+		}
+
+		name, value, _ := strings.Cut(directive, " ")
+		value = strings.TrimSpace(value)
+
+		if seen[name] {
+			return Directives{}, i + 1, fmt.Errorf("%w: %s is repeated", ErrInvalidDirective, name)
+		}
+
+		seen[name] = true
+
+		if err := d.set(name, value); err != nil {
+			return Directives{}, i + 1, err
+		}
+	}
+
+	return d, 0, nil
+}
+
+// set applies one directive to d.
+func (d *Directives) set(name, value string) error {
+	switch name {
+	case directiveNoTransaction:
+		if value != "" {
+			return fmt.Errorf("%w: %s takes no value", ErrInvalidDirective, name)
+		}
+
+		d.NoTransaction = true
+	case directiveLockTimeout, directiveStatementTimeout:
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return fmt.Errorf("%w: %s needs a duration such as 2s or 0, got %q", ErrInvalidDirective, name, value)
+		}
+
+		if name == directiveLockTimeout {
+			d.LockTimeout = &timeout
+		} else {
+			d.StatementTimeout = &timeout
+		}
+	case directiveRole:
+		if value == "" || strings.ContainsAny(value, " \t") {
+			return fmt.Errorf("%w: %s needs a role name, got %q", ErrInvalidDirective, name, value)
+		}
+
+		d.Role = value
+	default:
+		return fmt.Errorf("%w: unknown directive %q", ErrInvalidDirective, name)
+	}
+
+	return nil
+}
+
+// parseFileDirectives parses the directives of the SQL read from path,
+// naming the file and line in errors.
+func parseFileDirectives(path, sql string) (Directives, error) {
+	d, line, err := parseDirectives(sql)
+	if err != nil {
+		return Directives{}, fmt.Errorf("%s:%d: %w", path, line, err)
+	}
+
+	return d, nil
+}
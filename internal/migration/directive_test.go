@@ -0,0 +1,88 @@
+package migration_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+func TestLoadFromFS_parsesHeaderDirectives(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"V001_index.up.sql": {Data: []byte(`-- Adds the email index.
+-- migrate:no-transaction
+-- migrate:lock-timeout 2s
+--migrate:statement-timeout 0
+-- migrate:role ${owner}
+
+CREATE INDEX CONCURRENTLY idx_users_email ON users (email);
+-- migrate:role ignored_after_first_statement`)},
+		"V001_index.down.sql": {Data: []byte("-- migrate:no-transaction\nDROP INDEX CONCURRENTLY idx_users_email;")},
+		"V002_plain.up.sql":   {Data: []byte("SELECT 1;")},
+	}
+
+	ms, err := migration.LoadFromFS(fsys, ".", migration.WithVars(map[string]string{"owner": "app_owner"}))
+	require.NoError(t, err)
+
+	ms = migration.Sort(ms)
+	require.Len(t, ms, 2)
+
+	d := ms[0].Directives
+	assert.True(t, d.NoTransaction)
+	require.NotNil(t, d.LockTimeout)
+	assert.Equal(t, 2*time.Second, *d.LockTimeout)
+	require.NotNil(t, d.StatementTimeout)
+	assert.Zero(t, *d.StatementTimeout)
+	assert.Equal(t, "app_owner", d.Role)
+	assert.Equal(t, "no-transaction, lock-timeout 2s, statement-timeout 0s, role app_owner", d.String())
+
+	assert.Equal(t, migration.Directives{NoTransaction: true}, ms[0].DownDirectives)
+	assert.True(t, ms[1].Directives.IsZero())
+	assert.Empty(t, ms[1].Directives.String())
+}
+
+func TestLoadFromFS_invalidDirective(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"unknown", "-- migrate:no-transactions"},
+		{"repeated", "-- migrate:role a\n-- migrate:role b"},
+		{"bad duration", "-- migrate:lock-timeout soon"},
+		{"negative duration", "-- migrate:statement-timeout -1s"},
+		{"missing role", "-- migrate:role"},
+		{"unexpected value", "-- migrate:no-transaction yes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fsys := fstest.MapFS{"V001_a.up.sql": {Data: []byte(tt.header + "\nSELECT 1;")}}
+
+			_, err := migration.LoadFromFS(fsys, ".")
+			require.ErrorIs(t, err, migration.ErrInvalidDirective)
+		})
+	}
+}
+
+func TestValidate_reportsInvalidDirective(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "V001_a.up.sql", "-- Header.\n-- migrate:lock-timeout 2\nSELECT 1;")
+
+	diags, err := migration.Validate(dir)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, migration.DiagInvalidDirective, diags[0].Kind)
+	assert.Equal(t, 2, diags[0].Line)
+}
@@ -0,0 +1,31 @@
+package migration
+
+import "errors"
+
+// ErrVersionNotFound indicates a requested version has no migration file.
+var ErrVersionNotFound = errors.New("migration version not found")
+
+// ErrDuplicateVersion indicates two migration files share a version.
+var ErrDuplicateVersion = errors.New("duplicate migration version")
+
+// ErrMixedVersionSchemes indicates V-prefixed and timestamp versions are
+// mixed in one directory without being explicitly allowed.
+var ErrMixedVersionSchemes = errors.New("mixed migration version schemes")
+
+// ErrInvalidGoMigration indicates a Go migration was registered with a
+// missing or malformed field.
+var ErrInvalidGoMigration = errors.New("invalid Go migration")
+
+// ErrDuplicateRepeatable indicates two repeatable migration files share a name.
+var ErrDuplicateRepeatable = errors.New("duplicate repeatable migration")
+
+// ErrUnresolvedPlaceholder indicates migration SQL contains a ${name}
+// placeholder with no value.
+var ErrUnresolvedPlaceholder = errors.New("unresolved placeholder")
+
+// ErrInvalidDirective indicates a malformed -- migrate: header directive.
+var ErrInvalidDirective = errors.New("invalid migration directive")
+
+// ErrInvalidSections indicates a single-file migration whose -- migrate:up
+// and -- migrate:down sections are missing, repeated or preceded by SQL.
+var ErrInvalidSections = errors.New("invalid migration sections")
@@ -0,0 +1,152 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// goVersionPattern matches the version of a Go migration: digits, as in
+// the V-prefixed or timestamp filename forms.
+var goVersionPattern = regexp.MustCompile(`^\d+$`) //nolint:gochecknoglobals // compiled once
+
+// GoFunc is a migration step written in Go. Exactly one of Tx and Pool must
+// be set.
+type GoFunc struct {
+	// Tx runs inside a transaction with lock_timeout and statement_timeout
+	// set, like a SQL migration. It is committed only if Tx returns nil.
+	Tx func(ctx context.Context, tx pgx.Tx) error
+
+	// Pool runs outside any transaction with the connection pool, for work
+	// that must commit as it goes, such as chunked backfills. It should
+	// return promptly once ctx is cancelled.
+	Pool func(ctx context.Context, pool *pgxpool.Pool) error
+}
+
+// valid reports whether exactly one of Tx and Pool is set.
+func (f *GoFunc) valid() bool {
+	return (f.Tx != nil) != (f.Pool != nil)
+}
+
+// GoMigration describes a Go migration to register.
+type GoMigration struct {
+	Version  string  // digits, e.g. "005" or "20240101120000"
+	Name     string  // e.g. "backfill_password_hashes"
+	Hash     string  // content hash of the migration's logic; change it when the logic changes
+	Up       GoFunc  // required
+	Down     *GoFunc // optional
+	FilePath string  // source file; Register fills in the caller's file if empty
+}
+
+// GoRegistry holds Go migrations keyed by version. Register them from init
+// functions and merge them with SQL migrations using Merge.
+type GoRegistry struct {
+	migrations map[string]Migration // keyed by normalized version
+}
+
+// NewGoRegistry creates an empty GoRegistry.
+func NewGoRegistry() *GoRegistry {
+	return &GoRegistry{migrations: make(map[string]Migration)}
+}
+
+// Register adds a Go migration. Its checksum is derived from gm.Hash, so
+// changing the hash of an applied migration is reported as a checksum
+// mismatch, just like editing an applied SQL file. Returns ErrInvalidGoMigration for missing or
+// malformed fields and ErrDuplicateVersion if the version is taken.
+func (r *GoRegistry) Register(gm GoMigration) error {
+	if err := validateGoMigration(&gm); err != nil {
+		return err
+	}
+
+	key := normalizeVersion(gm.Version)
+	if existing, ok := r.migrations[key]; ok {
+		return fmt.Errorf("%w: Go migrations %s_%s and %s_%s",
+			ErrDuplicateVersion, existing.Version, existing.Name, gm.Version, gm.Name)
+	}
+
+	if gm.FilePath == "" {
+		_, gm.FilePath, _, _ = runtime.Caller(1)
+	}
+
+	up := gm.Up
+	r.migrations[key] = Migration{
+		Version:  gm.Version,
+		Name:     gm.Name,
+		Checksum: ComputeChecksum("go:" + gm.Hash),
+		FilePath: gm.FilePath,
+		UpFunc:   &up,
+		DownFunc: gm.Down,
+	}
+
+	return nil
+}
+
+// Migrations returns the registered migrations, unsorted.
+func (r *GoRegistry) Migrations() []Migration {
+	ms := make([]Migration, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		ms = append(ms, m)
+	}
+
+	return ms
+}
+
+// validateGoMigration checks the required fields of gm.
+func validateGoMigration(gm *GoMigration) error {
+	switch {
+	case !goVersionPattern.MatchString(gm.Version):
+		return fmt.Errorf("%w: version %q must be digits", ErrInvalidGoMigration, gm.Version)
+	case gm.Name == "":
+		return fmt.Errorf("%w: %s has no name", ErrInvalidGoMigration, gm.Version)
+	case gm.Hash == "":
+		return fmt.Errorf("%w: %s_%s has no content hash", ErrInvalidGoMigration, gm.Version, gm.Name)
+	case !gm.Up.valid():
+		return fmt.Errorf("%w: %s_%s up must set exactly one of Tx and Pool", ErrInvalidGoMigration, gm.Version, gm.Name)
+	case gm.Down != nil && !gm.Down.valid():
+		return fmt.Errorf("%w: %s_%s down must set exactly one of Tx and Pool", ErrInvalidGoMigration, gm.Version, gm.Name)
+	}
+
+	return nil
+}
+
+// Merge combines migration sets, such as SQL migrations from LoadFromDir and
+// Go migrations from a GoRegistry, into one unsorted set. Returns
+// ErrDuplicateVersion if a version appears in more than one set, or
+// ErrDuplicateRepeatable if a repeatable migration name does.
+func Merge(sets ...[]Migration) ([]Migration, error) {
+	versions := make(map[string]*Migration)
+	repeatables := make(map[string]*Migration)
+
+	var merged []Migration
+
+	for _, set := range sets {
+		for i := range set {
+			seen, key, dupErr := versions, normalizeVersion(set[i].Version), ErrDuplicateVersion
+			if set[i].Repeatable {
+				seen, key, dupErr = repeatables, set[i].Name, ErrDuplicateRepeatable
+			}
+
+			if prev, ok := seen[key]; ok {
+				return nil, fmt.Errorf("%w: %s and %s", dupErr, describe(prev), describe(&set[i]))
+			}
+
+			seen[key] = &set[i]
+			merged = append(merged, set[i])
+		}
+	}
+
+	return merged, nil
+}
+
+// describe names a migration by its file, or by version and name if it has none.
+func describe(m *Migration) string {
+	if m.FilePath != "" && !m.IsGo() {
+		return m.FilePath
+	}
+
+	return fmt.Sprintf("%s_%s (Go)", m.Version, m.Name)
+}
@@ -0,0 +1,113 @@
+package migration_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+func txFunc(context.Context, pgx.Tx) error { return nil }
+
+func poolFunc(context.Context, *pgxpool.Pool) error { return nil }
+
+func TestGoRegistry_Register(t *testing.T) {
+	t.Parallel()
+
+	r := migration.NewGoRegistry()
+
+	require.NoError(t, r.Register(migration.GoMigration{
+		Version: "002", Name: "backfill", Hash: "v1",
+		Up:   migration.GoFunc{Tx: txFunc},
+		Down: &migration.GoFunc{Tx: txFunc},
+	}))
+
+	ms := r.Migrations()
+	require.Len(t, ms, 1)
+	assert.True(t, ms[0].IsGo())
+	assert.True(t, ms[0].HasDown())
+	assert.Empty(t, ms[0].UpSQL)
+	assert.Equal(t, migration.ComputeChecksum("go:v1"), ms[0].Checksum)
+	assert.Equal(t, "gomigration_test.go", filepath.Base(ms[0].FilePath))
+
+	err := r.Register(migration.GoMigration{Version: "2", Name: "other", Hash: "v1", Up: migration.GoFunc{Tx: txFunc}})
+	require.ErrorIs(t, err, migration.ErrDuplicateVersion)
+}
+
+func TestGoRegistry_Register_invalid(t *testing.T) {
+	t.Parallel()
+
+	up := migration.GoFunc{Tx: txFunc}
+
+	tests := []struct {
+		name string
+		gm   migration.GoMigration
+	}{
+		{"non-numeric version", migration.GoMigration{Version: "V1", Name: "a", Hash: "h", Up: up}},
+		{"missing name", migration.GoMigration{Version: "1", Hash: "h", Up: up}},
+		{"missing hash", migration.GoMigration{Version: "1", Name: "a", Up: up}},
+		{"missing up", migration.GoMigration{Version: "1", Name: "a", Hash: "h"}},
+		{"both tx and pool", migration.GoMigration{Version: "1", Name: "a", Hash: "h", Up: migration.GoFunc{Tx: txFunc, Pool: poolFunc}}},
+		{"empty down", migration.GoMigration{Version: "1", Name: "a", Hash: "h", Up: up, Down: &migration.GoFunc{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := migration.NewGoRegistry().Register(tt.gm)
+			require.ErrorIs(t, err, migration.ErrInvalidGoMigration)
+		})
+	}
+}
+
+func TestMerge_interleavesAndRejectsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	r := migration.NewGoRegistry()
+	require.NoError(t, r.Register(migration.GoMigration{Version: "002", Name: "go", Hash: "h", Up: migration.GoFunc{Tx: txFunc}}))
+
+	sqlMs := []migration.Migration{
+		{Version: "001", Name: "a", FilePath: "V001_a.up.sql"},
+		{Version: "003", Name: "c", FilePath: "V003_c.up.sql"},
+	}
+
+	merged, err := migration.Merge(sqlMs, r.Migrations())
+	require.NoError(t, err)
+
+	sorted := migration.Sort(merged)
+	require.Len(t, sorted, 3)
+	assert.Equal(t, "002", sorted[1].Version)
+	assert.True(t, sorted[1].IsGo())
+
+	_, err = migration.Merge(sqlMs, []migration.Migration{{Version: "1", Name: "dup", FilePath: "V1_dup.up.sql"}})
+	require.ErrorIs(t, err, migration.ErrDuplicateVersion)
+	assert.Contains(t, err.Error(), "V001_a.up.sql and V1_dup.up.sql")
+}
+
+func TestMerge_repeatablesKeyedByName(t *testing.T) {
+	t.Parallel()
+
+	sqlMs := []migration.Migration{
+		{Version: "001", Name: "a", FilePath: "V001_a.up.sql"},
+		{Name: "views", FilePath: "R__views.sql", Repeatable: true},
+	}
+
+	merged, err := migration.Merge(sqlMs, []migration.Migration{
+		{Name: "functions", FilePath: "R__functions.sql", Repeatable: true},
+	})
+	require.NoError(t, err)
+	assert.Len(t, merged, 3)
+
+	_, err = migration.Merge(sqlMs, []migration.Migration{
+		{Name: "views", FilePath: "other/R__views.sql", Repeatable: true},
+	})
+	require.ErrorIs(t, err, migration.ErrDuplicateRepeatable)
+	assert.Contains(t, err.Error(), "R__views.sql and other/R__views.sql")
+}
@@ -0,0 +1,148 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// HookPoint names the point in an apply or rollback at which a hook runs.
+type HookPoint string
+
+// Hook points, in the order they run. The callback file for each is named
+// after it, e.g. beforeEach.sql.
+const (
+	// HookBeforeAll runs once per apply or rollback, under the advisory lock
+	// and before the first migration, in its own transaction.
+	HookBeforeAll HookPoint = "beforeAll"
+
+	// HookBeforeEach runs before every migration that is executed, in the
+	// migration's transaction, so a failure rolls the migration back. For
+	// migrations that run outside a transaction, such as CREATE INDEX
+	// CONCURRENTLY, it runs on the same connection right before them; for
+	// Go migrations using the pool, on a connection of its own. Settings
+	// made by the hook are reset before its connection returns to the pool.
+	HookBeforeEach HookPoint = "beforeEach"
+
+	// HookAfterEach runs after every migration that is executed, like
+	// HookBeforeEach. A failure fails the migration.
+	HookAfterEach HookPoint = "afterEach"
+
+	// HookAfterAll runs once per apply or rollback after the last migration,
+	// in its own transaction, unless a migration failed.
+	HookAfterAll HookPoint = "afterAll"
+)
+
+// hookPoints maps callback filenames to their hook points.
+var hookPoints = map[string]HookPoint{ //nolint:gochecknoglobals // fixed lookup table
+	string(HookBeforeAll) + ".sql":  HookBeforeAll,
+	string(HookBeforeEach) + ".sql": HookBeforeEach,
+	string(HookAfterEach) + ".sql":  HookAfterEach,
+	string(HookAfterAll) + ".sql":   HookAfterAll,
+}
+
+// DB is the part of pgx.Tx, *pgxpool.Conn and *pgxpool.Pool a Go hook can use.
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// HookEvent describes what a hook runs for.
+type HookEvent struct {
+	Point     HookPoint
+	Direction string     // "up" for apply, "down" for rollback
+	Migration *Migration // the migration beforeEach and afterEach run around; nil for beforeAll and afterAll
+}
+
+// HookFunc is a hook written in Go. db is the transaction or connection the
+// hook point runs on.
+type HookFunc func(ctx context.Context, db DB, ev HookEvent) error
+
+// Hook is SQL or Go code run at a fixed point of every apply and rollback,
+// e.g. to set a role before each migration or refresh grants after all of
+// them. Hooks are not tracked: they run every time, even when no migration
+// is pending.
+type Hook struct {
+	Point    HookPoint
+	SQL      string   // contents of the callback file, with ${name} placeholders substituted
+	Func     HookFunc // Go hook, run instead of SQL
+	FilePath string   // path to the callback file (empty for Go hooks)
+}
+
+// Label returns the hook's display name: its callback file, or
+// "Go beforeEach hook" for Go hooks.
+func (h *Hook) Label() string {
+	if h.Func != nil {
+		return "Go " + string(h.Point) + " hook"
+	}
+
+	return h.FilePath
+}
+
+// parseHook returns the hook point of a callback filename. Reports false if
+// filename is not a callback file.
+func parseHook(filename string) (HookPoint, bool) {
+	point, ok := hookPoints[filename]
+
+	return point, ok
+}
+
+// LoadHooks loads the callback files in dirs (beforeAll.sql, beforeEach.sql,
+// afterEach.sql and afterAll.sql). When several directories hold a callback
+// for the same point, all of them run, in directory order. Empty callback
+// files are ignored.
+func LoadHooks(dirs []string, opts ...LoadOption) ([]Hook, error) {
+	return loadHooks(osSource{}, dirs, opts)
+}
+
+// LoadHooksFromFS is LoadHooks for dir within fsys.
+func LoadHooksFromFS(fsys fs.FS, dir string, opts ...LoadOption) ([]Hook, error) {
+	return loadHooks(fsSource{fsys: fsys}, []string{dir}, opts)
+}
+
+// loadHooks reads the callback files in dirs from src.
+func loadHooks(src source, dirs []string, opts []LoadOption) ([]Hook, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	paths, err := listFiles(src, dirs, o.recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	var hooks []Hook
+
+	for _, path := range paths {
+		point, ok := parseHook(filepath.Base(path))
+		if !ok {
+			continue
+		}
+
+		data, err := src.readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading callback file %s: %w", path, err)
+		}
+
+		sql := strings.TrimSpace(string(data))
+		if sql == "" {
+			continue
+		}
+
+		rendered, err := renderFile(path, sql, o.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		hooks = append(hooks, Hook{Point: point, SQL: rendered, FilePath: path})
+	}
+
+	return hooks, nil
+}
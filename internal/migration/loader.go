@@ -2,7 +2,7 @@ package migration
 
 import (
 	"fmt"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -16,68 +16,261 @@ var filenamePattern = regexp.MustCompile( //nolint:gochecknoglobals // compiled
 	`^(?:V(\d+)|(\d{14}))_(.+)\.(up|down)\.sql$`,
 )
 
+// singleFilePattern matches single-file migrations, which hold both
+// directions in -- migrate:up and -- migrate:down sections:
+//
+//	V{version}_{name}.sql   (e.g., V001_create_users.sql)
+//	{timestamp}_{name}.sql  (e.g., 20240101120000_create_users.sql)
+var singleFilePattern = regexp.MustCompile( //nolint:gochecknoglobals // compiled once, used by LoadFromDir
+	`^(?:V(\d+)|(\d{14}))_(.+)\.sql$`,
+)
+
+// LoadOption configures LoadFromDir.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	allowMixedSchemes bool
+	recursive         bool
+	vars              map[string]string
+}
+
+// WithAllowMixedSchemes permits V-prefixed and timestamp versions in the same
+// directory. Sequential versions then sort before all timestamps.
+func WithAllowMixedSchemes(allow bool) LoadOption {
+	return func(o *loadOptions) { o.allowMixedSchemes = allow }
+}
+
+// WithRecursive makes the loader descend into subdirectories of each source
+// directory. Versions must still be unique across the whole tree.
+func WithRecursive(recursive bool) LoadOption {
+	return func(o *loadOptions) { o.recursive = recursive }
+}
+
 // LoadFromDir scans a directory for migration files and returns them as unsorted Migration values.
-// Files that do not match the expected naming pattern are skipped.
-func LoadFromDir(dir string) ([]Migration, error) {
-	entries, err := os.ReadDir(dir)
+// A migration is either a pair of .up.sql and .down.sql files or a single
+// .sql file with -- migrate:up and -- migrate:down sections.
+// Files that do not match the expected naming pattern are skipped. Returns
+// ErrDuplicateVersion if two files share a version under different names,
+// or a single-file migration shares its version with any other file, and
+// ErrMixedVersionSchemes if V-prefixed and timestamp versions are mixed
+// without WithAllowMixedSchemes.
+func LoadFromDir(dir string, opts ...LoadOption) ([]Migration, error) {
+	return LoadFromDirs([]string{dir}, opts...)
+}
+
+// LoadFromDirs loads migrations from several source directories and merges
+// them into one unsorted set. Versions must be unique across all sources;
+// each Migration's FilePath names the file it was read from.
+func LoadFromDirs(dirs []string, opts ...LoadOption) ([]Migration, error) {
+	return load(osSource{}, dirs, opts)
+}
+
+// LoadFromFS loads migrations from dir within fsys, such as an embed.FS
+// compiled into a service binary. Paths use forward slashes; use "." for
+// the root of fsys. FilePath values are paths within fsys.
+func LoadFromFS(fsys fs.FS, dir string, opts ...LoadOption) ([]Migration, error) {
+	return load(fsSource{fsys: fsys}, []string{dir}, opts)
+}
+
+// load reads and pairs the migration files in dirs from src.
+func load(src source, dirs []string, opts []LoadOption) ([]Migration, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	paths, err := listFiles(src, dirs, o.recursive)
 	if err != nil {
-		return nil, fmt.Errorf("reading migrations directory %s: %w", dir, err)
+		return nil, err
+	}
+
+	grouped, err := scanEntries(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	if !o.allowMixedSchemes {
+		if err := checkSchemes(grouped); err != nil {
+			return nil, err
+		}
 	}
 
-	grouped := scanEntries(entries)
+	return buildMigrations(src, grouped, o.vars)
+}
+
+// listFiles returns the paths of all regular files in dirs, descending into
+// subdirectories if recursive is set. Paths are in lexical order per source.
+func listFiles(src source, dirs []string, recursive bool) ([]string, error) {
+	var paths []string
+
+	for _, dir := range dirs {
+		if !recursive {
+			entries, err := src.readDir(dir)
+			if err != nil {
+				return nil, fmt.Errorf("reading migrations directory %s: %w", dir, err)
+			}
+
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					paths = append(paths, src.join(dir, entry.Name()))
+				}
+			}
+
+			continue
+		}
+
+		err := src.walkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() {
+				paths = append(paths, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading migrations directory %s: %w", dir, err)
+		}
+	}
 
-	return buildMigrations(grouped, dir)
+	return paths, nil
 }
 
 // migrationFile is an intermediate struct for pairing up/down files.
 type migrationFile struct {
 	version  string
 	name     string
-	upFile   string // filename only (not full path)
-	downFile string // filename only (not full path)
+	scheme   Scheme
+	upFile   string // path including the source directory; the only file of a single-file migration
+	downFile string // path including the source directory
+	single   bool   // upFile holds both directions in sections
 }
 
-// scanEntries groups directory entries by version+name key.
-func scanEntries(entries []os.DirEntry) map[string]*migrationFile {
-	grouped := make(map[string]*migrationFile)
+// parsedFilename holds the parts of a migration filename.
+type parsedFilename struct {
+	version   string
+	name      string
+	scheme    Scheme
+	direction string // "up" or "down"; empty for single-file migrations
+}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
+// parseFilename splits a migration filename into its parts. Reports false
+// if the name matches neither filenamePattern nor singleFilePattern.
+func parseFilename(filename string) (parsedFilename, bool) {
+	matches := filenamePattern.FindStringSubmatch(filename)
+	if matches == nil {
+		if matches = singleFilePattern.FindStringSubmatch(filename); matches == nil {
+			return parsedFilename{}, false
 		}
 
-		matches := filenamePattern.FindStringSubmatch(entry.Name())
-		if matches == nil {
-			continue
-		}
+		matches = append(matches, "")
+	}
+
+	fn := parsedFilename{version: matches[1], name: matches[3], scheme: SchemeSequential, direction: matches[4]}
+	if fn.version == "" {
+		fn.version, fn.scheme = matches[2], SchemeTimestamp
+	}
+
+	return fn, true
+}
 
-		version := matches[1] // V-prefixed version
-		if version == "" {
-			version = matches[2] // timestamp version
+// add records the file at path, parsed as fn, in mf.
+func (mf *migrationFile) add(fn parsedFilename, path string) {
+	switch fn.direction {
+	case "up":
+		mf.upFile = path
+	case "down":
+		mf.downFile = path
+	default:
+		mf.upFile, mf.single = path, true
+	}
+}
+
+// firstFile returns the up file name, or the down file name for orphans.
+func (mf *migrationFile) firstFile() string {
+	if mf.upFile != "" {
+		return mf.upFile
+	}
+
+	return mf.downFile
+}
+
+// scanEntries groups file paths by numeric version. Files whose versions
+// are numerically equal must share the same version string, name and
+// directory, and each direction may appear only once.
+func scanEntries(paths []string) (map[string]*migrationFile, error) {
+	grouped := make(map[string]*migrationFile)
+
+	for _, path := range paths {
+		fn, ok := parseFilename(filepath.Base(path))
+		if !ok {
+			continue
 		}
 
-		name := matches[3]
-		direction := matches[4]
-		key := version + "_" + name
+		key := normalizeVersion(fn.version)
 
 		mf, ok := grouped[key]
 		if !ok {
-			mf = &migrationFile{version: version, name: name}
+			mf = &migrationFile{version: fn.version, name: fn.name, scheme: fn.scheme}
 			grouped[key] = mf
+		} else if mf.conflicts(fn, path) {
+			return nil, fmt.Errorf("%w: %s and %s", ErrDuplicateVersion, mf.firstFile(), path)
 		}
 
-		if direction == "up" {
-			mf.upFile = entry.Name()
-		} else {
-			mf.downFile = entry.Name()
+		mf.add(fn, path)
+	}
+
+	return grouped, nil
+}
+
+// conflicts reports whether the file at path, parsed as fn, cannot join mf:
+// it names a different migration, lives in another directory, repeats a
+// direction mf already has, or mixes the single-file and two-file forms,
+// which would leave it ambiguous which SQL to run.
+func (mf *migrationFile) conflicts(fn parsedFilename, path string) bool {
+	if mf.version != fn.version || mf.name != fn.name {
+		return true
+	}
+
+	if mf.single || fn.direction == "" {
+		return true
+	}
+
+	if filepath.Dir(mf.firstFile()) != filepath.Dir(path) {
+		return true
+	}
+
+	if fn.direction == "up" {
+		return mf.upFile != ""
+	}
+
+	return mf.downFile != ""
+}
+
+// checkSchemes returns ErrMixedVersionSchemes if grouped contains both
+// V-prefixed and timestamp versions, naming one file of each.
+func checkSchemes(grouped map[string]*migrationFile) error {
+	examples := make(map[Scheme]string)
+
+	for _, mf := range grouped {
+		if cur, ok := examples[mf.scheme]; !ok || mf.firstFile() < cur {
+			examples[mf.scheme] = mf.firstFile()
 		}
 	}
 
-	return grouped
+	if len(examples) < 2 { //nolint:mnd // two schemes exist
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s (%s) and %s (%s)", ErrMixedVersionSchemes,
+		examples[SchemeSequential], SchemeSequential, examples[SchemeTimestamp], SchemeTimestamp)
 }
 
-// buildMigrations reads file contents and constructs Migration values from grouped files.
-func buildMigrations(grouped map[string]*migrationFile, dir string) ([]Migration, error) {
+// buildMigrations reads file contents and constructs Migration values from
+// grouped files, substituting vars into their placeholders.
+func buildMigrations(src source, grouped map[string]*migrationFile, vars map[string]string) ([]Migration, error) {
 	var migrations []Migration
 
 	for _, mf := range grouped {
@@ -85,7 +278,7 @@ func buildMigrations(grouped map[string]*migrationFile, dir string) ([]Migration
 			continue // orphan .down.sql — skip
 		}
 
-		m, err := readMigration(mf, dir)
+		m, err := readMigration(src, mf, vars)
 		if err != nil {
 			return nil, err
 		}
@@ -96,36 +289,56 @@ func buildMigrations(grouped map[string]*migrationFile, dir string) ([]Migration
 	return migrations, nil
 }
 
-// readMigration reads up/down SQL files and builds a Migration.
-func readMigration(mf *migrationFile, dir string) (Migration, error) {
-	upPath := filepath.Join(dir, mf.upFile)
+// readMigration reads up/down SQL files and builds a Migration. Checksums
+// cover the SQL as written; UpSQL and DownSQL have vars substituted.
+func readMigration(src source, mf *migrationFile, vars map[string]string) (Migration, error) {
+	if mf.single {
+		return readSingleFile(src, mf, vars)
+	}
+
+	upPath := mf.upFile
 
-	upData, err := os.ReadFile(upPath)
+	upData, err := src.readFile(upPath)
 	if err != nil {
 		return Migration{}, fmt.Errorf("reading migration file %s: %w", upPath, err)
 	}
 
 	upSQL := strings.TrimSpace(string(upData))
 
-	var downSQL string
+	m := Migration{
+		Version:  mf.version,
+		Name:     mf.name,
+		Checksum: ComputeChecksum(upSQL),
+		FilePath: upPath,
+	}
+
+	if m.UpSQL, err = renderFile(upPath, upSQL, vars); err != nil {
+		return Migration{}, err
+	}
+
+	if m.Directives, err = parseFileDirectives(upPath, m.UpSQL); err != nil {
+		return Migration{}, err
+	}
 
 	if mf.downFile != "" {
-		downPath := filepath.Join(dir, mf.downFile)
+		downPath := mf.downFile
 
-		downData, err := os.ReadFile(downPath)
+		downData, err := src.readFile(downPath)
 		if err != nil {
 			return Migration{}, fmt.Errorf("reading migration file %s: %w", downPath, err)
 		}
 
-		downSQL = strings.TrimSpace(string(downData))
+		downSQL := strings.TrimSpace(string(downData))
+		m.DownChecksum = ComputeChecksum(downSQL)
+
+		if m.DownSQL, err = renderFile(downPath, downSQL, vars); err != nil {
+			return Migration{}, err
+		}
+
+		if m.DownDirectives, err = parseFileDirectives(downPath, m.DownSQL); err != nil {
+			return Migration{}, err
+		}
 	}
 
-	return Migration{
-		Version:  mf.version,
-		Name:     mf.name,
-		UpSQL:    upSQL,
-		DownSQL:  downSQL,
-		Checksum: ComputeChecksum(upSQL),
-		FilePath: upPath,
-	}, nil
+	return m, nil
 }
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -191,6 +192,45 @@ func TestLoadFromDir(t *testing.T) {
 				assert.Equal(t, expected, ms[0].Checksum)
 			},
 		},
+		{
+			name: "duplicate version with different names returns error",
+			setup: func(t *testing.T) string {
+				t.Helper()
+				dir := t.TempDir()
+				writeFile(t, dir, "V001_a.up.sql", "SELECT 1;")
+				writeFile(t, dir, "V001_b.up.sql", "SELECT 2;")
+
+				return dir
+			},
+			wantErr:     true,
+			errContains: "V001_a.up.sql and ",
+		},
+		{
+			name: "numerically equal versions return error",
+			setup: func(t *testing.T) string {
+				t.Helper()
+				dir := t.TempDir()
+				writeFile(t, dir, "V001_a.up.sql", "SELECT 1;")
+				writeFile(t, dir, "V1_a.up.sql", "SELECT 2;")
+
+				return dir
+			},
+			wantErr:     true,
+			errContains: "duplicate migration version",
+		},
+		{
+			name: "mixed version schemes return error",
+			setup: func(t *testing.T) string {
+				t.Helper()
+				dir := t.TempDir()
+				writeFile(t, dir, "V001_a.up.sql", "SELECT 1;")
+				writeFile(t, dir, "20240101120000_b.up.sql", "SELECT 2;")
+
+				return dir
+			},
+			wantErr:     true,
+			errContains: "V001_a.up.sql (sequential) and ",
+		},
 	}
 
 	for _, tt := range tests {
@@ -216,6 +256,127 @@ func TestLoadFromDir(t *testing.T) {
 	}
 }
 
+func TestLoadFromDir_allowMixedSchemes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "V001_a.up.sql", "SELECT 1;")
+	writeFile(t, dir, "20240101120000_b.up.sql", "SELECT 2;")
+
+	ms, err := migration.LoadFromDir(dir, migration.WithAllowMixedSchemes(true))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"001", "20240101120000"}, versions(t, migration.Sort(ms)))
+}
+
+func TestLoadFromDir_errorsAreSentinels(t *testing.T) {
+	t.Parallel()
+
+	dup := t.TempDir()
+	writeFile(t, dup, "V002_a.up.sql", "SELECT 1;")
+	writeFile(t, dup, "V002_b.down.sql", "SELECT 2;")
+
+	_, err := migration.LoadFromDir(dup)
+	require.ErrorIs(t, err, migration.ErrDuplicateVersion)
+
+	mixed := t.TempDir()
+	writeFile(t, mixed, "V002_a.up.sql", "SELECT 1;")
+	writeFile(t, mixed, "20240101120000_b.up.sql", "SELECT 2;")
+
+	_, err = migration.LoadFromDir(mixed)
+	require.ErrorIs(t, err, migration.ErrMixedVersionSchemes)
+}
+
+func TestLoadFromDirs_mergesSources(t *testing.T) {
+	t.Parallel()
+
+	core, billing := t.TempDir(), t.TempDir()
+	writeFile(t, core, "V001_users.up.sql", "CREATE TABLE users (id int);")
+	writeFile(t, billing, "V002_invoices.up.sql", "CREATE TABLE invoices (id int);")
+	writeFile(t, billing, "V002_invoices.down.sql", "DROP TABLE invoices;")
+
+	ms, err := migration.LoadFromDirs([]string{core, billing})
+	require.NoError(t, err)
+
+	byVersion := indexByVersion(t, ms)
+	require.Len(t, byVersion, 2)
+	assert.Equal(t, filepath.Join(core, "V001_users.up.sql"), byVersion["001"].FilePath)
+	assert.Equal(t, filepath.Join(billing, "V002_invoices.up.sql"), byVersion["002"].FilePath)
+	assert.Equal(t, "DROP TABLE invoices;", byVersion["002"].DownSQL)
+}
+
+func TestLoadFromDirs_versionsUniqueAcrossSources(t *testing.T) {
+	t.Parallel()
+
+	core, billing := t.TempDir(), t.TempDir()
+	writeFile(t, core, "V001_users.up.sql", "SELECT 1;")
+	writeFile(t, billing, "V001_users.up.sql", "SELECT 1;")
+
+	_, err := migration.LoadFromDirs([]string{core, billing})
+	require.ErrorIs(t, err, migration.ErrDuplicateVersion)
+	assert.Contains(t, err.Error(), filepath.Join(billing, "V001_users.up.sql"))
+
+	// An up and down pair must live in the same directory.
+	split := t.TempDir()
+	writeFile(t, split, "V001_users.down.sql", "SELECT 1;")
+
+	_, err = migration.LoadFromDirs([]string{core, split})
+	require.ErrorIs(t, err, migration.ErrDuplicateVersion)
+}
+
+func TestLoadFromDir_recursive(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "V001_root.up.sql", "SELECT 1;")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "2024q1", "billing"), 0o755))
+	writeFile(t, filepath.Join(dir, "2024q1", "billing"), "V002_nested.up.sql", "SELECT 2;")
+
+	flat, err := migration.LoadFromDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, flat, 1)
+
+	ms, err := migration.LoadFromDir(dir, migration.WithRecursive(true))
+	require.NoError(t, err)
+
+	byVersion := indexByVersion(t, ms)
+	require.Len(t, byVersion, 2)
+	assert.Equal(t, filepath.Join(dir, "2024q1", "billing", "V002_nested.up.sql"), byVersion["002"].FilePath)
+}
+
+func TestLoadFromFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"db/V001_users.up.sql":         {Data: []byte("CREATE TABLE users (id int);\n")},
+		"db/V001_users.down.sql":       {Data: []byte("DROP TABLE users;")},
+		"db/2024q1/V002_orders.up.sql": {Data: []byte("CREATE TABLE orders (id int);")},
+		"db/README.md":                 {Data: []byte("not a migration")},
+		"other/V003_elsewhere.up.sql":  {Data: []byte("SELECT 1;")},
+	}
+
+	ms, err := migration.LoadFromFS(fsys, "db")
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+	assert.Equal(t, "db/V001_users.up.sql", ms[0].FilePath)
+	assert.Equal(t, "CREATE TABLE users (id int);", ms[0].UpSQL)
+	assert.Equal(t, "DROP TABLE users;", ms[0].DownSQL)
+
+	ms, err = migration.LoadFromFS(fsys, "db", migration.WithRecursive(true))
+	require.NoError(t, err)
+
+	byVersion := indexByVersion(t, ms)
+	require.Len(t, byVersion, 2)
+	assert.Equal(t, "db/2024q1/V002_orders.up.sql", byVersion["002"].FilePath)
+
+	ms, err = migration.LoadFromFS(fsys, ".", migration.WithRecursive(true))
+	require.NoError(t, err)
+	assert.Len(t, ms, 3)
+
+	_, err = migration.LoadFromFS(fsys, "missing")
+	require.ErrorContains(t, err, "reading migrations directory missing")
+}
+
 func writeFile(t *testing.T, dir, name, content string) {
 	t.Helper()
 	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
@@ -231,3 +392,186 @@ func indexByVersion(t *testing.T, ms []migration.Migration) map[string]*migratio
 
 	return index
 }
+
+func TestValidate_reportsProblems(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "V001_ok.up.sql", "CREATE TABLE a (id int);")
+	writeFile(t, dir, "V001_ok.down.sql", "DROP TABLE a;")
+	writeFile(t, dir, "V002_bad.up.sql", "-- header\nSELECT 1;\nCREATE TABLE (id int);")
+	writeFile(t, dir, "V002_bad.down.sql", "DROP TABL b;")
+	writeFile(t, dir, "V003_empty.up.sql", "-- nothing yet\n")
+	writeFile(t, dir, "V004_orphan.down.sql", "DROP TABLE c;")
+	writeFile(t, dir, "V05_dup.up.sql", "SELECT 1;")
+	writeFile(t, dir, "V5_other.up.sql", "SELECT 1;")
+	writeFile(t, dir, "create_users.sql", "SELECT 1;")
+	writeFile(t, dir, "R__views.sql", "CREATE VIEW v AS SELECT 1;")
+	writeFile(t, dir, "R__broken.sql", "CREATE FUNCTION;")
+	writeFile(t, dir, "README.md", "not sql")
+
+	diags, err := migration.Validate(dir)
+	require.NoError(t, err)
+
+	byFile := make(map[string]migration.Diagnostic, len(diags))
+	for _, d := range diags {
+		byFile[filepath.Base(d.File)] = d
+	}
+
+	require.Len(t, diags, 7)
+	assert.Equal(t, migration.DiagSyntaxError, byFile["R__broken.sql"].Kind)
+	assert.Equal(t, migration.DiagSyntaxError, byFile["V002_bad.up.sql"].Kind)
+	assert.Equal(t, 3, byFile["V002_bad.up.sql"].Line)
+	assert.Equal(t, migration.DiagInvalidDown, byFile["V002_bad.down.sql"].Kind)
+	assert.Equal(t, 1, byFile["V002_bad.down.sql"].Line)
+	assert.Equal(t, migration.DiagEmptyUp, byFile["V003_empty.up.sql"].Kind)
+	assert.Equal(t, migration.DiagOrphanDown, byFile["V004_orphan.down.sql"].Kind)
+	assert.Equal(t, migration.DiagDuplicateVersion, byFile["V5_other.up.sql"].Kind)
+	assert.Equal(t, migration.DiagMisnamedFile, byFile["create_users.sql"].Kind)
+}
+
+func TestValidate_cleanDirectory(t *testing.T) {
+	t.Parallel()
+
+	diags, err := migration.Validate(filepath.Join("..", "..", "testdata", "migrations"))
+	require.NoError(t, err)
+	assert.Empty(t, diags)
+}
+
+func TestValidate_mixedSchemes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "V002_a.up.sql", "SELECT 1;")
+	writeFile(t, dir, "20240101120000_b.up.sql", "SELECT 2;")
+
+	diags, err := migration.Validate(dir)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, migration.DiagMixedSchemes, diags[0].Kind)
+
+	diags, err = migration.Validate(dir, migration.WithAllowMixedSchemes(true))
+	require.NoError(t, err)
+	assert.Empty(t, diags)
+}
+
+func TestLoadRepeatables(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "V001_users.up.sql", "CREATE TABLE users (id int);")
+	writeFile(t, dir, "R__user_view.sql", "CREATE OR REPLACE VIEW user_ids AS SELECT id FROM users;\n")
+	writeFile(t, dir, "R__active_users.sql", "CREATE OR REPLACE VIEW active AS SELECT 1;")
+	writeFile(t, dir, "R_missing_underscore.sql", "SELECT 1;")
+
+	rs, err := migration.LoadRepeatables([]string{dir})
+	require.NoError(t, err)
+	require.Len(t, rs, 2)
+
+	assert.Equal(t, "active_users", rs[0].Name)
+	assert.Equal(t, "user_view", rs[1].Name)
+	assert.True(t, rs[1].Repeatable)
+	assert.Empty(t, rs[1].Version)
+	assert.Equal(t, "CREATE OR REPLACE VIEW user_ids AS SELECT id FROM users;", rs[1].UpSQL)
+	assert.Equal(t, migration.ComputeChecksum(rs[1].UpSQL), rs[1].Checksum)
+	assert.Equal(t, filepath.Join(dir, "R__user_view.sql"), rs[1].FilePath)
+	assert.Equal(t, "R__user_view", rs[1].Label())
+
+	ms, err := migration.LoadFromDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, ms, 1, "repeatables are not versioned migrations")
+}
+
+func TestLoadRepeatables_duplicateAcrossDirs(t *testing.T) {
+	t.Parallel()
+
+	a, b := t.TempDir(), t.TempDir()
+	writeFile(t, a, "R__views.sql", "SELECT 1;")
+	writeFile(t, b, "R__views.sql", "SELECT 2;")
+
+	_, err := migration.LoadRepeatables([]string{a, b})
+	require.ErrorIs(t, err, migration.ErrDuplicateRepeatable)
+
+	diags, err := migration.ValidateDirs([]string{a, b})
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, migration.DiagDuplicateRepeatable, diags[0].Kind)
+}
+
+func TestLoadRepeatablesFromFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"db/R__views.sql":        {Data: []byte("SELECT 1;")},
+		"db/nested/R__funcs.sql": {Data: []byte("SELECT 2;")},
+	}
+
+	rs, err := migration.LoadRepeatablesFromFS(fsys, "db")
+	require.NoError(t, err)
+	require.Len(t, rs, 1)
+	assert.Equal(t, "db/R__views.sql", rs[0].FilePath)
+
+	rs, err = migration.LoadRepeatablesFromFS(fsys, "db", migration.WithRecursive(true))
+	require.NoError(t, err)
+	require.Len(t, rs, 2)
+	assert.Equal(t, "funcs", rs[0].Name)
+}
+
+func TestLoadHooks(t *testing.T) {
+	t.Parallel()
+
+	a, b := t.TempDir(), t.TempDir()
+	writeFile(t, a, "V001_users.up.sql", "CREATE TABLE users (id int);")
+	writeFile(t, a, "beforeEach.sql", "SET LOCAL ROLE ${owner};\n")
+	writeFile(t, a, "afterAll.sql", "   \n")
+	writeFile(t, b, "beforeEach.sql", "SELECT 1;")
+	writeFile(t, b, "afterEach.sql", "SELECT 2;")
+	writeFile(t, b, "beforeeach.sql", "SELECT 3;")
+
+	hooks, err := migration.LoadHooks([]string{a, b}, migration.WithVars(map[string]string{"owner": "app_owner"}))
+	require.NoError(t, err)
+	require.Len(t, hooks, 3)
+
+	assert.Equal(t, migration.HookBeforeEach, hooks[0].Point)
+	assert.Equal(t, "SET LOCAL ROLE app_owner;", hooks[0].SQL)
+	assert.Equal(t, filepath.Join(a, "beforeEach.sql"), hooks[0].Label())
+	assert.Equal(t, migration.HookAfterEach, hooks[1].Point)
+	assert.Equal(t, migration.HookBeforeEach, hooks[2].Point)
+	assert.Equal(t, filepath.Join(b, "beforeEach.sql"), hooks[2].FilePath)
+
+	_, err = migration.LoadHooks([]string{a})
+	require.ErrorIs(t, err, migration.ErrUnresolvedPlaceholder)
+
+	ms, err := migration.LoadFromDirs([]string{a, b})
+	require.NoError(t, err)
+	assert.Len(t, ms, 1, "callback files are not migrations")
+}
+
+func TestLoadHooksFromFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"db/beforeAll.sql": {Data: []byte("SELECT 1;")},
+		"db/R__views.sql":  {Data: []byte("SELECT 2;")},
+	}
+
+	hooks, err := migration.LoadHooksFromFS(fsys, "db")
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	assert.Equal(t, migration.HookBeforeAll, hooks[0].Point)
+	assert.Equal(t, "db/beforeAll.sql", hooks[0].FilePath)
+}
+
+func TestValidate_callbackFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "afterAll.sql", "ANALYZE;")
+	writeFile(t, dir, "beforeEach.sql", "SET LOCAL ROLE;")
+
+	diags, err := migration.Validate(dir)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, filepath.Join(dir, "beforeEach.sql"), diags[0].File)
+	assert.Equal(t, migration.DiagSyntaxError, diags[0].Kind)
+}
@@ -5,14 +5,41 @@ import (
 	"encoding/hex"
 )
 
-// Migration represents a single database migration loaded from disk.
+// Migration represents a single database migration loaded from disk, or a
+// Go migration registered with a GoRegistry.
 type Migration struct {
-	Version  string // "001" or "20240101120000" — extracted from filename
-	Name     string // "create_users" — extracted from filename
-	UpSQL    string // Contents of the .up.sql file
-	DownSQL  string // Contents of the .down.sql file (empty if none)
-	Checksum string // SHA-256 hex digest of UpSQL
-	FilePath string // Path to the .up.sql file
+	Version        string     // "001" or "20240101120000" — extracted from filename (empty if Repeatable)
+	Name           string     // "create_users" — extracted from filename
+	UpSQL          string     // Contents of the .up.sql file, with ${name} placeholders substituted
+	DownSQL        string     // Contents of the .down.sql file (empty if none), substituted likewise
+	Checksum       string     // SHA-256 hex digest of the .up.sql file as written, or of a Go migration's hash
+	DownChecksum   string     // SHA-256 hex digest of the .down.sql file as written (empty computes it from DownSQL)
+	FilePath       string     // Path to the .up.sql file, or the .go file that registered it
+	UpFunc         *GoFunc    // Go migration step, run instead of UpSQL (nil for SQL migrations)
+	DownFunc       *GoFunc    // Go rollback step, run instead of DownSQL
+	Repeatable     bool       // R__<name>.sql file, re-applied whenever its checksum changes
+	Directives     Directives // -- migrate: header directives of the .up.sql file
+	DownDirectives Directives // -- migrate: header directives of the .down.sql file
+}
+
+// Label returns the migration's display name: "001_create_users" for
+// versioned migrations and "R__refresh_views" for repeatable ones.
+func (m *Migration) Label() string {
+	if m.Repeatable {
+		return repeatablePrefix + m.Name
+	}
+
+	return m.Version + "_" + m.Name
+}
+
+// IsGo reports whether m is a Go migration.
+func (m *Migration) IsGo() bool {
+	return m.UpFunc != nil
+}
+
+// HasDown reports whether m can be rolled back.
+func (m *Migration) HasDown() bool {
+	return m.DownSQL != "" || m.DownFunc != nil
 }
 
 // ComputeChecksum returns the SHA-256 hex digest of the given SQL string.
@@ -71,3 +71,13 @@ func TestComputeChecksum(t *testing.T) {
 		})
 	}
 }
+
+func TestMigration_Label(t *testing.T) {
+	t.Parallel()
+
+	versioned := migration.Migration{Version: "001", Name: "create_users"}
+	repeatable := migration.Migration{Name: "refresh_views", Repeatable: true}
+
+	assert.Equal(t, "001_create_users", versioned.Label())
+	assert.Equal(t, "R__refresh_views", repeatable.Label())
+}
@@ -0,0 +1,106 @@
+package migration
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// repeatablePrefix starts every repeatable migration filename.
+const repeatablePrefix = "R__"
+
+// repeatablePattern matches repeatable migration files, e.g.
+// R__refresh_views.sql. They have no version and no down file.
+var repeatablePattern = regexp.MustCompile( //nolint:gochecknoglobals // compiled once, used by LoadRepeatables
+	`^R__(.+)\.sql$`,
+)
+
+// parseRepeatable returns the name of a repeatable migration file. Reports
+// false if filename does not match repeatablePattern.
+func parseRepeatable(filename string) (string, bool) {
+	matches := repeatablePattern.FindStringSubmatch(filename)
+	if matches == nil {
+		return "", false
+	}
+
+	return matches[1], true
+}
+
+// LoadRepeatables loads the repeatable migrations (R__<name>.sql) in dirs,
+// sorted by name. They are applied after all versioned migrations, and
+// again whenever their checksum changes, so they suit views, functions and
+// triggers that are edited in place. Returns ErrDuplicateRepeatable if two
+// files share a name.
+func LoadRepeatables(dirs []string, opts ...LoadOption) ([]Migration, error) {
+	return loadRepeatables(osSource{}, dirs, opts)
+}
+
+// LoadRepeatablesFromFS is LoadRepeatables for dir within fsys.
+func LoadRepeatablesFromFS(fsys fs.FS, dir string, opts ...LoadOption) ([]Migration, error) {
+	return loadRepeatables(fsSource{fsys: fsys}, []string{dir}, opts)
+}
+
+// loadRepeatables reads the repeatable migration files in dirs from src.
+func loadRepeatables(src source, dirs []string, opts []LoadOption) ([]Migration, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	paths, err := listFiles(src, dirs, o.recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]string)
+
+	var repeatables []Migration
+
+	for _, path := range paths {
+		name, ok := parseRepeatable(filepath.Base(path))
+		if !ok {
+			continue
+		}
+
+		if prev, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%w: %s and %s", ErrDuplicateRepeatable, prev, path)
+		}
+
+		seen[name] = path
+
+		data, err := src.readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading migration file %s: %w", path, err)
+		}
+
+		sql := strings.TrimSpace(string(data))
+
+		rendered, err := renderFile(path, sql, o.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		directives, err := parseFileDirectives(path, rendered)
+		if err != nil {
+			return nil, err
+		}
+
+		repeatables = append(repeatables, Migration{
+			Name:       name,
+			UpSQL:      rendered,
+			Checksum:   ComputeChecksum(sql),
+			FilePath:   path,
+			Repeatable: true,
+			Directives: directives,
+		})
+	}
+
+	sort.Slice(repeatables, func(i, j int) bool {
+		return repeatables[i].Name < repeatables[j].Name
+	})
+
+	return repeatables, nil
+}
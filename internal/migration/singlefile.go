@@ -0,0 +1,217 @@
+package migration
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Section markers of single-file migrations, after the leading "--".
+const (
+	upMarker   = "migrate:up"
+	downMarker = "migrate:down"
+)
+
+// section is the SQL of one direction of a single-file migration.
+type section struct {
+	sql    string // lines between the marker and the next marker or the end of the file
+	offset int    // number of lines in the file before sql
+}
+
+// sections are the up and down sections of a single-file migration. down
+// is nil if the file has no -- migrate:down marker.
+type sections struct {
+	up   *section
+	down *section
+}
+
+// sectionMarker returns the marker on line, or "" if it is not a marker.
+func sectionMarker(line string) string {
+	comment, ok := strings.CutPrefix(strings.TrimSpace(line), "--")
+	if !ok {
+		return ""
+	}
+
+	switch marker := strings.TrimSpace(comment); marker {
+	case upMarker, downMarker:
+		return marker
+	default:
+		return ""
+	}
+}
+
+// checkPreamble returns ErrInvalidSections if line, which precedes the first
+// marker, is SQL or a -- migrate: directive. Directives apply to one
+// section and belong after its marker; ignoring one here would, for
+// example, run a no-transaction migration inside a transaction.
+func checkPreamble(line string) error {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return nil
+	}
+
+	comment, ok := strings.CutPrefix(trimmed, "--")
+	if !ok {
+		return fmt.Errorf("%w: SQL before the first -- %s or -- %s marker",
+			ErrInvalidSections, upMarker, downMarker)
+	}
+
+	if directive, ok := strings.CutPrefix(strings.TrimSpace(comment), "migrate:"); ok {
+		return fmt.Errorf("%w: -- migrate:%s must follow the -- %s or -- %s marker of its section",
+			ErrInvalidSections, directive, upMarker, downMarker)
+	}
+
+	return nil
+}
+
+// splitSections splits a single-file migration at its -- migrate:up and
+// -- migrate:down markers, in either order. Only blank and comment lines
+// other than -- migrate: directives may precede the first marker. Returns
+// ErrInvalidSections if the up marker is missing, a marker is repeated, or
+// SQL or a directive precedes the first marker, with the 1-based line of the
+// problem (zero for a missing marker).
+func splitSections(text string) (sections, int, error) {
+	lines := strings.Split(text, "\n")
+
+	var (
+		s       sections
+		current *section
+		start   int
+	)
+
+	closeSection := func(end int) {
+		if current != nil {
+			current.sql = strings.Join(lines[start:end], "\n")
+		}
+	}
+
+	for i, line := range lines {
+		marker := sectionMarker(line)
+		if marker == "" {
+			if current == nil {
+				if err := checkPreamble(line); err != nil {
+					return sections{}, i + 1, err
+				}
+			}
+
+			continue
+		}
+
+		target := &s.up
+		if marker == downMarker {
+			target = &s.down
+		}
+
+		if *target != nil {
+			return sections{}, i + 1, fmt.Errorf("%w: -- %s is repeated", ErrInvalidSections, marker)
+		}
+
+		closeSection(i)
+
+		current = &section{offset: i + 1}
+		*target = current
+		start = i + 1
+	}
+
+	closeSection(len(lines))
+
+	if s.up == nil {
+		return sections{}, 0, fmt.Errorf("%w: no -- %s marker", ErrInvalidSections, upMarker)
+	}
+
+	return s, 0, nil
+}
+
+// IsSingleFile reports whether path names a single-file migration, whose
+// up and down SQL are sections of one file.
+func IsSingleFile(path string) bool {
+	fn, ok := parseFilename(filepath.Base(path))
+
+	return ok && fn.direction == ""
+}
+
+// UpSection returns the byte offsets in text, the content of a single-file
+// migration, of its up section: the lines after the -- migrate:up marker,
+// up to the next marker or the end of the file. Returns ErrInvalidSections
+// like the loader.
+func UpSection(text string) (start, end int, err error) {
+	s, line, err := splitSections(text)
+	if err != nil {
+		return 0, 0, fmt.Errorf("line %d: %w", line, err)
+	}
+
+	for range s.up.offset {
+		start += strings.IndexByte(text[start:], '\n') + 1
+	}
+
+	return start, start + len(s.up.sql), nil
+}
+
+// readSingleFile reads a single-file migration. Its checksums cover each
+// section as written, so they match the equivalent two-file migration.
+func readSingleFile(src source, mf *migrationFile, vars map[string]string) (Migration, error) {
+	path := mf.upFile
+
+	data, err := src.readFile(path)
+	if err != nil {
+		return Migration{}, fmt.Errorf("reading migration file %s: %w", path, err)
+	}
+
+	s, line, err := splitSections(string(data))
+	if err != nil {
+		return Migration{}, fmt.Errorf("%s:%d: %w", path, line, err)
+	}
+
+	upSQL := strings.TrimSpace(s.up.sql)
+
+	m := Migration{
+		Version:  mf.version,
+		Name:     mf.name,
+		Checksum: ComputeChecksum(upSQL),
+		FilePath: path,
+	}
+
+	if m.UpSQL, err = renderFile(path, upSQL, vars); err != nil {
+		return Migration{}, err
+	}
+
+	if m.Directives, err = sectionDirectives(path, s.up, m.UpSQL); err != nil {
+		return Migration{}, err
+	}
+
+	if s.down == nil {
+		return m, nil
+	}
+
+	downSQL := strings.TrimSpace(s.down.sql)
+	if downSQL == "" {
+		return m, nil
+	}
+
+	m.DownChecksum = ComputeChecksum(downSQL)
+
+	if m.DownSQL, err = renderFile(path, downSQL, vars); err != nil {
+		return Migration{}, err
+	}
+
+	if m.DownDirectives, err = sectionDirectives(path, s.down, m.DownSQL); err != nil {
+		return Migration{}, err
+	}
+
+	return m, nil
+}
+
+// sectionDirectives parses the header directives of sql, the trimmed and
+// rendered SQL of sec. Line numbers in errors refer to the whole file.
+func sectionDirectives(path string, sec *section, sql string) (Directives, error) {
+	d, line, err := parseDirectives(sql)
+	if err != nil {
+		trimmed := len(sec.sql) - len(strings.TrimLeftFunc(sec.sql, unicode.IsSpace))
+		line += sec.offset + strings.Count(sec.sql[:trimmed], "\n")
+
+		return Directives{}, fmt.Errorf("%s:%d: %w", path, line, err)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,181 @@
+package migration_test
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+func TestLoadFromFS_singleFile(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"V001_users.sql": {Data: []byte(`-- Users table.
+
+-- migrate:up
+CREATE TABLE users (id INT, email TEXT);
+
+-- migrate:down
+DROP TABLE users;
+`)},
+		"20240101120000_index.sql": {Data: []byte(`-- migrate:down
+-- migrate:no-transaction
+DROP INDEX CONCURRENTLY idx_users_email;
+-- migrate:up
+-- migrate:no-transaction
+CREATE INDEX CONCURRENTLY idx_users_email ON users (email);`)},
+		"V002_posts.up.sql": {Data: []byte("CREATE TABLE users (id INT, email TEXT);")},
+	}
+
+	ms, err := migration.LoadFromFS(fsys, ".", migration.WithAllowMixedSchemes(true))
+	require.NoError(t, err)
+
+	ms = migration.Sort(ms)
+	require.Len(t, ms, 3)
+
+	users := ms[0]
+	assert.Equal(t, "001", users.Version)
+	assert.Equal(t, "users", users.Name)
+	assert.Equal(t, "CREATE TABLE users (id INT, email TEXT);", users.UpSQL)
+	assert.Equal(t, "DROP TABLE users;", users.DownSQL)
+	assert.Equal(t, "V001_users.sql", users.FilePath)
+	assert.Equal(t, ms[1].Checksum, users.Checksum, "checksum matches the two-file form")
+	assert.Equal(t, migration.ComputeChecksum("DROP TABLE users;"), users.DownChecksum)
+
+	index := ms[2]
+	assert.Equal(t, "20240101120000", index.Version)
+	assert.Contains(t, index.UpSQL, "CREATE INDEX CONCURRENTLY")
+	assert.True(t, index.Directives.NoTransaction)
+	assert.True(t, index.DownDirectives.NoTransaction)
+}
+
+func TestLoadFromFS_singleFileWithoutDown(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"V001_a.sql": {Data: []byte("-- migrate:up\nSELECT 1;\n-- migrate:down\n")}}
+
+	ms, err := migration.LoadFromFS(fsys, ".")
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+	assert.False(t, ms[0].HasDown())
+}
+
+func TestLoadFromFS_invalidSections(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"missing up":          "SELECT 1;",
+		"only down":           "-- migrate:down\nSELECT 1;",
+		"repeated up":         "-- migrate:up\nSELECT 1;\n-- migrate:up\nSELECT 2;",
+		"SQL before up":       "SELECT 0;\n-- migrate:up\nSELECT 1;",
+		"repeated down":       "-- migrate:up\nSELECT 1;\n-- migrate:down\n-- migrate:down",
+		"directive first":     "-- migrate:no-transaction\nSELECT 1;",
+		"directive before up": "-- migrate:no-transaction\n-- migrate:up\nCREATE INDEX CONCURRENTLY i ON t (a);",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := migration.LoadFromFS(fstest.MapFS{"V001_a.sql": {Data: []byte(content)}}, ".")
+			require.ErrorIs(t, err, migration.ErrInvalidSections)
+		})
+	}
+}
+
+func TestLoadFromFS_singleFileInvalidDirective_reportsFileLine(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"V001_a.sql": {Data: []byte(
+		"-- Header.\n\n-- migrate:up\n\n-- migrate:lock-timeout soon\nSELECT 1;\n" +
+			"-- migrate:down\n-- migrate:role\nSELECT 2;\n",
+	)}}
+
+	_, err := migration.LoadFromFS(fsys, ".")
+	require.ErrorIs(t, err, migration.ErrInvalidDirective)
+	assert.Contains(t, err.Error(), "V001_a.sql:5:")
+
+	fsys["V001_a.sql"] = &fstest.MapFile{Data: []byte("-- migrate:up\nSELECT 1;\n-- migrate:down\n-- migrate:role\nSELECT 2;\n")}
+
+	_, err = migration.LoadFromFS(fsys, ".")
+	require.ErrorIs(t, err, migration.ErrInvalidDirective)
+	assert.Contains(t, err.Error(), "V001_a.sql:4:")
+}
+
+func TestLoadFromFS_singleFileConflictsWithTwoFileForm(t *testing.T) {
+	t.Parallel()
+
+	for _, other := range []string{"V001_a.up.sql", "V001_a.down.sql", "V1_b.sql"} {
+		t.Run(other, func(t *testing.T) {
+			t.Parallel()
+
+			fsys := fstest.MapFS{
+				"V001_a.sql": {Data: []byte("-- migrate:up\nSELECT 1;")},
+				other:        {Data: []byte("-- migrate:up\nSELECT 2;")},
+			}
+
+			_, err := migration.LoadFromFS(fsys, ".")
+			require.ErrorIs(t, err, migration.ErrDuplicateVersion)
+		})
+	}
+}
+
+func TestValidate_singleFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "V001_ok.sql", "-- migrate:up\nSELECT 1;\n-- migrate:down\nSELECT 2;")
+	writeFile(t, dir, "V002_bad_down.sql", "-- migrate:up\nSELECT 1;\n\n-- migrate:down\nDROP TABL b;")
+	writeFile(t, dir, "V003_empty.sql", "-- migrate:up\n-- migrate:down\nSELECT 1;")
+	writeFile(t, dir, "V004_no_marker.sql", "SELECT 1;")
+	writeFile(t, dir, "V005_dup.sql", "-- migrate:up\nSELECT 1;")
+	writeFile(t, dir, "V005_dup.up.sql", "SELECT 1;")
+
+	diags, err := migration.Validate(dir)
+	require.NoError(t, err)
+
+	byFile := make(map[string]migration.Diagnostic, len(diags))
+	for _, d := range diags {
+		byFile[filepath.Base(d.File)] = d
+	}
+
+	require.Len(t, diags, 4)
+	assert.Equal(t, migration.DiagInvalidDown, byFile["V002_bad_down.sql"].Kind)
+	assert.Equal(t, 5, byFile["V002_bad_down.sql"].Line)
+	assert.Equal(t, migration.DiagEmptyUp, byFile["V003_empty.sql"].Kind)
+	assert.Equal(t, migration.DiagInvalidSections, byFile["V004_no_marker.sql"].Kind)
+	assert.Equal(t, migration.DiagDuplicateVersion, byFile["V005_dup.up.sql"].Kind)
+}
+
+func TestIsSingleFile(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, migration.IsSingleFile("migrations/V001_users.sql"))
+	assert.True(t, migration.IsSingleFile("20240101120000_index.sql"))
+	assert.False(t, migration.IsSingleFile("migrations/V001_users.up.sql"))
+	assert.False(t, migration.IsSingleFile("R__views.sql"))
+}
+
+func TestUpSection(t *testing.T) {
+	t.Parallel()
+
+	text := "-- Users.\n-- migrate:up\nCREATE TABLE users (id INT);\n\n-- migrate:down\nDROP TABLE users;\n"
+
+	start, end, err := migration.UpSection(text)
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE users (id INT);\n", text[start:end])
+
+	text = "-- migrate:down\nDROP TABLE users;\n-- migrate:up\nCREATE TABLE users (id INT);\n"
+
+	start, end, err = migration.UpSection(text)
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE users (id INT);\n", text[start:end])
+
+	_, _, err = migration.UpSection("SELECT 1;")
+	require.ErrorIs(t, err, migration.ErrInvalidSections)
+}
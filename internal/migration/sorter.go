@@ -1,16 +1,59 @@
 package migration
 
-import "sort"
+import (
+	"fmt"
+	"sort"
+)
 
-// Sort returns a new slice of migrations sorted by Version in lexicographic order.
-// The sort is stable to preserve insertion order for equal versions.
+// Sort returns a new slice of migrations sorted by numeric Version (see
+// CompareVersions), so V9 sorts before V10. The sort is stable to preserve
+// insertion order for equal versions.
 func Sort(migrations []Migration) []Migration {
 	sorted := make([]Migration, len(migrations))
 	copy(sorted, migrations)
 
 	sort.SliceStable(sorted, func(i, j int) bool {
-		return sorted[i].Version < sorted[j].Version
+		return CompareVersions(sorted[i].Version, sorted[j].Version) < 0
 	})
 
 	return sorted
 }
+
+// UpTo returns the prefix of sorted migrations ending with the target
+// version. Returns ErrVersionNotFound if no migration has that version.
+func UpTo(sorted []Migration, target string) ([]Migration, error) {
+	for i := range sorted {
+		if CompareVersions(sorted[i].Version, target) == 0 {
+			return sorted[:i+1], nil
+		}
+	}
+
+	return nil, fmt.Errorf("version %s: %w", target, ErrVersionNotFound)
+}
+
+// OutOfOrder returns the migrations in sorted that are not applied but sort
+// before the latest applied migration, along with that migration's version.
+// Applied versions with no migration in sorted are ignored.
+func OutOfOrder(sorted []Migration, applied map[string]bool) ([]Migration, string) {
+	last := -1
+
+	for i := range sorted {
+		if applied[sorted[i].Version] {
+			last = i
+		}
+	}
+
+	if last < 0 {
+		return nil, ""
+	}
+
+	var gaps []Migration
+
+	for i := range sorted[:last] {
+		if !applied[sorted[i].Version] {
+			gaps = append(gaps, sorted[i])
+		}
+	}
+
+	return gaps, sorted[last].Version
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/aqasim81/database-migration-engine/internal/migration"
 )
@@ -58,6 +59,16 @@ func TestSort(t *testing.T) {
 			input:    []string{"20240201120000", "20240101120000", "20240301120000"},
 			expected: []string{"20240101120000", "20240201120000", "20240301120000"},
 		},
+		{
+			name:     "numeric versions sort by value",
+			input:    []string{"10", "9", "100", "1"},
+			expected: []string{"1", "9", "10", "100"},
+		},
+		{
+			name:     "sequential versions sort before timestamps",
+			input:    []string{"20240101120000", "002", "001"},
+			expected: []string{"001", "002", "20240101120000"},
+		},
 		{
 			name:     "empty slice returns empty",
 			input:    []string{},
@@ -95,3 +106,84 @@ func TestSort_doesNotMutateOriginal(t *testing.T) {
 
 	assert.Equal(t, original, versions(t, input), "original slice should not be mutated")
 }
+
+func TestUpTo(t *testing.T) {
+	t.Parallel()
+
+	sorted := makeMigrations(t, "001", "002", "003")
+
+	tests := []struct {
+		name     string
+		target   string
+		expected []string
+	}{
+		{name: "first", target: "001", expected: []string{"001"}},
+		{name: "middle", target: "002", expected: []string{"001", "002"}},
+		{name: "last", target: "003", expected: []string{"001", "002", "003"}},
+		{name: "numerically equal", target: "2", expected: []string{"001", "002"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := migration.UpTo(sorted, tt.target)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, versions(t, result))
+		})
+	}
+}
+
+func TestUpTo_unknownVersion_returnsErrVersionNotFound(t *testing.T) {
+	t.Parallel()
+
+	_, err := migration.UpTo(makeMigrations(t, "001", "002"), "004")
+
+	require.ErrorIs(t, err, migration.ErrVersionNotFound)
+	assert.Contains(t, err.Error(), "004")
+}
+
+func TestOutOfOrder(t *testing.T) {
+	t.Parallel()
+
+	sorted := makeMigrations(t, "001", "002", "003", "004")
+
+	tests := []struct {
+		name       string
+		applied    map[string]bool
+		expected   []string
+		wantLatest string
+	}{
+		{name: "nothing applied", applied: map[string]bool{}, expected: []string{}},
+		{
+			name:       "applied prefix has no gaps",
+			applied:    map[string]bool{"001": true, "002": true},
+			expected:   []string{},
+			wantLatest: "002",
+		},
+		{
+			name:       "gap before latest applied",
+			applied:    map[string]bool{"001": true, "003": true},
+			expected:   []string{"002"},
+			wantLatest: "003",
+		},
+		{
+			name:       "applied version missing on disk is ignored",
+			applied:    map[string]bool{"002": true, "999": true},
+			expected:   []string{"001"},
+			wantLatest: "002",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gaps, latest := migration.OutOfOrder(sorted, tt.applied)
+
+			assert.Equal(t, tt.expected, versions(t, gaps))
+			assert.Equal(t, tt.wantLatest, latest)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package migration
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// source abstracts the file system migrations are read from, so the loader
+// works on local directories and on any fs.FS (embed.FS, zip archives,
+// fstest.MapFS) alike.
+type source interface {
+	readDir(dir string) ([]fs.DirEntry, error)
+	readFile(name string) ([]byte, error)
+	walkDir(root string, fn fs.WalkDirFunc) error
+	join(elem ...string) string
+}
+
+// osSource reads from the local file system using OS paths.
+type osSource struct{}
+
+func (osSource) readDir(dir string) ([]fs.DirEntry, error) {
+	return os.ReadDir(dir) //nolint:wrapcheck // callers wrap
+}
+
+func (osSource) readFile(name string) ([]byte, error) {
+	return os.ReadFile(name) //nolint:wrapcheck // callers wrap
+}
+
+func (osSource) walkDir(root string, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, fn) //nolint:wrapcheck // callers wrap
+}
+
+func (osSource) join(elem ...string) string {
+	return filepath.Join(elem...)
+}
+
+// fsSource reads from an fs.FS using slash-separated paths.
+type fsSource struct {
+	fsys fs.FS
+}
+
+func (s fsSource) readDir(dir string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(s.fsys, dir) //nolint:wrapcheck // callers wrap
+}
+
+func (s fsSource) readFile(name string) ([]byte, error) {
+	return fs.ReadFile(s.fsys, name) //nolint:wrapcheck // callers wrap
+}
+
+func (s fsSource) walkDir(root string, fn fs.WalkDirFunc) error {
+	return fs.WalkDir(s.fsys, root, fn) //nolint:wrapcheck // callers wrap
+}
+
+func (fsSource) join(elem ...string) string {
+	return path.Join(elem...)
+}
@@ -0,0 +1,80 @@
+package migration
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// placeholderPattern matches ${name} placeholders in migration SQL. Names
+// start with a letter or underscore and contain letters, digits and
+// underscores.
+var placeholderPattern = regexp.MustCompile( //nolint:gochecknoglobals // compiled once, used by render
+	`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`,
+)
+
+// WithVars sets the values substituted for ${name} placeholders in
+// migration SQL. Checksums are computed over the file as written, so
+// changing a value does not invalidate applied migrations. A placeholder
+// with no value is a load error (ErrUnresolvedPlaceholder).
+func WithVars(vars map[string]string) LoadOption {
+	return func(o *loadOptions) { o.vars = vars }
+}
+
+// render substitutes vars into the ${name} placeholders of sql. Returns
+// ErrUnresolvedPlaceholder naming every placeholder without a value.
+func render(sql string, vars map[string]string) (string, error) {
+	if !strings.Contains(sql, "${") {
+		return sql, nil
+	}
+
+	missing := make(map[string]bool)
+
+	rendered := placeholderPattern.ReplaceAllStringFunc(sql, func(match string) string {
+		name := match[2 : len(match)-1]
+
+		value, ok := vars[name]
+		if !ok {
+			missing[name] = true
+			return match
+		}
+
+		return value
+	})
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, "${"+name+"}")
+		}
+
+		sort.Strings(names)
+
+		return "", fmt.Errorf("%w: %s", ErrUnresolvedPlaceholder, strings.Join(names, ", "))
+	}
+
+	return rendered, nil
+}
+
+// renderFile renders the SQL read from path, naming the file in errors.
+func renderFile(path, sql string, vars map[string]string) (string, error) {
+	rendered, err := render(sql, vars)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	return rendered, nil
+}
+
+// placeholderLine returns the 1-based line of the first placeholder in sql
+// without a value in vars, or zero if there is none.
+func placeholderLine(sql string, vars map[string]string) int {
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(sql, -1) {
+		if _, ok := vars[sql[loc[2]:loc[3]]]; !ok {
+			return strings.Count(sql[:loc[0]], "\n") + 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,98 @@
+package migration_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+func TestWithVars_rendersSQLAndKeepsTemplateChecksum(t *testing.T) {
+	t.Parallel()
+
+	up := "CREATE TABLE ${schema}.users (id int) TABLESPACE ${tablespace};\nGRANT SELECT ON ${schema}.users TO ${app_role};"
+	down := "DROP TABLE ${schema}.users;"
+
+	fsys := fstest.MapFS{
+		"V001_users.up.sql":   {Data: []byte(up)},
+		"V001_users.down.sql": {Data: []byte(down)},
+		"R__grants.sql":       {Data: []byte("GRANT USAGE ON SCHEMA ${schema} TO ${app_role};")},
+	}
+
+	vars := map[string]string{"schema": "billing", "tablespace": "fast_ssd", "app_role": "app_rw"}
+
+	ms, err := migration.LoadFromFS(fsys, ".", migration.WithVars(vars))
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+
+	assert.Equal(t,
+		"CREATE TABLE billing.users (id int) TABLESPACE fast_ssd;\nGRANT SELECT ON billing.users TO app_rw;",
+		ms[0].UpSQL)
+	assert.Equal(t, "DROP TABLE billing.users;", ms[0].DownSQL)
+	assert.Equal(t, migration.ComputeChecksum(up), ms[0].Checksum)
+	assert.Equal(t, migration.ComputeChecksum(down), ms[0].DownChecksum)
+
+	// Other environments render differently but share checksums.
+	vars["schema"] = "billing_staging"
+
+	staging, err := migration.LoadFromFS(fsys, ".", migration.WithVars(vars))
+	require.NoError(t, err)
+	assert.Contains(t, staging[0].UpSQL, "billing_staging.users")
+	assert.Equal(t, ms[0].Checksum, staging[0].Checksum)
+
+	rs, err := migration.LoadRepeatablesFromFS(fsys, ".", migration.WithVars(vars))
+	require.NoError(t, err)
+	require.Len(t, rs, 1)
+	assert.Equal(t, "GRANT USAGE ON SCHEMA billing_staging TO app_rw;", rs[0].UpSQL)
+}
+
+func TestWithVars_unresolvedPlaceholder_isLoadError(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"V001_users.up.sql": {Data: []byte("GRANT SELECT ON users TO ${app_role}, ${audit_role}, ${app_role};")},
+	}
+
+	_, err := migration.LoadFromFS(fsys, ".", migration.WithVars(map[string]string{"unused": "x"}))
+	require.ErrorIs(t, err, migration.ErrUnresolvedPlaceholder)
+	assert.ErrorContains(t, err, "V001_users.up.sql")
+	assert.ErrorContains(t, err, "${app_role}, ${audit_role}")
+
+	_, err = migration.LoadFromFS(fsys, ".")
+	require.ErrorIs(t, err, migration.ErrUnresolvedPlaceholder)
+
+	_, err = migration.LoadRepeatablesFromFS(fstest.MapFS{"R__v.sql": {Data: []byte("SELECT '${x}';")}}, ".")
+	require.ErrorIs(t, err, migration.ErrUnresolvedPlaceholder)
+}
+
+func TestWithVars_sqlWithoutPlaceholdersIsUnchanged(t *testing.T) {
+	t.Parallel()
+
+	sql := "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1 $$ LANGUAGE sql;\nSELECT '$1', '{x}', '$ {y}';"
+
+	ms, err := migration.LoadFromFS(fstest.MapFS{"V001_f.up.sql": {Data: []byte(sql)}}, ".")
+	require.NoError(t, err)
+	assert.Equal(t, sql, ms[0].UpSQL)
+}
+
+func TestValidate_rendersPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "V001_users.up.sql", "CREATE TABLE ${schema}.users (id int);")
+	writeFile(t, dir, "V002_grant.up.sql", "-- grants\nGRANT SELECT ON users TO ${app_role};")
+
+	diags, err := migration.Validate(dir, migration.WithVars(map[string]string{"schema": "billing"}))
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	assert.Equal(t, migration.DiagUnresolvedPlaceholder, diags[0].Kind)
+	assert.Equal(t, 2, diags[0].Line)
+	assert.Contains(t, diags[0].Message, "${app_role}")
+
+	diags, err = migration.Validate(dir, migration.WithVars(map[string]string{"schema": "billing", "app_role": "app_rw"}))
+	require.NoError(t, err)
+	assert.Empty(t, diags)
+}
@@ -0,0 +1,129 @@
+package migration
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scheme identifies how a migration's version is written in its filename.
+type Scheme int
+
+// Version schemes recognised by the loader.
+const (
+	SchemeSequential Scheme = iota // V{digits}_name, e.g. V001_create_users
+	SchemeTimestamp                // {14 digits}_name, e.g. 20240101120000_create_users
+)
+
+// String returns a human-readable name for the scheme.
+func (s Scheme) String() string {
+	if s == SchemeTimestamp {
+		return "timestamp"
+	}
+
+	return "sequential"
+}
+
+// CompareVersions compares two numeric version strings by value, so that
+// "9" sorts before "10" and "001" equals "1". Returns -1, 0 or +1.
+// Versions of any length are supported.
+func CompareVersions(a, b string) int {
+	a = normalizeVersion(a)
+	b = normalizeVersion(b)
+
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+// normalizeVersion strips leading zeros, keeping a single "0" for zero.
+func normalizeVersion(v string) string {
+	trimmed := strings.TrimLeft(v, "0")
+	if trimmed == "" && v != "" {
+		return "0"
+	}
+
+	return trimmed
+}
+
+// timestampLayout is the time layout of SchemeTimestamp versions.
+const timestampLayout = "20060102150405"
+
+// sequentialWidth is the number of digits of the first sequential version.
+const sequentialWidth = 3
+
+// Filename returns the name of a migration file in the scheme, e.g.
+// V002_add_email.up.sql for direction "up", or V002_add_email.sql for an
+// empty direction.
+func (s Scheme) Filename(version, name, direction string) string {
+	stem := version + "_" + name
+	if s == SchemeSequential {
+		stem = "V" + stem
+	}
+
+	if direction == "" {
+		return stem + ".sql"
+	}
+
+	return stem + "." + direction + ".sql"
+}
+
+// NextVersions returns n versions that sort after every migration in
+// migrations, in the scheme of the latest one. Sequential versions count
+// up from it with the same zero padding; timestamp versions start at now,
+// one second apart, or a second after the latest if that is not in the
+// past. With no migrations, versions are sequential from 001.
+func NextVersions(migrations []Migration, n int, now time.Time) (Scheme, []string) {
+	var latest *Migration
+
+	for i := range migrations {
+		m := &migrations[i]
+		if m.Repeatable || m.IsGo() {
+			continue
+		}
+
+		if latest == nil || CompareVersions(m.Version, latest.Version) > 0 {
+			latest = m
+		}
+	}
+
+	scheme, width, last := SchemeSequential, sequentialWidth, uint64(0)
+
+	if latest != nil {
+		if fn, ok := parseFilename(filepath.Base(latest.FilePath)); ok {
+			scheme = fn.scheme
+		}
+
+		width = len(latest.Version)
+		last, _ = strconv.ParseUint(latest.Version, 10, 64)
+	}
+
+	versions := make([]string, n)
+
+	if scheme == SchemeTimestamp {
+		next := now.UTC().Truncate(time.Second)
+		if prev, err := time.Parse(timestampLayout, latest.Version); err == nil && !next.After(prev) {
+			next = prev.Add(time.Second)
+		}
+
+		for i := range versions {
+			versions[i] = next.Add(time.Duration(i) * time.Second).Format(timestampLayout)
+		}
+
+		return scheme, versions
+	}
+
+	for i := range versions {
+		versions[i] = fmt.Sprintf("%0*d", width, last+uint64(i)+1) //nolint:gosec // i is a small non-negative index
+	}
+
+	return scheme, versions
+}
@@ -0,0 +1,91 @@
+package migration_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"9", "10", -1},
+		{"10", "9", 1},
+		{"001", "1", 0},
+		{"001", "002", -1},
+		{"0", "000", 0},
+		{"0", "1", -1},
+		{"999", "20240101120000", -1},
+		{"20240101120000", "20240101120001", -1},
+		{"123456789012345678901234567890", "123456789012345678901234567891", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_vs_"+tt.b, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, migration.CompareVersions(tt.a, tt.b))
+		})
+	}
+}
+
+func TestScheme_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "sequential", migration.SchemeSequential.String())
+	assert.Equal(t, "timestamp", migration.SchemeTimestamp.String())
+}
+
+func TestScheme_Filename(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "V002_add_email.up.sql", migration.SchemeSequential.Filename("002", "add_email", "up"))
+	assert.Equal(t, "20240101120000_add_email.down.sql", migration.SchemeTimestamp.Filename("20240101120000", "add_email", "down"))
+	assert.Equal(t, "V002_add_email.sql", migration.SchemeSequential.Filename("002", "add_email", ""))
+}
+
+func TestNextVersions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		migrations []migration.Migration
+		wantScheme migration.Scheme
+		want       []string
+	}{
+		{"none", nil, migration.SchemeSequential, []string{"001", "002"}},
+		{"sequential", []migration.Migration{
+			{Version: "009", FilePath: "migrations/V009_b.up.sql"},
+			{Version: "002", FilePath: "migrations/V002_a.up.sql"},
+		}, migration.SchemeSequential, []string{"010", "011"}},
+		{"timestamp", []migration.Migration{
+			{Version: "20240101120000", FilePath: "migrations/20240101120000_a.up.sql"},
+		}, migration.SchemeTimestamp, []string{"20240301120000", "20240301120001"}},
+		{"timestamp in the future", []migration.Migration{
+			{Version: "20250101120059", FilePath: "migrations/20250101120059_a.sql"},
+		}, migration.SchemeTimestamp, []string{"20250101120100", "20250101120101"}},
+		{"repeatables ignored", []migration.Migration{
+			{Version: "1", FilePath: "migrations/V1_a.up.sql"},
+			{Name: "views", Repeatable: true, FilePath: "migrations/R__views.sql"},
+		}, migration.SchemeSequential, []string{"2", "3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			scheme, versions := migration.NextVersions(tt.migrations, 2, now)
+			assert.Equal(t, tt.wantScheme, scheme)
+			assert.Equal(t, tt.want, versions)
+		})
+	}
+}
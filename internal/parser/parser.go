@@ -1,10 +1,12 @@
 package parser //nolint:revive // intentional: does not conflict with go/parser in internal package
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	pg_query "github.com/pganalyze/pg_query_go/v6"
+	pgparser "github.com/pganalyze/pg_query_go/v6/parser"
 )
 
 // ParseResult holds the parsed AST and original SQL.
@@ -13,8 +15,26 @@ type ParseResult struct {
 	SQL   string
 }
 
+// SyntaxError is a parse error located in the SQL passed to Parse.
+// Line and Column are 1-based; both are zero if the parser reported no position.
+type SyntaxError struct {
+	Message string
+	Line    int
+	Column  int
+}
+
+// Error returns the message prefixed with its position, if known.
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
 // Parse parses a PostgreSQL SQL string and returns the AST.
 // Returns an empty result (zero statements) for empty or whitespace-only input.
+// Syntax errors wrap a *SyntaxError positioned relative to sql.
 func Parse(sql string) (*ParseResult, error) {
 	trimmed := strings.TrimSpace(sql)
 	if trimmed == "" {
@@ -23,7 +43,7 @@ func Parse(sql string) (*ParseResult, error) {
 
 	tree, err := pg_query.Parse(trimmed)
 	if err != nil {
-		return nil, fmt.Errorf("parsing SQL: %w", err)
+		return nil, fmt.Errorf("parsing SQL: %w", syntaxError(sql, trimmed, err))
 	}
 
 	return &ParseResult{
@@ -31,3 +51,27 @@ func Parse(sql string) (*ParseResult, error) {
 		SQL:   sql,
 	}, nil
 }
+
+// syntaxError converts a pg_query error into a *SyntaxError. The parser's
+// cursor position is a 1-based character offset into trimmed, which starts
+// after the leading whitespace of sql.
+func syntaxError(sql, trimmed string, err error) error {
+	var pgErr *pgparser.Error
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	if pgErr.Cursorpos <= 0 {
+		return &SyntaxError{Message: pgErr.Message}
+	}
+
+	leading := sql[:len(sql)-len(strings.TrimLeft(sql, " \t\r\n\v\f"))]
+	runes := []rune(leading + trimmed)
+	pos := min(len([]rune(leading))+pgErr.Cursorpos-1, len(runes))
+
+	before := string(runes[:pos])
+	line := strings.Count(before, "\n") + 1
+	column := len([]rune(before[strings.LastIndex(before, "\n")+1:])) + 1
+
+	return &SyntaxError{Message: pgErr.Message, Line: line, Column: column}
+}
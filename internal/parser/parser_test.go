@@ -145,3 +145,55 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_syntaxError_reportsLineAndColumn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		sql        string
+		wantLine   int
+		wantColumn int
+	}{
+		{
+			name:       "first line",
+			sql:        "CREATE TABLE (id INT);",
+			wantLine:   1,
+			wantColumn: 14,
+		},
+		{
+			name:       "later line",
+			sql:        "CREATE TABLE a (id INT);\nSELECT 1;\nCREAT TABLE b (id INT);",
+			wantLine:   3,
+			wantColumn: 1,
+		},
+		{
+			name:       "leading blank lines are counted",
+			sql:        "\n\n  SELEC 1;",
+			wantLine:   3,
+			wantColumn: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parser.Parse(tt.sql)
+
+			var syntaxErr *parser.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tt.wantLine, syntaxErr.Line)
+			assert.Equal(t, tt.wantColumn, syntaxErr.Column)
+			assert.Contains(t, syntaxErr.Error(), "syntax error")
+		})
+	}
+}
+
+func TestSyntaxError_Error_withoutPosition(t *testing.T) {
+	t.Parallel()
+
+	err := &parser.SyntaxError{Message: "unterminated quoted string"}
+
+	assert.Equal(t, "unterminated quoted string", err.Error())
+}
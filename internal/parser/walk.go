@@ -0,0 +1,45 @@
+package parser //nolint:revive // intentional: does not conflict with go/parser in internal package
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Walk calls fn for node and, depth first, every syntax tree message nested
+// in it, such as each *pg_query.ColumnRef of an expression. Siblings are
+// visited in no particular order.
+func Walk(node proto.Message, fn func(proto.Message)) {
+	if node == nil {
+		return
+	}
+
+	walk(node.ProtoReflect(), fn)
+}
+
+// walk calls fn for m and the messages in its set fields.
+func walk(m protoreflect.Message, fn func(proto.Message)) {
+	if !m.IsValid() {
+		return
+	}
+
+	fn(m.Interface())
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() != protoreflect.MessageKind {
+			return true
+		}
+
+		if fd.IsList() {
+			list := v.List()
+			for i := range list.Len() {
+				walk(list.Get(i).Message(), fn)
+			}
+
+			return true
+		}
+
+		walk(v.Message(), fn)
+
+		return true
+	})
+}
@@ -0,0 +1,38 @@
+package parser_test
+
+import (
+	"testing"
+
+	pg_query "github.com/pganalyze/pg_query_go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/aqasim81/database-migration-engine/internal/parser"
+)
+
+func TestWalk_visitsNestedNodes(t *testing.T) {
+	t.Parallel()
+
+	result, err := parser.Parse("SELECT a FROM t WHERE b > 0 AND lower(c) = 'x';")
+	require.NoError(t, err)
+
+	var columns []string
+
+	parser.Walk(result.Stmts[0], func(msg proto.Message) {
+		if ref, ok := msg.(*pg_query.ColumnRef); ok {
+			columns = append(columns, ref.Fields[0].GetString_().GetSval())
+		}
+	})
+
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, columns)
+}
+
+func TestWalk_nilNode(t *testing.T) {
+	t.Parallel()
+
+	called := false
+
+	parser.Walk((*pg_query.Node)(nil), func(proto.Message) { called = true })
+	assert.False(t, called)
+}
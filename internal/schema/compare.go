@@ -0,0 +1,148 @@
+package schema
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DifferenceKind says how an object in the database differs from the
+// expected schema.
+type DifferenceKind string
+
+// Difference kinds reported by Compare.
+const (
+	Missing DifferenceKind = "missing" // expected but not in the database
+	Extra   DifferenceKind = "extra"   // in the database but not expected
+	Changed DifferenceKind = "changed" // in both with a different property
+)
+
+// Difference is one way the actual schema differs from the expected one.
+type Difference struct {
+	Kind     DifferenceKind
+	Object   string // table, column, constraint, index, view, sequence, function or enum
+	Name     string // columns and constraints are prefixed with their table, e.g. public.users.email
+	Property string // the property that differs, such as type or default, for Changed
+	Expected string
+	Actual   string
+}
+
+// prop is a named property of an object, compared between schemas.
+type prop struct {
+	name  string
+	value string
+}
+
+// Compare returns the differences between the expected and actual schemas:
+// tables, columns, constraints, indexes, views, sequences, functions and
+// enum types that are missing, extra or changed. Grants are not compared,
+// since roles differ between databases.
+func Compare(expected, actual *Schema) []Difference {
+	var diffs []Difference
+
+	diffs = append(diffs, compareObjects("table", expected.Tables, actual.Tables,
+		func(t Table) string { return t.Name },
+		func(t Table) []prop {
+			return []prop{{"partition by", t.PartitionBy}, {"partition of", t.PartitionOf}, {"partition bound", t.Bound}}
+		})...)
+
+	actualTables := tableIndex(actual)
+
+	var columns, constraints []Difference
+
+	for _, e := range expected.Tables {
+		a := actualTables[e.Name]
+		if a == nil {
+			continue
+		}
+
+		columns = append(columns, compareObjects("column", e.Columns, a.Columns,
+			func(c Column) string { return e.Name + "." + c.Name }, columnProps)...)
+		constraints = append(constraints, compareObjects("constraint", e.Constraints, a.Constraints,
+			func(c Constraint) string { return e.Name + "." + c.Name },
+			func(c Constraint) []prop { return []prop{{"definition", c.Definition}} })...)
+	}
+
+	diffs = append(diffs, columns...)
+	diffs = append(diffs, constraints...)
+
+	diffs = append(diffs, compareObjects("index", expected.Indexes, actual.Indexes,
+		func(x Index) string { return x.Name },
+		func(x Index) []prop { return []prop{{"definition", x.Definition}} })...)
+
+	diffs = append(diffs, compareObjects("view", expected.Views, actual.Views,
+		func(v View) string { return v.Name },
+		func(v View) []prop { return []prop{{"definition", createView(v)}} })...)
+
+	diffs = append(diffs, compareObjects("sequence", expected.Sequences, actual.Sequences,
+		func(q Sequence) string { return q.Name },
+		func(q Sequence) []prop { return []prop{{"definition", createSequence(q)}, {"owned by", q.OwnedBy}} })...)
+
+	diffs = append(diffs, compareObjects("function", expected.Functions, actual.Functions,
+		func(f Function) string { return f.Name },
+		func(f Function) []prop { return []prop{{"definition", f.Definition}} })...)
+
+	return append(diffs, compareObjects("enum", expected.Enums, actual.Enums,
+		func(e Enum) string { return e.Name },
+		func(e Enum) []prop { return []prop{{"labels", strings.Join(e.Labels, ", ")}} })...)
+}
+
+// columnProps returns the compared properties of a column.
+func columnProps(c Column) []prop {
+	return []prop{
+		{"type", c.Type},
+		{"collation", c.Collation},
+		{"default", c.Default},
+		{"not null", strconv.FormatBool(c.NotNull)},
+		{"identity", c.Identity},
+		{"generated", strconv.FormatBool(c.Generated)},
+	}
+}
+
+// compareObjects matches expected and actual objects by name and reports
+// those only in one of them and, for those in both, each property whose
+// value differs. Differences are sorted by name.
+func compareObjects[T any](object string, expected, actual []T, name func(T) string, props func(T) []prop) []Difference {
+	want := make(map[string]T, len(expected))
+	for _, o := range expected {
+		want[name(o)] = o
+	}
+
+	got := make(map[string]T, len(actual))
+	for _, o := range actual {
+		got[name(o)] = o
+	}
+
+	var diffs []Difference
+
+	for n, e := range want {
+		a, ok := got[n]
+		if !ok {
+			diffs = append(diffs, Difference{Kind: Missing, Object: object, Name: n})
+			continue
+		}
+
+		ap := props(a)
+
+		for i, p := range props(e) {
+			if p.value != ap[i].value {
+				diffs = append(diffs, Difference{
+					Kind: Changed, Object: object, Name: n, Property: p.name, Expected: p.value, Actual: ap[i].value,
+				})
+			}
+		}
+	}
+
+	for n := range got {
+		if _, ok := want[n]; !ok {
+			diffs = append(diffs, Difference{Kind: Extra, Object: object, Name: n})
+		}
+	}
+
+	slices.SortFunc(diffs, func(a, b Difference) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Property, b.Property))
+	})
+
+	return diffs
+}
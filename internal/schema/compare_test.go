@@ -0,0 +1,71 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	expected := &schema.Schema{
+		Tables: []schema.Table{
+			{
+				Name: "public.users",
+				Columns: []schema.Column{
+					{Name: "id", Type: "bigint", NotNull: true},
+					{Name: "email", Type: "character varying(255)", NotNull: true},
+					{Name: "status", Type: "text", Default: "'active'::text"},
+				},
+				Constraints: []schema.Constraint{
+					{Name: "users_pkey", Kind: schema.PrimaryKey, Definition: "PRIMARY KEY (id)"},
+					{Name: "users_email_check", Kind: schema.Check, Definition: "CHECK (email <> '')"},
+				},
+			},
+			{Name: "public.audit_log"},
+		},
+		Indexes: []schema.Index{{Name: "public.users_email_idx", Table: "public.users",
+			Definition: "CREATE INDEX users_email_idx ON public.users USING btree (email)"}},
+	}
+
+	actual := &schema.Schema{
+		Tables: []schema.Table{
+			{
+				Name: "public.users",
+				Columns: []schema.Column{
+					{Name: "id", Type: "bigint", NotNull: true},
+					{Name: "email", Type: "text"},
+					{Name: "status", Type: "text"},
+					{Name: "nickname", Type: "text"},
+				},
+				Constraints: []schema.Constraint{
+					{Name: "users_pkey", Kind: schema.PrimaryKey, Definition: "PRIMARY KEY (id)"},
+				},
+			},
+			{Name: "public.tmp_fix"},
+		},
+		Indexes: []schema.Index{{Name: "public.users_email_idx", Table: "public.users",
+			Definition: "CREATE INDEX users_email_idx ON public.users USING btree (lower(email))"}},
+	}
+
+	assert.Equal(t, []schema.Difference{
+		{Kind: schema.Missing, Object: "table", Name: "public.audit_log"},
+		{Kind: schema.Extra, Object: "table", Name: "public.tmp_fix"},
+		{Kind: schema.Changed, Object: "column", Name: "public.users.email", Property: "not null",
+			Expected: "true", Actual: "false"},
+		{Kind: schema.Changed, Object: "column", Name: "public.users.email", Property: "type",
+			Expected: "character varying(255)", Actual: "text"},
+		{Kind: schema.Extra, Object: "column", Name: "public.users.nickname"},
+		{Kind: schema.Changed, Object: "column", Name: "public.users.status", Property: "default",
+			Expected: "'active'::text"},
+		{Kind: schema.Missing, Object: "constraint", Name: "public.users.users_email_check"},
+		{Kind: schema.Changed, Object: "index", Name: "public.users_email_idx", Property: "definition",
+			Expected: "CREATE INDEX users_email_idx ON public.users USING btree (email)",
+			Actual:   "CREATE INDEX users_email_idx ON public.users USING btree (lower(email))"},
+	}, schema.Compare(expected, actual))
+
+	assert.Empty(t, schema.Compare(expected, expected))
+}
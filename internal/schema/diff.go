@@ -0,0 +1,835 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Step is one migration of a change generated by Diff, as up and down
+// statements without semicolons. Statements that cannot run in a
+// transaction block, such as CREATE INDEX CONCURRENTLY, get a step each;
+// constraint validation gets a step of its own so that its table scan does
+// not run under the locks taken by the main step. Enum labels are added in
+// a step before the main one, as a label added in a transaction cannot be
+// used until it commits.
+type Step struct {
+	Name string // what the step does, e.g. validate; empty for the main step
+	Up   []string
+	Down []string // undoes Up; empty if there is nothing to undo
+}
+
+// Plan is a change from one schema to another, as steps to apply in order.
+type Plan struct {
+	Steps    []Step
+	Warnings []string // differences Diff does not migrate, left to a migration written by hand
+}
+
+// enumValuesStep names the step that adds enum labels; validateStep and
+// finalizeStep name the steps that validate constraints added NOT VALID,
+// then attach unique indexes and set columns NOT NULL.
+const (
+	enumValuesStep = "add_enum_values"
+	validateStep   = "validate"
+	finalizeStep   = "finalize"
+)
+
+// notValid marks a constraint that is not validated, as pg_get_constraintdef
+// writes it.
+const notValid = " NOT VALID"
+
+// maxIdentifierLength is PostgreSQL's NAMEDATALEN - 1.
+const maxIdentifierLength = 63
+
+// uniqueDefinition matches a primary key or unique constraint on plain
+// columns, which can be built as a unique index first.
+var uniqueDefinition = regexp.MustCompile(`^(PRIMARY KEY|UNIQUE) (\([^()]+\))$`) //nolint:gochecknoglobals // compiled once
+
+// change is a group of statements and the statements that undo them.
+type change struct {
+	up   []string
+	down []string
+}
+
+// undo pairs a statement with the statements that undo it.
+func undo(up string, down ...string) change {
+	return change{up: []string{up}, down: down}
+}
+
+// planner accumulates the statements of a Plan.
+type planner struct {
+	current, desired             *Schema
+	currentTables, desiredTables map[string]*Table
+	replacedViews                map[string]bool
+
+	dropIndexes, createIndexes           []Step
+	enumValues, main, validate, finalize []change
+	functions                            bool // main creates or replaces functions
+	warnings                             []string
+}
+
+// Diff returns the migrations that change the current schema into the
+// desired one. Indexes are created and dropped CONCURRENTLY, CHECK and
+// foreign key constraints are added NOT VALID and validated afterwards, and
+// primary keys and unique constraints of existing tables are built as a
+// unique index first; SET NOT NULL is preceded by a validated CHECK
+// constraint, so that it does not scan the table. Grants are not migrated.
+func Diff(current, desired *Schema) *Plan {
+	p := &planner{
+		current:       current,
+		desired:       desired,
+		currentTables: tableIndex(current),
+		desiredTables: tableIndex(desired),
+	}
+
+	p.dropViews()
+	p.dropConstraints()
+	p.dropTables()
+	p.createTypes()
+	p.createTables()
+	p.createFunctions()
+	p.alterTables()
+	p.setSequenceOwners()
+	p.addConstraints()
+	p.createViews()
+	p.changeIndexes()
+	p.dropObjects()
+
+	return p.plan()
+}
+
+// plan assembles the steps: index drops, enum labels, the main step, index
+// builds, then validation and finalization.
+func (p *planner) plan() *Plan {
+	var preamble []string
+	if p.functions {
+		preamble = []string{"SET LOCAL check_function_bodies = false"}
+	}
+
+	steps := slices.Clone(p.dropIndexes)
+
+	if len(p.enumValues) > 0 {
+		steps = append(steps, newStep(enumValuesStep, p.enumValues))
+	}
+
+	if len(p.main) > 0 {
+		steps = append(steps, newStep("", p.main, preamble...))
+	}
+
+	steps = append(steps, p.createIndexes...)
+
+	if len(p.validate) > 0 {
+		steps = append(steps, newStep(validateStep, p.validate))
+	}
+
+	if len(p.finalize) > 0 {
+		steps = append(steps, newStep(finalizeStep, p.finalize))
+	}
+
+	return &Plan{Steps: steps, Warnings: p.warnings}
+}
+
+// newStep returns a step that makes changes in order and undoes them in
+// reverse order, after the preamble statements.
+func newStep(name string, changes []change, preamble ...string) Step {
+	s := Step{Name: name, Up: slices.Clone(preamble), Down: slices.Clone(preamble)}
+
+	for _, c := range changes {
+		s.Up = append(s.Up, c.up...)
+	}
+
+	for i := len(changes) - 1; i >= 0; i-- {
+		s.Down = append(s.Down, changes[i].down...)
+	}
+
+	if len(s.Down) == len(preamble) {
+		s.Down = nil
+	}
+
+	return s
+}
+
+// warn records a difference that Diff does not migrate.
+func (p *planner) warn(format string, args ...any) {
+	p.warnings = append(p.warnings, fmt.Sprintf(format, args...))
+}
+
+// dropViews drops the views that are not desired or whose definition
+// changes, and the views that read from them, which createViews recreates.
+func (p *planner) dropViews() {
+	desired := make(map[string]View, len(p.desired.Views))
+	for _, v := range p.desired.Views {
+		desired[v.Name] = v
+	}
+
+	p.replacedViews = make(map[string]bool)
+
+	for _, v := range p.current.Views {
+		if d, ok := desired[v.Name]; !ok || createView(d) != createView(v) {
+			p.replacedViews[v.Name] = true
+		}
+	}
+
+	for found := true; found; {
+		found = false
+
+		for _, v := range p.current.Views {
+			if !p.replacedViews[v.Name] && slices.ContainsFunc(v.DependsOn, func(n string) bool { return p.replacedViews[n] }) {
+				p.replacedViews[v.Name], found = true, true
+			}
+		}
+	}
+
+	ordered := orderViews(p.current.Views)
+
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if v := ordered[i]; p.replacedViews[v.Name] {
+			p.main = append(p.main, undo(dropView(v), createView(v)))
+		}
+	}
+}
+
+// createViews creates the desired views that are missing or were dropped
+// by dropViews.
+func (p *planner) createViews() {
+	have := make(map[string]bool, len(p.current.Views))
+	for _, v := range p.current.Views {
+		have[v.Name] = true
+	}
+
+	for _, v := range orderViews(p.desired.Views) {
+		if !have[v.Name] || p.replacedViews[v.Name] {
+			p.main = append(p.main, undo(createView(v), dropView(v)))
+		}
+	}
+}
+
+// dropView renders DROP VIEW or DROP MATERIALIZED VIEW.
+func dropView(v View) string {
+	if v.Materialized {
+		return "DROP MATERIALIZED VIEW " + v.Name
+	}
+
+	return "DROP VIEW " + v.Name
+}
+
+// dropConstraints drops the constraints of kept tables that are not desired
+// or whose definition changes, foreign keys first, and the foreign keys of
+// dropped tables, so that the tables can be recreated in any order on the
+// way down.
+func (p *planner) dropConstraints() {
+	for _, foreignKeys := range []bool{true, false} {
+		for _, t := range p.current.Tables {
+			d := p.desiredTables[t.Name]
+			if d == nil && !foreignKeys {
+				continue
+			}
+
+			for _, c := range t.Constraints {
+				if (c.Kind == ForeignKey) != foreignKeys {
+					continue
+				}
+
+				if want, ok := constraintOf(d, c.Name); ok && (want == c || onlyValidation(c, want)) {
+					continue
+				}
+
+				p.main = append(p.main, undo(
+					fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", t.Name, c.Name),
+					addConstraint(t.Name, c)))
+			}
+		}
+	}
+}
+
+// addConstraints adds the desired constraints that are missing or were
+// dropped by dropConstraints, and validates those only left NOT VALID.
+func (p *planner) addConstraints() {
+	for i := range p.desired.Tables {
+		t := &p.desired.Tables[i]
+		cur := p.currentTables[t.Name]
+
+		for _, c := range t.Constraints {
+			switch have, ok := constraintOf(cur, c.Name); {
+			case cur == nil && c.Kind == ForeignKey:
+				p.addConstraint(t, c, true)
+			case cur == nil || have == c:
+			case ok && onlyValidation(have, c):
+				p.validate = append(p.validate, undo(validateConstraint(t.Name, c.Name)))
+			default:
+				p.addConstraint(t, c, false)
+			}
+		}
+	}
+}
+
+// addConstraint adds c to table t. A CHECK or foreign key constraint is
+// added NOT VALID and validated in the validate step. A primary key or
+// unique constraint of an existing table is built as a unique index
+// CONCURRENTLY, then attached in the finalize step.
+func (p *planner) addConstraint(t *Table, c Constraint, newTable bool) {
+	drop := fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", t.Name, c.Name)
+
+	if c.Kind == Check || c.Kind == ForeignKey {
+		if strings.HasSuffix(c.Definition, notValid) {
+			p.main = append(p.main, undo(addConstraint(t.Name, c), drop))
+			return
+		}
+
+		p.main = append(p.main, undo(addConstraint(t.Name, c)+notValid, drop))
+		p.validate = append(p.validate, undo(validateConstraint(t.Name, c.Name)))
+
+		return
+	}
+
+	if m := uniqueDefinition.FindStringSubmatch(c.Definition); m != nil && !newTable && t.PartitionBy == "" {
+		p.createIndexes = append(p.createIndexes, Step{
+			Name: "create_index_" + stepName(c.Name),
+			Up:   []string{fmt.Sprintf("CREATE UNIQUE INDEX CONCURRENTLY %s ON %s %s", c.Name, t.Name, m[2])},
+			Down: []string{fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s.%s", schemaOf(t.Name), c.Name)},
+		})
+		p.finalize = append(p.finalize, undo(
+			fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s USING INDEX %s", t.Name, c.Name, m[1], c.Name), drop))
+
+		return
+	}
+
+	p.main = append(p.main, undo(addConstraint(t.Name, c), drop))
+}
+
+// addConstraint renders ALTER TABLE ... ADD CONSTRAINT.
+func addConstraint(table string, c Constraint) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", table, c.Name, c.Definition)
+}
+
+// validateConstraint renders ALTER TABLE ... VALIDATE CONSTRAINT.
+func validateConstraint(table, name string) string {
+	return fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s", table, name)
+}
+
+// constraintOf returns the constraint of t named name, if t has one.
+func constraintOf(t *Table, name string) (Constraint, bool) {
+	if t == nil {
+		return Constraint{}, false
+	}
+
+	i := slices.IndexFunc(t.Constraints, func(c Constraint) bool { return c.Name == name })
+	if i < 0 {
+		return Constraint{}, false
+	}
+
+	return t.Constraints[i], true
+}
+
+// onlyValidation reports whether have is want left NOT VALID.
+func onlyValidation(have, want Constraint) bool {
+	return have.Kind == want.Kind && have.Definition == want.Definition+notValid
+}
+
+// dropTables drops the tables that are not desired, partitions before
+// their parent. Their foreign keys were dropped by dropConstraints; the way
+// down recreates the tables with their other constraints and indexes.
+func (p *planner) dropTables() {
+	ordered := orderTables(p.current.Tables)
+
+	for i := len(ordered) - 1; i >= 0; i-- {
+		t := ordered[i]
+		if p.desiredTables[t.Name] != nil {
+			continue
+		}
+
+		down := newTableStatements(t)
+
+		for _, x := range p.current.Indexes {
+			if x.Table == t.Name {
+				down = append(down, x.Definition)
+			}
+		}
+
+		p.main = append(p.main, change{up: []string{"DROP TABLE " + t.Name}, down: down})
+	}
+}
+
+// createTables creates the desired tables that are missing, with their
+// constraints other than foreign keys. addConstraints adds the foreign
+// keys once every table exists.
+func (p *planner) createTables() {
+	for _, t := range orderTables(p.desired.Tables) {
+		if p.currentTables[t.Name] == nil {
+			p.main = append(p.main, change{up: newTableStatements(t), down: []string{"DROP TABLE " + t.Name}})
+		}
+	}
+}
+
+// newTableStatements renders CREATE TABLE for t with its constraints other
+// than foreign keys inline. Partitions take no inline constraints, so
+// theirs are added with ALTER TABLE.
+func newTableStatements(t Table) []string {
+	var inline []Constraint
+
+	for _, c := range t.Constraints {
+		if c.Kind != ForeignKey {
+			inline = append(inline, c)
+		}
+	}
+
+	if t.PartitionOf == "" {
+		return []string{createTable(t, inline...)}
+	}
+
+	stmts := []string{createTable(t)}
+	for _, c := range inline {
+		stmts = append(stmts, addConstraint(t.Name, c))
+	}
+
+	return stmts
+}
+
+// createTypes creates the schemas, extensions, enum types and sequences
+// that are missing, adds labels to enum types and alters changed sequences.
+func (p *planner) createTypes() {
+	for _, n := range missing(p.desired.Schemas, p.current.Schemas, func(n string) string { return n }) {
+		p.main = append(p.main, undo("CREATE SCHEMA "+n, "DROP SCHEMA "+n))
+	}
+
+	for _, x := range missing(p.desired.Extensions, p.current.Extensions, func(x Extension) string { return x.Name }) {
+		p.main = append(p.main, undo(createExtension(x), "DROP EXTENSION "+x.Name))
+	}
+
+	current := make(map[string]Enum, len(p.current.Enums))
+	for _, e := range p.current.Enums {
+		current[e.Name] = e
+	}
+
+	for _, e := range p.desired.Enums {
+		have, ok := current[e.Name]
+		if !ok {
+			p.main = append(p.main, undo(createEnum(e), "DROP TYPE "+e.Name))
+			continue
+		}
+
+		stmts, ok := addEnumValues(e.Name, have.Labels, e.Labels)
+		if !ok {
+			p.warn("enum %s: labels are removed or reordered", e.Name)
+		}
+
+		for _, s := range stmts {
+			p.enumValues = append(p.enumValues, undo(s))
+		}
+	}
+
+	p.createSequences()
+}
+
+// createSequences creates the missing sequences and alters the changed ones.
+func (p *planner) createSequences() {
+	current := make(map[string]Sequence, len(p.current.Sequences))
+	for _, q := range p.current.Sequences {
+		current[q.Name] = q
+	}
+
+	for _, q := range p.desired.Sequences {
+		have, ok := current[q.Name]
+
+		switch {
+		case !ok:
+			p.main = append(p.main, undo(createSequence(q), "DROP SEQUENCE "+q.Name))
+		case createSequence(have) != createSequence(q):
+			p.main = append(p.main, undo(alterSequence(q), alterSequence(have)))
+		}
+	}
+}
+
+// setSequenceOwners sets the owning column of sequences whose owner
+// changes, once the columns exist.
+func (p *planner) setSequenceOwners() {
+	current := make(map[string]Sequence, len(p.current.Sequences))
+	for _, q := range p.current.Sequences {
+		current[q.Name] = q
+	}
+
+	for _, q := range p.desired.Sequences {
+		if have := current[q.Name]; have.OwnedBy != q.OwnedBy {
+			p.main = append(p.main, undo(ownedBy(q.Name, q.OwnedBy), ownedBy(q.Name, have.OwnedBy)))
+		}
+	}
+}
+
+// ownedBy renders ALTER SEQUENCE ... OWNED BY, with NONE for no owner.
+func ownedBy(sequence, owner string) string {
+	if owner == "" {
+		owner = "NONE"
+	}
+
+	return fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s", sequence, owner)
+}
+
+// alterSequence renders ALTER SEQUENCE setting every option of q.
+func alterSequence(q Sequence) string {
+	stmt := "ALTER" + strings.TrimPrefix(createSequence(q), "CREATE")
+	if !q.Cycle {
+		stmt += " NO CYCLE"
+	}
+
+	return stmt
+}
+
+// createExtension renders CREATE EXTENSION.
+func createExtension(x Extension) string {
+	return fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %s WITH SCHEMA %s", x.Name, x.Schema)
+}
+
+// createEnum renders CREATE TYPE ... AS ENUM.
+func createEnum(e Enum) string {
+	return fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", e.Name, strings.Join(e.Labels, ", "))
+}
+
+// addEnumValues renders ALTER TYPE ... ADD VALUE for the labels of want
+// that have lacks, each placed next to a label already there. Reports
+// false if have is not want with labels added, as labels can be neither
+// removed nor reordered.
+func addEnumValues(enum string, have, want []string) ([]string, bool) {
+	if len(have) == 0 {
+		return nil, len(want) == 0
+	}
+
+	exists := make(map[string]bool, len(have))
+	first, next := -1, 0
+
+	for i, l := range want {
+		if next < len(have) && l == have[next] {
+			exists[l] = true
+			next++
+
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+
+	if next < len(have) {
+		return nil, false
+	}
+
+	var stmts []string
+
+	for i := first - 1; i >= 0; i-- {
+		stmts = append(stmts, fmt.Sprintf("ALTER TYPE %s ADD VALUE %s BEFORE %s", enum, want[i], want[i+1]))
+	}
+
+	for i := first + 1; i < len(want); i++ {
+		if !exists[want[i]] {
+			stmts = append(stmts, fmt.Sprintf("ALTER TYPE %s ADD VALUE %s AFTER %s", enum, want[i], want[i-1]))
+		}
+	}
+
+	return stmts, true
+}
+
+// createFunctions creates the missing functions and replaces the changed
+// ones, then sets the defaults of new tables that call them.
+func (p *planner) createFunctions() {
+	current := make(map[string]Function, len(p.current.Functions))
+	for _, f := range p.current.Functions {
+		current[f.Name] = f
+	}
+
+	for _, f := range p.desired.Functions {
+		have, ok := current[f.Name]
+
+		switch {
+		case !ok:
+			p.main = append(p.main, undo(f.Definition, fmt.Sprintf("DROP %s %s", f.Kind(), f.Name)))
+		case have.Definition != f.Definition:
+			p.main = append(p.main, undo(f.Definition, have.Definition))
+		default:
+			continue
+		}
+
+		p.functions = true
+	}
+
+	var created []Table
+
+	for _, t := range p.desired.Tables {
+		if p.currentTables[t.Name] == nil {
+			created = append(created, t)
+		}
+	}
+
+	for _, s := range deferredDefaults(created) {
+		p.main = append(p.main, undo(s))
+	}
+}
+
+// alterTables drops, alters and adds the columns of the tables in both
+// schemas.
+func (p *planner) alterTables() {
+	for _, t := range p.desired.Tables {
+		cur := p.currentTables[t.Name]
+		if cur == nil {
+			continue
+		}
+
+		if cur.PartitionBy != t.PartitionBy || cur.PartitionOf != t.PartitionOf || cur.Bound != t.Bound {
+			p.warn("table %s: partitioning changes", t.Name)
+		}
+
+		want := make(map[string]Column, len(t.Columns))
+		for _, c := range t.Columns {
+			want[c.Name] = c
+		}
+
+		for _, c := range cur.Columns {
+			if _, ok := want[c.Name]; !ok {
+				c.DeferDefault = false
+				p.main = append(p.main, undo(
+					fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", t.Name, c.Name),
+					fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", t.Name, columnDefinition(c))))
+			}
+		}
+
+		for _, c := range t.Columns {
+			i := slices.IndexFunc(cur.Columns, func(h Column) bool { return h.Name == c.Name })
+			if i >= 0 {
+				p.alterColumn(t.Name, cur.Columns[i], c)
+				continue
+			}
+
+			c.DeferDefault = false
+			p.main = append(p.main, undo(
+				fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", t.Name, columnDefinition(c)),
+				fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", t.Name, c.Name)))
+		}
+	}
+}
+
+// alterColumn changes column have of table into want.
+func (p *planner) alterColumn(table string, have, want Column) {
+	alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ", table, want.Name)
+
+	if have.Generated != want.Generated || (want.Generated && have.Default != want.Default) {
+		p.warn("column %s.%s: generation expression changes", table, want.Name)
+		return
+	}
+
+	if have.Type != want.Type || have.Collation != want.Collation {
+		p.main = append(p.main, undo(alter+columnType(want), alter+columnType(have)))
+	}
+
+	if have.Identity != want.Identity {
+		p.main = append(p.main, undo(alter+setIdentity(have, want), alter+setIdentity(want, have)))
+	}
+
+	if have.Default != want.Default && !want.Generated {
+		p.main = append(p.main, undo(alter+setDefault(want), alter+setDefault(have)))
+	}
+
+	switch {
+	case want.NotNull && !have.NotNull:
+		p.setNotNull(table, want.Name)
+	case have.NotNull && !want.NotNull:
+		p.main = append(p.main, undo(alter+"DROP NOT NULL", alter+"SET NOT NULL"))
+	}
+}
+
+// setNotNull sets a column NOT NULL without scanning the table under an
+// ACCESS EXCLUSIVE lock: a CHECK (column IS NOT NULL) constraint is added
+// NOT VALID and validated, which SET NOT NULL uses as proof on
+// PostgreSQL 12 and later, and is then dropped.
+func (p *planner) setNotNull(table, column string) {
+	name := notNullConstraint(table, column)
+	alter := fmt.Sprintf("ALTER TABLE %s ", table)
+	check := fmt.Sprintf("%sADD CONSTRAINT %s CHECK (%s IS NOT NULL)%s", alter, name, column, notValid)
+
+	p.main = append(p.main, undo(check, alter+"DROP CONSTRAINT "+name))
+	p.validate = append(p.validate, undo(validateConstraint(table, name)))
+	p.finalize = append(p.finalize, change{
+		up:   []string{alter + "ALTER COLUMN " + column + " SET NOT NULL", alter + "DROP CONSTRAINT " + name},
+		down: []string{check, alter + "ALTER COLUMN " + column + " DROP NOT NULL"},
+	})
+}
+
+// columnType renders the TYPE clause of ALTER COLUMN.
+func columnType(c Column) string {
+	if c.Collation != "" {
+		return "TYPE " + c.Type + " COLLATE " + c.Collation
+	}
+
+	return "TYPE " + c.Type
+}
+
+// setIdentity renders the ALTER COLUMN action that turns the identity of
+// column have into that of want.
+func setIdentity(have, want Column) string {
+	switch {
+	case want.Identity == "":
+		return "DROP IDENTITY"
+	case have.Identity == "":
+		return "ADD GENERATED " + want.Identity + " AS IDENTITY"
+	default:
+		return "SET GENERATED " + want.Identity
+	}
+}
+
+// setDefault renders SET DEFAULT, or DROP DEFAULT for no default.
+func setDefault(c Column) string {
+	if c.Default == "" {
+		return "DROP DEFAULT"
+	}
+
+	return "SET DEFAULT " + c.Default
+}
+
+// changeIndexes drops the indexes that are not desired or whose definition
+// changes and creates the missing ones, CONCURRENTLY and each in a step of
+// its own. Indexes of partitioned tables, which cannot be built
+// concurrently, are changed in the main step. Indexes of dropped tables go
+// with the table.
+func (p *planner) changeIndexes() {
+	desired := make(map[string]Index, len(p.desired.Indexes))
+	for _, x := range p.desired.Indexes {
+		desired[x.Name] = x
+	}
+
+	current := make(map[string]Index, len(p.current.Indexes))
+
+	for _, x := range p.current.Indexes {
+		current[x.Name] = x
+
+		if want, ok := desired[x.Name]; (ok && want.Definition == x.Definition) || p.desiredTables[x.Table] == nil {
+			continue
+		}
+
+		if t := p.currentTables[x.Table]; t != nil && t.PartitionBy != "" {
+			p.main = append(p.main, undo("DROP INDEX "+x.Name, x.Definition))
+			continue
+		}
+
+		p.dropIndexes = append(p.dropIndexes, Step{
+			Name: "drop_index_" + stepName(x.Name),
+			Up:   []string{"DROP INDEX CONCURRENTLY IF EXISTS " + x.Name},
+			Down: []string{concurrently(x.Definition)},
+		})
+	}
+
+	for _, x := range p.desired.Indexes {
+		if have, ok := current[x.Name]; ok && have.Definition == x.Definition {
+			continue
+		}
+
+		if t := p.desiredTables[x.Table]; t != nil && t.PartitionBy != "" {
+			p.main = append(p.main, undo(x.Definition, "DROP INDEX "+x.Name))
+			continue
+		}
+
+		p.createIndexes = append(p.createIndexes, Step{
+			Name: "create_index_" + stepName(x.Name),
+			Up:   []string{concurrently(x.Definition)},
+			Down: []string{"DROP INDEX CONCURRENTLY IF EXISTS " + x.Name},
+		})
+	}
+}
+
+// concurrently adds CONCURRENTLY to a CREATE INDEX statement from
+// pg_get_indexdef.
+func concurrently(definition string) string {
+	return strings.Replace(definition, " INDEX ", " INDEX CONCURRENTLY ", 1)
+}
+
+// dropObjects drops the functions, sequences, enum types, extensions and
+// schemas that are not desired, once nothing kept uses them.
+func (p *planner) dropObjects() {
+	for _, f := range missing(p.current.Functions, p.desired.Functions, func(f Function) string { return f.Name }) {
+		p.main = append(p.main, undo(fmt.Sprintf("DROP %s %s", f.Kind(), f.Name), f.Definition))
+		p.functions = true
+	}
+
+	for _, q := range missing(p.current.Sequences, p.desired.Sequences, func(q Sequence) string { return q.Name }) {
+		down := []string{createSequence(q)}
+		if q.OwnedBy != "" {
+			down = append(down, ownedBy(q.Name, q.OwnedBy))
+		}
+
+		// A sequence owned by a dropped column or table is already gone.
+		p.main = append(p.main, undo("DROP SEQUENCE IF EXISTS "+q.Name, down...))
+	}
+
+	for _, e := range missing(p.current.Enums, p.desired.Enums, func(e Enum) string { return e.Name }) {
+		p.main = append(p.main, undo("DROP TYPE "+e.Name, createEnum(e)))
+	}
+
+	for _, x := range missing(p.current.Extensions, p.desired.Extensions, func(x Extension) string { return x.Name }) {
+		p.main = append(p.main, undo("DROP EXTENSION "+x.Name, createExtension(x)))
+	}
+
+	for _, n := range missing(p.current.Schemas, p.desired.Schemas, func(n string) string { return n }) {
+		p.main = append(p.main, undo("DROP SCHEMA "+n, "CREATE SCHEMA "+n))
+	}
+}
+
+// missing returns the items of from whose name is not in to.
+func missing[T any](from, to []T, name func(T) string) []T {
+	names := make(map[string]bool, len(to))
+	for _, o := range to {
+		names[name(o)] = true
+	}
+
+	var out []T
+
+	for _, o := range from {
+		if !names[name(o)] {
+			out = append(out, o)
+		}
+	}
+
+	return out
+}
+
+// notNullConstraint names the temporary CHECK constraint setNotNull adds,
+// e.g. users_email_not_null.
+func notNullConstraint(table, column string) string {
+	name := stepName(table) + "_" + stepName(column)
+	if len(name) > maxIdentifierLength-len("_not_null") {
+		name = name[:maxIdentifierLength-len("_not_null")]
+	}
+
+	return name + "_not_null"
+}
+
+// stepName turns the last part of a quoted, possibly qualified name into
+// lower-case letters, digits and underscores, for use in a file name or a
+// generated identifier.
+func stepName(name string) string {
+	last := name[len(schemaOf(name)):]
+	last = strings.Trim(strings.TrimPrefix(last, "."), `"`)
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '_'
+		}
+	}, last)
+}
+
+// schemaOf returns the schema part of a qualified name such as
+// public."Users", or "" if the name is not qualified.
+func schemaOf(name string) string {
+	quoted := false
+
+	for i, r := range name {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '.' && !quoted:
+			return name[:i]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,198 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	current := &schema.Schema{
+		Tables: []schema.Table{{
+			Name: "public.users",
+			Columns: []schema.Column{
+				{Name: "id", Type: "bigint", NotNull: true},
+				{Name: "email", Type: "text"},
+				{Name: "nickname", Type: "text"},
+			},
+			Constraints: []schema.Constraint{{Name: "users_pkey", Kind: schema.PrimaryKey, Definition: "PRIMARY KEY (id)"}},
+		}},
+		Indexes: []schema.Index{{Name: "public.users_nickname_idx", Table: "public.users",
+			Definition: "CREATE INDEX users_nickname_idx ON public.users USING btree (nickname)"}},
+	}
+
+	desired := &schema.Schema{
+		Tables: []schema.Table{{
+			Name: "public.users",
+			Columns: []schema.Column{
+				{Name: "id", Type: "bigint", NotNull: true},
+				{Name: "email", Type: "text", NotNull: true},
+				{Name: "status", Type: "text", Default: "'active'::text"},
+			},
+			Constraints: []schema.Constraint{
+				{Name: "users_pkey", Kind: schema.PrimaryKey, Definition: "PRIMARY KEY (id)"},
+				{Name: "users_email_key", Kind: schema.Unique, Definition: "UNIQUE (email)"},
+				{Name: "users_status_check", Kind: schema.Check, Definition: "CHECK ((status <> ''::text))"},
+			},
+		}},
+		Indexes: []schema.Index{{Name: "public.users_status_idx", Table: "public.users",
+			Definition: "CREATE INDEX users_status_idx ON public.users USING btree (status)"}},
+	}
+
+	assert.Equal(t, []schema.Step{
+		{
+			Name: "drop_index_users_nickname_idx",
+			Up:   []string{"DROP INDEX CONCURRENTLY IF EXISTS public.users_nickname_idx"},
+			Down: []string{"CREATE INDEX CONCURRENTLY users_nickname_idx ON public.users USING btree (nickname)"},
+		},
+		{
+			Up: []string{
+				"ALTER TABLE public.users DROP COLUMN nickname",
+				"ALTER TABLE public.users ADD CONSTRAINT users_email_not_null CHECK (email IS NOT NULL) NOT VALID",
+				"ALTER TABLE public.users ADD COLUMN status text DEFAULT 'active'::text",
+				"ALTER TABLE public.users ADD CONSTRAINT users_status_check CHECK ((status <> ''::text)) NOT VALID",
+			},
+			Down: []string{
+				"ALTER TABLE public.users DROP CONSTRAINT users_status_check",
+				"ALTER TABLE public.users DROP COLUMN status",
+				"ALTER TABLE public.users DROP CONSTRAINT users_email_not_null",
+				"ALTER TABLE public.users ADD COLUMN nickname text",
+			},
+		},
+		{
+			Name: "create_index_users_email_key",
+			Up:   []string{"CREATE UNIQUE INDEX CONCURRENTLY users_email_key ON public.users (email)"},
+			Down: []string{"DROP INDEX CONCURRENTLY IF EXISTS public.users_email_key"},
+		},
+		{
+			Name: "create_index_users_status_idx",
+			Up:   []string{"CREATE INDEX CONCURRENTLY users_status_idx ON public.users USING btree (status)"},
+			Down: []string{"DROP INDEX CONCURRENTLY IF EXISTS public.users_status_idx"},
+		},
+		{
+			Name: "validate",
+			Up: []string{
+				"ALTER TABLE public.users VALIDATE CONSTRAINT users_email_not_null",
+				"ALTER TABLE public.users VALIDATE CONSTRAINT users_status_check",
+			},
+		},
+		{
+			Name: "finalize",
+			Up: []string{
+				"ALTER TABLE public.users ALTER COLUMN email SET NOT NULL",
+				"ALTER TABLE public.users DROP CONSTRAINT users_email_not_null",
+				"ALTER TABLE public.users ADD CONSTRAINT users_email_key UNIQUE USING INDEX users_email_key",
+			},
+			Down: []string{
+				"ALTER TABLE public.users DROP CONSTRAINT users_email_key",
+				"ALTER TABLE public.users ADD CONSTRAINT users_email_not_null CHECK (email IS NOT NULL) NOT VALID",
+				"ALTER TABLE public.users ALTER COLUMN email DROP NOT NULL",
+			},
+		},
+	}, schema.Diff(current, desired).Steps)
+}
+
+func TestDiff_newTables(t *testing.T) {
+	t.Parallel()
+
+	desired := &schema.Schema{
+		Enums: []schema.Enum{{Name: "public.mood", Labels: []string{"'sad'", "'happy'"}}},
+		Tables: []schema.Table{
+			{
+				Name:        "public.orders",
+				Columns:     []schema.Column{{Name: "id", Type: "bigint", NotNull: true}, {Name: "user_id", Type: "bigint"}},
+				Constraints: []schema.Constraint{{Name: "orders_user_fk", Kind: schema.ForeignKey, Definition: "FOREIGN KEY (user_id) REFERENCES public.users(id)"}},
+			},
+			{
+				Name:        "public.users",
+				Columns:     []schema.Column{{Name: "id", Type: "bigint", NotNull: true}},
+				Constraints: []schema.Constraint{{Name: "users_pkey", Kind: schema.PrimaryKey, Definition: "PRIMARY KEY (id)"}},
+			},
+		},
+	}
+
+	plan := schema.Diff(&schema.Schema{}, desired)
+	require.Len(t, plan.Steps, 2)
+
+	assert.Equal(t, []string{
+		"CREATE TYPE public.mood AS ENUM ('sad', 'happy')",
+		"CREATE TABLE public.orders (\n    id bigint NOT NULL,\n    user_id bigint\n)",
+		"CREATE TABLE public.users (\n    id bigint NOT NULL,\n    CONSTRAINT users_pkey PRIMARY KEY (id)\n)",
+		"ALTER TABLE public.orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES public.users(id) NOT VALID",
+	}, plan.Steps[0].Up)
+	assert.Equal(t, []string{
+		"ALTER TABLE public.orders DROP CONSTRAINT orders_user_fk",
+		"DROP TABLE public.users",
+		"DROP TABLE public.orders",
+		"DROP TYPE public.mood",
+	}, plan.Steps[0].Down)
+	assert.Equal(t, []string{"ALTER TABLE public.orders VALIDATE CONSTRAINT orders_user_fk"}, plan.Steps[1].Up)
+
+	plan = schema.Diff(desired, &schema.Schema{})
+	require.Len(t, plan.Steps, 1)
+	assert.Equal(t, []string{
+		"ALTER TABLE public.orders DROP CONSTRAINT orders_user_fk",
+		"DROP TABLE public.users",
+		"DROP TABLE public.orders",
+		"DROP TYPE public.mood",
+	}, plan.Steps[0].Up)
+}
+
+func TestDiff_enumLabels(t *testing.T) {
+	t.Parallel()
+
+	current := &schema.Schema{Enums: []schema.Enum{
+		{Name: "public.mood", Labels: []string{"'ok'"}},
+		{Name: "public.size", Labels: []string{"'s'", "'m'"}},
+	}}
+	desired := &schema.Schema{Enums: []schema.Enum{
+		{Name: "public.mood", Labels: []string{"'sad'", "'ok'", "'happy'"}},
+		{Name: "public.size", Labels: []string{"'m'", "'s'"}},
+	}}
+
+	plan := schema.Diff(current, desired)
+	require.Len(t, plan.Steps, 1)
+	assert.Equal(t, "add_enum_values", plan.Steps[0].Name)
+	assert.Equal(t, []string{
+		"ALTER TYPE public.mood ADD VALUE 'sad' BEFORE 'ok'",
+		"ALTER TYPE public.mood ADD VALUE 'happy' AFTER 'ok'",
+	}, plan.Steps[0].Up)
+	assert.Empty(t, plan.Steps[0].Down)
+	assert.Equal(t, []string{"enum public.size: labels are removed or reordered"}, plan.Warnings)
+}
+
+func TestDiff_enumLabels_addedBeforeMainStep(t *testing.T) {
+	t.Parallel()
+
+	current := &schema.Schema{Enums: []schema.Enum{{Name: "public.mood", Labels: []string{"'ok'"}}}}
+	desired := &schema.Schema{
+		Enums: []schema.Enum{{Name: "public.mood", Labels: []string{"'ok'", "'happy'"}}},
+		Tables: []schema.Table{{Name: "public.users", Columns: []schema.Column{
+			{Name: "mood", Type: "public.mood", Default: "'happy'::public.mood"},
+		}}},
+	}
+
+	plan := schema.Diff(current, desired)
+	require.Len(t, plan.Steps, 2)
+	assert.Equal(t, "add_enum_values", plan.Steps[0].Name)
+	assert.Equal(t, []string{"ALTER TYPE public.mood ADD VALUE 'happy' AFTER 'ok'"}, plan.Steps[0].Up)
+	assert.Empty(t, plan.Steps[1].Name)
+	assert.Contains(t, plan.Steps[1].Up[0], "CREATE TABLE public.users")
+}
+
+func TestDiff_noChanges(t *testing.T) {
+	t.Parallel()
+
+	s := &schema.Schema{
+		Tables:  []schema.Table{{Name: "public.users", Columns: []schema.Column{{Name: "id", Type: "bigint"}}}},
+		Views:   []schema.View{{Name: "public.v", Definition: "SELECT 1"}},
+		Indexes: []schema.Index{{Name: "public.i", Table: "public.users", Definition: "CREATE INDEX i ON public.users USING btree (id)"}},
+	}
+
+	assert.Empty(t, schema.Diff(s, s).Steps)
+}
@@ -0,0 +1,581 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Option configures Inspect.
+type Option func(*inspector)
+
+// WithSchemas limits Inspect to the named schemas. By default every schema
+// other than the system schemas is read.
+func WithSchemas(names ...string) Option {
+	return func(i *inspector) {
+		i.schemas = append(i.schemas, names...)
+	}
+}
+
+// WithExcludedTables leaves out tables given as name or schema.name,
+// unquoted, such as the migration tracking tables. Sequences owned by them
+// are left out too.
+func WithExcludedTables(names ...string) Option {
+	return func(i *inspector) {
+		i.excluded = append(i.excluded, names...)
+	}
+}
+
+// inspector holds the filters and the transaction Inspect reads through.
+type inspector struct {
+	schemas  []string
+	excluded []string
+	tx       pgx.Tx
+}
+
+// schemaFilter limits a query to user schemas, as namespace n, and to the
+// schemas in $1 if not empty.
+const schemaFilter = `n.nspname NOT IN ('pg_catalog', 'information_schema')
+	AND n.nspname NOT LIKE 'pg\_%'
+	AND (cardinality($1::text[]) = 0 OR n.nspname = ANY($1::text[]))`
+
+// relationFilter limits a query to relations c in namespace n that are in
+// user schemas, not excluded by $2 and not created by an extension.
+const relationFilter = schemaFilter + `
+	AND NOT (c.relname = ANY($2::text[]) OR n.nspname || '.' || c.relname = ANY($2::text[]))
+	AND NOT EXISTS (SELECT 1 FROM pg_depend e
+		WHERE e.classid = 'pg_class'::regclass AND e.objid = c.oid AND e.deptype = 'e')`
+
+// relationName is the quoted, schema-qualified name of relation c in namespace n.
+const relationName = `quote_ident(n.nspname) || '.' || quote_ident(c.relname)`
+
+// Inspect reads the schema of the database pool connects to. It reads in a
+// single read-only transaction with an empty search_path, so every name in
+// the definitions PostgreSQL returns is schema-qualified.
+func Inspect(ctx context.Context, pool *pgxpool.Pool, opts ...Option) (*Schema, error) {
+	i := &inspector{schemas: []string{}, excluded: []string{}}
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("beginning catalog transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // read-only transaction
+
+	if _, err := tx.Exec(ctx, `SET LOCAL search_path = ''`); err != nil {
+		return nil, fmt.Errorf("clearing search_path: %w", err)
+	}
+
+	i.tx = tx
+
+	s := &Schema{}
+
+	steps := []struct {
+		what string
+		fn   func(context.Context, *Schema) error
+	}{
+		{"schemas", i.readSchemas},
+		{"extensions", i.readExtensions},
+		{"enum types", i.readEnums},
+		{"tables", i.readTables},
+		{"columns", i.readColumns},
+		{"constraints", i.readConstraints},
+		{"sequences", i.readSequences},
+		{"indexes", i.readIndexes},
+		{"functions", i.readFunctions},
+		{"views", i.readViews},
+		{"grants", i.readGrants},
+	}
+
+	for _, step := range steps {
+		if err := step.fn(ctx, s); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", step.what, err)
+		}
+	}
+
+	pruneSchemas(s)
+	sortSchema(s)
+
+	return s, nil
+}
+
+// query runs a catalog query in tx and collects its rows with scan.
+func query[T any](ctx context.Context, tx pgx.Tx, sql string, scan pgx.RowToFunc[T], args ...any) ([]T, error) {
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Inspect wraps with the objects read
+	}
+
+	return pgx.CollectRows(rows, scan) //nolint:wrapcheck // Inspect wraps with the objects read
+}
+
+// readSchemas reads the user schemas other than public.
+func (i *inspector) readSchemas(ctx context.Context, s *Schema) error {
+	names, err := query(ctx, i.tx, `
+		SELECT quote_ident(n.nspname) FROM pg_namespace n
+		WHERE `+schemaFilter+` AND n.nspname <> 'public'
+			AND NOT EXISTS (SELECT 1 FROM pg_depend e
+				WHERE e.classid = 'pg_namespace'::regclass AND e.objid = n.oid AND e.deptype = 'e')`,
+		pgx.RowTo[string], i.schemas)
+	if err != nil {
+		return err
+	}
+
+	s.Schemas = names
+
+	return nil
+}
+
+// readExtensions reads the installed extensions other than plpgsql, which
+// every database has.
+func (i *inspector) readExtensions(ctx context.Context, s *Schema) error {
+	exts, err := query(ctx, i.tx, `
+		SELECT quote_ident(x.extname), quote_ident(n.nspname)
+		FROM pg_extension x JOIN pg_namespace n ON n.oid = x.extnamespace
+		WHERE x.extname <> 'plpgsql'`,
+		pgx.RowToStructByPos[Extension])
+	if err != nil {
+		return err
+	}
+
+	s.Extensions = exts
+
+	return nil
+}
+
+// readEnums reads enum types and their labels.
+func (i *inspector) readEnums(ctx context.Context, s *Schema) error {
+	enums, err := query(ctx, i.tx, `
+		SELECT quote_ident(n.nspname) || '.' || quote_ident(t.typname),
+			array_agg(quote_literal(l.enumlabel) ORDER BY l.enumsortorder)
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		JOIN pg_enum l ON l.enumtypid = t.oid
+		WHERE `+schemaFilter+`
+			AND NOT EXISTS (SELECT 1 FROM pg_depend e
+				WHERE e.classid = 'pg_type'::regclass AND e.objid = t.oid AND e.deptype = 'e')
+		GROUP BY n.nspname, t.typname`,
+		pgx.RowToStructByPos[Enum], i.schemas)
+	if err != nil {
+		return err
+	}
+
+	s.Enums = enums
+
+	return nil
+}
+
+// readTables reads tables and partitioned tables, with the partition key of
+// partitioned tables and the parent and bound of partitions.
+func (i *inspector) readTables(ctx context.Context, s *Schema) error {
+	tables, err := query(ctx, i.tx, `
+		SELECT `+relationName+`,
+			COALESCE(pg_get_partkeydef(c.oid), ''),
+			COALESCE((SELECT quote_ident(pn.nspname) || '.' || quote_ident(p.relname)
+				FROM pg_inherits h
+				JOIN pg_class p ON p.oid = h.inhparent
+				JOIN pg_namespace pn ON pn.oid = p.relnamespace
+				WHERE h.inhrelid = c.oid AND c.relispartition), ''),
+			COALESCE(pg_get_expr(c.relpartbound, c.oid), '')
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p') AND `+relationFilter,
+		func(r pgx.CollectableRow) (Table, error) {
+			var t Table
+			err := r.Scan(&t.Name, &t.PartitionBy, &t.PartitionOf, &t.Bound)
+
+			return t, err //nolint:wrapcheck // Inspect wraps with the objects read
+		}, i.schemas, i.excluded)
+	if err != nil {
+		return err
+	}
+
+	s.Tables = tables
+
+	return nil
+}
+
+// readColumns reads the columns of tables that are not partitions, whose
+// columns come from their parent.
+func (i *inspector) readColumns(ctx context.Context, s *Schema) error {
+	type row struct {
+		Table string
+		Column
+	}
+
+	cols, err := query(ctx, i.tx, `
+		SELECT `+relationName+`, quote_ident(a.attname), format_type(a.atttypid, a.atttypmod),
+			CASE WHEN a.attcollation <> t.typcollation
+				THEN quote_ident(cn.nspname) || '.' || quote_ident(co.collname) ELSE '' END,
+			a.attnotnull,
+			COALESCE(pg_get_expr(d.adbin, d.adrelid), ''),
+			a.attgenerated <> '',
+			CASE a.attidentity WHEN 'a' THEN 'ALWAYS' WHEN 'd' THEN 'BY DEFAULT' ELSE '' END,
+			EXISTS (SELECT 1 FROM pg_depend dep
+				JOIN pg_proc p ON p.oid = dep.refobjid
+				JOIN pg_namespace pn ON pn.oid = p.pronamespace
+				WHERE dep.classid = 'pg_attrdef'::regclass AND dep.objid = d.oid
+					AND dep.refclassid = 'pg_proc'::regclass AND pn.nspname <> 'pg_catalog')
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_type t ON t.oid = a.atttypid
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		LEFT JOIN pg_collation co ON co.oid = a.attcollation
+		LEFT JOIN pg_namespace cn ON cn.oid = co.collnamespace
+		WHERE a.attnum > 0 AND NOT a.attisdropped
+			AND c.relkind IN ('r', 'p') AND NOT c.relispartition AND `+relationFilter+`
+		ORDER BY a.attnum`,
+		func(r pgx.CollectableRow) (row, error) {
+			var x row
+			err := r.Scan(&x.Table, &x.Name, &x.Type, &x.Collation, &x.NotNull,
+				&x.Default, &x.Generated, &x.Identity, &x.DeferDefault)
+
+			return x, err //nolint:wrapcheck // Inspect wraps with the objects read
+		}, i.schemas, i.excluded)
+	if err != nil {
+		return err
+	}
+
+	tables := tableIndex(s)
+
+	for _, c := range cols {
+		if t := tables[c.Table]; t != nil {
+			t.Columns = append(t.Columns, c.Column)
+		}
+	}
+
+	return nil
+}
+
+// readConstraints reads the constraints defined on each table itself, not
+// those a partition inherits from its parent.
+func (i *inspector) readConstraints(ctx context.Context, s *Schema) error {
+	type row struct {
+		Table string
+		Constraint
+	}
+
+	cons, err := query(ctx, i.tx, `
+		SELECT `+relationName+`, quote_ident(k.conname), k.contype::text, pg_get_constraintdef(k.oid)
+		FROM pg_constraint k
+		JOIN pg_class c ON c.oid = k.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE k.contype IN ('p', 'u', 'c', 'f', 'x') AND k.conislocal AND k.conparentid = 0
+			AND c.relkind IN ('r', 'p') AND `+relationFilter,
+		func(r pgx.CollectableRow) (row, error) {
+			var x row
+			err := r.Scan(&x.Table, &x.Name, &x.Kind, &x.Definition)
+
+			return x, err //nolint:wrapcheck // Inspect wraps with the objects read
+		}, i.schemas, i.excluded)
+	if err != nil {
+		return err
+	}
+
+	tables := tableIndex(s)
+
+	for _, c := range cons {
+		if t := tables[c.Table]; t != nil {
+			t.Constraints = append(t.Constraints, c.Constraint)
+		}
+	}
+
+	return nil
+}
+
+// readSequences reads sequences other than those backing identity columns.
+// Sequences owned by a column of a table left out are left out too.
+func (i *inspector) readSequences(ctx context.Context, s *Schema) error {
+	type row struct {
+		Sequence
+		ownerTable string
+	}
+
+	seqs, err := query(ctx, i.tx, `
+		SELECT `+relationName+`, format_type(q.seqtypid, NULL), q.seqstart, q.seqincrement,
+			q.seqmin, q.seqmax, q.seqcache, q.seqcycle,
+			COALESCE(quote_ident(tn.nspname) || '.' || quote_ident(t.relname), ''),
+			COALESCE(quote_ident(a.attname), '')
+		FROM pg_sequence q
+		JOIN pg_class c ON c.oid = q.seqrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_depend d ON d.classid = 'pg_class'::regclass AND d.objid = c.oid
+			AND d.refclassid = 'pg_class'::regclass AND d.deptype = 'a'
+		LEFT JOIN pg_class t ON t.oid = d.refobjid
+		LEFT JOIN pg_namespace tn ON tn.oid = t.relnamespace
+		LEFT JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+		WHERE `+relationFilter+`
+			AND NOT EXISTS (SELECT 1 FROM pg_depend i
+				WHERE i.classid = 'pg_class'::regclass AND i.objid = c.oid AND i.deptype = 'i')`,
+		func(r pgx.CollectableRow) (row, error) {
+			var (
+				x      row
+				column string
+			)
+
+			err := r.Scan(&x.Name, &x.Type, &x.Start, &x.Increment, &x.Min, &x.Max, &x.Cache, &x.Cycle,
+				&x.ownerTable, &column)
+			if x.ownerTable != "" {
+				x.OwnedBy = x.ownerTable + "." + column
+			}
+
+			return x, err //nolint:wrapcheck // Inspect wraps with the objects read
+		}, i.schemas, i.excluded)
+	if err != nil {
+		return err
+	}
+
+	tables := tableIndex(s)
+
+	for _, q := range seqs {
+		if q.ownerTable == "" || tables[q.ownerTable] != nil {
+			s.Sequences = append(s.Sequences, q.Sequence)
+		}
+	}
+
+	return nil
+}
+
+// readIndexes reads the indexes of tables and materialized views that do
+// not back a constraint and are not a partition's part of an index on its
+// parent. Indexes on a partitioned table are created ON ONLY the table by
+// pg_get_indexdef; ONLY is dropped so they cascade to the partitions.
+func (i *inspector) readIndexes(ctx context.Context, s *Schema) error {
+	indexes, err := query(ctx, i.tx, `
+		SELECT quote_ident(n.nspname) || '.' || quote_ident(ic.relname), `+relationName+`,
+			pg_get_indexdef(x.indexrelid)
+		FROM pg_index x
+		JOIN pg_class ic ON ic.oid = x.indexrelid
+		JOIN pg_class c ON c.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'm') AND `+relationFilter+`
+			AND NOT EXISTS (SELECT 1 FROM pg_constraint k
+				WHERE k.conindid = x.indexrelid AND k.contype IN ('p', 'u', 'x'))
+			AND NOT EXISTS (SELECT 1 FROM pg_inherits h WHERE h.inhrelid = x.indexrelid)`,
+		pgx.RowToStructByPos[Index], i.schemas, i.excluded)
+	if err != nil {
+		return err
+	}
+
+	for j := range indexes {
+		indexes[j].Definition = strings.Replace(indexes[j].Definition, " ON ONLY ", " ON ", 1)
+	}
+
+	s.Indexes = indexes
+
+	return nil
+}
+
+// readFunctions reads functions and procedures; aggregates and window
+// functions are not read.
+func (i *inspector) readFunctions(ctx context.Context, s *Schema) error {
+	fns, err := query(ctx, i.tx, `
+		SELECT quote_ident(n.nspname) || '.' || quote_ident(p.proname)
+				|| '(' || pg_get_function_identity_arguments(p.oid) || ')',
+			p.prokind = 'p',
+			pg_get_functiondef(p.oid),
+			p.proacl IS NOT NULL AND NOT EXISTS (SELECT 1 FROM aclexplode(p.proacl) a
+				WHERE a.grantee = 0 AND a.privilege_type = 'EXECUTE')
+		FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE p.prokind IN ('f', 'p') AND `+schemaFilter+`
+			AND NOT EXISTS (SELECT 1 FROM pg_depend e
+				WHERE e.classid = 'pg_proc'::regclass AND e.objid = p.oid AND e.deptype = 'e')`,
+		pgx.RowToStructByPos[Function], i.schemas)
+	if err != nil {
+		return err
+	}
+
+	for j := range fns {
+		fns[j].Definition = strings.TrimSpace(fns[j].Definition)
+	}
+
+	s.Functions = fns
+
+	return nil
+}
+
+// readViews reads views and materialized views, with the views each reads from.
+func (i *inspector) readViews(ctx context.Context, s *Schema) error {
+	views, err := query(ctx, i.tx, `
+		SELECT `+relationName+`, c.relkind = 'm', pg_get_viewdef(c.oid),
+			ARRAY(SELECT DISTINCT quote_ident(rn.nspname) || '.' || quote_ident(ref.relname)
+				FROM pg_rewrite w
+				JOIN pg_depend d ON d.classid = 'pg_rewrite'::regclass AND d.objid = w.oid
+					AND d.refclassid = 'pg_class'::regclass
+				JOIN pg_class ref ON ref.oid = d.refobjid
+				JOIN pg_namespace rn ON rn.oid = ref.relnamespace
+				WHERE w.ev_class = c.oid AND ref.oid <> c.oid AND ref.relkind IN ('v', 'm'))
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('v', 'm') AND `+relationFilter,
+		pgx.RowToStructByPos[View], i.schemas, i.excluded)
+	if err != nil {
+		return err
+	}
+
+	for j := range views {
+		views[j].Definition = strings.TrimSuffix(strings.TrimSpace(views[j].Definition), ";")
+		slices.Sort(views[j].DependsOn)
+	}
+
+	s.Views = views
+
+	return nil
+}
+
+// grantsQuery lists the privileges granted on relations, functions and
+// schemas to roles other than their owner, one row per privilege.
+const grantsQuery = `
+	SELECT CASE c.relkind WHEN 'S' THEN 'SEQUENCE ' ELSE 'TABLE ' END || ` + relationName + `,
+		a.grantee, a.privilege_type, a.is_grantable
+	FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace, aclexplode(c.relacl) a
+	WHERE c.relkind IN ('r', 'p', 'v', 'm', 'S') AND a.grantee <> c.relowner AND ` + relationFilter + `
+	UNION ALL
+	SELECT CASE p.prokind WHEN 'p' THEN 'PROCEDURE ' ELSE 'FUNCTION ' END
+			|| quote_ident(n.nspname) || '.' || quote_ident(p.proname)
+			|| '(' || pg_get_function_identity_arguments(p.oid) || ')',
+		a.grantee, a.privilege_type, a.is_grantable
+	FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace, aclexplode(p.proacl) a
+	WHERE p.prokind IN ('f', 'p') AND a.grantee <> p.proowner
+		AND NOT (a.grantee = 0 AND a.privilege_type = 'EXECUTE') AND ` + schemaFilter + `
+		AND NOT EXISTS (SELECT 1 FROM pg_depend e
+			WHERE e.classid = 'pg_proc'::regclass AND e.objid = p.oid AND e.deptype = 'e')
+	UNION ALL
+	SELECT 'SCHEMA ' || quote_ident(n.nspname), a.grantee, a.privilege_type, a.is_grantable
+	FROM pg_namespace n, aclexplode(n.nspacl) a
+	WHERE n.nspname <> 'public' AND a.grantee <> n.nspowner AND ` + schemaFilter
+
+// readGrants reads the privileges granted to roles other than the owner,
+// grouped by object, grantee and grant option.
+func (i *inspector) readGrants(ctx context.Context, s *Schema) error {
+	type row struct {
+		object    string
+		grantee   string
+		privilege string
+		grantable bool
+	}
+
+	rows, err := query(ctx, i.tx, `
+		SELECT g.object, CASE g.grantee WHEN 0 THEN 'PUBLIC' ELSE quote_ident(r.rolname) END,
+			g.privilege, g.grantable
+		FROM (`+grantsQuery+`) g (object, grantee, privilege, grantable)
+		LEFT JOIN pg_roles r ON r.oid = g.grantee`,
+		func(r pgx.CollectableRow) (row, error) {
+			var x row
+			err := r.Scan(&x.object, &x.grantee, &x.privilege, &x.grantable)
+
+			return x, err //nolint:wrapcheck // Inspect wraps with the objects read
+		}, i.schemas, i.excluded)
+	if err != nil {
+		return err
+	}
+
+	type key struct {
+		object    string
+		grantee   string
+		grantable bool
+	}
+
+	grants := make(map[key]*Grant)
+
+	for _, r := range rows {
+		k := key{r.object, r.grantee, r.grantable}
+		if grants[k] == nil {
+			grants[k] = &Grant{Object: r.object, Grantee: r.grantee, WithGrantOption: r.grantable}
+		}
+
+		grants[k].Privileges = append(grants[k].Privileges, r.privilege)
+	}
+
+	known := objectNames(s)
+
+	for _, g := range grants {
+		if !known[g.Object] {
+			continue
+		}
+
+		slices.Sort(g.Privileges)
+		s.Grants = append(s.Grants, *g)
+	}
+
+	return nil
+}
+
+// pruneSchemas drops schemas that hold none of the objects read, such as
+// a schema holding only the tracking tables, which the tracker creates.
+func pruneSchemas(s *Schema) {
+	var names []string
+
+	for _, x := range s.Extensions {
+		names = append(names, x.Schema+".")
+	}
+
+	for _, e := range s.Enums {
+		names = append(names, e.Name)
+	}
+
+	for _, q := range s.Sequences {
+		names = append(names, q.Name)
+	}
+
+	for _, t := range s.Tables {
+		names = append(names, t.Name)
+	}
+
+	for _, f := range s.Functions {
+		names = append(names, f.Name)
+	}
+
+	for _, v := range s.Views {
+		names = append(names, v.Name)
+	}
+
+	s.Schemas = slices.DeleteFunc(s.Schemas, func(schema string) bool {
+		return !slices.ContainsFunc(names, func(name string) bool {
+			return strings.HasPrefix(name, schema+".")
+		})
+	})
+}
+
+// objectNames returns the objects of s as named in grants, such as TABLE
+// public.users, to leave out grants on objects that were not read.
+func objectNames(s *Schema) map[string]bool {
+	names := make(map[string]bool)
+
+	for _, n := range s.Schemas {
+		names["SCHEMA "+n] = true
+	}
+
+	for _, q := range s.Sequences {
+		names["SEQUENCE "+q.Name] = true
+	}
+
+	for _, t := range s.Tables {
+		names["TABLE "+t.Name] = true
+	}
+
+	for _, v := range s.Views {
+		names["TABLE "+v.Name] = true
+	}
+
+	for _, f := range s.Functions {
+		names[f.Kind()+" "+f.Name] = true
+	}
+
+	return names
+}
+
+// tableIndex maps table names to the tables of s.
+func tableIndex(s *Schema) map[string]*Table {
+	tables := make(map[string]*Table, len(s.Tables))
+	for i := range s.Tables {
+		tables[s.Tables[i].Name] = &s.Tables[i]
+	}
+
+	return tables
+}
@@ -0,0 +1,139 @@
+// Package schema reads the schema of a live PostgreSQL database from
+// pg_catalog, renders it as SQL that recreates it, compares it with
+// another schema and plans the migrations from one to the other. The model encodes to JSON, so a schema can be saved and
+// compared later without a database.
+//
+// Names in the model are quoted as PostgreSQL quotes them (quote_ident), and
+// relation, type and function names are schema-qualified, so they can be
+// written into SQL as they are.
+package schema
+
+// Schema is the set of objects read from a database, each list sorted by name.
+type Schema struct {
+	Schemas    []string    `json:"schemas,omitempty"` // schemas other than public that hold objects
+	Extensions []Extension `json:"extensions,omitempty"`
+	Enums      []Enum      `json:"enums,omitempty"`
+	Sequences  []Sequence  `json:"sequences,omitempty"`
+	Tables     []Table     `json:"tables,omitempty"`
+	Functions  []Function  `json:"functions,omitempty"`
+	Views      []View      `json:"views,omitempty"`
+	Indexes    []Index     `json:"indexes,omitempty"`
+	Grants     []Grant     `json:"grants,omitempty"`
+}
+
+// Extension is an installed extension. Objects belonging to extensions are
+// created by the extension and are not part of the model.
+type Extension struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema,omitempty"`
+}
+
+// Enum is an enum type and its labels, as quoted literals in sort order.
+type Enum struct {
+	Name   string   `json:"name"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Sequence is a sequence that does not back an identity column.
+type Sequence struct {
+	Name      string `json:"name"`
+	Type      string `json:"type,omitempty"`
+	Start     int64  `json:"start,omitempty"`
+	Increment int64  `json:"increment,omitempty"`
+	Min       int64  `json:"min,omitempty"`
+	Max       int64  `json:"max,omitempty"`
+	Cache     int64  `json:"cache,omitempty"`
+	Cycle     bool   `json:"cycle,omitempty"`
+	OwnedBy   string `json:"owned_by,omitempty"` // table.column owning the sequence (serial columns), if any
+}
+
+// Table is a table or partitioned table.
+type Table struct {
+	Name        string       `json:"name"`
+	Columns     []Column     `json:"columns,omitempty"` // in column order; empty for partitions
+	Constraints []Constraint `json:"constraints,omitempty"`
+	PartitionBy string       `json:"partition_by,omitempty"` // partition key of a partitioned table, e.g. RANGE (created_at)
+	PartitionOf string       `json:"partition_of,omitempty"` // parent of a partition
+	Bound       string       `json:"bound,omitempty"`        // partition bound, e.g. FOR VALUES FROM (...) TO (...)
+}
+
+// Column is a table column.
+type Column struct {
+	Name      string `json:"name"`
+	Type      string `json:"type,omitempty"`
+	Collation string `json:"collation,omitempty"` // collation other than the type's default, if any
+	NotNull   bool   `json:"not_null,omitempty"`
+	Default   string `json:"default,omitempty"`   // default expression, or the expression of a generated column
+	Generated bool   `json:"generated,omitempty"` // stored generated column
+	Identity  string `json:"identity,omitempty"`  // ALWAYS or BY DEFAULT for identity columns
+	// DeferDefault is set when the default calls a function of the schema, so
+	// it is set after the functions are created.
+	DeferDefault bool `json:"defer_default,omitempty"`
+}
+
+// ConstraintKind is a pg_constraint.contype value.
+type ConstraintKind string
+
+// Constraint kinds in the model. NOT NULL is a column property.
+const (
+	PrimaryKey ConstraintKind = "p"
+	Unique     ConstraintKind = "u"
+	Check      ConstraintKind = "c"
+	ForeignKey ConstraintKind = "f"
+	Exclusion  ConstraintKind = "x"
+)
+
+// Constraint is a table constraint with its definition from
+// pg_get_constraintdef, e.g. PRIMARY KEY (id).
+type Constraint struct {
+	Name       string         `json:"name"`
+	Kind       ConstraintKind `json:"kind,omitempty"`
+	Definition string         `json:"definition,omitempty"`
+}
+
+// Index is an index that does not back a constraint, with its CREATE INDEX
+// statement from pg_get_indexdef.
+type Index struct {
+	Name       string `json:"name"`
+	Table      string `json:"table,omitempty"`
+	Definition string `json:"definition,omitempty"`
+}
+
+// Function is a function or procedure. Name includes its argument types,
+// e.g. public.add(integer, integer); Definition is the CREATE statement
+// from pg_get_functiondef.
+type Function struct {
+	Name       string `json:"name"`
+	Procedure  bool   `json:"procedure,omitempty"`
+	Definition string `json:"definition,omitempty"`
+	// PublicExecuteRevoked is set when EXECUTE, granted to PUBLIC by default,
+	// has been revoked.
+	PublicExecuteRevoked bool `json:"public_execute_revoked,omitempty"`
+}
+
+// View is a view or materialized view. Definition is its query.
+type View struct {
+	Name         string   `json:"name"`
+	Materialized bool     `json:"materialized,omitempty"`
+	Definition   string   `json:"definition,omitempty"`
+	DependsOn    []string `json:"depends_on,omitempty"` // views the query reads from
+}
+
+// Grant is a set of privileges granted on an object, such as TABLE
+// public.users or FUNCTION public.add(integer, integer). Grantee is a quoted
+// role name or PUBLIC. The owner's own privileges are not listed.
+type Grant struct {
+	Object          string   `json:"object,omitempty"`
+	Grantee         string   `json:"grantee,omitempty"`
+	Privileges      []string `json:"privileges,omitempty"`
+	WithGrantOption bool     `json:"with_grant_option,omitempty"`
+}
+
+// Kind returns FUNCTION or PROCEDURE, as the object is named in GRANT.
+func (f Function) Kind() string {
+	if f.Procedure {
+		return "PROCEDURE"
+	}
+
+	return "FUNCTION"
+}
@@ -0,0 +1,147 @@
+package schema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/parser"
+	"github.com/aqasim81/database-migration-engine/internal/schema"
+)
+
+func sampleSchema() *schema.Schema {
+	return &schema.Schema{
+		Schemas:    []string{"app"},
+		Extensions: []schema.Extension{{Name: "citext", Schema: "public"}},
+		Enums:      []schema.Enum{{Name: "public.mood", Labels: []string{"'sad'", "'happy'"}}},
+		Sequences: []schema.Sequence{{
+			Name: "public.orders_id_seq", Type: "integer", Start: 1, Increment: 1, Min: 1, Max: 2147483647, Cache: 1,
+			OwnedBy: "public.orders.id",
+		}},
+		Tables: []schema.Table{
+			{
+				Name: "public.events_2024", PartitionOf: "public.events",
+				Bound: "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+			},
+			{
+				Name:        "public.events",
+				Columns:     []schema.Column{{Name: "created_at", Type: "date", NotNull: true}},
+				PartitionBy: "RANGE (created_at)",
+			},
+			{
+				Name: "public.orders",
+				Columns: []schema.Column{
+					{Name: "id", Type: "integer", NotNull: true, Default: "nextval('public.orders_id_seq'::regclass)"},
+					{Name: "user_id", Type: "bigint", NotNull: true},
+					{Name: "code", Type: "text", Default: "public.new_code()", DeferDefault: true},
+				},
+				Constraints: []schema.Constraint{
+					{Name: "orders_pkey", Kind: schema.PrimaryKey, Definition: "PRIMARY KEY (id)"},
+					{Name: "orders_user_id_fkey", Kind: schema.ForeignKey, Definition: "FOREIGN KEY (user_id) REFERENCES public.users(id)"},
+				},
+			},
+			{
+				Name: "public.users",
+				Columns: []schema.Column{
+					{Name: "id", Type: "bigint", NotNull: true, Identity: "BY DEFAULT"},
+					{Name: "email", Type: "public.citext", NotNull: true},
+					{Name: "name", Type: "text", Collation: `pg_catalog."C"`},
+					{Name: "mood", Type: "public.mood", Default: "'happy'::public.mood"},
+					{Name: "lower_email", Type: "text", Default: "lower((email)::text)", Generated: true},
+				},
+				Constraints: []schema.Constraint{
+					{Name: "users_pkey", Kind: schema.PrimaryKey, Definition: "PRIMARY KEY (id)"},
+				},
+			},
+		},
+		Functions: []schema.Function{{
+			Name: "public.new_code()",
+			Definition: "CREATE OR REPLACE FUNCTION public.new_code()\n RETURNS text\n LANGUAGE sql\n" +
+				"AS $function$SELECT md5(random()::text)$function$",
+			PublicExecuteRevoked: true,
+		}},
+		Views: []schema.View{
+			{Name: "app.active_users", Definition: " SELECT id\n   FROM app.user_emails", DependsOn: []string{"app.user_emails"}},
+			{Name: "app.user_emails", Definition: " SELECT id,\n    email\n   FROM public.users", Materialized: true},
+		},
+		Indexes: []schema.Index{{
+			Name: "public.users_email_idx", Table: "public.users",
+			Definition: "CREATE UNIQUE INDEX users_email_idx ON public.users USING btree (email)",
+		}},
+		Grants: []schema.Grant{
+			{Object: "SCHEMA app", Grantee: "reader", Privileges: []string{"USAGE"}},
+			{Object: "TABLE public.users", Grantee: "reader", Privileges: []string{"INSERT", "SELECT"}, WithGrantOption: true},
+		},
+	}
+}
+
+func TestSQL_parsesInDependencyOrder(t *testing.T) {
+	t.Parallel()
+
+	s := sampleSchema()
+	sql := s.SQL()
+
+	res, err := parser.Parse(sql)
+	require.NoError(t, err)
+	assert.Len(t, res.Stmts, len(s.Statements()))
+
+	order := []string{
+		"SET LOCAL check_function_bodies = false;",
+		"CREATE SCHEMA app;",
+		"CREATE EXTENSION IF NOT EXISTS citext WITH SCHEMA public;",
+		"CREATE TYPE public.mood AS ENUM ('sad', 'happy');",
+		"CREATE SEQUENCE public.orders_id_seq AS integer START WITH 1 INCREMENT BY 1 MINVALUE 1 MAXVALUE 2147483647 CACHE 1;",
+		"CREATE TABLE public.events (\n    created_at date NOT NULL\n) PARTITION BY RANGE (created_at);",
+		"CREATE TABLE public.events_2024 PARTITION OF public.events FOR VALUES FROM ('2024-01-01') TO ('2025-01-01');",
+		"    code text\n",
+		"    id bigint GENERATED BY DEFAULT AS IDENTITY NOT NULL,\n",
+		`    name text COLLATE pg_catalog."C",`,
+		"    mood public.mood DEFAULT 'happy'::public.mood,\n",
+		"    lower_email text GENERATED ALWAYS AS (lower((email)::text)) STORED\n",
+		"ALTER SEQUENCE public.orders_id_seq OWNED BY public.orders.id;",
+		"CREATE OR REPLACE FUNCTION public.new_code()",
+		"ALTER TABLE public.orders ALTER COLUMN code SET DEFAULT public.new_code();",
+		"ALTER TABLE public.orders ADD CONSTRAINT orders_pkey PRIMARY KEY (id);",
+		"ALTER TABLE public.users ADD CONSTRAINT users_pkey PRIMARY KEY (id);",
+		"CREATE MATERIALIZED VIEW app.user_emails AS\n SELECT id,",
+		"CREATE VIEW app.active_users AS\n SELECT id\n   FROM app.user_emails;",
+		"CREATE UNIQUE INDEX users_email_idx ON public.users USING btree (email);",
+		"ALTER TABLE public.orders ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);",
+		"REVOKE EXECUTE ON FUNCTION public.new_code() FROM PUBLIC;",
+		"GRANT USAGE ON SCHEMA app TO reader;",
+		"GRANT INSERT, SELECT ON TABLE public.users TO reader WITH GRANT OPTION;",
+	}
+
+	pos := 0
+
+	for _, want := range order {
+		i := strings.Index(sql[pos:], want)
+		require.GreaterOrEqual(t, i, 0, "%q not found after position %d in:\n%s", want, pos, sql)
+
+		pos += i + len(want)
+	}
+
+	assert.Equal(t, sql, s.SQL(), "rendering is deterministic")
+}
+
+func TestSQL_empty(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, (&schema.Schema{}).SQL())
+}
+
+func TestSQL_viewCycleIsBroken(t *testing.T) {
+	t.Parallel()
+
+	s := &schema.Schema{Views: []schema.View{
+		{Name: "public.a", Definition: "SELECT 1", DependsOn: []string{"public.b"}},
+		{Name: "public.b", Definition: "SELECT 2", DependsOn: []string{"public.a", "public.missing"}},
+	}}
+
+	assert.Equal(t, []string{
+		"CREATE VIEW public.b AS\nSELECT 2",
+		"CREATE VIEW public.a AS\nSELECT 1",
+	}, s.Statements())
+}
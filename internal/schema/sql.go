@@ -0,0 +1,320 @@
+package schema
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SQL renders statements that recreate s, each terminated by a semicolon
+// and separated by a blank line.
+func (s *Schema) SQL() string {
+	stmts := s.Statements()
+	if len(stmts) == 0 {
+		return ""
+	}
+
+	return strings.Join(stmts, ";\n\n") + ";\n"
+}
+
+// Statements returns the statements that recreate s, without semicolons, in
+// dependency order:
+//
+//   - schemas, extensions, enum types and sequences;
+//   - tables, parents before partitions, then sequence ownership;
+//   - functions, then column defaults that call them;
+//   - constraints other than foreign keys;
+//   - views, each after the views it reads from;
+//   - indexes, then foreign keys, which may reference unique indexes;
+//   - grants.
+//
+// Function bodies are not checked at creation, as they may use objects
+// created after them.
+func (s *Schema) Statements() []string {
+	stmts := createPreamble(s)
+	tables := orderTables(s.Tables)
+
+	for _, t := range tables {
+		stmts = append(stmts, createTable(t))
+	}
+
+	for _, q := range s.Sequences {
+		if q.OwnedBy != "" {
+			stmts = append(stmts, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s", q.Name, q.OwnedBy))
+		}
+	}
+
+	for _, f := range s.Functions {
+		stmts = append(stmts, f.Definition)
+	}
+
+	stmts = append(stmts, deferredDefaults(tables)...)
+	stmts = append(stmts, addConstraints(tables, func(k ConstraintKind) bool { return k != ForeignKey })...)
+
+	for _, v := range orderViews(s.Views) {
+		stmts = append(stmts, createView(v))
+	}
+
+	for _, x := range s.Indexes {
+		stmts = append(stmts, x.Definition)
+	}
+
+	stmts = append(stmts, addConstraints(tables, func(k ConstraintKind) bool { return k == ForeignKey })...)
+
+	return append(stmts, grants(s)...)
+}
+
+// createPreamble renders the statements that come before the tables: schemas,
+// extensions, enum types and sequences.
+func createPreamble(s *Schema) []string {
+	var stmts []string
+
+	if len(s.Functions) > 0 {
+		stmts = append(stmts, "SET LOCAL check_function_bodies = false")
+	}
+
+	for _, n := range s.Schemas {
+		stmts = append(stmts, "CREATE SCHEMA "+n)
+	}
+
+	for _, x := range s.Extensions {
+		stmts = append(stmts, fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %s WITH SCHEMA %s", x.Name, x.Schema))
+	}
+
+	for _, e := range s.Enums {
+		stmts = append(stmts, fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", e.Name, strings.Join(e.Labels, ", ")))
+	}
+
+	for _, q := range s.Sequences {
+		stmts = append(stmts, createSequence(q))
+	}
+
+	return stmts
+}
+
+// createSequence renders CREATE SEQUENCE with every option spelled out.
+func createSequence(q Sequence) string {
+	stmt := fmt.Sprintf("CREATE SEQUENCE %s AS %s START WITH %d INCREMENT BY %d MINVALUE %d MAXVALUE %d CACHE %d",
+		q.Name, q.Type, q.Start, q.Increment, q.Min, q.Max, q.Cache)
+	if q.Cycle {
+		stmt += " CYCLE"
+	}
+
+	return stmt
+}
+
+// createTable renders CREATE TABLE with the table's columns followed by
+// the inline constraints, or CREATE TABLE ... PARTITION OF for a partition,
+// which takes none.
+func createTable(t Table, inline ...Constraint) string {
+	var b strings.Builder
+
+	if t.PartitionOf != "" {
+		fmt.Fprintf(&b, "CREATE TABLE %s PARTITION OF %s %s", t.Name, t.PartitionOf, t.Bound)
+	} else {
+		fmt.Fprintf(&b, "CREATE TABLE %s (", t.Name)
+
+		elems := make([]string, 0, len(t.Columns)+len(inline))
+		for _, c := range t.Columns {
+			elems = append(elems, columnDefinition(c))
+		}
+
+		for _, c := range inline {
+			elems = append(elems, "CONSTRAINT "+c.Name+" "+c.Definition)
+		}
+
+		for i, e := range elems {
+			if i > 0 {
+				b.WriteString(",")
+			}
+
+			b.WriteString("\n    " + e)
+		}
+
+		if len(elems) > 0 {
+			b.WriteString("\n")
+		}
+
+		b.WriteString(")")
+	}
+
+	if t.PartitionBy != "" {
+		b.WriteString(" PARTITION BY " + t.PartitionBy)
+	}
+
+	return b.String()
+}
+
+// columnDefinition renders a column as written in CREATE TABLE. A deferred
+// default is left out.
+func columnDefinition(c Column) string {
+	def := c.Name + " " + c.Type
+
+	if c.Collation != "" {
+		def += " COLLATE " + c.Collation
+	}
+
+	switch {
+	case c.Generated:
+		def += " GENERATED ALWAYS AS (" + c.Default + ") STORED"
+	case c.Identity != "":
+		def += " GENERATED " + c.Identity + " AS IDENTITY"
+	case c.Default != "" && !c.DeferDefault:
+		def += " DEFAULT " + c.Default
+	}
+
+	if c.NotNull {
+		def += " NOT NULL"
+	}
+
+	return def
+}
+
+// deferredDefaults renders ALTER TABLE ... SET DEFAULT for the defaults
+// left out of CREATE TABLE because they call a function of the schema.
+func deferredDefaults(tables []Table) []string {
+	var stmts []string
+
+	for _, t := range tables {
+		for _, c := range t.Columns {
+			if c.DeferDefault && !c.Generated {
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", t.Name, c.Name, c.Default))
+			}
+		}
+	}
+
+	return stmts
+}
+
+// addConstraints renders ALTER TABLE ... ADD CONSTRAINT for the constraints
+// of tables whose kind matches.
+func addConstraints(tables []Table, match func(ConstraintKind) bool) []string {
+	var stmts []string
+
+	for _, t := range tables {
+		for _, c := range t.Constraints {
+			if match(c.Kind) {
+				stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", t.Name, c.Name, c.Definition))
+			}
+		}
+	}
+
+	return stmts
+}
+
+// createView renders CREATE VIEW or CREATE MATERIALIZED VIEW.
+func createView(v View) string {
+	kind := "VIEW"
+	if v.Materialized {
+		kind = "MATERIALIZED VIEW"
+	}
+
+	return fmt.Sprintf("CREATE %s %s AS\n%s", kind, v.Name, v.Definition)
+}
+
+// grants renders GRANT for each grant, and REVOKE for functions whose
+// EXECUTE privilege was revoked from PUBLIC.
+func grants(s *Schema) []string {
+	var stmts []string
+
+	for _, f := range s.Functions {
+		if f.PublicExecuteRevoked {
+			stmts = append(stmts, fmt.Sprintf("REVOKE EXECUTE ON %s %s FROM PUBLIC", f.Kind(), f.Name))
+		}
+	}
+
+	for _, g := range s.Grants {
+		stmt := fmt.Sprintf("GRANT %s ON %s TO %s", strings.Join(g.Privileges, ", "), g.Object, g.Grantee)
+		if g.WithGrantOption {
+			stmt += " WITH GRANT OPTION"
+		}
+
+		stmts = append(stmts, stmt)
+	}
+
+	return stmts
+}
+
+// orderTables orders partitions after their parent.
+func orderTables(tables []Table) []Table {
+	parents := make(map[string][]string, len(tables))
+	for _, t := range tables {
+		if t.PartitionOf != "" {
+			parents[t.Name] = []string{t.PartitionOf}
+		}
+	}
+
+	return dependencyOrder(tables, func(t Table) string { return t.Name }, func(name string) []string {
+		return parents[name]
+	})
+}
+
+// orderViews orders views after the views they read from.
+func orderViews(views []View) []View {
+	deps := make(map[string][]string, len(views))
+	for _, v := range views {
+		deps[v.Name] = v.DependsOn
+	}
+
+	return dependencyOrder(views, func(v View) string { return v.Name }, func(name string) []string {
+		return deps[name]
+	})
+}
+
+// dependencyOrder orders items so that each comes after the items deps
+// names for it, keeping the given order otherwise. Names that are not items
+// are ignored, and a cycle is broken at the first item of it reached.
+func dependencyOrder[T any](items []T, name func(T) string, deps func(string) []string) []T {
+	byName := make(map[string]T, len(items))
+	for _, item := range items {
+		byName[name(item)] = item
+	}
+
+	visited := make(map[string]bool, len(items))
+	ordered := make([]T, 0, len(items))
+
+	var visit func(string)
+
+	visit = func(n string) {
+		item, ok := byName[n]
+		if !ok || visited[n] {
+			return
+		}
+
+		visited[n] = true
+
+		for _, d := range deps(n) {
+			visit(d)
+		}
+
+		ordered = append(ordered, item)
+	}
+
+	for _, item := range items {
+		visit(name(item))
+	}
+
+	return ordered
+}
+
+// sortSchema sorts the objects of s by name, so that the SQL rendered for
+// a database does not depend on catalog order.
+func sortSchema(s *Schema) {
+	slices.Sort(s.Schemas)
+	slices.SortFunc(s.Extensions, func(a, b Extension) int { return cmp.Compare(a.Name, b.Name) })
+	slices.SortFunc(s.Enums, func(a, b Enum) int { return cmp.Compare(a.Name, b.Name) })
+	slices.SortFunc(s.Sequences, func(a, b Sequence) int { return cmp.Compare(a.Name, b.Name) })
+	slices.SortFunc(s.Tables, func(a, b Table) int { return cmp.Compare(a.Name, b.Name) })
+	slices.SortFunc(s.Functions, func(a, b Function) int { return cmp.Compare(a.Name, b.Name) })
+	slices.SortFunc(s.Views, func(a, b View) int { return cmp.Compare(a.Name, b.Name) })
+	slices.SortFunc(s.Indexes, func(a, b Index) int { return cmp.Compare(a.Name, b.Name) })
+	slices.SortFunc(s.Grants, func(a, b Grant) int {
+		return cmp.Or(cmp.Compare(a.Object, b.Object), cmp.Compare(a.Grantee, b.Grantee),
+			cmp.Compare(fmt.Sprint(a.WithGrantOption), fmt.Sprint(b.WithGrantOption)))
+	})
+
+	for i := range s.Tables {
+		slices.SortFunc(s.Tables[i].Constraints, func(a, b Constraint) int { return cmp.Compare(a.Name, b.Name) })
+	}
+}
@@ -3,10 +3,16 @@ package tracker
 import "errors"
 
 // ErrMigrationNotFound indicates no record exists for the given migration version.
-var ErrMigrationNotFound = errors.New("migration not found in schema_migrations")
+var ErrMigrationNotFound = errors.New("migration not found in tracking table")
 
 // ErrChecksumMismatch indicates the recorded checksum differs from the expected one.
 var ErrChecksumMismatch = errors.New("migration checksum mismatch")
 
-// ErrTableCreation indicates the schema_migrations table could not be created.
-var ErrTableCreation = errors.New("creating schema_migrations table")
+// ErrTableCreation indicates the tracking tables could not be created.
+var ErrTableCreation = errors.New("creating migration tracking tables")
+
+// ErrInvalidIdentifier indicates the configured tracking schema or table name is not usable.
+var ErrInvalidIdentifier = errors.New("invalid tracking table identifier")
+
+// ErrSchemaTooNew indicates the tracking tables were upgraded by a newer version of the tool.
+var ErrSchemaTooNew = errors.New("tracking schema is newer than this tool supports")
@@ -0,0 +1,147 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Direction values recorded in the history table.
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+	// DirectionImport marks a migration recorded as applied by migrate
+	// import from another tool's history, without running it.
+	DirectionImport = "import"
+	// DirectionBaseline marks a migration recorded by migrate baseline,
+	// without running it.
+	DirectionBaseline = "baseline"
+)
+
+// Outcome values recorded in the history table.
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	// OutcomeInterrupted marks an attempt cancelled by a signal or lost
+	// lock. Its transaction was rolled back unless it ran outside one.
+	OutcomeInterrupted = "interrupted"
+)
+
+// unknownActor is recorded when the OS user or hostname cannot be determined.
+const unknownActor = "unknown"
+
+// HistoryParams contains the fields needed to append a history entry.
+type HistoryParams struct {
+	Version    string
+	Filename   string
+	Direction  string
+	Checksum   string
+	DurationMs int
+	Outcome    string
+	Error      string // Error message for failed attempts (empty on success)
+	Ticket     string // Optional change-ticket reference
+}
+
+// HistoryEntry represents a row from the schema_migrations_history table.
+type HistoryEntry struct {
+	ID          int64
+	Version     string
+	Filename    string
+	Direction   string
+	Checksum    string
+	DurationMs  int
+	Outcome     string
+	Error       string
+	DBUser      string
+	OSUser      string
+	Hostname    string
+	ToolVersion string
+	Ticket      string
+	ExecutedAt  time.Time
+}
+
+// HistoryQuery filters the entries returned by GetHistory.
+type HistoryQuery struct {
+	Version string // Only entries for this version (empty for all)
+	Limit   int    // Maximum number of entries, newest first (0 for no limit)
+}
+
+// actor identifies who is running the tool. The database user is captured
+// server-side via current_user.
+type actor struct {
+	osUser      string
+	hostname    string
+	toolVersion string
+}
+
+// currentActor returns the OS user and hostname of the running process.
+func currentActor() actor {
+	a := actor{osUser: unknownActor, hostname: unknownActor, toolVersion: unknownActor}
+
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		a.osUser = u.Username
+	} else if v := os.Getenv("USER"); v != "" {
+		a.osUser = v
+	}
+
+	if h, err := os.Hostname(); err == nil && h != "" {
+		a.hostname = h
+	}
+
+	return a
+}
+
+// RecordHistory appends an entry to the schema_migrations_history table.
+func (t *Tracker) RecordHistory(ctx context.Context, p HistoryParams) error {
+	_, err := t.db.Exec(ctx, t.sql(
+		`INSERT INTO {{history}}
+		     (version, filename, direction, checksum, duration_ms, outcome,
+		      error_message, os_user, hostname, tool_version, ticket)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`),
+		p.Version, p.Filename, p.Direction, p.Checksum, p.DurationMs, p.Outcome,
+		p.Error, t.actor.osUser, t.actor.hostname, t.actor.toolVersion, p.Ticket,
+	)
+	if err != nil {
+		return fmt.Errorf("recording history for migration %s: %w", p.Version, err)
+	}
+
+	return nil
+}
+
+// GetHistory returns history entries, newest first.
+func (t *Tracker) GetHistory(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) {
+	rows, err := t.db.Query(ctx, t.sql(
+		`SELECT id, version, filename, direction, checksum, duration_ms, outcome,
+		        error_message, db_user, os_user, hostname, tool_version, ticket, executed_at
+		 FROM {{history}}
+		 WHERE $1 = '' OR version = $1
+		 ORDER BY id DESC
+		 LIMIT NULLIF($2::int, 0)`),
+		q.Version, q.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying migration history: %w", err)
+	}
+	defer rows.Close()
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (HistoryEntry, error) {
+		var h HistoryEntry
+		if scanErr := row.Scan(
+			&h.ID, &h.Version, &h.Filename, &h.Direction, &h.Checksum, &h.DurationMs, &h.Outcome,
+			&h.Error, &h.DBUser, &h.OSUser, &h.Hostname, &h.ToolVersion, &h.Ticket, &h.ExecutedAt,
+		); scanErr != nil {
+			return HistoryEntry{}, fmt.Errorf("scanning history row: %w", scanErr)
+		}
+
+		return h, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning migration history: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,80 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AppliedRepeatable represents a row from the repeatable migrations table:
+// the last successful run of a repeatable migration.
+type AppliedRepeatable struct {
+	Name       string
+	Filename   string
+	Checksum   string
+	AppliedAt  time.Time
+	DurationMs int
+	Hostname   string
+}
+
+// RepeatableParams contains the fields needed to record a repeatable
+// migration as applied.
+type RepeatableParams struct {
+	Name       string
+	Filename   string
+	Checksum   string
+	DurationMs int
+}
+
+// GetRepeatables returns the last applied run of every repeatable
+// migration, ordered by name.
+func (t *Tracker) GetRepeatables(ctx context.Context) ([]AppliedRepeatable, error) {
+	rows, err := t.db.Query(ctx, t.sql(
+		`SELECT name, filename, checksum, applied_at, duration_ms, hostname
+		 FROM {{repeatable}}
+		 ORDER BY name`,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("querying repeatable migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (AppliedRepeatable, error) {
+		var r AppliedRepeatable
+		if scanErr := row.Scan(
+			&r.Name, &r.Filename, &r.Checksum, &r.AppliedAt, &r.DurationMs, &r.Hostname,
+		); scanErr != nil {
+			return AppliedRepeatable{}, fmt.Errorf("scanning repeatable migration row: %w", scanErr)
+		}
+
+		return r, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning repeatable migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// RecordRepeatable inserts or replaces the record of a repeatable migration,
+// so that it is not re-applied until its checksum changes again.
+func (t *Tracker) RecordRepeatable(ctx context.Context, p RepeatableParams) error {
+	_, err := t.db.Exec(ctx, t.sql(
+		`INSERT INTO {{repeatable}} (name, filename, checksum, duration_ms, hostname)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (name) DO UPDATE SET
+		     filename = EXCLUDED.filename,
+		     checksum = EXCLUDED.checksum,
+		     applied_at = NOW(),
+		     duration_ms = EXCLUDED.duration_ms,
+		     hostname = EXCLUDED.hostname`),
+		p.Name, p.Filename, p.Checksum, p.DurationMs, t.actor.hostname,
+	)
+	if err != nil {
+		return fmt.Errorf("recording repeatable migration %s as applied: %w", p.Name, err)
+	}
+
+	return nil
+}
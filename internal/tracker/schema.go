@@ -1,7 +1,11 @@
 package tracker
 
+// createNamespaceSQL creates the configured tracking schema.
+const createNamespaceSQL = `CREATE SCHEMA IF NOT EXISTS {{schema}}`
+
 // createSchemaSQL is the DDL for the schema_migrations tracking table.
-const createSchemaSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (
+// Table names are placeholders substituted by Tracker.sql.
+const createSchemaSQL = `CREATE TABLE IF NOT EXISTS {{table}} (
     version      TEXT PRIMARY KEY,
     filename     TEXT NOT NULL,
     checksum     TEXT NOT NULL,
@@ -9,3 +13,41 @@ const createSchemaSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (
     duration_ms  INTEGER NOT NULL,
     status       TEXT NOT NULL DEFAULT 'applied'
 )`
+
+// createHistorySQL is the DDL for the append-only schema_migrations_history
+// audit log. Rows are only ever inserted, never updated or deleted.
+const createHistorySQL = `CREATE TABLE IF NOT EXISTS {{history}} (
+    id            BIGSERIAL PRIMARY KEY,
+    version       TEXT NOT NULL,
+    filename      TEXT NOT NULL,
+    direction     TEXT NOT NULL,
+    checksum      TEXT NOT NULL,
+    duration_ms   INTEGER NOT NULL,
+    outcome       TEXT NOT NULL,
+    error_message TEXT NOT NULL DEFAULT '',
+    db_user       TEXT NOT NULL DEFAULT current_user,
+    os_user       TEXT NOT NULL,
+    hostname      TEXT NOT NULL,
+    tool_version  TEXT NOT NULL,
+    ticket        TEXT NOT NULL DEFAULT '',
+    executed_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)`
+
+// createRepeatableSQL is the DDL for the table recording the last applied
+// checksum of each repeatable migration, keyed by name.
+const createRepeatableSQL = `CREATE TABLE IF NOT EXISTS {{repeatable}} (
+    name         TEXT PRIMARY KEY,
+    filename     TEXT NOT NULL,
+    checksum     TEXT NOT NULL,
+    applied_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    duration_ms  INTEGER NOT NULL,
+    hostname     TEXT NOT NULL DEFAULT ''
+)`
+
+// createMetaSQL is the DDL for the single-row table recording the version of
+// the tracking schema itself.
+const createMetaSQL = `CREATE TABLE IF NOT EXISTS {{meta}} (
+    id              BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    schema_version  INTEGER NOT NULL,
+    upgraded_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)`
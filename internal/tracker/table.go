@@ -0,0 +1,116 @@
+package tracker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultTable is the default name of the migration tracking table.
+const DefaultTable = "schema_migrations"
+
+// historySuffix is appended to the tracking table name to form the
+// history table name (e.g., schema_migrations_history).
+const historySuffix = "_history"
+
+// metaSuffix is appended to the tracking table name to form the metadata
+// table name (e.g., schema_migrations_meta).
+const metaSuffix = "_meta"
+
+// repeatableSuffix is appended to the tracking table name to form the table
+// recording repeatable migrations (e.g., schema_migrations_repeatable). It is
+// the longest suffix, so it bounds the tracking table name's length.
+const repeatableSuffix = "_repeatable"
+
+// maxIdentifierLen is PostgreSQL's identifier length limit (NAMEDATALEN - 1).
+const maxIdentifierLen = 63
+
+// Placeholders substituted into tracker SQL by Tracker.sql.
+const (
+	tablePlaceholder      = "{{table}}"
+	historyPlaceholder    = "{{history}}"
+	metaPlaceholder       = "{{meta}}"
+	repeatablePlaceholder = "{{repeatable}}"
+	schemaPlaceholder     = "{{schema}}"
+)
+
+// tableNames identifies the tracking tables. An empty schema means the
+// tables are resolved through the connection's search_path.
+type tableNames struct {
+	schema string
+	table  string
+}
+
+// historyTable returns the unquoted name of the history table.
+func (n tableNames) historyTable() string {
+	return n.table + historySuffix
+}
+
+// metaTable returns the unquoted name of the metadata table.
+func (n tableNames) metaTable() string {
+	return n.table + metaSuffix
+}
+
+// repeatableTable returns the unquoted name of the repeatable migrations table.
+func (n tableNames) repeatableTable() string {
+	return n.table + repeatableSuffix
+}
+
+// qualify returns the quoted, optionally schema-qualified identifier for name.
+func (n tableNames) qualify(name string) string {
+	if n.schema == "" {
+		return pgx.Identifier{name}.Sanitize()
+	}
+
+	return pgx.Identifier{n.schema, name}.Sanitize()
+}
+
+// validate checks that the configured names are usable PostgreSQL identifiers.
+func (n tableNames) validate() error {
+	if n.table == "" {
+		return fmt.Errorf("%w: tracking table name is empty", ErrInvalidIdentifier)
+	}
+
+	if len(n.repeatableTable()) > maxIdentifierLen {
+		return fmt.Errorf("%w: tracking table name %q exceeds %d characters",
+			ErrInvalidIdentifier, n.table, maxIdentifierLen-len(repeatableSuffix))
+	}
+
+	if len(n.schema) > maxIdentifierLen {
+		return fmt.Errorf("%w: tracking schema name %q exceeds %d characters",
+			ErrInvalidIdentifier, n.schema, maxIdentifierLen)
+	}
+
+	if strings.ContainsRune(n.table, 0) || strings.ContainsRune(n.schema, 0) {
+		return fmt.Errorf("%w: identifiers must not contain NUL bytes", ErrInvalidIdentifier)
+	}
+
+	return nil
+}
+
+// replacer returns a Replacer that substitutes quoted identifiers for the
+// placeholders used in tracker SQL.
+func (n tableNames) replacer() *strings.Replacer {
+	return strings.NewReplacer(
+		tablePlaceholder, n.qualify(n.table),
+		historyPlaceholder, n.qualify(n.historyTable()),
+		metaPlaceholder, n.qualify(n.metaTable()),
+		repeatablePlaceholder, n.qualify(n.repeatableTable()),
+		schemaPlaceholder, pgx.Identifier{n.schema}.Sanitize(),
+	)
+}
+
+// Tables returns the unquoted names of the tracking tables, qualified as
+// schema.name when a tracking schema is configured.
+func (t *Tracker) Tables() []string {
+	names := []string{t.names.table, t.names.historyTable(), t.names.metaTable(), t.names.repeatableTable()}
+
+	if t.names.schema != "" {
+		for i, name := range names {
+			names[i] = t.names.schema + "." + name
+		}
+	}
+
+	return names
+}
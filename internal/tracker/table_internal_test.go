@@ -0,0 +1,97 @@
+package tracker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableNames_qualify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		names    tableNames
+		expected string
+	}{
+		{
+			name:     "unqualified table",
+			names:    tableNames{table: "schema_migrations"},
+			expected: `"schema_migrations"`,
+		},
+		{
+			name:     "schema-qualified table",
+			names:    tableNames{schema: "migrate", table: "schema_migrations"},
+			expected: `"migrate"."schema_migrations"`,
+		},
+		{
+			name:     "embedded quotes are escaped",
+			names:    tableNames{schema: `we"ird`, table: `t"; DROP TABLE users; --`},
+			expected: `"we""ird"."t""; DROP TABLE users; --"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, tt.names.qualify(tt.names.table))
+		})
+	}
+}
+
+func TestTableNames_validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		names   tableNames
+		wantErr bool
+	}{
+		{name: "default table", names: tableNames{table: DefaultTable}},
+		{name: "schema and table", names: tableNames{schema: "migrate", table: "orders"}},
+		{name: "empty table", names: tableNames{}, wantErr: true},
+		{name: "table too long for repeatable suffix", names: tableNames{table: strings.Repeat("a", 53)}, wantErr: true},
+		{name: "longest valid table", names: tableNames{table: strings.Repeat("a", 52)}},
+		{name: "schema too long", names: tableNames{schema: strings.Repeat("s", 64), table: "t"}, wantErr: true},
+		{name: "NUL byte", names: tableNames{table: "a\x00b"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.names.validate()
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidIdentifier)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTracker_sql_substitutesPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	tr := New(nil, WithTable("migrate", "orders_migrations"))
+
+	got := tr.sql("SELECT * FROM {{table}} JOIN {{history}} USING (version); CREATE SCHEMA {{schema}}")
+
+	assert.Equal(t,
+		`SELECT * FROM "migrate"."orders_migrations" JOIN "migrate"."orders_migrations_history" USING (version); CREATE SCHEMA "migrate"`,
+		got,
+	)
+	assert.Equal(t, `SELECT * FROM "migrate"."orders_migrations_repeatable"`, tr.sql("SELECT * FROM {{repeatable}}"))
+}
+
+func TestWithTable_emptyTable_keepsDefault(t *testing.T) {
+	t.Parallel()
+
+	tr := New(nil, WithTable("", ""))
+
+	assert.Equal(t, DefaultTable, tr.names.table)
+	assert.Equal(t, `SELECT 1 FROM "schema_migrations"`, tr.sql("SELECT 1 FROM {{table}}"))
+}
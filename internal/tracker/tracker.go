@@ -4,56 +4,168 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Status values of rows in the tracking table.
+const (
+	StatusApplied = "applied"
+	// StatusBaseline marks a migration recorded by migrate baseline: its
+	// changes were already in the database, so it was never run.
+	StatusBaseline   = "baseline"
+	StatusRolledBack = "rolled_back"
+)
+
 // AppliedMigration represents a migration record from the schema_migrations table.
 type AppliedMigration struct {
-	Version    string
-	Filename   string
-	Checksum   string
-	AppliedAt  time.Time
-	DurationMs int
-	Status     string
+	Version      string
+	Filename     string
+	Checksum     string
+	AppliedAt    time.Time
+	DurationMs   int
+	Status       string
+	Hostname     string
+	DownChecksum string
 }
 
 // RecordParams contains the fields needed to record a migration as applied.
 type RecordParams struct {
-	Version    string
-	Filename   string
-	Checksum   string
-	DurationMs int
+	Version      string
+	Filename     string
+	Checksum     string
+	DownChecksum string // Checksum of the down SQL (empty if none)
+	DurationMs   int
+}
+
+// querier is the subset of pgx pool and transaction methods the tracker
+// queries with.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 }
 
-// Tracker manages the schema_migrations table.
+// Tracker manages the schema_migrations and schema_migrations_history tables.
 type Tracker struct {
-	pool *pgxpool.Pool
+	pool     *pgxpool.Pool
+	db       querier // pool, or the transaction of a Tracker passed to InTx
+	actor    actor
+	names    tableNames
+	replacer *strings.Replacer
+}
+
+// Option configures a Tracker.
+type Option func(*Tracker)
+
+// WithToolVersion sets the tool version recorded in the history table.
+func WithToolVersion(v string) Option {
+	return func(t *Tracker) { t.actor.toolVersion = v }
+}
+
+// WithTable sets the schema and name of the tracking table. An empty schema
+// resolves the table through the connection's search_path; an empty table
+// keeps DefaultTable. The history table is named after the tracking table
+// with a "_history" suffix.
+func WithTable(schema, table string) Option {
+	return func(t *Tracker) {
+		t.names.schema = schema
+		if table != "" {
+			t.names.table = table
+		}
+	}
 }
 
 // New creates a Tracker backed by the given connection pool.
-func New(pool *pgxpool.Pool) *Tracker {
-	return &Tracker{pool: pool}
+func New(pool *pgxpool.Pool, opts ...Option) *Tracker {
+	t := &Tracker{
+		pool:  pool,
+		db:    pool,
+		actor: currentActor(),
+		names: tableNames{table: DefaultTable},
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	t.replacer = t.names.replacer()
+
+	return t
 }
 
-// EnsureTable creates the schema_migrations table if it does not exist.
+// EnsureTable creates the tracking schema (if configured) and tables if they
+// do not exist, and upgrades tables created by older versions of the tool to
+// SchemaVersion. Callers running migrations should hold the advisory lock.
 func (t *Tracker) EnsureTable(ctx context.Context) error {
-	_, err := t.pool.Exec(ctx, createSchemaSQL)
+	if err := t.names.validate(); err != nil {
+		return err
+	}
+
+	return t.upgrade(ctx)
+}
+
+// Exists reports whether the tracking table exists, without creating it.
+// Use it instead of EnsureTable where nothing may be written, as in dry runs.
+func (t *Tracker) Exists(ctx context.Context) (bool, error) {
+	var exists bool
+
+	if err := t.db.QueryRow(ctx,
+		`SELECT to_regclass($1) IS NOT NULL`, t.names.qualify(t.names.table),
+	).Scan(&exists); err != nil {
+		return false, fmt.Errorf("looking up tracking table: %w", err)
+	}
+
+	return exists, nil
+}
+
+// InTx calls fn with a Tracker whose writes all happen in one transaction,
+// committed if fn returns nil and rolled back otherwise. EnsureTable must not
+// be called on the Tracker passed to fn.
+func (t *Tracker) InTx(ctx context.Context, fn func(*Tracker) error) error {
+	tx, err := t.pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrTableCreation, err)
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck // rollback on committed tx returns ErrTxClosed
+
+	txTracker := *t
+	txTracker.db = tx
+
+	if err := fn(&txTracker); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
 	}
 
 	return nil
 }
 
-// IsApplied checks whether a migration version has been successfully applied.
+// Table returns the configured schema and name of the tracking table. The
+// schema is empty when the table is resolved through the search_path.
+func (t *Tracker) Table() (schema, table string) {
+	return t.names.schema, t.names.table
+}
+
+// sql substitutes the quoted tracking table identifiers into a query.
+func (t *Tracker) sql(query string) string {
+	return t.replacer.Replace(query)
+}
+
+// IsApplied checks whether a migration version has been successfully applied
+// or baselined.
 func (t *Tracker) IsApplied(ctx context.Context, version string) (bool, error) {
 	var exists bool
 
-	err := t.pool.QueryRow(ctx,
-		`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1 AND status = 'applied')`,
+	err := t.db.QueryRow(ctx,
+		t.sql(`SELECT EXISTS(SELECT 1 FROM {{table}} WHERE version = $1 AND status IN ('applied', 'baseline'))`),
 		version,
 	).Scan(&exists)
 	if err != nil {
@@ -63,14 +175,16 @@ func (t *Tracker) IsApplied(ctx context.Context, version string) (bool, error) {
 	return exists, nil
 }
 
-// GetApplied returns all applied migrations ordered by version.
+// GetApplied returns all applied and baselined migrations ordered by numeric
+// version, so that "9" sorts before "10" (see migration.CompareVersions).
+// Status tells the two apart.
 func (t *Tracker) GetApplied(ctx context.Context) ([]AppliedMigration, error) {
-	rows, err := t.pool.Query(ctx,
-		`SELECT version, filename, checksum, applied_at, duration_ms, status
-		 FROM schema_migrations
-		 WHERE status = 'applied'
-		 ORDER BY version`,
-	)
+	rows, err := t.db.Query(ctx, t.sql(
+		`SELECT version, filename, checksum, applied_at, duration_ms, status, hostname, down_checksum
+		 FROM {{table}}
+		 WHERE status IN ('applied', 'baseline')
+		 ORDER BY length(ltrim(version, '0')), ltrim(version, '0'), version`,
+	))
 	if err != nil {
 		return nil, fmt.Errorf("querying applied migrations: %w", err)
 	}
@@ -78,7 +192,9 @@ func (t *Tracker) GetApplied(ctx context.Context) ([]AppliedMigration, error) {
 
 	applied, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (AppliedMigration, error) {
 		var m AppliedMigration
-		if scanErr := row.Scan(&m.Version, &m.Filename, &m.Checksum, &m.AppliedAt, &m.DurationMs, &m.Status); scanErr != nil {
+		if scanErr := row.Scan(
+			&m.Version, &m.Filename, &m.Checksum, &m.AppliedAt, &m.DurationMs, &m.Status, &m.Hostname, &m.DownChecksum,
+		); scanErr != nil {
 			return AppliedMigration{}, fmt.Errorf("scanning migration row: %w", scanErr)
 		}
 
@@ -94,28 +210,46 @@ func (t *Tracker) GetApplied(ctx context.Context) ([]AppliedMigration, error) {
 // RecordApplied inserts or updates a migration record with status 'applied'.
 // Uses upsert to handle re-applying a previously rolled-back migration.
 func (t *Tracker) RecordApplied(ctx context.Context, p RecordParams) error {
-	_, err := t.pool.Exec(ctx,
-		`INSERT INTO schema_migrations (version, filename, checksum, duration_ms, status)
-		 VALUES ($1, $2, $3, $4, 'applied')
+	if err := t.record(ctx, p, StatusApplied); err != nil {
+		return fmt.Errorf("recording migration %s as applied: %w", p.Version, err)
+	}
+
+	return nil
+}
+
+// RecordBaseline inserts or updates a migration record with status
+// 'baseline', for a migration whose changes are already in the database.
+func (t *Tracker) RecordBaseline(ctx context.Context, p RecordParams) error {
+	if err := t.record(ctx, p, StatusBaseline); err != nil {
+		return fmt.Errorf("recording migration %s as baseline: %w", p.Version, err)
+	}
+
+	return nil
+}
+
+// record upserts a migration record with the given status.
+func (t *Tracker) record(ctx context.Context, p RecordParams, status string) error {
+	_, err := t.db.Exec(ctx, t.sql(
+		`INSERT INTO {{table}} (version, filename, checksum, duration_ms, status, hostname, down_checksum)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
 		 ON CONFLICT (version) DO UPDATE SET
 		     filename = EXCLUDED.filename,
 		     checksum = EXCLUDED.checksum,
 		     applied_at = NOW(),
 		     duration_ms = EXCLUDED.duration_ms,
-		     status = 'applied'`,
-		p.Version, p.Filename, p.Checksum, p.DurationMs,
+		     status = EXCLUDED.status,
+		     hostname = EXCLUDED.hostname,
+		     down_checksum = EXCLUDED.down_checksum`),
+		p.Version, p.Filename, p.Checksum, p.DurationMs, status, t.actor.hostname, p.DownChecksum,
 	)
-	if err != nil {
-		return fmt.Errorf("recording migration %s as applied: %w", p.Version, err)
-	}
 
-	return nil
+	return err //nolint:wrapcheck // callers wrap with the status recorded
 }
 
 // RecordRolledBack updates a migration's status to 'rolled_back'.
 func (t *Tracker) RecordRolledBack(ctx context.Context, version string) error {
-	tag, err := t.pool.Exec(ctx,
-		`UPDATE schema_migrations SET status = 'rolled_back' WHERE version = $1`,
+	tag, err := t.db.Exec(ctx,
+		t.sql(`UPDATE {{table}} SET status = 'rolled_back' WHERE version = $1`),
 		version,
 	)
 	if err != nil {
@@ -133,8 +267,8 @@ func (t *Tracker) RecordRolledBack(ctx context.Context, version string) error {
 func (t *Tracker) GetChecksum(ctx context.Context, version string) (string, error) {
 	var checksum string
 
-	err := t.pool.QueryRow(ctx,
-		`SELECT checksum FROM schema_migrations WHERE version = $1`,
+	err := t.db.QueryRow(ctx,
+		t.sql(`SELECT checksum FROM {{table}} WHERE version = $1`),
 		version,
 	).Scan(&checksum)
 	if err != nil {
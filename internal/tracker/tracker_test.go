@@ -15,3 +15,34 @@ func TestNew_returnsNonNil(t *testing.T) {
 	tr := tracker.New(nil)
 	assert.NotNil(t, tr)
 }
+
+func TestNew_withToolVersion_returnsNonNil(t *testing.T) {
+	t.Parallel()
+
+	tr := tracker.New(nil, tracker.WithToolVersion("1.2.3"))
+	assert.NotNil(t, tr)
+}
+
+func TestTracker_Table(t *testing.T) {
+	t.Parallel()
+
+	schema, table := tracker.New(nil).Table()
+	assert.Empty(t, schema)
+	assert.Equal(t, tracker.DefaultTable, table)
+
+	schema, table = tracker.New(nil, tracker.WithTable("ops", "versions")).Table()
+	assert.Equal(t, "ops", schema)
+	assert.Equal(t, "versions", table)
+}
+
+func TestTracker_Tables(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t,
+		[]string{"schema_migrations", "schema_migrations_history", "schema_migrations_meta", "schema_migrations_repeatable"},
+		tracker.New(nil).Tables())
+
+	assert.Equal(t,
+		[]string{"ops.versions", "ops.versions_history", "ops.versions_meta", "ops.versions_repeatable"},
+		tracker.New(nil, tracker.WithTable("ops", "versions")).Tables())
+}
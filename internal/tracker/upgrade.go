@@ -0,0 +1,136 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SchemaVersion is the version of the tracking schema this build expects.
+// Databases at an older version are upgraded in place by EnsureTable.
+const SchemaVersion = 3
+
+// upgradeStep is an internal migration of the tracking tables themselves.
+type upgradeStep struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// upgradeSteps returns every tracking schema upgrade in version order.
+// Steps must be idempotent so that databases created before versioning
+// was introduced (tables present, no metadata row) upgrade cleanly.
+func upgradeSteps() []upgradeStep {
+	return []upgradeStep{
+		{
+			version:     1,
+			description: "create tracking and history tables",
+			statements:  []string{createSchemaSQL, createHistorySQL},
+		},
+		{
+			version:     2,
+			description: "record execution host and down checksum",
+			statements: []string{
+				`ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS hostname TEXT NOT NULL DEFAULT ''`,
+				`ALTER TABLE {{table}} ADD COLUMN IF NOT EXISTS down_checksum TEXT NOT NULL DEFAULT ''`,
+			},
+		},
+		{
+			version:     3,
+			description: "create repeatable migrations table",
+			statements:  []string{createRepeatableSQL},
+		},
+	}
+}
+
+// pendingSteps returns the upgrade steps newer than current.
+func pendingSteps(current int) []upgradeStep {
+	var pending []upgradeStep
+
+	for _, step := range upgradeSteps() {
+		if step.version > current {
+			pending = append(pending, step)
+		}
+	}
+
+	return pending
+}
+
+// upgrade brings the tracking tables to SchemaVersion inside a single
+// transaction. The metadata row is locked for the duration so concurrent
+// callers serialize. Returns ErrSchemaTooNew if the database was upgraded
+// by a newer version of the tool.
+func (t *Tracker) upgrade(ctx context.Context) error {
+	tx, err := t.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: beginning transaction: %w", ErrTableCreation, err)
+	}
+
+	defer tx.Rollback(ctx) //nolint:errcheck // rollback on committed tx returns ErrTxClosed
+
+	if t.names.schema != "" {
+		if _, err := tx.Exec(ctx, t.sql(createNamespaceSQL)); err != nil {
+			return fmt.Errorf("%w: %w", ErrTableCreation, err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, t.sql(createMetaSQL)); err != nil {
+		return fmt.Errorf("%w: %w", ErrTableCreation, err)
+	}
+
+	current, err := t.lockedSchemaVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if current > SchemaVersion {
+		return fmt.Errorf("%w: database is at version %d, this tool supports up to %d",
+			ErrSchemaTooNew, current, SchemaVersion)
+	}
+
+	if current == SchemaVersion {
+		return nil
+	}
+
+	for _, step := range pendingSteps(current) {
+		for _, stmt := range step.statements {
+			if _, err := tx.Exec(ctx, t.sql(stmt)); err != nil {
+				return fmt.Errorf("%w: upgrading to version %d (%s): %w",
+					ErrTableCreation, step.version, step.description, err)
+			}
+		}
+	}
+
+	if _, err := tx.Exec(ctx, t.sql(
+		`INSERT INTO {{meta}} (id, schema_version) VALUES (TRUE, $1)
+		 ON CONFLICT (id) DO UPDATE SET schema_version = EXCLUDED.schema_version, upgraded_at = NOW()`),
+		SchemaVersion,
+	); err != nil {
+		return fmt.Errorf("%w: recording schema version: %w", ErrTableCreation, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: committing upgrade: %w", ErrTableCreation, err)
+	}
+
+	return nil
+}
+
+// lockedSchemaVersion reads and locks the metadata row. A missing row means
+// the tracking tables predate versioning (or do not exist yet) and reports 0.
+func (t *Tracker) lockedSchemaVersion(ctx context.Context, tx pgx.Tx) (int, error) {
+	var version int
+
+	err := tx.QueryRow(ctx, t.sql(`SELECT schema_version FROM {{meta}} FOR UPDATE`)).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("reading tracking schema version: %w", err)
+	}
+
+	return version, nil
+}
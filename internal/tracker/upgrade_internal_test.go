@@ -0,0 +1,51 @@
+package tracker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeSteps_contiguousAndEndAtSchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	steps := upgradeSteps()
+	require.NotEmpty(t, steps)
+
+	for i, step := range steps {
+		assert.Equal(t, i+1, step.version, "step versions must be contiguous starting at 1")
+		assert.NotEmpty(t, step.description)
+		assert.NotEmpty(t, step.statements)
+	}
+
+	assert.Equal(t, SchemaVersion, steps[len(steps)-1].version)
+}
+
+func TestPendingSteps(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		current  int
+		expected []int
+	}{
+		{name: "fresh database runs every step", current: 0, expected: []int{1, 2, 3}},
+		{name: "partially upgraded runs remaining steps", current: 1, expected: []int{2, 3}},
+		{name: "up to date runs nothing", current: SchemaVersion, expected: nil},
+		{name: "newer database runs nothing", current: SchemaVersion + 1, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []int
+			for _, step := range pendingSteps(tt.current) {
+				got = append(got, step.version)
+			}
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/aqasim81/database-migration-engine/internal/analyzer"
+	"github.com/aqasim81/database-migration-engine/internal/analyzer/rules"
+)
+
+// Severity is the danger level of a Finding.
+type Severity int
+
+// Severity levels, from least to most dangerous.
+const (
+	SeveritySafe Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// String returns the uppercase label for the severity, e.g. "HIGH".
+func (s Severity) String() string {
+	return analyzer.Severity(s).String()
+}
+
+// Finding is a dangerous operation detected in a migration.
+type Finding struct {
+	Rule       string // rule ID, e.g. "create-index-not-concurrent"
+	Severity   Severity
+	Table      string // affected table
+	Statement  string // offending statement, truncated for display
+	Message    string // what the danger is
+	Suggestion string // safe alternative
+	LockType   string // lock acquired, e.g. "ACCESS EXCLUSIVE"
+}
+
+// Analysis holds the findings for one migration.
+type Analysis struct {
+	Migration   Migration
+	Findings    []Finding
+	MaxSeverity Severity
+}
+
+// AnalyzeOption configures Analyze.
+type AnalyzeOption func(*analyzeConfig)
+
+type analyzeConfig struct {
+	pgVersion int
+}
+
+// WithPGVersion sets the PostgreSQL major version the migrations target.
+// Some operations are only safe on newer versions. Defaults to 14.
+func WithPGVersion(v int) AnalyzeOption {
+	return func(c *analyzeConfig) { c.pgVersion = v }
+}
+
+// Analyze checks the up SQL of each migration for dangerous DDL using the
+// built-in rules. It needs no database connection. Results are in version
+// order, one per migration.
+func Analyze(ms []Migration, opts ...AnalyzeOption) ([]Analysis, error) {
+	c := analyzeConfig{pgVersion: 14} //nolint:mnd // default PostgreSQL version
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	a := analyzer.New(
+		analyzer.WithRegistry(rules.NewDefaultRegistry()),
+		analyzer.WithPGVersion(c.pgVersion),
+	)
+
+	results, err := a.AnalyzeAll(toInternal(ms))
+	if err != nil {
+		return nil, fmt.Errorf("analyzing migrations: %w", err)
+	}
+
+	analyses := make([]Analysis, len(results))
+	for i, r := range results {
+		analyses[i] = Analysis{
+			Migration:   fromInternalOne(r.Migration),
+			Findings:    make([]Finding, len(r.Findings)),
+			MaxSeverity: Severity(r.MaxSeverity),
+		}
+
+		for j, f := range r.Findings {
+			analyses[i].Findings[j] = Finding{
+				Rule:       f.Rule,
+				Severity:   Severity(f.Severity),
+				Table:      f.Table,
+				Statement:  f.Statement,
+				Message:    f.Message,
+				Suggestion: f.Suggestion,
+				LockType:   f.LockType,
+			}
+		}
+	}
+
+	return analyses, nil
+}
+
+// HasHighOrCritical reports whether any finding is High or Critical.
+func (a *Analysis) HasHighOrCritical() bool {
+	return a.MaxSeverity >= SeverityHigh
+}
@@ -0,0 +1,34 @@
+// Package migrate is the public Go API of the migration engine. It loads,
+// analyzes, plans, applies and rolls back PostgreSQL migrations from a Go
+// program, without shelling out to the migrate CLI.
+//
+// A service that embeds its migrations can apply them on startup:
+//
+//	//go:embed migrations/*.sql
+//	var migrationsFS embed.FS
+//
+//	ms, err := migrate.LoadFS(migrationsFS, "migrations")
+//	if err != nil {
+//		return err
+//	}
+//
+//	m := migrate.New(pool, migrate.WithTrackingTable("", "schema_migrations"))
+//	result, err := m.Apply(ctx, ms)
+//
+// A migration is either a V001_name.up.sql and V001_name.down.sql pair or a
+// single V001_name.sql file with -- migrate:up and -- migrate:down sections.
+//
+// Repeatable migrations (R__<name>.sql), for views and functions edited in
+// place, are loaded alongside versioned ones and applied after them whenever
+// their checksum changes.
+//
+// Callback files (beforeAll.sql, beforeEach.sql, afterEach.sql, afterAll.sql)
+// and Go hooks run at fixed points of every Apply and Rollback; load them
+// with LoadHooks and pass them to WithHooks.
+//
+// Migrations that need Go code, such as chunked backfills, can be registered
+// in a Registry and merged with SQL migrations; see GoMigration.
+//
+// The exported API of this package follows semantic versioning. It wraps the
+// module's internal packages, which may change without notice.
+package migrate
@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"github.com/aqasim81/database-migration-engine/internal/database"
+	"github.com/aqasim81/database-migration-engine/internal/executor"
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// Errors returned by this package. Test for them with errors.Is.
+var (
+	// ErrExecutionFailed indicates a migration failed to execute.
+	ErrExecutionFailed = executor.ErrExecutionFailed
+	// ErrNoDownSQL indicates a migration to roll back has no down SQL.
+	ErrNoDownSQL = executor.ErrNoDownSQL
+	// ErrNothingToRollback indicates no applied migrations are available to roll back.
+	ErrNothingToRollback = executor.ErrNothingToRollback
+	// ErrTargetNotFound indicates a rollback target is not among the applied migrations.
+	ErrTargetNotFound = executor.ErrTargetNotFound
+	// ErrInterrupted indicates the context was cancelled before all migrations ran.
+	ErrInterrupted = executor.ErrInterrupted
+	// ErrOutOfOrder indicates pending migrations sort before the latest applied one.
+	ErrOutOfOrder = executor.ErrOutOfOrder
+	// ErrChecksumMismatch indicates an applied migration's file has changed.
+	ErrChecksumMismatch = tracker.ErrChecksumMismatch
+	// ErrVersionNotFound indicates an apply target has no migration.
+	ErrVersionNotFound = migration.ErrVersionNotFound
+	// ErrDuplicateVersion indicates two migration files share a version.
+	ErrDuplicateVersion = migration.ErrDuplicateVersion
+	// ErrDuplicateRepeatable indicates two repeatable migration files share a name.
+	ErrDuplicateRepeatable = migration.ErrDuplicateRepeatable
+	// ErrMixedVersionSchemes indicates V-prefixed and timestamp versions are
+	// mixed without WithAllowMixedVersions.
+	ErrMixedVersionSchemes = migration.ErrMixedVersionSchemes
+	// ErrUnresolvedPlaceholder indicates migration SQL has a ${name}
+	// placeholder without a value.
+	ErrUnresolvedPlaceholder = migration.ErrUnresolvedPlaceholder
+	// ErrInvalidSections indicates a single-file migration whose
+	// -- migrate:up and -- migrate:down sections are missing or repeated.
+	ErrInvalidSections = migration.ErrInvalidSections
+	// ErrInvalidDirective indicates a malformed -- migrate: header directive.
+	ErrInvalidDirective = migration.ErrInvalidDirective
+	// ErrInvalidGoMigration indicates a Go migration has missing or malformed fields.
+	ErrInvalidGoMigration = migration.ErrInvalidGoMigration
+	// ErrLockNotAcquired indicates another process holds the migration lock.
+	ErrLockNotAcquired = database.ErrLockNotAcquired
+	// ErrLockLost indicates the migration lock was lost during a run.
+	ErrLockLost = database.ErrLockLost
+)
@@ -0,0 +1,117 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+// GoFunc is a migration step written in Go. Exactly one of Tx and Pool must
+// be set.
+type GoFunc struct {
+	// Tx runs inside a transaction with lock_timeout and statement_timeout
+	// set, like a SQL migration. It is committed only if Tx returns nil.
+	Tx func(ctx context.Context, tx pgx.Tx) error
+
+	// Pool runs outside any transaction with the connection pool, for work
+	// that must commit as it goes, such as chunked backfills. Its context is
+	// cancelled once the grace period after an interruption expires.
+	Pool func(ctx context.Context, pool *pgxpool.Pool) error
+}
+
+// GoMigration describes a Go migration to register.
+type GoMigration struct {
+	Version string  // digits, e.g. "005" or "20240101120000"
+	Name    string  // e.g. "backfill_password_hashes"
+	Hash    string  // content hash of the migration's logic; change it when the logic changes
+	Up      GoFunc  // required
+	Down    *GoFunc // optional; without it the migration cannot be rolled back
+}
+
+// Registry holds Go migrations keyed by version:
+//
+//	var registry = migrate.NewRegistry()
+//
+//	func init() {
+//		registry.MustRegister(migrate.GoMigration{
+//			Version: "005",
+//			Name:    "backfill_password_hashes",
+//			Hash:    "v1",
+//			Up:      migrate.GoFunc{Pool: backfillPasswordHashes},
+//		})
+//	}
+//
+// Combine its migrations with SQL migrations using Merge.
+type Registry struct {
+	r *migration.GoRegistry
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{r: migration.NewGoRegistry()}
+}
+
+// Register adds a Go migration. Its checksum is derived from gm.Hash, so
+// changing the hash of an applied migration fails Apply with
+// ErrChecksumMismatch, just like editing an applied SQL file. Returns
+// ErrInvalidGoMigration for missing or malformed fields and
+// ErrDuplicateVersion if the version is taken.
+func (r *Registry) Register(gm GoMigration) error {
+	_, file, _, _ := runtime.Caller(1)
+
+	return r.register(gm, file)
+}
+
+// MustRegister is like Register but panics on error. It is meant for init
+// functions.
+func (r *Registry) MustRegister(gm GoMigration) {
+	_, file, _, _ := runtime.Caller(1)
+
+	if err := r.register(gm, file); err != nil {
+		panic(err)
+	}
+}
+
+func (r *Registry) register(gm GoMigration, file string) error {
+	err := r.r.Register(migration.GoMigration{
+		Version:  gm.Version,
+		Name:     gm.Name,
+		Hash:     gm.Hash,
+		Up:       migration.GoFunc(gm.Up),
+		Down:     (*migration.GoFunc)(gm.Down),
+		FilePath: file,
+	})
+	if err != nil {
+		return fmt.Errorf("registering Go migration: %w", err)
+	}
+
+	return nil
+}
+
+// Migrations returns the registered migrations sorted by version.
+func (r *Registry) Migrations() []Migration {
+	return fromInternal(migration.Sort(r.r.Migrations()))
+}
+
+// Merge combines migration sets, such as the result of LoadFS and a
+// Registry's migrations, and returns them sorted by version. Returns
+// ErrDuplicateVersion if a version appears more than once, or
+// ErrDuplicateRepeatable if a repeatable migration name does.
+func Merge(sets ...[]Migration) ([]Migration, error) {
+	internal := make([][]migration.Migration, len(sets))
+	for i, set := range sets {
+		internal[i] = toInternal(set)
+	}
+
+	merged, err := migration.Merge(internal...)
+	if err != nil {
+		return nil, fmt.Errorf("merging migrations: %w", err)
+	}
+
+	return fromInternal(migration.Sort(merged)), nil
+}
@@ -0,0 +1,121 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+// HookPoint names the point in an Apply or Rollback at which a hook runs.
+type HookPoint string
+
+// Hook points. The callback file for each is named after it, e.g.
+// beforeEach.sql.
+const (
+	// HookBeforeAll runs once per Apply or Rollback before the first
+	// migration, in its own transaction.
+	HookBeforeAll = HookPoint(migration.HookBeforeAll)
+
+	// HookBeforeEach runs before every migration that is executed, in its
+	// transaction. For migrations that run outside a transaction it runs on
+	// the same connection, or the pool for Go migrations using GoFunc.Pool.
+	HookBeforeEach = HookPoint(migration.HookBeforeEach)
+
+	// HookAfterEach runs after every migration that is executed, like
+	// HookBeforeEach. A failure fails the migration.
+	HookAfterEach = HookPoint(migration.HookAfterEach)
+
+	// HookAfterAll runs once per Apply or Rollback after the last migration,
+	// in its own transaction, unless a migration failed.
+	HookAfterAll = HookPoint(migration.HookAfterAll)
+)
+
+// DB is the part of pgx.Tx, *pgxpool.Conn and *pgxpool.Pool a Go hook can use.
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// HookEvent describes what a hook runs for.
+type HookEvent struct {
+	Point     HookPoint
+	Direction string     // "up" for Apply, "down" for Rollback
+	Migration *Migration // the migration beforeEach and afterEach run around; nil for beforeAll and afterAll
+}
+
+// HookFunc is a hook written in Go. db is the transaction or connection the
+// hook point runs on.
+type HookFunc func(ctx context.Context, db DB, ev HookEvent) error
+
+// Hook is SQL or Go code run at a fixed point of every Apply and Rollback,
+// even when no migration is pending. Hooks are not tracked. A failing hook
+// fails the run like a failing migration; beforeAll and afterAll failures
+// are recorded in the history table under the hook point's name.
+type Hook struct {
+	Point    HookPoint
+	SQL      string   // run unless Func is set
+	Func     HookFunc // Go hook
+	FilePath string   // callback file the SQL was loaded from
+}
+
+// LoadHooks loads the callback files in dirs: beforeAll.sql, beforeEach.sql,
+// afterEach.sql and afterAll.sql. Callbacks for the same point in several
+// directories all run, in directory order.
+func LoadHooks(dirs []string, opts ...LoadOption) ([]Hook, error) {
+	hs, err := migration.LoadHooks(dirs, loadOptions(opts)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading callback files: %w", err)
+	}
+
+	return fromInternalHooks(hs), nil
+}
+
+// LoadHooksFS loads the callback files in dir within fsys, like LoadHooks.
+func LoadHooksFS(fsys fs.FS, dir string, opts ...LoadOption) ([]Hook, error) {
+	hs, err := migration.LoadHooksFromFS(fsys, dir, loadOptions(opts)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading callback files: %w", err)
+	}
+
+	return fromInternalHooks(hs), nil
+}
+
+// fromInternalHooks converts loaded SQL hooks to the public type.
+func fromInternalHooks(hs []migration.Hook) []Hook {
+	out := make([]Hook, len(hs))
+	for i, h := range hs {
+		out[i] = Hook{Point: HookPoint(h.Point), SQL: h.SQL, FilePath: h.FilePath}
+	}
+
+	return out
+}
+
+// toInternalHooks converts public hooks to the internal type, adapting Go
+// hooks to receive public events.
+func toInternalHooks(hs []Hook) []migration.Hook {
+	out := make([]migration.Hook, len(hs))
+	for i, h := range hs {
+		out[i] = migration.Hook{Point: migration.HookPoint(h.Point), SQL: h.SQL, FilePath: h.FilePath}
+
+		if fn := h.Func; fn != nil {
+			out[i].Func = func(ctx context.Context, db migration.DB, ev migration.HookEvent) error {
+				pub := HookEvent{Point: HookPoint(ev.Point), Direction: ev.Direction}
+
+				if ev.Migration != nil {
+					m := fromInternalOne(ev.Migration)
+					pub.Migration = &m
+				}
+
+				return fn(ctx, db, pub)
+			}
+		}
+	}
+
+	return out
+}
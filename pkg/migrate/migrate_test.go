@@ -0,0 +1,239 @@
+package migrate_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/pkg/migrate"
+)
+
+func TestLoadFS_sortsByVersion(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/V10_c.up.sql":  {Data: []byte("SELECT 3;")},
+		"migrations/V2_b.up.sql":   {Data: []byte("SELECT 2;")},
+		"migrations/V1_a.up.sql":   {Data: []byte("SELECT 1;")},
+		"migrations/V1_a.down.sql": {Data: []byte("SELECT -1;")},
+	}
+
+	ms, err := migrate.LoadFS(fsys, "migrations")
+	require.NoError(t, err)
+	require.Len(t, ms, 3)
+	assert.Equal(t, "1", ms[0].Version)
+	assert.Equal(t, "SELECT -1;", ms[0].DownSQL)
+	assert.Equal(t, "2", ms[1].Version)
+	assert.Equal(t, "10", ms[2].Version)
+	assert.Len(t, ms[0].Checksum, 64)
+}
+
+func TestLoadFS_repeatablesFollowVersionedMigrations(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"R__views.sql":  {Data: []byte("CREATE OR REPLACE VIEW v AS SELECT 1;")},
+		"V1_a.up.sql":   {Data: []byte("SELECT 1;")},
+		"R__funcs.sql":  {Data: []byte("SELECT 2;")},
+		"R__funcs2.sql": {Data: []byte("SELECT 3;")},
+	}
+
+	ms, err := migrate.LoadFS(fsys, ".")
+	require.NoError(t, err)
+	require.Len(t, ms, 4)
+	assert.Equal(t, "1", ms[0].Version)
+	assert.False(t, ms[0].Repeatable)
+	assert.Equal(t, "funcs", ms[1].Name)
+	assert.True(t, ms[1].Repeatable)
+	assert.Equal(t, "funcs2", ms[2].Name)
+	assert.Equal(t, "views", ms[3].Name)
+}
+
+func TestLoadFS_withVars(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"V1_grant.up.sql": {Data: []byte("GRANT SELECT ON users TO ${app_role};")}}
+
+	ms, err := migrate.LoadFS(fsys, ".", migrate.WithVars(map[string]string{"app_role": "app_rw"}))
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+	assert.Equal(t, "GRANT SELECT ON users TO app_rw;", ms[0].UpSQL)
+
+	_, err = migrate.LoadFS(fsys, ".")
+	require.ErrorIs(t, err, migrate.ErrUnresolvedPlaceholder)
+}
+
+func TestLoadHooksFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"V1_a.up.sql":    {Data: []byte("SELECT 1;")},
+		"beforeEach.sql": {Data: []byte("SET LOCAL ROLE ${owner};")},
+		"afterAll.sql":   {Data: []byte("ANALYZE;")},
+	}
+
+	hooks, err := migrate.LoadHooksFS(fsys, ".", migrate.WithVars(map[string]string{"owner": "app_owner"}))
+	require.NoError(t, err)
+	require.Len(t, hooks, 2)
+	assert.Equal(t, migrate.HookAfterAll, hooks[0].Point)
+	assert.Equal(t, migrate.HookBeforeEach, hooks[1].Point)
+	assert.Equal(t, "SET LOCAL ROLE app_owner;", hooks[1].SQL)
+	assert.Equal(t, "beforeEach.sql", hooks[1].FilePath)
+
+	ms, err := migrate.LoadFS(fsys, ".", migrate.WithVars(map[string]string{"owner": "app_owner"}))
+	require.NoError(t, err)
+	assert.Len(t, ms, 1, "callback files are not migrations")
+}
+
+func TestLoadFS_duplicateVersion_returnsErrDuplicateVersion(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"V1_a.up.sql":  {Data: []byte("SELECT 1;")},
+		"V01_b.up.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	_, err := migrate.LoadFS(fsys, ".")
+	require.ErrorIs(t, err, migrate.ErrDuplicateVersion)
+}
+
+func TestLoadDirs_recursive(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "billing")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "V001_a.up.sql"), []byte("SELECT 1;"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "V002_b.up.sql"), []byte("SELECT 2;"), 0o644))
+
+	ms, err := migrate.LoadDirs([]string{dir}, migrate.WithRecursive(true))
+	require.NoError(t, err)
+	require.Len(t, ms, 2)
+	assert.Equal(t, filepath.Join(sub, "V002_b.up.sql"), ms[1].FilePath)
+}
+
+func TestAnalyze_reportsFindings(t *testing.T) {
+	t.Parallel()
+
+	ms := []migrate.Migration{
+		{Version: "002", Name: "index", UpSQL: "CREATE INDEX idx_users_email ON users (email);"},
+		{Version: "001", Name: "safe", UpSQL: "CREATE TABLE users (id int, email text);"},
+	}
+
+	analyses, err := migrate.Analyze(ms, migrate.WithPGVersion(16))
+	require.NoError(t, err)
+	require.Len(t, analyses, 2)
+
+	assert.Equal(t, "001", analyses[0].Migration.Version)
+	assert.Empty(t, analyses[0].Findings)
+	assert.False(t, analyses[0].HasHighOrCritical())
+
+	assert.Equal(t, "002", analyses[1].Migration.Version)
+	require.NotEmpty(t, analyses[1].Findings)
+	assert.Equal(t, "create-index-not-concurrent", analyses[1].Findings[0].Rule)
+	assert.Equal(t, "users", analyses[1].Findings[0].Table)
+	assert.True(t, analyses[1].HasHighOrCritical())
+}
+
+func TestAnalyze_invalidSQL_returnsError(t *testing.T) {
+	t.Parallel()
+
+	_, err := migrate.Analyze([]migrate.Migration{{Version: "001", UpSQL: "CREAT TABLE x ();"}})
+	require.ErrorContains(t, err, "analyzing migrations")
+}
+
+func TestSeverity_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "SAFE", migrate.SeveritySafe.String())
+	assert.Equal(t, "HIGH", migrate.SeverityHigh.String())
+	assert.Equal(t, "CRITICAL", migrate.SeverityCritical.String())
+}
+
+func TestResult_Completed(t *testing.T) {
+	t.Parallel()
+
+	r := migrate.Result{Migrations: []migrate.Event{
+		{Migration: migrate.Migration{Version: "001"}, Status: migrate.StatusCompleted},
+		{Migration: migrate.Migration{Version: "002"}, Status: migrate.StatusFailed},
+	}}
+
+	completed := r.Completed()
+	require.Len(t, completed, 1)
+	assert.Equal(t, "001", completed[0].Version)
+}
+
+func TestRegistry_mergeWithSQL(t *testing.T) {
+	t.Parallel()
+
+	r := migrate.NewRegistry()
+	r.MustRegister(migrate.GoMigration{
+		Version: "002",
+		Name:    "backfill",
+		Hash:    "v1",
+		Up:      migrate.GoFunc{Tx: func(context.Context, pgx.Tx) error { return nil }},
+	})
+
+	goMs := r.Migrations()
+	require.Len(t, goMs, 1)
+	assert.Equal(t, "migrate_test.go", filepath.Base(goMs[0].FilePath))
+	assert.NotNil(t, goMs[0].UpFunc)
+
+	sqlMs := []migrate.Migration{
+		{Version: "003", Name: "c", UpSQL: "SELECT 3;"},
+		{Version: "001", Name: "a", UpSQL: "SELECT 1;"},
+	}
+
+	merged, err := migrate.Merge(sqlMs, goMs)
+	require.NoError(t, err)
+	require.Len(t, merged, 3)
+	assert.Equal(t, "001", merged[0].Version)
+	assert.Equal(t, "002", merged[1].Version)
+	assert.NotNil(t, merged[1].UpFunc)
+	assert.Equal(t, "003", merged[2].Version)
+
+	_, err = migrate.Merge(sqlMs, []migrate.Migration{{Version: "3", Name: "dup"}})
+	require.ErrorIs(t, err, migrate.ErrDuplicateVersion)
+
+	merged, err = migrate.Merge(sqlMs, []migrate.Migration{
+		{Name: "views", UpSQL: "SELECT 1;", Repeatable: true},
+		{Name: "functions", UpSQL: "SELECT 2;", Repeatable: true},
+	})
+	require.NoError(t, err)
+	assert.Len(t, merged, 4)
+}
+
+func TestRegistry_Register_invalid(t *testing.T) {
+	t.Parallel()
+
+	err := migrate.NewRegistry().Register(migrate.GoMigration{Version: "001", Name: "x"})
+	require.ErrorIs(t, err, migrate.ErrInvalidGoMigration)
+
+	assert.Panics(t, func() { migrate.NewRegistry().MustRegister(migrate.GoMigration{}) })
+}
+
+func TestLoadFS_headerDirectives(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"V1_index.up.sql": {Data: []byte("-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY i ON t (c);")},
+		"V2_bad.up.sql":   {Data: []byte("-- migrate:isolation serializable\nSELECT 1;")},
+	}
+
+	_, err := migrate.LoadFS(fsys, ".")
+	require.ErrorIs(t, err, migrate.ErrInvalidDirective)
+
+	delete(fsys, "V2_bad.up.sql")
+
+	ms, err := migrate.LoadFS(fsys, ".")
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+	assert.True(t, ms[0].Directives.NoTransaction)
+	assert.Equal(t, "no-transaction", ms[0].Directives.String())
+}
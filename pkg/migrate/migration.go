@@ -0,0 +1,205 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+)
+
+// Migration is a versioned schema change with its up and optional down SQL,
+// or a repeatable migration that is re-applied whenever its SQL changes.
+type Migration struct {
+	Version        string     // e.g. "001" or "20240101120000"; empty if Repeatable
+	Name           string     // e.g. "create_users"
+	UpSQL          string     // with ${name} placeholders substituted (see WithVars)
+	DownSQL        string     // empty if the migration has no down file
+	Checksum       string     // SHA-256 of the up file as written, or of a Go migration's hash; computed from UpSQL if empty
+	DownChecksum   string     // SHA-256 of the down file as written; computed from DownSQL if empty
+	FilePath       string     // path of the up file, within its directory or fs.FS, or of the registering .go file
+	UpFunc         *GoFunc    // Go migration step, run instead of UpSQL
+	DownFunc       *GoFunc    // Go rollback step, run instead of DownSQL
+	Repeatable     bool       // R__<name>.sql: applied after all versioned migrations when its checksum changes
+	Directives     Directives // -- migrate: header directives of the up file
+	DownDirectives Directives // -- migrate: header directives of the down file
+}
+
+// Directives are per-migration settings declared in the header comments of
+// a migration file, before its first statement:
+//
+//	-- migrate:no-transaction
+//	-- migrate:lock-timeout 2s
+//	-- migrate:statement-timeout 0
+//	-- migrate:role app_owner
+//
+// Timeouts override WithLockTimeout and WithStatementTimeout for that
+// migration; zero disables the timeout. LoadDirs and LoadFS fail with
+// ErrInvalidDirective on unknown or malformed directives.
+type Directives struct {
+	NoTransaction    bool           // run outside a transaction
+	LockTimeout      *time.Duration // nil uses the Migrator's lock timeout
+	StatementTimeout *time.Duration // nil uses the Migrator's statement timeout
+	Role             string         // role the migration runs as, empty for the connecting role
+}
+
+// String returns the directives as written, without the "-- migrate:"
+// prefix, e.g. "no-transaction, lock-timeout 2s".
+func (d Directives) String() string {
+	return migration.Directives(d).String()
+}
+
+// LoadOption configures LoadDirs and LoadFS.
+type LoadOption func(*loadConfig)
+
+type loadConfig struct {
+	recursive          bool
+	allowMixedVersions bool
+	vars               map[string]string
+}
+
+// WithRecursive also loads migrations from subdirectories.
+func WithRecursive(recursive bool) LoadOption {
+	return func(c *loadConfig) { c.recursive = recursive }
+}
+
+// WithAllowMixedVersions permits V-prefixed and timestamp versions in one set.
+// Sequential versions then sort before all timestamps.
+func WithAllowMixedVersions(allow bool) LoadOption {
+	return func(c *loadConfig) { c.allowMixedVersions = allow }
+}
+
+// WithVars sets the values substituted for ${name} placeholders in migration
+// SQL. Checksums cover the files as written, so the same migrations can be
+// applied to environments with different values. A placeholder without a
+// value fails the load with ErrUnresolvedPlaceholder.
+func WithVars(vars map[string]string) LoadOption {
+	return func(c *loadConfig) { c.vars = vars }
+}
+
+// LoadDirs loads the migrations in one or more directories and returns them
+// sorted by version, followed by the repeatable migrations (R__<name>.sql)
+// sorted by name. Versions and repeatable names must be unique across all
+// directories.
+func LoadDirs(dirs []string, opts ...LoadOption) ([]Migration, error) {
+	ms, err := migration.LoadFromDirs(dirs, loadOptions(opts)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading migrations: %w", err)
+	}
+
+	rs, err := migration.LoadRepeatables(dirs, loadOptions(opts)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading repeatable migrations: %w", err)
+	}
+
+	return fromInternal(append(migration.Sort(ms), rs...)), nil
+}
+
+// LoadFS loads the migrations in dir within fsys, such as an embed.FS, and
+// returns them in the same order as LoadDirs. Use "." for the root of fsys.
+func LoadFS(fsys fs.FS, dir string, opts ...LoadOption) ([]Migration, error) {
+	ms, err := migration.LoadFromFS(fsys, dir, loadOptions(opts)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading migrations: %w", err)
+	}
+
+	rs, err := migration.LoadRepeatablesFromFS(fsys, dir, loadOptions(opts)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading repeatable migrations: %w", err)
+	}
+
+	return fromInternal(append(migration.Sort(ms), rs...)), nil
+}
+
+// loadOptions converts public load options to the internal loader's.
+func loadOptions(opts []LoadOption) []migration.LoadOption {
+	var c loadConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return []migration.LoadOption{
+		migration.WithRecursive(c.recursive),
+		migration.WithAllowMixedSchemes(c.allowMixedVersions),
+		migration.WithVars(c.vars),
+	}
+}
+
+// fromInternal converts internal migrations to the public type.
+func fromInternal(ms []migration.Migration) []Migration {
+	out := make([]Migration, len(ms))
+	for i := range ms {
+		out[i] = fromInternalOne(&ms[i])
+	}
+
+	return out
+}
+
+func fromInternalOne(m *migration.Migration) Migration {
+	return Migration{
+		Version:        m.Version,
+		Name:           m.Name,
+		UpSQL:          m.UpSQL,
+		DownSQL:        m.DownSQL,
+		Checksum:       m.Checksum,
+		DownChecksum:   m.DownChecksum,
+		FilePath:       m.FilePath,
+		UpFunc:         (*GoFunc)(m.UpFunc),
+		DownFunc:       (*GoFunc)(m.DownFunc),
+		Repeatable:     m.Repeatable,
+		Directives:     Directives(m.Directives),
+		DownDirectives: Directives(m.DownDirectives),
+	}
+}
+
+// toInternal converts public migrations to the internal type, sorted by
+// version and followed by the repeatable migrations sorted by name. An empty
+// Checksum is computed from UpSQL.
+func toInternal(ms []Migration) []migration.Migration {
+	out := make([]migration.Migration, len(ms))
+	for i, m := range ms {
+		out[i] = migration.Migration{
+			Version:        m.Version,
+			Name:           m.Name,
+			UpSQL:          m.UpSQL,
+			DownSQL:        m.DownSQL,
+			Checksum:       m.Checksum,
+			DownChecksum:   m.DownChecksum,
+			FilePath:       m.FilePath,
+			UpFunc:         (*migration.GoFunc)(m.UpFunc),
+			DownFunc:       (*migration.GoFunc)(m.DownFunc),
+			Repeatable:     m.Repeatable,
+			Directives:     migration.Directives(m.Directives),
+			DownDirectives: migration.Directives(m.DownDirectives),
+		}
+
+		if out[i].Checksum == "" {
+			out[i].Checksum = migration.ComputeChecksum(m.UpSQL)
+		}
+	}
+
+	versioned, repeatables := splitRepeatables(out)
+
+	sort.SliceStable(repeatables, func(i, j int) bool {
+		return repeatables[i].Name < repeatables[j].Name
+	})
+
+	return append(migration.Sort(versioned), repeatables...)
+}
+
+// splitRepeatables separates versioned from repeatable migrations,
+// preserving their order.
+func splitRepeatables(ms []migration.Migration) ([]migration.Migration, []migration.Migration) {
+	var versioned, repeatables []migration.Migration
+
+	for i := range ms {
+		if ms[i].Repeatable {
+			repeatables = append(repeatables, ms[i])
+		} else {
+			versioned = append(versioned, ms[i])
+		}
+	}
+
+	return versioned, repeatables
+}
@@ -0,0 +1,306 @@
+package migrate
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/aqasim81/database-migration-engine/internal/config"
+	"github.com/aqasim81/database-migration-engine/internal/database"
+	"github.com/aqasim81/database-migration-engine/internal/executor"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// Status values reported in Events and Results.
+const (
+	StatusStarting    = executor.StatusStarting    // an up migration is about to run
+	StatusRollingBack = executor.StatusRollingBack // a down migration is about to run
+	StatusCompleted   = executor.StatusCompleted   // the migration ran and was recorded
+	StatusFailed      = executor.StatusFailed      // the migration's SQL failed
+	StatusInterrupted = executor.StatusInterrupted // the context was cancelled mid-migration
+	StatusDryRun      = executor.StatusDryRun      // dry run: the migration would have run
+)
+
+// Event reports progress on one migration, or on the beforeAll or afterAll
+// hooks, during Apply or Rollback. Migrations that were already applied are
+// not reported.
+type Event struct {
+	Migration Migration // zero for hook events
+	Hook      HookPoint // set for beforeAll and afterAll hook events
+	Status    string
+	Duration  time.Duration // set once the migration has finished
+	Err       error         // set for StatusFailed and StatusInterrupted
+}
+
+// Result lists the migrations an Apply or Rollback processed, in order.
+// Migrations that were already applied and hooks are not included.
+type Result struct {
+	Migrations []Event // final event per migration: completed, failed, interrupted or dry run
+}
+
+// Completed returns the migrations that ran and were recorded.
+func (r *Result) Completed() []Migration {
+	var ms []Migration
+
+	for _, ev := range r.Migrations {
+		if ev.Status == StatusCompleted {
+			ms = append(ms, ev.Migration)
+		}
+	}
+
+	return ms
+}
+
+// Option configures a Migrator.
+type Option func(*Migrator)
+
+// WithTrackingTable sets the schema and name of the tracking table. An empty
+// schema uses the connection's search_path; an empty table keeps
+// "schema_migrations".
+func WithTrackingTable(schema, table string) Option {
+	return func(m *Migrator) {
+		m.schema = schema
+		if table != "" {
+			m.table = table
+		}
+	}
+}
+
+// WithLockKey sets the advisory lock key. By default the key is derived from
+// the database name and tracking table, matching the migrate CLI, so the
+// CLI and a Migrator never run migrations concurrently.
+func WithLockKey(key int64) Option {
+	return func(m *Migrator) { m.lockKey = key }
+}
+
+// WithLockWait sets how long to wait for the advisory lock when another
+// process holds it. Zero fails immediately with ErrLockNotAcquired.
+func WithLockWait(d time.Duration) Option {
+	return func(m *Migrator) { m.lockWait = d }
+}
+
+// WithLockTimeout sets lock_timeout for each migration. Defaults to 5s.
+func WithLockTimeout(d time.Duration) Option {
+	return func(m *Migrator) { m.lockTimeout = d }
+}
+
+// WithStatementTimeout sets statement_timeout for each migration. Defaults to 30s.
+func WithStatementTimeout(d time.Duration) Option {
+	return func(m *Migrator) { m.statementTimeout = d }
+}
+
+// WithGracePeriod sets how long an in-flight migration may keep running
+// after the context is cancelled before its queries are cancelled.
+func WithGracePeriod(d time.Duration) Option {
+	return func(m *Migrator) { m.gracePeriod = &d }
+}
+
+// WithAllowOutOfOrder permits applying pending migrations that sort before
+// the latest applied one. Otherwise Apply returns ErrOutOfOrder.
+func WithAllowOutOfOrder(allow bool) Option {
+	return func(m *Migrator) { m.allowOutOfOrder = allow }
+}
+
+// WithDryRun reports what Apply or Rollback would run without executing SQL.
+func WithDryRun(dryRun bool) Option {
+	return func(m *Migrator) { m.dryRun = dryRun }
+}
+
+// WithTicket sets the change-ticket reference recorded in the history table.
+func WithTicket(ticket string) Option {
+	return func(m *Migrator) { m.ticket = ticket }
+}
+
+// WithHooks sets the hooks run at fixed points of every Apply and Rollback,
+// such as the callback files returned by LoadHooks or Go hooks. Hooks for
+// the same point run in the given order.
+func WithHooks(hooks ...Hook) Option {
+	return func(m *Migrator) { m.hooks = hooks }
+}
+
+// WithProgress sets a function called as each migration starts and finishes.
+func WithProgress(fn func(Event)) Option {
+	return func(m *Migrator) { m.onProgress = fn }
+}
+
+// Migrator applies and rolls back migrations against one database, tracking
+// them in a tracking table and serializing runs with an advisory lock.
+// A Migrator is safe to reuse but not for concurrent calls.
+type Migrator struct {
+	pool             *pgxpool.Pool
+	tracker          executor.MigrationTracker
+	schema           string
+	table            string
+	lockKey          int64
+	lockWait         time.Duration
+	lockTimeout      time.Duration
+	statementTimeout time.Duration
+	gracePeriod      *time.Duration
+	allowOutOfOrder  bool
+	dryRun           bool
+	ticket           string
+	hooks            []Hook
+	onProgress       func(Event)
+}
+
+// New creates a Migrator for the database behind pool.
+func New(pool *pgxpool.Pool, opts ...Option) *Migrator {
+	m := &Migrator{
+		pool:             pool,
+		table:            tracker.DefaultTable,
+		lockTimeout:      config.DefaultLockTimeout,
+		statementTimeout: config.DefaultStatementTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.tracker = tracker.New(pool,
+		tracker.WithToolVersion(toolVersion()),
+		tracker.WithTable(m.schema, m.table),
+	)
+
+	if m.lockKey == 0 {
+		m.lockKey = database.DeriveLockKey(pool.Config().ConnConfig.Database, m.schema, m.table)
+	}
+
+	return m
+}
+
+// Apply runs all pending migrations in version order, then every repeatable
+// migration whose checksum changed since it was last applied. Applied
+// migrations are verified against their checksums and skipped. The Result
+// lists what ran, including the failed or interrupted migration when err is
+// non-nil.
+func (m *Migrator) Apply(ctx context.Context, ms []Migration) (*Result, error) {
+	versioned, repeatables := splitRepeatables(toInternal(ms))
+
+	return m.run(func(e *executor.Executor) error {
+		return e.Apply(ctx, versioned)
+	}, executor.WithRepeatables(repeatables))
+}
+
+// ApplySteps runs at most steps pending migrations, in version order.
+// Repeatable migrations run only if no versioned migration is left pending.
+func (m *Migrator) ApplySteps(ctx context.Context, ms []Migration, steps int) (*Result, error) {
+	versioned, repeatables := splitRepeatables(toInternal(ms))
+
+	return m.run(func(e *executor.Executor) error {
+		return e.ApplySteps(ctx, versioned, steps)
+	}, executor.WithRepeatables(repeatables))
+}
+
+// ApplyToVersion runs pending migrations up to and including version, which
+// must be one of ms. Returns ErrVersionNotFound otherwise. Repeatable
+// migrations run only if version is the last migration.
+func (m *Migrator) ApplyToVersion(ctx context.Context, ms []Migration, version string) (*Result, error) {
+	versioned, repeatables := splitRepeatables(toInternal(ms))
+
+	return m.run(func(e *executor.Executor) error {
+		return e.ApplyToVersion(ctx, versioned, version)
+	}, executor.WithRepeatables(repeatables))
+}
+
+// Rollback reverts the most recent steps applied migrations using their down
+// SQL. ms must include every migration to roll back. Repeatable migrations
+// are never rolled back.
+func (m *Migrator) Rollback(ctx context.Context, ms []Migration, steps int) (*Result, error) {
+	versioned, _ := splitRepeatables(toInternal(ms))
+
+	return m.run(func(e *executor.Executor) error {
+		return e.Rollback(ctx, versioned, steps)
+	})
+}
+
+// RollbackToVersion reverts every applied migration after version; version
+// itself stays applied. Returns ErrTargetNotFound if it is not applied.
+func (m *Migrator) RollbackToVersion(ctx context.Context, ms []Migration, version string) (*Result, error) {
+	versioned, _ := splitRepeatables(toInternal(ms))
+
+	return m.run(func(e *executor.Executor) error {
+		return e.RollbackToVersion(ctx, versioned, version)
+	})
+}
+
+// run builds an executor with extra options that records each finished
+// migration in a Result and calls fn with it.
+func (m *Migrator) run(fn func(*executor.Executor) error, extra ...executor.Option) (*Result, error) {
+	result := &Result{}
+
+	opts := []executor.Option{
+		executor.WithLockKey(m.lockKey),
+		executor.WithLockWait(m.lockWait),
+		executor.WithLockTimeout(m.lockTimeout),
+		executor.WithStatementTimeout(m.statementTimeout),
+		executor.WithAllowOutOfOrder(m.allowOutOfOrder),
+		executor.WithDryRun(m.dryRun),
+		executor.WithTicket(m.ticket),
+		executor.WithHooks(toInternalHooks(m.hooks)),
+		executor.WithProgressCallback(func(pe executor.ProgressEvent) {
+			if pe.Status == executor.StatusSkipped {
+				return
+			}
+
+			ev := Event{
+				Hook:     HookPoint(pe.Hook),
+				Status:   pe.Status,
+				Duration: pe.Duration,
+				Err:      pe.Error,
+			}
+
+			if pe.Migration != nil {
+				ev.Migration = fromInternalOne(pe.Migration)
+
+				if pe.Status != StatusStarting && pe.Status != StatusRollingBack {
+					result.Migrations = append(result.Migrations, ev)
+				}
+			}
+
+			if m.onProgress != nil {
+				m.onProgress(ev)
+			}
+		}),
+	}
+
+	if m.gracePeriod != nil {
+		opts = append(opts, executor.WithGracePeriod(*m.gracePeriod))
+	}
+
+	opts = append(opts, extra...)
+
+	err := fn(executor.New(m.pool, m.tracker, opts...))
+
+	return result, err
+}
+
+// modulePath is the module this package belongs to.
+const modulePath = "github.com/aqasim81/database-migration-engine"
+
+// toolVersion returns the version of this module in the running binary,
+// recorded in the history table like the CLI's version. Builds of the
+// module itself report "(devel)".
+func toolVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+
+	if bi.Main.Path == modulePath {
+		return bi.Main.Version
+	}
+
+	for _, dep := range bi.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+
+			return dep.Version
+		}
+	}
+
+	return "(devel)"
+}
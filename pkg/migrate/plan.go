@@ -0,0 +1,114 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aqasim81/database-migration-engine/internal/migration"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// PlanStep is one migration in a Plan.
+type PlanStep struct {
+	Migration  Migration
+	Applied    bool // already recorded in the tracking table (for repeatables, with the same checksum)
+	OutOfOrder bool // pending, but sorts before the latest applied migration
+}
+
+// Plan describes which migrations are applied and which Apply would run.
+type Plan struct {
+	Steps         []PlanStep // every migration in version order, then repeatables by name
+	LatestApplied string     // highest applied version, empty if none
+}
+
+// Pending returns the migrations Apply would run, in order.
+func (p *Plan) Pending() []Migration {
+	var ms []Migration
+
+	for _, s := range p.Steps {
+		if !s.Applied {
+			ms = append(ms, s.Migration)
+		}
+	}
+
+	return ms
+}
+
+// HasOutOfOrder reports whether any pending migration is out of order, in
+// which case Apply fails with ErrOutOfOrder unless WithAllowOutOfOrder is set.
+func (p *Plan) HasOutOfOrder() bool {
+	for _, s := range p.Steps {
+		if s.OutOfOrder {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Plan compares ms with the tracking table without changing the schema. It
+// treats every migration as pending if the tracking table does not exist,
+// and never writes to the database.
+func (m *Migrator) Plan(ctx context.Context, ms []Migration) (*Plan, error) {
+	applied, appliedRepeatables, err := m.recorded(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedSet := make(map[string]bool, len(applied))
+	for _, a := range applied {
+		appliedSet[a.Version] = true
+	}
+
+	checksums := make(map[string]string, len(appliedRepeatables))
+	for _, r := range appliedRepeatables {
+		checksums[r.Name] = r.Checksum
+	}
+
+	sorted, repeatables := splitRepeatables(toInternal(ms))
+	outOfOrder, latest := migration.OutOfOrder(sorted, appliedSet)
+
+	late := make(map[string]bool, len(outOfOrder))
+	for _, o := range outOfOrder {
+		late[o.Version] = true
+	}
+
+	plan := &Plan{LatestApplied: latest, Steps: make([]PlanStep, 0, len(sorted)+len(repeatables))}
+	for i := range sorted {
+		plan.Steps = append(plan.Steps, PlanStep{
+			Migration:  fromInternalOne(&sorted[i]),
+			Applied:    appliedSet[sorted[i].Version],
+			OutOfOrder: late[sorted[i].Version],
+		})
+	}
+
+	for i := range repeatables {
+		plan.Steps = append(plan.Steps, PlanStep{
+			Migration: fromInternalOne(&repeatables[i]),
+			Applied:   checksums[repeatables[i].Name] == repeatables[i].Checksum,
+		})
+	}
+
+	return plan, nil
+}
+
+// recorded returns the applied versioned and repeatable migrations, or
+// nothing if the tracking table does not exist.
+func (m *Migrator) recorded(ctx context.Context) ([]tracker.AppliedMigration, []tracker.AppliedRepeatable, error) {
+	exists, err := m.tracker.Exists(ctx)
+	if err != nil || !exists {
+		return nil, nil, err
+	}
+
+	applied, err := m.tracker.GetApplied(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting applied migrations: %w", err)
+	}
+
+	repeatables, err := m.tracker.GetRepeatables(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting applied repeatable migrations: %w", err)
+	}
+
+	return applied, repeatables, nil
+}
@@ -0,0 +1,110 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aqasim81/database-migration-engine/internal/executor"
+	"github.com/aqasim81/database-migration-engine/internal/tracker"
+)
+
+// fakeTracker returns a fixed applied list; other methods are unused by Plan,
+// which must not write, so calling them panics.
+type fakeTracker struct {
+	executor.MigrationTracker
+	applied     []tracker.AppliedMigration
+	repeatables []tracker.AppliedRepeatable
+	missing     bool
+	err         error
+}
+
+func (f *fakeTracker) Exists(_ context.Context) (bool, error) { return !f.missing, nil }
+
+func (f *fakeTracker) GetApplied(_ context.Context) ([]tracker.AppliedMigration, error) {
+	return f.applied, f.err
+}
+
+func (f *fakeTracker) GetRepeatables(_ context.Context) ([]tracker.AppliedRepeatable, error) {
+	return f.repeatables, nil
+}
+
+func TestMigrator_Plan_marksAppliedPendingAndOutOfOrder(t *testing.T) {
+	t.Parallel()
+
+	m := &Migrator{tracker: &fakeTracker{applied: []tracker.AppliedMigration{{Version: "001"}, {Version: "003"}}}}
+
+	plan, err := m.Plan(context.Background(), []Migration{
+		{Version: "004", UpSQL: "SELECT 4;"},
+		{Version: "002", UpSQL: "SELECT 2;"},
+		{Version: "001", UpSQL: "SELECT 1;"},
+		{Version: "003", UpSQL: "SELECT 3;"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, plan.Steps, 4)
+	assert.Equal(t, "003", plan.LatestApplied)
+	assert.True(t, plan.Steps[0].Applied)
+	assert.True(t, plan.Steps[1].OutOfOrder)
+	assert.False(t, plan.Steps[3].OutOfOrder)
+	assert.True(t, plan.HasOutOfOrder())
+
+	pending := plan.Pending()
+	require.Len(t, pending, 2)
+	assert.Equal(t, "002", pending[0].Version)
+	assert.Equal(t, "004", pending[1].Version)
+}
+
+func TestMigrator_Plan_missingTrackingTable_allPending(t *testing.T) {
+	t.Parallel()
+
+	m := &Migrator{tracker: &fakeTracker{missing: true}}
+
+	plan, err := m.Plan(context.Background(), []Migration{
+		{Version: "001", UpSQL: "SELECT 1;"},
+		{Name: "views", UpSQL: "CREATE VIEW v AS SELECT 1;", Repeatable: true},
+	})
+	require.NoError(t, err)
+	assert.Len(t, plan.Pending(), 2)
+	assert.Empty(t, plan.LatestApplied)
+}
+
+func TestMigrator_Plan_trackerError(t *testing.T) {
+	t.Parallel()
+
+	boom := errors.New("boom")
+	m := &Migrator{tracker: &fakeTracker{err: boom}}
+
+	_, err := m.Plan(context.Background(), nil)
+	require.ErrorIs(t, err, boom)
+}
+
+func TestMigrator_Plan_repeatablesFollowVersionedMigrations(t *testing.T) {
+	t.Parallel()
+
+	m := &Migrator{tracker: &fakeTracker{
+		applied:     []tracker.AppliedMigration{{Version: "001"}},
+		repeatables: []tracker.AppliedRepeatable{{Name: "views", Checksum: "old"}, {Name: "funcs", Checksum: "f"}},
+	}}
+
+	plan, err := m.Plan(context.Background(), []Migration{
+		{Name: "views", UpSQL: "CREATE VIEW v AS SELECT 2;", Repeatable: true},
+		{Version: "001", UpSQL: "SELECT 1;"},
+		{Name: "funcs", UpSQL: "SELECT 'f';", Checksum: "f", Repeatable: true},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, plan.Steps, 3)
+	assert.Equal(t, "001", plan.Steps[0].Migration.Version)
+	assert.Equal(t, "funcs", plan.Steps[1].Migration.Name)
+	assert.True(t, plan.Steps[1].Applied)
+	assert.Equal(t, "views", plan.Steps[2].Migration.Name)
+	assert.False(t, plan.Steps[2].Applied)
+
+	pending := plan.Pending()
+	require.Len(t, pending, 1)
+	assert.True(t, pending[0].Repeatable)
+}